@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
@@ -12,28 +13,62 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sort"
+	"strconv"
 	"strings"
+	"syscall"
 	"text/tabwriter"
 	"time"
 
 	"github.com/draganm/executr/internal/executor"
-	"github.com/draganm/executr/internal/models"
 	"github.com/draganm/executr/internal/server"
+	"github.com/draganm/executr/internal/utils"
 	"github.com/draganm/executr/pkg/client"
+	"github.com/draganm/executr/pkg/models"
 	"github.com/google/uuid"
 	"github.com/urfave/cli/v2"
 )
 
+// Build information, set with -ldflags "-X main.version=... -X main.commit=...
+// -X main.buildDate=..."
+var (
+	version   = "dev"
+	commit    = "unknown"
+	buildDate = "unknown"
+)
+
+// buildInfo returns the build information of this binary
+func buildInfo() models.VersionInfo {
+	return models.VersionInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: buildDate,
+	}
+}
+
 func main() {
+	// Builds without -ldflags keep the module version from the build info
+	if v := buildInfo().Version; v != "dev" {
+		utils.SetVersion(v)
+	}
+
 	app := &cli.App{
-		Name:  "executr",
-		Usage: "Distributed job execution system",
+		Name:    "executr",
+		Usage:   "Distributed job execution system",
+		Version: version,
 		Commands: []*cli.Command{
 			serverCommand(),
 			executorCommand(),
 			submitCommand(),
 			statusCommand(),
+			listCommand(),
 			cancelCommand(),
+			requeueCommand(),
+			reprioritizeCommand(),
+			schedulesCommand(),
+			limitsCommand(),
+			cacheCommand(),
+			versionCommand(),
 		},
 	}
 
@@ -76,6 +111,130 @@ func serverCommand() *cli.Command {
 				Value:   15 * time.Second,
 				EnvVars: []string{"EXECUTR_HEARTBEAT_TIMEOUT"},
 			},
+			&cli.DurationFlag{
+				Name:    "retry-interval",
+				Usage:   "How often failed jobs are checked for retries (e.g. 30s, 1m)",
+				Value:   30 * time.Second,
+				EnvVars: []string{"EXECUTR_RETRY_INTERVAL"},
+			},
+			&cli.DurationFlag{
+				Name:    "shutdown-timeout",
+				Usage:   "How long in-flight requests and background workers get to finish on shutdown (e.g. 30s, 2m)",
+				Value:   30 * time.Second,
+				EnvVars: []string{"EXECUTR_SHUTDOWN_TIMEOUT"},
+			},
+			&cli.IntFlag{
+				Name:    "max-output-bytes-limit",
+				Usage:   "Ceiling in bytes for a job's max_output_bytes; larger requests are clamped",
+				Value:   server.DefaultMaxOutputBytesLimit,
+				EnvVars: []string{"EXECUTR_MAX_OUTPUT_BYTES_LIMIT"},
+			},
+			&cli.StringFlag{
+				Name:    "api-keys-file",
+				Usage:   "File of API keys and their scopes; enables authentication when set",
+				EnvVars: []string{"EXECUTR_API_KEYS_FILE"},
+			},
+			&cli.BoolFlag{
+				Name:    "hash-binaries",
+				Usage:   "Download binaries of jobs submitted without a SHA256 and calculate it on the server",
+				EnvVars: []string{"EXECUTR_HASH_BINARIES"},
+			},
+			&cli.StringFlag{
+				Name:    "binary-auth-config",
+				Usage:   "File of credentials sent with downloads of binaries when validating or hashing them, by URL prefix",
+				EnvVars: []string{"EXECUTR_BINARY_AUTH_CONFIG"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "sensitive-env",
+				Usage:   "Globs of env variable keys whose values are shown as *** in job responses (can be specified multiple times); executors still receive them. --sensitive-env '' redacts none",
+				Value:   cli.NewStringSlice(server.DefaultSensitiveEnvPatterns...),
+				EnvVars: []string{"EXECUTR_SENSITIVE_ENV"},
+			},
+			&cli.Float64Flag{
+				Name:    "claim-rate-per-sec",
+				Usage:   "Claims per second allowed for each executor, excess requests get 429 (0 disables)",
+				EnvVars: []string{"EXECUTR_CLAIM_RATE_PER_SEC"},
+			},
+			&cli.Float64Flag{
+				Name:    "heartbeat-rate-per-sec",
+				Usage:   "Heartbeats per second allowed for each executor across its jobs, excess requests get 429 (0 disables)",
+				EnvVars: []string{"EXECUTR_HEARTBEAT_RATE_PER_SEC"},
+			},
+			&cli.Float64Flag{
+				Name:    "submit-rate-per-sec",
+				Usage:   "Job submissions per second allowed for each API key, or client address without authentication, excess requests get 429 (0 disables)",
+				EnvVars: []string{"EXECUTR_SUBMIT_RATE_PER_SEC"},
+			},
+			&cli.Int64Flag{
+				Name:    "max-request-bytes",
+				Usage:   "Size limit of JSON request bodies, larger requests get 413; submissions and job output may add four times --max-output-bytes-limit",
+				Value:   server.DefaultMaxRequestBytes,
+				EnvVars: []string{"EXECUTR_MAX_REQUEST_BYTES"},
+			},
+			&cli.StringFlag{
+				Name:    "scheduling",
+				Usage:   "Order in which pending jobs of the same priority are claimed: oldest first (fifo) or the oldest job of the least recently claimed type (fair)",
+				Value:   server.SchedulingFIFO,
+				EnvVars: []string{"EXECUTR_SCHEDULING"},
+			},
+			&cli.StringFlag{
+				Name:    "priority-mode",
+				Usage:   "How claims choose between priorities: always the highest one with a pending job (strict) or a lottery by --priority-weights (weighted)",
+				Value:   server.PriorityModeStrict,
+				EnvVars: []string{"EXECUTR_PRIORITY_MODE"},
+			},
+			&cli.IntSliceFlag{
+				Name:    "priority-weights",
+				Usage:   "Shares of foreground, background and best_effort claims in the weighted priority mode",
+				Value:   cli.NewIntSlice(server.DefaultPriorityWeights...),
+				EnvVars: []string{"EXECUTR_PRIORITY_WEIGHTS"},
+			},
+			&cli.BoolFlag{
+				Name:    "enable-pprof",
+				Usage:   "Serve pprof profiling endpoints under /debug/pprof/, only expose them on trusted networks",
+				EnvVars: []string{"EXECUTR_ENABLE_PPROF"},
+			},
+			&cli.StringFlag{
+				Name:    "archive-sink",
+				Usage:   "Keep a record of jobs deleted by the retention cleanup in the jobs_archive table (db), a JSON lines file (file) or an S3-compatible bucket (s3); jobs are deleted without a record when empty",
+				EnvVars: []string{"EXECUTR_ARCHIVE_SINK"},
+			},
+			&cli.StringFlag{
+				Name:    "archive-file",
+				Usage:   "JSON lines file deleted jobs are appended to with --archive-sink file",
+				EnvVars: []string{"EXECUTR_ARCHIVE_FILE"},
+			},
+			&cli.StringFlag{
+				Name:    "archive-s3-url",
+				Usage:   "S3-compatible endpoint for --archive-sink s3 (e.g. https://s3.eu-west-1.amazonaws.com)",
+				EnvVars: []string{"EXECUTR_ARCHIVE_S3_URL"},
+			},
+			&cli.StringFlag{
+				Name:    "archive-s3-bucket",
+				Usage:   "Bucket for archived jobs",
+				EnvVars: []string{"EXECUTR_ARCHIVE_S3_BUCKET"},
+			},
+			&cli.StringFlag{
+				Name:    "archive-s3-prefix",
+				Usage:   "Key prefix for archived jobs",
+				EnvVars: []string{"EXECUTR_ARCHIVE_S3_PREFIX"},
+			},
+			&cli.StringFlag{
+				Name:    "archive-s3-region",
+				Usage:   "Region of the archive bucket",
+				Value:   "us-east-1",
+				EnvVars: []string{"EXECUTR_ARCHIVE_S3_REGION"},
+			},
+			&cli.StringFlag{
+				Name:    "archive-s3-access-key",
+				Usage:   "Access key ID for the archive bucket",
+				EnvVars: []string{"EXECUTR_ARCHIVE_S3_ACCESS_KEY", "AWS_ACCESS_KEY_ID"},
+			},
+			&cli.StringFlag{
+				Name:    "archive-s3-secret-key",
+				Usage:   "Secret access key for the archive bucket",
+				EnvVars: []string{"EXECUTR_ARCHIVE_S3_SECRET_KEY", "AWS_SECRET_ACCESS_KEY"},
+			},
 			&cli.StringFlag{
 				Name:    "log-level",
 				Usage:   "Log level (debug/info/warn/error)",
@@ -88,12 +247,38 @@ func serverCommand() *cli.Command {
 			defer cancel()
 
 			cfg := &server.Config{
-				DatabaseURL:       c.String("db-url"),
-				Port:              c.Int("port"),
-				CleanupInterval:   int(c.Duration("cleanup-interval").Seconds()),
-				JobRetention:      int(c.Duration("job-retention").Seconds()),
-				HeartbeatTimeout:  int(c.Duration("heartbeat-timeout").Seconds()),
-				LogLevel:          c.String("log-level"),
+				DatabaseURL:         c.String("db-url"),
+				Port:                c.Int("port"),
+				CleanupInterval:     int(c.Duration("cleanup-interval").Seconds()),
+				JobRetention:        int(c.Duration("job-retention").Seconds()),
+				HeartbeatTimeout:    int(c.Duration("heartbeat-timeout").Seconds()),
+				RetryInterval:       int(c.Duration("retry-interval").Seconds()),
+				ShutdownTimeout:     int(c.Duration("shutdown-timeout").Seconds()),
+				LogLevel:            c.String("log-level"),
+				MaxOutputBytesLimit: c.Int("max-output-bytes-limit"),
+				APIKeysFile:         c.String("api-keys-file"),
+				HashBinaries:        c.Bool("hash-binaries"),
+				BinaryAuthConfig:    c.String("binary-auth-config"),
+
+				SensitiveEnvPatterns: c.StringSlice("sensitive-env"),
+				ClaimRatePerSec:      c.Float64("claim-rate-per-sec"),
+				HeartbeatRatePerSec:  c.Float64("heartbeat-rate-per-sec"),
+				SubmitRatePerSec:     c.Float64("submit-rate-per-sec"),
+				MaxRequestBytes:      c.Int64("max-request-bytes"),
+				Scheduling:           c.String("scheduling"),
+				PriorityMode:         c.String("priority-mode"),
+				PriorityWeights:      c.IntSlice("priority-weights"),
+				EnablePprof:          c.Bool("enable-pprof"),
+				Version:              buildInfo(),
+
+				ArchiveSink:        c.String("archive-sink"),
+				ArchiveFile:        c.String("archive-file"),
+				ArchiveS3URL:       c.String("archive-s3-url"),
+				ArchiveS3Bucket:    c.String("archive-s3-bucket"),
+				ArchiveS3Prefix:    c.String("archive-s3-prefix"),
+				ArchiveS3Region:    c.String("archive-s3-region"),
+				ArchiveS3AccessKey: c.String("archive-s3-access-key"),
+				ArchiveS3SecretKey: c.String("archive-s3-secret-key"),
 			}
 
 			// Setup logging
@@ -110,9 +295,9 @@ func serverCommand() *cli.Command {
 			default:
 				logLevel = slog.LevelInfo
 			}
-			slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
+			slog.SetDefault(slog.New(utils.NewRequestIDHandler(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
 				Level: logLevel,
-			})))
+			}))))
 
 			if cfg.DatabaseURL == "" {
 				return fmt.Errorf("database URL is required")
@@ -139,6 +324,11 @@ func executorCommand() *cli.Command {
 				Required: true,
 				EnvVars:  []string{"EXECUTR_SERVER_URL"},
 			},
+			&cli.StringFlag{
+				Name:    "api-key",
+				Usage:   "API key for server authentication",
+				EnvVars: []string{"EXECUTR_API_KEY"},
+			},
 			&cli.StringFlag{
 				Name:     "name",
 				Usage:    "Executor name (used as prefix for executor ID)",
@@ -169,12 +359,64 @@ func executorCommand() *cli.Command {
 				Value:   5 * time.Second,
 				EnvVars: []string{"EXECUTR_POLL_INTERVAL"},
 			},
+			&cli.DurationFlag{
+				Name:    "claim-wait",
+				Usage:   "How long a claim waits on the server for a job to be submitted, at most 20s (0 disables long-polling)",
+				Value:   10 * time.Second,
+				EnvVars: []string{"EXECUTR_CLAIM_WAIT"},
+			},
 			&cli.IntFlag{
 				Name:    "max-cache-size",
 				Usage:   "Maximum cache size in MB",
 				Value:   400,
 				EnvVars: []string{"EXECUTR_MAX_CACHE_SIZE"},
 			},
+			&cli.IntFlag{
+				Name:    "min-free-disk",
+				Usage:   "Free disk space in MB to keep on the cache filesystem, evicting cached binaries before downloads (0 disables)",
+				Value:   0,
+				EnvVars: []string{"EXECUTR_MIN_FREE_DISK"},
+			},
+			&cli.IntFlag{
+				Name:    "max-concurrent-downloads",
+				Usage:   "Maximum number of binaries downloaded at the same time, bounding network usage independently of max-jobs (0 for no limit)",
+				Value:   0,
+				EnvVars: []string{"EXECUTR_MAX_CONCURRENT_DOWNLOADS"},
+			},
+			&cli.DurationFlag{
+				Name:    "download-timeout",
+				Usage:   "Maximum time a binary download may take (0 for no limit)",
+				Value:   time.Hour,
+				EnvVars: []string{"EXECUTR_DOWNLOAD_TIMEOUT"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "allowed-binary-roots",
+				Usage:   "Directories file:// binaries may be copied from, e.g. an NFS mount (can be specified multiple times); file:// binaries are rejected when unset",
+				EnvVars: []string{"EXECUTR_ALLOWED_BINARY_ROOTS"},
+			},
+			&cli.StringFlag{
+				Name:    "binary-auth-config",
+				Usage:   "File of credentials sent with downloads of binaries, signatures and input files, by URL prefix",
+				EnvVars: []string{"EXECUTR_BINARY_AUTH_CONFIG"},
+			},
+			&cli.IntFlag{
+				Name:    "max-input-file-size",
+				Usage:   "Maximum size in MB of each input file of a job",
+				Value:   executor.DefaultMaxInputFileMB,
+				EnvVars: []string{"EXECUTR_MAX_INPUT_FILE_SIZE"},
+			},
+			&cli.IntFlag{
+				Name:    "max-input-files-size",
+				Usage:   "Maximum size in MB of all input files of a job together",
+				Value:   executor.DefaultMaxInputFilesMB,
+				EnvVars: []string{"EXECUTR_MAX_INPUT_FILES_SIZE"},
+			},
+			&cli.IntFlag{
+				Name:    "max-artifacts-size",
+				Usage:   "Maximum size in MB of all artifacts collected from a job together",
+				Value:   executor.DefaultMaxArtifactsMB,
+				EnvVars: []string{"EXECUTR_MAX_ARTIFACTS_SIZE"},
+			},
 			&cli.DurationFlag{
 				Name:    "heartbeat-interval",
 				Usage:   "Heartbeat frequency (e.g. 5s, 10s)",
@@ -187,6 +429,102 @@ func executorCommand() *cli.Command {
 				Value:   60 * time.Second,
 				EnvVars: []string{"EXECUTR_NETWORK_TIMEOUT"},
 			},
+			&cli.Float64Flag{
+				Name:    "jitter",
+				Usage:   "Fraction by which poll and heartbeat intervals are randomized, so executors don't hit the server in lockstep (0 disables)",
+				Value:   executor.DefaultJitter,
+				EnvVars: []string{"EXECUTR_JITTER"},
+			},
+			&cli.IntFlag{
+				Name:    "breaker-threshold",
+				Usage:   "Failed job claims in a row after which polling backs off for the breaker cooldown (0 disables)",
+				Value:   5,
+				EnvVars: []string{"EXECUTR_BREAKER_THRESHOLD"},
+			},
+			&cli.DurationFlag{
+				Name:    "breaker-cooldown",
+				Usage:   "How long polling backs off before probing the server again (e.g. 30s, 1m)",
+				Value:   executor.DefaultBreakerCooldown * time.Second,
+				EnvVars: []string{"EXECUTR_BREAKER_COOLDOWN"},
+			},
+			&cli.DurationFlag{
+				Name:    "drain-timeout",
+				Usage:   "On SIGTERM, fail jobs still running after this long (e.g. 10m); 0 waits indefinitely",
+				EnvVars: []string{"EXECUTR_DRAIN_TIMEOUT"},
+			},
+			&cli.StringFlag{
+				Name:    "metrics-addr",
+				Usage:   "Address to serve Prometheus metrics on /metrics (e.g. :9090); disabled when empty",
+				EnvVars: []string{"EXECUTR_METRICS_ADDR"},
+			},
+			&cli.StringFlag{
+				Name:    "advertise-addr",
+				Usage:   "Address (host:port) the metrics listener is reachable at, registered with the server for service discovery; defaults to the host name and metrics port",
+				EnvVars: []string{"EXECUTR_ADVERTISE_ADDR"},
+			},
+			&cli.StringFlag{
+				Name:    "advertise-ip",
+				Usage:   "IP address reported with job claims; defaults to the address of the interface used to reach the server",
+				EnvVars: []string{"EXECUTR_ADVERTISE_IP"},
+			},
+			&cli.StringFlag{
+				Name:    "cgroup-parent",
+				Usage:   "cgroup v2 directory to run jobs with CPU or memory limits below, e.g. /sys/fs/cgroup/executr (Linux only); limits are ignored when empty",
+				EnvVars: []string{"EXECUTR_CGROUP_PARENT"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "inherit-env",
+				Usage:   "Host environment variables passed to jobs, overridden by the job's own (can be specified multiple times); --inherit-env '' passes none",
+				Value:   cli.NewStringSlice(executor.DefaultInheritEnv...),
+				EnvVars: []string{"EXECUTR_INHERIT_ENV"},
+			},
+			&cli.StringFlag{
+				Name:    "secrets-dir",
+				Usage:   "Directory of secret files, e.g. prod/db-pass, that env variables like DB_PASS=secret://prod/db-pass resolve to when a job runs",
+				EnvVars: []string{"EXECUTR_SECRETS_DIR"},
+			},
+			&cli.IntFlag{
+				Name:    "max-output-size",
+				Usage:   "Default stdout/stderr size limit in bytes for jobs that don't set max_output_bytes",
+				Value:   executor.DefaultMaxOutputSize,
+				EnvVars: []string{"EXECUTR_MAX_OUTPUT_SIZE"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "capabilities",
+				Usage:   "Capabilities of this executor (e.g. gpu,avx512); only jobs requiring a subset of them are claimed",
+				EnvVars: []string{"EXECUTR_CAPABILITIES"},
+			},
+			&cli.BoolFlag{
+				Name:    "require-signatures",
+				Usage:   "Only run jobs whose binary has a valid minisign signature",
+				EnvVars: []string{"EXECUTR_REQUIRE_SIGNATURES"},
+			},
+			&cli.StringFlag{
+				Name:    "output-store-url",
+				Usage:   "S3-compatible endpoint for storing full job output (e.g. https://s3.eu-west-1.amazonaws.com); output is sent inline when empty",
+				EnvVars: []string{"EXECUTR_OUTPUT_STORE_URL"},
+			},
+			&cli.StringFlag{
+				Name:    "output-store-bucket",
+				Usage:   "Bucket for stored job output",
+				EnvVars: []string{"EXECUTR_OUTPUT_STORE_BUCKET"},
+			},
+			&cli.StringFlag{
+				Name:    "output-store-region",
+				Usage:   "Region of the output store bucket",
+				Value:   "us-east-1",
+				EnvVars: []string{"EXECUTR_OUTPUT_STORE_REGION"},
+			},
+			&cli.StringFlag{
+				Name:    "output-store-access-key",
+				Usage:   "Access key ID for the output store",
+				EnvVars: []string{"EXECUTR_OUTPUT_STORE_ACCESS_KEY", "AWS_ACCESS_KEY_ID"},
+			},
+			&cli.StringFlag{
+				Name:    "output-store-secret-key",
+				Usage:   "Secret access key for the output store",
+				EnvVars: []string{"EXECUTR_OUTPUT_STORE_SECRET_KEY", "AWS_SECRET_ACCESS_KEY"},
+			},
 			&cli.StringFlag{
 				Name:    "log-level",
 				Usage:   "Log level (debug/info/warn/error)",
@@ -195,7 +533,8 @@ func executorCommand() *cli.Command {
 			},
 		},
 		Action: func(c *cli.Context) error {
-			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
+			// Interrupt stops immediately; SIGTERM drains (see below)
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 			defer cancel()
 
 			// Setup logging
@@ -218,14 +557,45 @@ func executorCommand() *cli.Command {
 
 			cfg := &executor.Config{
 				ServerURL:         c.String("server-url"),
+				APIKey:            c.String("api-key"),
 				Name:              c.String("name"),
 				CacheDir:          c.String("cache-dir"),
 				WorkDir:           c.String("work-dir"),
 				MaxJobs:           c.Int("max-jobs"),
 				PollInterval:      int(c.Duration("poll-interval").Seconds()),
+				ClaimWait:         int(c.Duration("claim-wait").Seconds()),
 				MaxCacheSize:      c.Int("max-cache-size"),
+				MinFreeDiskMB:     c.Int("min-free-disk"),
+				MaxDownloads:      c.Int("max-concurrent-downloads"),
+				DownloadTimeout:   ceilSeconds(c.Duration("download-timeout")),
+				BinaryAuthConfig:  c.String("binary-auth-config"),
+				MaxInputFileMB:    c.Int("max-input-file-size"),
+				MaxInputFilesMB:   c.Int("max-input-files-size"),
+				MaxArtifactsMB:    c.Int("max-artifacts-size"),
 				HeartbeatInterval: int(c.Duration("heartbeat-interval").Seconds()),
 				NetworkTimeout:    int(c.Duration("network-timeout").Seconds()),
+				Jitter:            c.Float64("jitter"),
+				BreakerThreshold:  c.Int("breaker-threshold"),
+				BreakerCooldown:   int(c.Duration("breaker-cooldown").Seconds()),
+				MaxOutputSize:     c.Int("max-output-size"),
+				RequireSignatures: c.Bool("require-signatures"),
+				Capabilities:      c.StringSlice("capabilities"),
+				DrainTimeout:      int(c.Duration("drain-timeout").Seconds()),
+				MetricsAddr:       c.String("metrics-addr"),
+				AdvertiseAddr:     c.String("advertise-addr"),
+				AdvertiseIP:       c.String("advertise-ip"),
+				CgroupParent:      c.String("cgroup-parent"),
+				InheritEnv:        c.StringSlice("inherit-env"),
+				SecretsDir:        c.String("secrets-dir"),
+
+				AllowedBinaryRoots: c.StringSlice("allowed-binary-roots"),
+
+				OutputStoreURL:       c.String("output-store-url"),
+				OutputStoreBucket:    c.String("output-store-bucket"),
+				OutputStoreRegion:    c.String("output-store-region"),
+				OutputStoreAccessKey: c.String("output-store-access-key"),
+				OutputStoreSecretKey: c.String("output-store-secret-key"),
+				Version:              version,
 			}
 
 			exec, err := executor.New(cfg)
@@ -233,6 +603,18 @@ func executorCommand() *cli.Command {
 				return fmt.Errorf("failed to create executor: %w", err)
 			}
 
+			// SIGTERM stops claiming new jobs and lets running ones finish
+			terminate := make(chan os.Signal, 1)
+			signal.Notify(terminate, syscall.SIGTERM)
+			defer signal.Stop(terminate)
+			go func() {
+				select {
+				case <-terminate:
+					exec.Drain()
+				case <-ctx.Done():
+				}
+			}()
+
 			return exec.Run(ctx)
 		},
 	}
@@ -249,9 +631,14 @@ func submitCommand() *cli.Command {
 				Required: true,
 				EnvVars:  []string{"EXECUTR_SERVER_URL"},
 			},
+			&cli.StringFlag{
+				Name:    "api-key",
+				Usage:   "API key for server authentication",
+				EnvVars: []string{"EXECUTR_API_KEY"},
+			},
 			&cli.StringFlag{
 				Name:     "binary-url",
-				Usage:    "Binary download URL",
+				Usage:    "Binary download URL, or oci://registry/repository:tag#/path/to/binary for a binary in a container image",
 				Required: true,
 				EnvVars:  []string{"EXECUTR_BINARY_URL"},
 			},
@@ -282,6 +669,101 @@ func submitCommand() *cli.Command {
 				Value:   "background",
 				EnvVars: []string{"EXECUTR_PRIORITY"},
 			},
+			&cli.IntFlag{
+				Name:    "max-retries",
+				Usage:   "Number of times the job is retried after failing",
+				EnvVars: []string{"EXECUTR_MAX_RETRIES"},
+			},
+			&cli.DurationFlag{
+				Name:    "retry-backoff",
+				Usage:   "Base delay between retries, doubled after each retry (e.g. 30s, 5m); 0 uses the server default",
+				EnvVars: []string{"EXECUTR_RETRY_BACKOFF"},
+			},
+			&cli.DurationFlag{
+				Name:    "timeout",
+				Usage:   "Maximum job execution time (e.g. 5m, 1h), rounded up to whole seconds, 0 means no timeout",
+				EnvVars: []string{"EXECUTR_TIMEOUT"},
+			},
+			&cli.IntFlag{
+				Name:    "max-output-bytes",
+				Usage:   "Maximum stdout/stderr size in bytes, 0 means the executor default",
+				EnvVars: []string{"EXECUTR_MAX_OUTPUT_BYTES"},
+			},
+			&cli.Int64Flag{
+				Name:    "workdir-quota",
+				Usage:   "Maximum size in bytes of the job's working directory while it runs, 0 means unlimited",
+				EnvVars: []string{"EXECUTR_WORKDIR_QUOTA"},
+			},
+			&cli.IntFlag{
+				Name:    "cpu-millicores",
+				Usage:   "CPU limit of the job, 1000 is one CPU; 0 means unlimited",
+				EnvVars: []string{"EXECUTR_CPU_MILLICORES"},
+			},
+			&cli.Int64Flag{
+				Name:    "mem-limit",
+				Usage:   "Memory limit of the job in bytes; 0 means unlimited",
+				EnvVars: []string{"EXECUTR_MEM_LIMIT"},
+			},
+			&cli.StringFlag{
+				Name:    "stdin-file",
+				Usage:   "File whose contents are fed to the binary's standard input (- reads this command's stdin)",
+				EnvVars: []string{"EXECUTR_STDIN_FILE"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "input-file",
+				Usage:   "File NAME=URL to download into the job's working directory, or NAME=@PATH to send a local file (can be specified multiple times)",
+				EnvVars: []string{"EXECUTR_INPUT_FILE"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "output-glob",
+				Usage:   "Glob of files in the job's working directory to collect as artifacts after it ran, e.g. 'out/*.csv' (can be specified multiple times)",
+				EnvVars: []string{"EXECUTR_OUTPUT_GLOB"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "label",
+				Usage:   "Label KEY=VALUE to attach to the job (can be specified multiple times)",
+				EnvVars: []string{"EXECUTR_LABEL"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "require-capability",
+				Usage:   "Capability the executor must have (can be specified multiple times)",
+				EnvVars: []string{"EXECUTR_REQUIRE_CAPABILITY"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "depends-on",
+				Usage:   "ID of a job that must complete before this one runs (can be specified multiple times)",
+				EnvVars: []string{"EXECUTR_DEPENDS_ON"},
+			},
+			&cli.StringFlag{
+				Name:    "schedule-at",
+				Usage:   "Don't run the job before this time (RFC3339, or relative like +2h)",
+				EnvVars: []string{"EXECUTR_SCHEDULE_AT"},
+			},
+			&cli.StringFlag{
+				Name:    "cron",
+				Usage:   "Submit the job on a 5-field cron schedule (UTC) instead of once",
+				EnvVars: []string{"EXECUTR_CRON"},
+			},
+			&cli.StringFlag{
+				Name:    "idempotency-key",
+				Usage:   "Key that makes resubmitting safe: a repeated submit returns the job created first",
+				EnvVars: []string{"EXECUTR_IDEMPOTENCY_KEY"},
+			},
+			&cli.BoolFlag{
+				Name:    "dry-run",
+				Usage:   "Only check on the server that the binary can be downloaded and matches its SHA256, don't submit the job",
+				EnvVars: []string{"EXECUTR_DRY_RUN"},
+			},
+			&cli.StringFlag{
+				Name:    "signature-url",
+				Usage:   "URL of a minisign detached signature for the binary",
+				EnvVars: []string{"EXECUTR_SIGNATURE_URL"},
+			},
+			&cli.StringFlag{
+				Name:    "public-key",
+				Usage:   "Minisign public key used to verify the signature",
+				EnvVars: []string{"EXECUTR_PUBLIC_KEY"},
+			},
 			&cli.StringFlag{
 				Name:    "output",
 				Usage:   "Output format (json/table)",
@@ -307,6 +789,11 @@ func statusCommand() *cli.Command {
 				Required: true,
 				EnvVars:  []string{"EXECUTR_SERVER_URL"},
 			},
+			&cli.StringFlag{
+				Name:    "api-key",
+				Usage:   "API key for server authentication",
+				EnvVars: []string{"EXECUTR_API_KEY"},
+			},
 			&cli.StringFlag{
 				Name:    "output",
 				Usage:   "Output format (json/table)",
@@ -323,10 +810,76 @@ func statusCommand() *cli.Command {
 	}
 }
 
+func listCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "list",
+		Usage: "List jobs",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "server-url",
+				Usage:    "Server API endpoint",
+				Required: true,
+				EnvVars:  []string{"EXECUTR_SERVER_URL"},
+			},
+			&cli.StringFlag{
+				Name:    "api-key",
+				Usage:   "API key for server authentication",
+				EnvVars: []string{"EXECUTR_API_KEY"},
+			},
+			&cli.StringFlag{
+				Name:  "status",
+				Usage: "Filter by status (pending/running/completed/failed/cancelled/dead_letter/skipped)",
+			},
+			&cli.StringFlag{
+				Name:  "type",
+				Usage: "Filter by job type",
+			},
+			&cli.StringFlag{
+				Name:  "priority",
+				Usage: "Filter by priority (foreground/background/best_effort)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "label",
+				Usage: "Filter by label KEY=VALUE (can be specified multiple times, all must match)",
+			},
+			&cli.StringFlag{
+				Name:  "executor",
+				Usage: "Filter by the ID of the executor that claimed the job",
+			},
+			&cli.IntFlag{
+				Name:  "limit",
+				Usage: "Maximum number of jobs to list",
+				Value: 100,
+			},
+			&cli.IntFlag{
+				Name:  "offset",
+				Usage: "Number of jobs to skip",
+			},
+			&cli.StringFlag{
+				Name:  "sort",
+				Usage: "Sort key (created_at/completed_at/priority/type/status)",
+				Value: "created_at",
+			},
+			&cli.StringFlag{
+				Name:  "order",
+				Usage: "Sort order (asc/desc)",
+				Value: "desc",
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Usage:   "Output format (json/table)",
+				Value:   "table",
+				EnvVars: []string{"EXECUTR_OUTPUT"},
+			},
+		},
+		Action: listJobs,
+	}
+}
+
 func cancelCommand() *cli.Command {
 	return &cli.Command{
 		Name:      "cancel",
-		Usage:     "Cancel a pending job",
+		Usage:     "Cancel a pending or running job",
 		ArgsUsage: "<job-id>",
 		Flags: []cli.Flag{
 			&cli.StringFlag{
@@ -335,12 +888,21 @@ func cancelCommand() *cli.Command {
 				Required: true,
 				EnvVars:  []string{"EXECUTR_SERVER_URL"},
 			},
+			&cli.StringFlag{
+				Name:    "api-key",
+				Usage:   "API key for server authentication",
+				EnvVars: []string{"EXECUTR_API_KEY"},
+			},
 			&cli.StringFlag{
 				Name:    "output",
 				Usage:   "Output format (json/table)",
 				Value:   "table",
 				EnvVars: []string{"EXECUTR_OUTPUT"},
 			},
+			&cli.StringFlag{
+				Name:  "reason",
+				Usage: "Why the job is cancelled, shown with the job until it is cleaned up",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			if c.NArg() < 1 {
@@ -351,23 +913,255 @@ func cancelCommand() *cli.Command {
 	}
 }
 
-// submitJob handles the job submission logic
-func submitJob(c *cli.Context) error {
-	serverURL := c.String("server-url")
-	binaryURL := c.String("binary-url")
-	binarySHA256 := c.String("binary-sha256")
-	jobType := c.String("type")
-	priority := c.String("priority")
-	outputFormat := c.String("output")
+func requeueCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "requeue",
+		Aliases:   []string{"retry"},
+		Usage:     "Run a completed, failed, cancelled or dead-lettered job again as a new job",
+		ArgsUsage: "<job-id>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "server-url",
+				Usage:    "Server API endpoint",
+				Required: true,
+				EnvVars:  []string{"EXECUTR_SERVER_URL"},
+			},
+			&cli.StringFlag{
+				Name:    "api-key",
+				Usage:   "API key for server authentication",
+				EnvVars: []string{"EXECUTR_API_KEY"},
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Usage:   "Output format (json/table)",
+				Value:   "table",
+				EnvVars: []string{"EXECUTR_OUTPUT"},
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() < 1 {
+				return fmt.Errorf("job ID is required")
+			}
+			return requeueJob(c)
+		},
+	}
+}
 
-	// Validate job type (no spaces allowed)
-	if strings.Contains(jobType, " ") {
-		return fmt.Errorf("job type cannot contain spaces")
+func reprioritizeCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "reprioritize",
+		Usage:     "Change the priority of a pending job",
+		ArgsUsage: "<job-id>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "server-url",
+				Usage:    "Server API endpoint",
+				Required: true,
+				EnvVars:  []string{"EXECUTR_SERVER_URL"},
+			},
+			&cli.StringFlag{
+				Name:    "api-key",
+				Usage:   "API key for server authentication",
+				EnvVars: []string{"EXECUTR_API_KEY"},
+			},
+			&cli.StringFlag{
+				Name:     "priority",
+				Usage:    "New priority (foreground/background/best_effort)",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Usage:   "Output format (json/table)",
+				Value:   "table",
+				EnvVars: []string{"EXECUTR_OUTPUT"},
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() < 1 {
+				return fmt.Errorf("job ID is required")
+			}
+			return reprioritizeJob(c)
+		},
 	}
+}
 
-	// Validate priority
-	var jobPriority models.Priority
-	switch priority {
+func schedulesCommand() *cli.Command {
+	flags := []cli.Flag{
+		&cli.StringFlag{
+			Name:     "server-url",
+			Usage:    "Server API endpoint",
+			Required: true,
+			EnvVars:  []string{"EXECUTR_SERVER_URL"},
+		},
+		&cli.StringFlag{
+			Name:    "api-key",
+			Usage:   "API key for server authentication",
+			EnvVars: []string{"EXECUTR_API_KEY"},
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Usage:   "Output format (json/table)",
+			Value:   "table",
+			EnvVars: []string{"EXECUTR_OUTPUT"},
+		},
+	}
+
+	return &cli.Command{
+		Name:  "schedules",
+		Usage: "Manage recurring jobs created with submit --cron",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "List recurring jobs",
+				Flags: flags,
+				Action: func(c *cli.Context) error {
+					return listSchedules(c)
+				},
+			},
+			{
+				Name:      "delete",
+				Usage:     "Delete a recurring job; jobs it already submitted are kept",
+				ArgsUsage: "<schedule-id>",
+				Flags:     flags,
+				Action: func(c *cli.Context) error {
+					if c.NArg() < 1 {
+						return fmt.Errorf("schedule ID is required")
+					}
+					return deleteSchedule(c)
+				},
+			},
+		},
+	}
+}
+
+func limitsCommand() *cli.Command {
+	flags := []cli.Flag{
+		&cli.StringFlag{
+			Name:     "server-url",
+			Usage:    "Server API endpoint",
+			Required: true,
+			EnvVars:  []string{"EXECUTR_SERVER_URL"},
+		},
+		&cli.StringFlag{
+			Name:    "api-key",
+			Usage:   "API key with the admin scope",
+			EnvVars: []string{"EXECUTR_API_KEY"},
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Usage:   "Output format (json/table)",
+			Value:   "table",
+			EnvVars: []string{"EXECUTR_OUTPUT"},
+		},
+	}
+
+	return &cli.Command{
+		Name:  "limits",
+		Usage: "Manage how many jobs of a type may run at once across all executors",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "list",
+				Usage:  "List job type limits and how many jobs of each type are running",
+				Flags:  flags,
+				Action: listJobTypeLimits,
+			},
+			{
+				Name:      "set",
+				Usage:     "Set the limit of a job type; 0 holds back all its jobs",
+				ArgsUsage: "<type> <max-running>",
+				Flags:     flags,
+				Action: func(c *cli.Context) error {
+					if c.NArg() < 2 {
+						return fmt.Errorf("job type and max running jobs are required")
+					}
+					return setJobTypeLimit(c)
+				},
+			},
+			{
+				Name:      "delete",
+				Usage:     "Remove the limit of a job type",
+				ArgsUsage: "<type>",
+				Flags:     flags,
+				Action: func(c *cli.Context) error {
+					if c.NArg() < 1 {
+						return fmt.Errorf("job type is required")
+					}
+					return deleteJobTypeLimit(c)
+				},
+			},
+		},
+	}
+}
+
+func cacheCommand() *cli.Command {
+	flags := []cli.Flag{
+		&cli.StringFlag{
+			Name:    "cache-dir",
+			Usage:   "Binary cache directory",
+			Value:   "~/.executr/cache",
+			EnvVars: []string{"EXECUTR_CACHE_DIR"},
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Usage:   "Output format (json/table)",
+			Value:   "table",
+			EnvVars: []string{"EXECUTR_OUTPUT"},
+		},
+	}
+
+	return &cli.Command{
+		Name:  "cache",
+		Usage: "Inspect and prune an executor's local binary cache",
+		Subcommands: []*cli.Command{
+			{
+				Name:   "list",
+				Usage:  "List cached binaries, least recently used first",
+				Flags:  flags,
+				Action: listCache,
+			},
+			{
+				Name:  "prune",
+				Usage: "Remove cached binaries that weren't used recently or don't fit a size limit",
+				Flags: append([]cli.Flag{
+					&cli.DurationFlag{
+						Name:  "older-than",
+						Usage: "Remove binaries not used within this duration (e.g. 168h)",
+					},
+					&cli.IntFlag{
+						Name:  "max-size",
+						Usage: "Remove least recently used binaries until the cache is at most this size in MB",
+						Value: -1,
+					},
+				}, flags...),
+				Action: pruneCache,
+			},
+			{
+				Name:   "verify",
+				Usage:  "Re-hash cached binaries and remove corrupt ones",
+				Flags:  flags,
+				Action: verifyCache,
+			},
+		},
+	}
+}
+
+// submitJob handles the job submission logic
+func submitJob(c *cli.Context) error {
+	serverURL := c.String("server-url")
+	binaryURL := c.String("binary-url")
+	binarySHA256 := c.String("binary-sha256")
+	jobType := c.String("type")
+	priority := c.String("priority")
+	outputFormat := c.String("output")
+
+	// Validate job type (no spaces allowed)
+	if strings.Contains(jobType, " ") {
+		return fmt.Errorf("job type cannot contain spaces")
+	}
+
+	// Validate priority
+	var jobPriority models.Priority
+	switch priority {
 	case "foreground":
 		jobPriority = models.PriorityForeground
 	case "background":
@@ -388,9 +1182,58 @@ func submitJob(c *cli.Context) error {
 		envVars[parts[0]] = parts[1]
 	}
 
-	// Calculate SHA256 if not provided
-	if binarySHA256 == "" {
-		calculatedSHA, err := calculateSHA256FromURL(binaryURL)
+	labels, err := parseLabels(c.StringSlice("label"))
+	if err != nil {
+		return err
+	}
+
+	// Parse dependencies
+	var dependsOn []uuid.UUID
+	for _, dep := range c.StringSlice("depends-on") {
+		id, err := uuid.Parse(dep)
+		if err != nil {
+			return fmt.Errorf("invalid dependency job ID: %s", dep)
+		}
+		dependsOn = append(dependsOn, id)
+	}
+
+	// Parse schedule
+	var scheduledAt *time.Time
+	if scheduleAt := c.String("schedule-at"); scheduleAt != "" {
+		t, err := parseScheduleAt(scheduleAt, time.Now())
+		if err != nil {
+			return err
+		}
+		scheduledAt = &t
+	}
+
+	stdin, err := readStdinFile(c.String("stdin-file"))
+	if err != nil {
+		return err
+	}
+
+	inputFiles, err := parseInputFiles(c.StringSlice("input-file"))
+	if err != nil {
+		return err
+	}
+
+	if c.Duration("timeout") < 0 || c.Duration("retry-backoff") < 0 {
+		return fmt.Errorf("--timeout and --retry-backoff must not be negative")
+	}
+
+	// Create client
+	cl := client.New(serverURL, client.WithAPIKey(c.String("api-key")))
+
+	// Calculate SHA256 if not provided, unless the server does it. Only
+	// downloads need one: executors cache binaries in images by digest and
+	// read file:// binaries from their own disk.
+	serverHashes := false
+	if binarySHA256 == "" && utils.IsHTTPURL(binaryURL) {
+		health, err := cl.Health(context.Background())
+		serverHashes = err == nil && health.ServerSideHashing
+	}
+	if binarySHA256 == "" && !serverHashes && utils.IsHTTPURL(binaryURL) {
+		calculatedSHA, err := calculateSHA256FromURL(c.Context, binaryURL)
 		if err != nil {
 			return fmt.Errorf("failed to calculate SHA256: %w", err)
 		}
@@ -400,24 +1243,58 @@ func submitJob(c *cli.Context) error {
 		}
 	}
 
-	// Create client
-	cl := client.New(serverURL)
-
 	// Submit job
 	submission := &models.JobSubmission{
-		Type:         jobType,
-		BinaryURL:    binaryURL,
-		BinarySHA256: binarySHA256,
-		Arguments:    c.StringSlice("args"),
-		EnvVariables: envVars,
-		Priority:     jobPriority,
+		Type:                 jobType,
+		BinaryURL:            binaryURL,
+		BinarySHA256:         binarySHA256,
+		Arguments:            c.StringSlice("args"),
+		EnvVariables:         envVars,
+		Priority:             jobPriority,
+		MaxRetries:           c.Int("max-retries"),
+		RetryBackoffBase:     ceilSeconds(c.Duration("retry-backoff")),
+		Timeout:              ceilSeconds(c.Duration("timeout")),
+		MaxOutputBytes:       c.Int("max-output-bytes"),
+		WorkdirQuotaBytes:    c.Int64("workdir-quota"),
+		CPUMillicores:        c.Int("cpu-millicores"),
+		MemLimitBytes:        c.Int64("mem-limit"),
+		SignatureURL:         c.String("signature-url"),
+		PublicKey:            c.String("public-key"),
+		RequiredCapabilities: c.StringSlice("require-capability"),
+		DependsOn:            dependsOn,
+		ScheduledAt:          scheduledAt,
+		Labels:               labels,
+		Stdin:                stdin,
+		InputFiles:           inputFiles,
+		OutputGlobs:          c.StringSlice("output-glob"),
+	}
+
+	if c.Bool("dry-run") {
+		result, err := cl.ValidateJob(context.Background(), submission)
+		if err != nil {
+			return fmt.Errorf("failed to validate job: %w", err)
+		}
+		return printValidationResult(result, outputFormat)
+	}
+
+	if cronSpec := c.String("cron"); cronSpec != "" {
+		submission.CronSpec = cronSpec
+		schedule, err := cl.CreateSchedule(context.Background(), submission)
+		if err != nil {
+			return fmt.Errorf("failed to create schedule: %w", err)
+		}
+		return printSchedule(schedule, outputFormat)
 	}
 
-	job, err := cl.SubmitJob(context.Background(), submission)
+	job, err := cl.SubmitJobWithKey(context.Background(), submission, c.String("idempotency-key"))
 	if err != nil {
 		return fmt.Errorf("failed to submit job: %w", err)
 	}
 
+	if serverHashes && outputFormat != "json" {
+		fmt.Fprintf(os.Stderr, "SHA256 calculated by server: %s\n", job.BinarySHA256)
+	}
+
 	// Output result
 	switch outputFormat {
 	case "json":
@@ -434,9 +1311,146 @@ func submitJob(c *cli.Context) error {
 	}
 }
 
+// printValidationResult prints the outcome of a dry-run submission and
+// fails when the job would not run
+func printValidationResult(result *models.ValidationResult, outputFormat string) error {
+	switch outputFormat {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			return err
+		}
+	default:
+		fmt.Printf("Binary reachable: %t\n", result.BinaryReachable)
+		if result.BinarySHA256 != "" {
+			fmt.Printf("Binary SHA256: %s\n", result.BinarySHA256)
+		}
+		fmt.Printf("SHA256 matches: %t\n", result.SHA256Matches)
+		for _, e := range result.Errors {
+			fmt.Printf("Error: %s\n", e)
+		}
+	}
+
+	if !result.Valid {
+		return fmt.Errorf("job validation failed")
+	}
+	if outputFormat != "json" {
+		fmt.Printf("Job is valid\n")
+	}
+	return nil
+}
+
+// parseLabels parses KEY=VALUE label flags
+func parseLabels(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	labels := make(map[string]string, len(values))
+	for _, label := range values {
+		key, value, ok := strings.Cut(label, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid label format: %s (expected KEY=VALUE)", label)
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
+
+// parseInputFiles parses NAME=URL and NAME=@PATH input file flags, reading
+// local files so they are sent inline
+func parseInputFiles(values []string) (map[string]models.InputFile, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	files := make(map[string]models.InputFile, len(values))
+	for _, value := range values {
+		name, source, ok := strings.Cut(value, "=")
+		if !ok || name == "" || source == "" {
+			return nil, fmt.Errorf("invalid input file format: %s (expected NAME=URL or NAME=@PATH)", value)
+		}
+		if path, ok := strings.CutPrefix(source, "@"); ok {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read input file: %w", err)
+			}
+			files[name] = models.InputFile{Content: content}
+			continue
+		}
+		files[name] = models.InputFile{URL: source}
+	}
+	return files, nil
+}
+
+// readStdinFile reads the data for a job's stdin from path, or from this
+// command's stdin when path is -. An empty path means no stdin.
+func readStdinFile(path string) ([]byte, error) {
+	var data []byte
+	var err error
+	switch path {
+	case "":
+		return nil, nil
+	case "-":
+		data, err = io.ReadAll(os.Stdin)
+	default:
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stdin file: %w", err)
+	}
+	return data, nil
+}
+
+// ceilSeconds converts a duration to whole seconds, rounding up, so a
+// sub-second timeout doesn't become 0, which means no timeout
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// parseScheduleAt parses an RFC3339 time or a duration relative to now,
+// written with a leading plus sign (e.g. +2h or +30m)
+func parseScheduleAt(value string, now time.Time) (time.Time, error) {
+	if rel, ok := strings.CutPrefix(value, "+"); ok {
+		d, err := time.ParseDuration(rel)
+		if err != nil || d < 0 {
+			return time.Time{}, fmt.Errorf("invalid schedule time: %s (expected RFC3339 or +duration)", value)
+		}
+		return now.Add(d), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid schedule time: %s (expected RFC3339 or +duration)", value)
+	}
+	return t, nil
+}
+
+// printSchedule prints a newly created schedule
+func printSchedule(schedule *models.Schedule, outputFormat string) error {
+	switch outputFormat {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(schedule)
+	default:
+		fmt.Printf("Schedule created successfully\n")
+		fmt.Printf("Schedule ID: %s\n", schedule.ID)
+		fmt.Printf("Next Run: %s\n", schedule.NextRunAt.Format("2006-01-02 15:04:05 MST"))
+		return nil
+	}
+}
+
 // calculateSHA256FromURL streams the binary from the URL and calculates SHA256
-func calculateSHA256FromURL(url string) (string, error) {
-	resp, err := http.Get(url)
+func calculateSHA256FromURL(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", utils.DefaultUserAgent)
+
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to download binary: %w", err)
 	}
@@ -467,7 +1481,7 @@ func getJobStatus(c *cli.Context) error {
 	}
 
 	// Create client
-	cl := client.New(serverURL)
+	cl := client.New(serverURL, client.WithAPIKey(c.String("api-key")))
 
 	// Get job
 	job, err := cl.GetJob(context.Background(), jobID)
@@ -486,6 +1500,84 @@ func getJobStatus(c *cli.Context) error {
 	}
 }
 
+// listJobs handles job listing
+func listJobs(c *cli.Context) error {
+	serverURL := c.String("server-url")
+	outputFormat := c.String("output")
+
+	// Validate status
+	if status := c.String("status"); status != "" && !models.Status(status).IsValid() {
+		return fmt.Errorf("invalid status: %s (must be pending/running/completed/failed/cancelled/dead_letter/skipped)", status)
+	}
+
+	// Create client
+	cl := client.New(serverURL, client.WithAPIKey(c.String("api-key")))
+
+	labels, err := parseLabels(c.StringSlice("label"))
+	if err != nil {
+		return err
+	}
+
+	// List jobs
+	result, err := cl.ListJobsPage(context.Background(), &client.ListJobsFilter{
+		Status:     c.String("status"),
+		Type:       c.String("type"),
+		Priority:   c.String("priority"),
+		Labels:     labels,
+		ExecutorID: c.String("executor"),
+		Limit:      c.Int("limit"),
+		Offset:     c.Int("offset"),
+		SortBy:     c.String("sort"),
+		SortOrder:  c.String("order"),
+		// The table doesn't show output, so don't transfer it
+		NoOutput: outputFormat != "json",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	// Output result
+	switch outputFormat {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	default:
+		return printJobList(result)
+	}
+}
+
+// printJobList prints a page of jobs as a table
+func printJobList(result *models.JobList) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "ID\tTYPE\tSTATUS\tPRIORITY\tCREATED\tEXECUTOR\n")
+	for _, job := range result.Jobs {
+		executorID := job.ExecutorID
+		if executorID == "" {
+			executorID = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			job.ID,
+			job.Type,
+			job.Status,
+			job.Priority,
+			job.CreatedAt.Format(time.RFC3339),
+			executorID,
+		)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(result.Jobs) == 0 {
+		fmt.Printf("\nNo jobs found (%d total)\n", result.Total)
+	} else {
+		fmt.Printf("\nShowing %d-%d of %d jobs\n", result.Offset+1, result.Offset+len(result.Jobs), result.Total)
+	}
+	return nil
+}
+
 // printJobTable prints job details in a formatted table
 func printJobTable(job *models.Job) error {
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
@@ -497,6 +1589,9 @@ func printJobTable(job *models.Job) error {
 	fmt.Fprintf(w, "Priority:\t%s\n", job.Priority)
 	fmt.Fprintf(w, "Binary URL:\t%s\n", job.BinaryURL)
 	fmt.Fprintf(w, "Binary SHA256:\t%s\n", job.BinarySHA256)
+	if job.SignatureURL != "" {
+		fmt.Fprintf(w, "Signature URL:\t%s\n", job.SignatureURL)
+	}
 	
 	if len(job.Arguments) > 0 {
 		fmt.Fprintf(w, "Arguments:\t%s\n", strings.Join(job.Arguments, " "))
@@ -505,16 +1600,62 @@ func printJobTable(job *models.Job) error {
 	if len(job.EnvVariables) > 0 {
 		fmt.Fprintf(w, "Environment:\n")
 		for k, v := range job.EnvVariables {
+			if _, ok := models.SecretRef(v); ok {
+				v = "***"
+			}
 			fmt.Fprintf(w, "  %s:\t%s\n", k, v)
 		}
 	}
 	
+	if job.Timeout > 0 {
+		fmt.Fprintf(w, "Timeout:\t%s\n", time.Duration(job.Timeout)*time.Second)
+	}
+	
+	if job.MaxRetries > 0 {
+		fmt.Fprintf(w, "Retries:\t%d/%d\n", job.RetryCount, job.MaxRetries)
+		if job.NextRetryAt != nil && job.Status == models.StatusFailed && job.RetryCount < job.MaxRetries {
+			fmt.Fprintf(w, "Next Retry:\t%s\n", job.NextRetryAt.Format("2006-01-02 15:04:05 MST"))
+		}
+	}
+	
+	if job.MaxOutputBytes > 0 {
+		fmt.Fprintf(w, "Max Output:\t%d bytes\n", job.MaxOutputBytes)
+	}
+	
+	if len(job.Labels) > 0 {
+		keys := make([]string, 0, len(job.Labels))
+		for k := range job.Labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fmt.Fprintf(w, "Labels:\n")
+		for _, k := range keys {
+			fmt.Fprintf(w, "  %s:\t%s\n", k, job.Labels[k])
+		}
+	}
+	
+	if len(job.RequiredCapabilities) > 0 {
+		fmt.Fprintf(w, "Capabilities:\t%s\n", strings.Join(job.RequiredCapabilities, ", "))
+	}
+	
+	if len(job.DependsOn) > 0 {
+		deps := make([]string, len(job.DependsOn))
+		for i, id := range job.DependsOn {
+			deps[i] = id.String()
+		}
+		fmt.Fprintf(w, "Depends On:\t%s\n", strings.Join(deps, ", "))
+	}
+	
 	if job.ExecutorID != "" {
 		fmt.Fprintf(w, "Executor ID:\t%s\n", job.ExecutorID)
 	}
 	
 	fmt.Fprintf(w, "Created At:\t%s\n", job.CreatedAt.Format("2006-01-02 15:04:05 MST"))
 	
+	if job.ScheduledAt != nil {
+		fmt.Fprintf(w, "Scheduled At:\t%s\n", job.ScheduledAt.Format("2006-01-02 15:04:05 MST"))
+	}
+	
 	if job.StartedAt != nil {
 		fmt.Fprintf(w, "Started At:\t%s\n", job.StartedAt.Format("2006-01-02 15:04:05 MST"))
 	}
@@ -527,16 +1668,44 @@ func printJobTable(job *models.Job) error {
 		fmt.Fprintf(w, "Last Heartbeat:\t%s\n", job.LastHeartbeat.Format("2006-01-02 15:04:05 MST"))
 	}
 	
+	if job.EstimatedCompletion != nil {
+		fmt.Fprintf(w, "Estimated Completion:\t%s (in about %s)\n",
+			job.EstimatedCompletion.Format("2006-01-02 15:04:05 MST"),
+			max(time.Until(*job.EstimatedCompletion), 0).Round(time.Second))
+	}
+	
 	if job.ErrorMessage != "" {
 		fmt.Fprintf(w, "Error:\t%s\n", job.ErrorMessage)
 	}
 	
+	if job.CancellationReason != "" {
+		fmt.Fprintf(w, "Cancellation Reason:\t%s\n", job.CancellationReason)
+	}
+	
 	if job.ExitCode != nil {
 		fmt.Fprintf(w, "Exit Code:\t%d\n", *job.ExitCode)
 	}
 	
+	if len(job.ResultJSON) > 0 {
+		var result bytes.Buffer
+		if err := json.Indent(&result, job.ResultJSON, "", "  "); err != nil {
+			result.Reset()
+			result.Write(job.ResultJSON)
+		}
+		fmt.Fprintf(w, "\n=== RESULT ===\n")
+		fmt.Fprintln(w, result.String())
+	}
+	
 	// Show output if job is completed or failed
 	if job.Status == models.StatusCompleted || job.Status == models.StatusFailed {
+		if job.StdoutURL != "" {
+			fmt.Fprintf(w, "Stdout URL:\t%s\n", job.StdoutURL)
+		}
+		
+		if job.StderrURL != "" {
+			fmt.Fprintf(w, "Stderr URL:\t%s\n", job.StderrURL)
+		}
+		
 		if job.Stdout != "" {
 			fmt.Fprintf(w, "\n=== STDOUT ===\n")
 			fmt.Fprintln(w, job.Stdout)
@@ -564,10 +1733,10 @@ func cancelJob(c *cli.Context) error {
 	}
 
 	// Create client
-	cl := client.New(serverURL)
+	cl := client.New(serverURL, client.WithAPIKey(c.String("api-key")))
 
 	// Cancel job
-	err = cl.CancelJob(context.Background(), jobID)
+	err = cl.CancelJobWithReason(context.Background(), jobID, c.String("reason"))
 	if err != nil {
 		return fmt.Errorf("failed to cancel job: %w", err)
 	}
@@ -587,4 +1756,371 @@ func cancelJob(c *cli.Context) error {
 		fmt.Printf("Job ID: %s\n", jobID.String())
 		return nil
 	}
+}
+
+// requeueJob handles job requeueing
+func requeueJob(c *cli.Context) error {
+	serverURL := c.String("server-url")
+	outputFormat := c.String("output")
+	jobIDStr := c.Args().First()
+
+	// Parse job ID
+	jobID, err := uuid.Parse(jobIDStr)
+	if err != nil {
+		return fmt.Errorf("invalid job ID: %w", err)
+	}
+
+	// Create client
+	cl := client.New(serverURL, client.WithAPIKey(c.String("api-key")))
+
+	// Requeue job
+	job, err := cl.RequeueJob(context.Background(), jobID)
+	if err != nil {
+		return fmt.Errorf("failed to requeue job: %w", err)
+	}
+
+	// Output result
+	switch outputFormat {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(job)
+	default:
+		fmt.Printf("Job requeued successfully\n")
+		fmt.Printf("Original Job ID: %s\n", jobID.String())
+		fmt.Printf("New Job ID: %s\n", job.ID.String())
+		return nil
+	}
+}
+
+// reprioritizeJob handles changing the priority of a pending job
+func reprioritizeJob(c *cli.Context) error {
+	jobID, err := uuid.Parse(c.Args().First())
+	if err != nil {
+		return fmt.Errorf("invalid job ID: %w", err)
+	}
+
+	priority := models.Priority(c.String("priority"))
+	if !priority.IsValid() {
+		return fmt.Errorf("invalid priority: %s (must be foreground/background/best_effort)", priority)
+	}
+
+	cl := client.New(c.String("server-url"), client.WithAPIKey(c.String("api-key")))
+
+	job, err := cl.UpdateJobPriority(context.Background(), jobID, priority)
+	if err != nil {
+		return fmt.Errorf("failed to reprioritize job: %w", err)
+	}
+
+	switch c.String("output") {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(job)
+	default:
+		fmt.Printf("Job %s now has priority %s\n", job.ID, job.Priority)
+		return nil
+	}
+}
+
+// listSchedules handles listing recurring jobs
+func listSchedules(c *cli.Context) error {
+	cl := client.New(c.String("server-url"), client.WithAPIKey(c.String("api-key")))
+
+	schedules, err := cl.ListSchedules(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to list schedules: %w", err)
+	}
+
+	switch c.String("output") {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(schedules)
+	default:
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "ID\tCRON\tTYPE\tLAST RUN\tNEXT RUN\n")
+		for _, schedule := range schedules {
+			lastRun := "-"
+			if schedule.LastRunAt != nil {
+				lastRun = schedule.LastRunAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				schedule.ID,
+				schedule.CronSpec,
+				schedule.Template.Type,
+				lastRun,
+				schedule.NextRunAt.Format(time.RFC3339),
+			)
+		}
+		return w.Flush()
+	}
+}
+
+// deleteSchedule handles deleting a recurring job
+func deleteSchedule(c *cli.Context) error {
+	scheduleID, err := uuid.Parse(c.Args().First())
+	if err != nil {
+		return fmt.Errorf("invalid schedule ID: %w", err)
+	}
+
+	cl := client.New(c.String("server-url"), client.WithAPIKey(c.String("api-key")))
+
+	if err := cl.DeleteSchedule(context.Background(), scheduleID); err != nil {
+		return fmt.Errorf("failed to delete schedule: %w", err)
+	}
+
+	switch c.String("output") {
+	case "json":
+		output := map[string]string{
+			"status":      "deleted",
+			"schedule_id": scheduleID.String(),
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(output)
+	default:
+		fmt.Printf("Schedule deleted successfully\n")
+		fmt.Printf("Schedule ID: %s\n", scheduleID)
+		return nil
+	}
+}
+
+// listJobTypeLimits handles listing the concurrency limits of job types
+func listJobTypeLimits(c *cli.Context) error {
+	cl := client.New(c.String("server-url"), client.WithAPIKey(c.String("api-key")))
+
+	limits, err := cl.ListJobTypeLimits(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to list job type limits: %w", err)
+	}
+
+	switch c.String("output") {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(limits)
+	default:
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "TYPE\tRUNNING\tMAX RUNNING\tUPDATED\n")
+		for _, limit := range limits {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%s\n",
+				limit.Type,
+				limit.Running,
+				limit.MaxRunning,
+				limit.UpdatedAt.Format(time.RFC3339),
+			)
+		}
+		return w.Flush()
+	}
+}
+
+// setJobTypeLimit handles setting the concurrency limit of a job type
+func setJobTypeLimit(c *cli.Context) error {
+	jobType := c.Args().Get(0)
+	maxRunning, err := strconv.Atoi(c.Args().Get(1))
+	if err != nil || maxRunning < 0 {
+		return fmt.Errorf("invalid max running jobs %q: must be a non-negative number", c.Args().Get(1))
+	}
+
+	cl := client.New(c.String("server-url"), client.WithAPIKey(c.String("api-key")))
+
+	limit, err := cl.SetJobTypeLimit(context.Background(), jobType, maxRunning)
+	if err != nil {
+		return fmt.Errorf("failed to set job type limit: %w", err)
+	}
+
+	switch c.String("output") {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(limit)
+	default:
+		fmt.Printf("Job type limit set successfully\n")
+		fmt.Printf("Type: %s\n", limit.Type)
+		fmt.Printf("Max Running: %d\n", limit.MaxRunning)
+		return nil
+	}
+}
+
+// deleteJobTypeLimit handles removing the concurrency limit of a job type
+func deleteJobTypeLimit(c *cli.Context) error {
+	jobType := c.Args().First()
+
+	cl := client.New(c.String("server-url"), client.WithAPIKey(c.String("api-key")))
+
+	if err := cl.DeleteJobTypeLimit(context.Background(), jobType); err != nil {
+		return fmt.Errorf("failed to delete job type limit: %w", err)
+	}
+
+	switch c.String("output") {
+	case "json":
+		output := map[string]string{
+			"status": "deleted",
+			"type":   jobType,
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(output)
+	default:
+		fmt.Printf("Job type limit deleted successfully\n")
+		fmt.Printf("Type: %s\n", jobType)
+		return nil
+	}
+}
+
+// openCache opens the binary cache in --cache-dir
+func openCache(c *cli.Context) (*executor.BinaryCache, error) {
+	cacheDir, err := executor.ExpandHome(c.String("cache-dir"))
+	if err != nil {
+		return nil, err
+	}
+
+	// The size limits only apply to downloads
+	return executor.NewBinaryCache(cacheDir, 0, 0)
+}
+
+// listCache handles listing the cached binaries
+func listCache(c *cli.Context) error {
+	cache, err := openCache(c)
+	if err != nil {
+		return err
+	}
+
+	return printCachedBinaries(cache.List(), c.String("output"), "No cached binaries")
+}
+
+// pruneCache handles removing cached binaries by age and size
+func pruneCache(c *cli.Context) error {
+	olderThan := c.Duration("older-than")
+	maxSize := c.Int("max-size")
+	if olderThan <= 0 && maxSize < 0 {
+		return fmt.Errorf("--older-than or --max-size is required")
+	}
+
+	cache, err := openCache(c)
+	if err != nil {
+		return err
+	}
+
+	var removed []executor.CachedBinary
+	if olderThan > 0 {
+		removed = append(removed, cache.Prune(olderThan)...)
+	}
+	if maxSize >= 0 {
+		removed = append(removed, cache.PruneToSize(maxSize)...)
+	}
+
+	return printCachedBinaries(removed, c.String("output"), "No cached binaries removed")
+}
+
+// verifyCache handles re-hashing the cached binaries
+func verifyCache(c *cli.Context) error {
+	cache, err := openCache(c)
+	if err != nil {
+		return err
+	}
+
+	checked := len(cache.List())
+	corrupt := cache.Verify()
+
+	if c.String("output") != "json" {
+		fmt.Printf("Verified %d cached binaries, %d corrupt\n", checked, len(corrupt))
+		if len(corrupt) == 0 {
+			return nil
+		}
+		fmt.Printf("\nRemoved:\n")
+	}
+	return printCachedBinaries(corrupt, c.String("output"), "")
+}
+
+// printCachedBinaries prints cached binaries, or empty when there are none
+func printCachedBinaries(binaries []executor.CachedBinary, outputFormat, empty string) error {
+	if binaries == nil {
+		binaries = []executor.CachedBinary{}
+	}
+
+	switch outputFormat {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(binaries)
+	default:
+		if len(binaries) == 0 {
+			fmt.Println(empty)
+			return nil
+		}
+
+		var total int64
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "SHA256\tSIZE\tLAST ACCESS\n")
+		for _, binary := range binaries {
+			fmt.Fprintf(w, "%s\t%d\t%s\n",
+				binary.SHA256,
+				binary.Size,
+				binary.LastAccess.Format(time.RFC3339),
+			)
+			total += binary.Size
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Printf("\n%d binaries, %d bytes\n", len(binaries), total)
+		return nil
+	}
+}
+
+func versionCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "version",
+		Usage: "Show the version of the CLI and, with --server-url, of the server",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "server-url",
+				Usage:   "Server API endpoint",
+				EnvVars: []string{"EXECUTR_SERVER_URL"},
+			},
+			&cli.StringFlag{
+				Name:    "api-key",
+				Usage:   "API key for server authentication",
+				EnvVars: []string{"EXECUTR_API_KEY"},
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Usage:   "Output format (json/table)",
+				Value:   "table",
+				EnvVars: []string{"EXECUTR_OUTPUT"},
+			},
+		},
+		Action: func(c *cli.Context) error {
+			result := map[string]models.VersionInfo{"client": buildInfo()}
+
+			if serverURL := c.String("server-url"); serverURL != "" {
+				cl := client.New(serverURL, client.WithAPIKey(c.String("api-key")))
+				info, err := cl.Version(context.Background())
+				if err != nil {
+					return fmt.Errorf("failed to get server version: %w", err)
+				}
+				result["server"] = *info
+			}
+
+			if c.String("output") == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(result)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "COMPONENT\tVERSION\tCOMMIT\tBUILD DATE")
+			for _, component := range []string{"client", "server"} {
+				info, ok := result[component]
+				if !ok {
+					continue
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", component, info.Version, info.Commit, info.BuildDate)
+			}
+			return w.Flush()
+		},
+	}
 }
\ No newline at end of file
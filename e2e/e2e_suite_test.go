@@ -15,6 +15,8 @@ import (
 
 	"github.com/draganm/executr/internal/server"
 	"github.com/draganm/executr/pkg/client"
+	"github.com/draganm/executr/pkg/models"
+	"github.com/google/uuid"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/testcontainers/testcontainers-go"
@@ -78,8 +80,12 @@ var _ = BeforeSuite(func() {
 		Port:             0, // Use random available port
 		CleanupInterval:  3600,
 		JobRetention:     172800,
-		HeartbeatTimeout: 15,
+		HeartbeatTimeout: 6,
+		RetryInterval:    1,
 		LogLevel:         "error",
+		HashBinaries:     true,
+
+		SensitiveEnvPatterns: server.DefaultSensitiveEnvPatterns,
 	}
 
 	serverInstance, err = server.New(serverConfig)
@@ -162,6 +168,17 @@ func getBinaryURL(filename string) string {
 	return binaryServer.URL + "/" + filename
 }
 
+// waitForJob waits up to timeout for a job to reach one of states and
+// returns it
+func waitForJob(jobID uuid.UUID, timeout time.Duration, states ...models.Status) *models.Job {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	job, err := testClient.WaitForJob(ctx, jobID, states...)
+	Expect(err).NotTo(HaveOccurred())
+	return job
+}
+
 func createTempDir() string {
 	dir, err := os.MkdirTemp("", "executr-test-*")
 	Expect(err).NotTo(HaveOccurred())
@@ -175,6 +192,10 @@ func buildTestBinaries() error {
 		"failure",
 		"longrunning",
 		"output",
+		"forker",
+		"cat",
+		"diskfill",
+		"result",
 	}
 
 	for _, binary := range binaries {
@@ -199,6 +220,10 @@ func cleanupTestBinaries() {
 		"testdata/binaries/failure",
 		"testdata/binaries/longrunning",
 		"testdata/binaries/output",
+		"testdata/binaries/forker",
+		"testdata/binaries/cat",
+		"testdata/binaries/diskfill",
+		"testdata/binaries/result",
 	}
 
 	for _, binary := range binaries {
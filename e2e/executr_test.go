@@ -4,18 +4,26 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/draganm/executr/internal/executor"
-	"github.com/draganm/executr/internal/models"
+	"github.com/draganm/executr/internal/metrics"
+	"github.com/draganm/executr/internal/server"
+	"github.com/draganm/executr/pkg/client"
+	"github.com/draganm/executr/pkg/models"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 var _ = Describe("Executr E2E Tests", func() {
@@ -74,23 +82,54 @@ var _ = Describe("Executr E2E Tests", func() {
 			}()
 
 			// Wait for job to complete
-			Eventually(func() models.Status {
-				job, err := testClient.GetJob(context.Background(), job.ID)
-				if err != nil {
-					return ""
-				}
-				return job.Status
-			}, 30*time.Second, 500*time.Millisecond).Should(Equal(models.StatusCompleted))
+			completedJob := waitForJob(job.ID, 30*time.Second, models.StatusCompleted)
 
 			// Verify job results
-			completedJob, err := testClient.GetJob(context.Background(), job.ID)
-			Expect(err).NotTo(HaveOccurred())
 			Expect(completedJob.ExitCode).NotTo(BeNil())
 			Expect(*completedJob.ExitCode).To(Equal(0))
 			Expect(completedJob.Stdout).To(ContainSubstring("Hello from success binary"))
 			Expect(completedJob.Stdout).To(ContainSubstring("Arguments: [arg1 arg2]"))
 			Expect(completedJob.Stdout).To(ContainSubstring("TEST_ENV=test_value"))
 			Expect(completedJob.Stderr).To(ContainSubstring("This is stderr output"))
+
+			Expect(completedJob.Attempts).To(HaveLen(1))
+			Expect(completedJob.Attempts[0].ExecutorID).To(Equal(completedJob.ExecutorID))
+			Expect(completedJob.Attempts[0].Status).To(Equal("completed"))
+			Expect(completedJob.Attempts[0].EndedAt).NotTo(BeNil())
+
+			// Output and metadata can be fetched separately
+			output, err := testClient.GetJobOutput(context.Background(), job.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output.Stdout).To(Equal(completedJob.Stdout))
+			Expect(output.Stderr).To(Equal(completedJob.Stderr))
+			Expect(output.ExitCode).NotTo(BeNil())
+			Expect(*output.ExitCode).To(Equal(0))
+
+			metadata, err := testClient.GetJobMetadata(context.Background(), job.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metadata.Status).To(Equal(models.StatusCompleted))
+			Expect(metadata.Stdout).To(BeEmpty())
+			Expect(metadata.Stderr).To(BeEmpty())
+			Expect(metadata.Attempts).To(HaveLen(1))
+
+			_, err = testClient.GetJobOutput(context.Background(), uuid.New())
+			Expect(client.IsNotFound(err)).To(BeTrue())
+
+			exists, status, err := testClient.JobExists(context.Background(), job.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exists).To(BeTrue())
+			Expect(status).To(Equal(models.StatusCompleted))
+
+			exists, _, err = testClient.JobExists(context.Background(), uuid.New())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exists).To(BeFalse())
+
+			// An unchanged job isn't downloaded again
+			_, etag, err := testClient.GetJobIfChanged(context.Background(), job.ID, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(etag).NotTo(BeEmpty())
+			_, _, err = testClient.GetJobIfChanged(context.Background(), job.ID, etag)
+			Expect(client.IsNotModified(err)).To(BeTrue())
 		})
 
 		It("should handle job failure correctly", func() {
@@ -130,20 +169,128 @@ var _ = Describe("Executr E2E Tests", func() {
 			}()
 
 			// Wait for job to fail
-			Eventually(func() models.Status {
-				job, err := testClient.GetJob(context.Background(), job.ID)
-				if err != nil {
-					return ""
-				}
-				return job.Status
-			}, 30*time.Second, 500*time.Millisecond).Should(Equal(models.StatusFailed))
+			failedJob := waitForJob(job.ID, 30*time.Second, models.StatusFailed)
 
 			// Verify failure details
-			failedJob, err := testClient.GetJob(context.Background(), job.ID)
-			Expect(err).NotTo(HaveOccurred())
 			Expect(failedJob.ExitCode).NotTo(BeNil())
 			Expect(*failedJob.ExitCode).To(Equal(42))
 			Expect(failedJob.Stderr).To(ContainSubstring("ERROR: Intentional failure"))
+
+			// The attempt is closed with the failure
+			Expect(failedJob.Attempts).To(HaveLen(1))
+			Expect(failedJob.Attempts[0].Status).To(Equal("failed"))
+			Expect(failedJob.Attempts[0].EndedAt).NotTo(BeNil())
+			Expect(failedJob.Attempts[0].ErrorMessage).NotTo(BeEmpty())
+		})
+
+		It("should return the failed job from SubmitAndWait", func() {
+			execCtx, execCancel := context.WithCancel(context.Background())
+			defer execCancel()
+
+			execConfig := &executor.Config{
+				ServerURL:         serverURL,
+				Name:              "test-executor-submit-and-wait",
+				CacheDir:          filepath.Join(createTempDir(), "cache"),
+				WorkDir:           filepath.Join(createTempDir(), "work"),
+				MaxJobs:           1,
+				PollInterval:      1,
+				MaxCacheSize:      100,
+				HeartbeatInterval: 2,
+				NetworkTimeout:    60,
+			}
+
+			exec, err := executor.New(execConfig)
+			Expect(err).NotTo(HaveOccurred())
+
+			go func() {
+				exec.Run(execCtx)
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			job, err := testClient.SubmitAndWait(ctx, &models.JobSubmission{
+				Type:         "test-submit-and-wait",
+				BinaryURL:    getBinaryURL("failure"),
+				BinarySHA256: failureBinarySHA256,
+				Arguments:    []string{"7"},
+				Priority:     models.PriorityForeground,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.Status).To(Equal(models.StatusFailed))
+			Expect(job.ExitCode).NotTo(BeNil())
+			Expect(*job.ExitCode).To(Equal(7))
+		})
+	})
+
+	Describe("Job Context", func() {
+		It("should tell the binary which job it runs as", func() {
+			job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:         "job-context",
+				BinaryURL:    getBinaryURL("success"),
+				BinarySHA256: successBinarySHA256,
+				Priority:     models.PriorityForeground,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			execCtx, execCancel := context.WithCancel(context.Background())
+			defer execCancel()
+
+			exec, err := executor.New(&executor.Config{
+				ServerURL:         serverURL,
+				Name:              "context-executor",
+				CacheDir:          filepath.Join(createTempDir(), "cache"),
+				WorkDir:           filepath.Join(createTempDir(), "work"),
+				MaxJobs:           1,
+				PollInterval:      1,
+				MaxCacheSize:      100,
+				HeartbeatInterval: 2,
+				NetworkTimeout:    60,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			go func() {
+				exec.Run(execCtx)
+			}()
+
+			completed := waitForJob(job.ID, 30*time.Second, models.StatusCompleted)
+			Expect(completed.Stdout).To(ContainSubstring("EXECUTR_JOB_ID=" + job.ID.String() + "\n"))
+			Expect(completed.Stdout).To(ContainSubstring("EXECUTR_EXECUTOR_ID=" + completed.ExecutorID + "\n"))
+			Expect(completed.Stdout).To(ContainSubstring("EXECUTR_SERVER_URL=" + serverURL + "\n"))
+		})
+
+		It("should let the job's env variables override the context", func() {
+			job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:         "job-context",
+				BinaryURL:    getBinaryURL("success"),
+				BinarySHA256: successBinarySHA256,
+				Priority:     models.PriorityForeground,
+				EnvVariables: map[string]string{"EXECUTR_SERVER_URL": "http://callback:8080"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			execCtx, execCancel := context.WithCancel(context.Background())
+			defer execCancel()
+
+			exec, err := executor.New(&executor.Config{
+				ServerURL:         serverURL,
+				Name:              "context-executor",
+				CacheDir:          filepath.Join(createTempDir(), "cache"),
+				WorkDir:           filepath.Join(createTempDir(), "work"),
+				MaxJobs:           1,
+				PollInterval:      1,
+				MaxCacheSize:      100,
+				HeartbeatInterval: 2,
+				NetworkTimeout:    60,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			go func() {
+				exec.Run(execCtx)
+			}()
+
+			completed := waitForJob(job.ID, 30*time.Second, models.StatusCompleted)
+			Expect(completed.Stdout).To(ContainSubstring("EXECUTR_SERVER_URL=http://callback:8080\n"))
 		})
 	})
 
@@ -306,37 +453,26 @@ var _ = Describe("Executr E2E Tests", func() {
 		})
 	})
 
-	Describe("Job Cancellation", func() {
-		It("should cancel pending jobs", func() {
-			// Submit a job
-			submission := &models.JobSubmission{
-				Type:         "cancel-test",
-				BinaryURL:    getBinaryURL("longrunning"),
-				BinarySHA256: calculateFileSHA256("testdata/binaries/longrunning"),
-				Arguments:    []string{"30s"},
-				Priority:     models.PriorityBackground,
-			}
-
-			job, err := testClient.SubmitJob(context.Background(), submission)
-			Expect(err).NotTo(HaveOccurred())
-			Expect(job.Status).To(Equal(models.StatusPending))
-
-			// Cancel the job before executor picks it up
-			err = testClient.CancelJob(context.Background(), job.ID)
-			Expect(err).NotTo(HaveOccurred())
-
-			// Verify job is cancelled
-			cancelledJob, err := testClient.GetJob(context.Background(), job.ID)
+	Describe("Scheduled Jobs", func() {
+		It("should not claim a scheduled job before its time", func() {
+			scheduledAt := time.Now().Add(5 * time.Second).Truncate(time.Second)
+			job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:         "scheduled",
+				BinaryURL:    getBinaryURL("success"),
+				BinarySHA256: successBinarySHA256,
+				Priority:     models.PriorityForeground,
+				ScheduledAt:  &scheduledAt,
+			})
 			Expect(err).NotTo(HaveOccurred())
-			Expect(cancelledJob.Status).To(Equal(models.StatusCancelled))
+			Expect(job.ScheduledAt).NotTo(BeNil())
+			Expect(job.ScheduledAt.Equal(scheduledAt)).To(BeTrue())
 
-			// Start executor and verify it doesn't pick up cancelled job
-			execCtx, execCancel := context.WithTimeout(context.Background(), 5*time.Second)
+			execCtx, execCancel := context.WithCancel(context.Background())
 			defer execCancel()
 
-			execConfig := &executor.Config{
+			exec, err := executor.New(&executor.Config{
 				ServerURL:         serverURL,
-				Name:              "cancel-executor",
+				Name:              "scheduled-executor",
 				CacheDir:          filepath.Join(createTempDir(), "cache"),
 				WorkDir:           filepath.Join(createTempDir(), "work"),
 				MaxJobs:           1,
@@ -344,144 +480,1817 @@ var _ = Describe("Executr E2E Tests", func() {
 				MaxCacheSize:      100,
 				HeartbeatInterval: 2,
 				NetworkTimeout:    60,
-			}
-
-			exec, err := executor.New(execConfig)
+			})
 			Expect(err).NotTo(HaveOccurred())
 
 			go func() {
 				exec.Run(execCtx)
 			}()
 
-			// Wait and verify job remains cancelled
-			time.Sleep(3 * time.Second)
-			stillCancelled, err := testClient.GetJob(context.Background(), job.ID)
-			Expect(err).NotTo(HaveOccurred())
-			Expect(stillCancelled.Status).To(Equal(models.StatusCancelled))
-		})
-	})
-
-	Describe("Multiple Executor Coordination", func() {
-		It("should coordinate multiple executors with different names", func() {
-			// Submit multiple jobs
-			var jobIDs []uuid.UUID
-			for i := 0; i < 3; i++ {
-				submission := &models.JobSubmission{
-					Type:         fmt.Sprintf("multi-exec-%d", i),
-					BinaryURL:    getBinaryURL("success"),
-					BinarySHA256: successBinarySHA256,
-					Arguments:    []string{fmt.Sprintf("job-%d", i)},
-					Priority:     models.PriorityBackground,
+			// The executor polls every second but must leave the job alone
+			Consistently(func() models.Status {
+				job, err := testClient.GetJob(context.Background(), job.ID)
+				if err != nil {
+					return ""
 				}
+				return job.Status
+			}, 3*time.Second, 500*time.Millisecond).Should(Equal(models.StatusPending))
 
-				job, err := testClient.SubmitJob(context.Background(), submission)
-				Expect(err).NotTo(HaveOccurred())
-				jobIDs = append(jobIDs, job.ID)
-			}
-
-			// Start multiple executors
-			execCtx, execCancel := context.WithCancel(context.Background())
-			defer execCancel()
-
-			for i := 0; i < 2; i++ {
-				execConfig := &executor.Config{
-					ServerURL:         serverURL,
-					Name:              fmt.Sprintf("executor-%d", i),
-					CacheDir:          filepath.Join(createTempDir(), fmt.Sprintf("cache-%d", i)),
-					WorkDir:           filepath.Join(createTempDir(), fmt.Sprintf("work-%d", i)),
-					MaxJobs:           1,
-					PollInterval:      1,
-					MaxCacheSize:      100,
-					HeartbeatInterval: 2,
-					NetworkTimeout:    60,
-				}
+			completedJob := waitForJob(job.ID, 30*time.Second, models.StatusCompleted)
+			Expect(completedJob.StartedAt).NotTo(BeNil())
+			Expect(completedJob.StartedAt.Before(scheduledAt)).To(BeFalse())
+		})
+	})
 
-				exec, err := executor.New(execConfig)
-				Expect(err).NotTo(HaveOccurred())
+	Describe("Recurring Jobs", func() {
+		It("should submit a job each time the cron spec fires", func() {
+			jobType := "recurring-" + uuid.New().String()[:8]
+			schedule, err := testClient.CreateSchedule(context.Background(), &models.JobSubmission{
+				Type:         jobType,
+				BinaryURL:    getBinaryURL("success"),
+				BinarySHA256: successBinarySHA256,
+				Priority:     models.PriorityBackground,
+				CronSpec:     "* * * * *",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(schedule.CronSpec).To(Equal("* * * * *"))
+			Expect(schedule.Template.Type).To(Equal(jobType))
+			Expect(schedule.NextRunAt.After(time.Now().Add(-time.Minute))).To(BeTrue())
 
-				go func(e *executor.Executor) {
-					e.Run(execCtx)
-				}(exec)
-			}
+			schedules, err := testClient.ListSchedules(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(schedules).To(ContainElement(HaveField("ID", schedule.ID)))
 
-			// Wait for all jobs to complete
-			Eventually(func() bool {
-				for _, jobID := range jobIDs {
-					job, err := testClient.GetJob(context.Background(), jobID)
-					if err != nil || job.Status != models.StatusCompleted {
-						return false
-					}
+			// The first run happens at the next full minute
+			Eventually(func() int {
+				jobs, err := testClient.ListJobs(context.Background(), &client.ListJobsFilter{Type: jobType})
+				if err != nil {
+					return 0
 				}
-				return true
-			}, 30*time.Second, 500*time.Millisecond).Should(BeTrue())
+				return len(jobs)
+			}, 90*time.Second, time.Second).Should(Equal(1))
 
-			// Verify all jobs completed and have executor IDs with correct format
-			executorIDs := make(map[string]bool)
-			for _, jobID := range jobIDs {
-				job, err := testClient.GetJob(context.Background(), jobID)
-				Expect(err).NotTo(HaveOccurred())
-				Expect(job.ExecutorID).NotTo(BeEmpty())
-				
-				// Verify executor ID format: {name}-{suffix}
-				Expect(job.ExecutorID).To(MatchRegexp(`executor-\d-[a-f0-9\-]+`))
-				executorIDs[job.ExecutorID] = true
-			}
+			err = testClient.DeleteSchedule(context.Background(), schedule.ID)
+			Expect(err).NotTo(HaveOccurred())
 
-			// Should have at least one unique executor ID (could be 1 or 2 depending on scheduling)
-			Expect(len(executorIDs)).To(BeNumerically(">=", 1))
-			Expect(len(executorIDs)).To(BeNumerically("<=", 2))
+			err = testClient.DeleteSchedule(context.Background(), schedule.ID)
+			Expect(client.IsNotFound(err)).To(BeTrue())
 		})
-	})
 
-	Describe("Output Truncation", func() {
-		It("should truncate large output correctly", func() {
-			// Submit job that generates lots of output
-			submission := &models.JobSubmission{
-				Type:         "output-test",
-				BinaryURL:    getBinaryURL("output"),
-				BinarySHA256: outputBinarySHA256,
-				Arguments:    []string{"10000"}, // Generate 10000 lines
+		It("should reject an invalid cron spec", func() {
+			_, err := testClient.CreateSchedule(context.Background(), &models.JobSubmission{
+				Type:         "recurring-invalid",
+				BinaryURL:    getBinaryURL("success"),
+				BinarySHA256: successBinarySHA256,
 				Priority:     models.PriorityBackground,
-			}
+				CronSpec:     "61 * * * *",
+			})
+			Expect(client.IsBadRequest(err)).To(BeTrue())
+		})
+	})
 
-			job, err := testClient.SubmitJob(context.Background(), submission)
+	Describe("Stale Job Recovery", func() {
+		It("should reset a job whose executor stops sending heartbeats", func() {
+			// A capability no real executor has, so only this test claims it
+			capability := "stale-" + uuid.New().String()[:8]
+			job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:                 "stale",
+				BinaryURL:            getBinaryURL("success"),
+				BinarySHA256:         successBinarySHA256,
+				Priority:             models.PriorityForeground,
+				RequiredCapabilities: []string{capability},
+			})
 			Expect(err).NotTo(HaveOccurred())
 
-			// Start executor
-			execCtx, execCancel := context.WithCancel(context.Background())
-			defer execCancel()
+			recoveredBefore := testutil.ToFloat64(metrics.StaleJobsRecovered)
 
-			execConfig := &executor.Config{
-				ServerURL:         serverURL,
-				Name:              "output-executor",
-				CacheDir:          filepath.Join(createTempDir(), "cache"),
-				WorkDir:           filepath.Join(createTempDir(), "work"),
-				MaxJobs:           1,
-				PollInterval:      1,
-				MaxCacheSize:      100,
-				HeartbeatInterval: 2,
-				NetworkTimeout:    60,
-			}
+			claimed, err := testClient.ClaimNextJob(context.Background(), "vanishing-executor", "127.0.0.1", []string{capability})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(claimed).NotTo(BeNil())
+			Expect(claimed.ID).To(Equal(job.ID))
+
+			// No heartbeat follows, so the job is reset once the 6s timeout passes
+			reset := waitForJob(job.ID, 20*time.Second, models.StatusPending)
+			Expect(reset.ExecutorID).To(BeEmpty())
+			Expect(testutil.ToFloat64(metrics.StaleJobsRecovered)).To(BeNumerically(">", recoveredBefore))
+			Expect(reset.Attempts).To(HaveLen(1))
+			Expect(reset.Attempts[0].ExecutorID).To(Equal("vanishing-executor"))
+			Expect(reset.Attempts[0].Status).To(Equal("timeout"))
+
+			Expect(testClient.CancelJob(context.Background(), job.ID)).To(Succeed())
+		})
 
-			exec, err := executor.New(execConfig)
+		It("should reset a claimed job that never recorded a heartbeat", func() {
+			capability := "stale-" + uuid.New().String()[:8]
+			job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:                 "stale",
+				BinaryURL:            getBinaryURL("success"),
+				BinarySHA256:         successBinarySHA256,
+				Priority:             models.PriorityForeground,
+				RequiredCapabilities: []string{capability},
+			})
 			Expect(err).NotTo(HaveOccurred())
 
-			go func() {
-				exec.Run(execCtx)
+			claimed, err := testClient.ClaimNextJob(context.Background(), "crashed-executor", "127.0.0.1", []string{capability})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(claimed).NotTo(BeNil())
+
+			// As if the executor crashed before its first heartbeat was recorded
+			conn, err := pgx.Connect(context.Background(), dbURL)
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close(context.Background())
+			_, err = conn.Exec(context.Background(), "UPDATE jobs SET last_heartbeat = NULL WHERE id = $1", job.ID)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Measured from the claim, so it's reset once the 6s timeout passes
+			reset := waitForJob(job.ID, 20*time.Second, models.StatusPending)
+			Expect(reset.ExecutorID).To(BeEmpty())
+			Expect(reset.Attempts).To(HaveLen(1))
+			Expect(reset.Attempts[0].Status).To(Equal("timeout"))
+
+			Expect(testClient.CancelJob(context.Background(), job.ID)).To(Succeed())
+		})
+	})
+
+	Describe("Job Retention", func() {
+		var capability string
+
+		BeforeEach(func() {
+			capability = "retention-" + uuid.New().String()[:8]
+		})
+
+		// jobWithStatus submits a job nobody claims and moves it to status,
+		// as if it finished age ago
+		jobWithStatus := func(status, age string, dependsOn ...uuid.UUID) uuid.UUID {
+			job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:                 "retention",
+				BinaryURL:            getBinaryURL("success"),
+				BinarySHA256:         successBinarySHA256,
+				Priority:             models.PriorityForeground,
+				RequiredCapabilities: []string{capability},
+				DependsOn:            dependsOn,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			conn, err := pgx.Connect(context.Background(), dbURL)
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close(context.Background())
+			_, err = conn.Exec(context.Background(),
+				"UPDATE jobs SET status = $2, completed_at = NOW() - $3::interval, last_heartbeat = NOW() WHERE id = $1",
+				job.ID, status, age)
+			Expect(err).NotTo(HaveOccurred())
+			return job.ID
+		}
+
+		// runCleanup runs a second server cleaning up every second and
+		// keeping jobs for an hour until expired is gone
+		runCleanup := func(expired uuid.UUID, archiveSink string) {
+			cleaner, err := server.New(&server.Config{
+				DatabaseURL:      dbURL,
+				Port:             0,
+				CleanupInterval:  1,
+				JobRetention:     3600,
+				HeartbeatTimeout: 6,
+				RetryInterval:    1,
+				LogLevel:         "error",
+				ArchiveSink:      archiveSink,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			cleanerCtx, stopCleaner := context.WithCancel(context.Background())
+			cleanerDone := make(chan struct{})
+			go func() {
+				defer close(cleanerDone)
+				cleaner.Run(cleanerCtx)
+			}()
+			defer func() {
+				stopCleaner()
+				<-cleanerDone
+			}()
+
+			Eventually(func() error {
+				_, err := testClient.GetJob(context.Background(), expired)
+				return err
+			}, 10*time.Second, 200*time.Millisecond).Should(HaveOccurred())
+		}
+
+		It("should delete finished jobs once the retention in seconds has passed", func() {
+			expired := jobWithStatus("completed", "2 hours")
+			kept := jobWithStatus("completed", "30 minutes")
+
+			runCleanup(expired, "")
+
+			_, err := testClient.GetJob(context.Background(), kept)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should keep old jobs that are running or that pending dependents wait for", func() {
+			running := jobWithStatus("running", "2 hours")
+			parent := jobWithStatus("completed", "2 hours")
+			dependent := jobWithStatus("pending", "2 hours", parent)
+			expired := jobWithStatus("completed", "2 hours")
+
+			runCleanup(expired, "")
+
+			for _, id := range []uuid.UUID{running, parent, dependent} {
+				_, err := testClient.GetJob(context.Background(), id)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			Expect(testClient.CancelJob(context.Background(), running)).To(Succeed())
+			Expect(testClient.CancelJob(context.Background(), dependent)).To(Succeed())
+		})
+
+		It("should archive jobs to the database before deleting them", func() {
+			expired := jobWithStatus("completed", "2 hours")
+
+			runCleanup(expired, server.ArchiveSinkDB)
+
+			conn, err := pgx.Connect(context.Background(), dbURL)
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close(context.Background())
+
+			var status, jobType string
+			err = conn.QueryRow(context.Background(),
+				"SELECT status, job->>'type' FROM jobs_archive WHERE id = $1", expired).Scan(&status, &jobType)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status).To(Equal("completed"))
+			Expect(jobType).To(Equal("retention"))
+
+			_, err = conn.Exec(context.Background(), "DELETE FROM jobs_archive WHERE id = $1", expired)
+			Expect(err).To(MatchError(ContainSubstring("append-only")))
+		})
+	})
+
+	Describe("Sensitive Env Redaction", func() {
+		It("should redact sensitive env variables on reads but deliver them on claim", func() {
+			capability := "redact-" + uuid.New().String()[:8]
+			job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:                 "redact",
+				BinaryURL:            getBinaryURL("success"),
+				BinarySHA256:         successBinarySHA256,
+				Priority:             models.PriorityForeground,
+				EnvVariables:         map[string]string{"MY_TOKEN": "t0ken", "MODE": "fast"},
+				RequiredCapabilities: []string{capability},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.EnvVariables).To(Equal(map[string]string{"MY_TOKEN": "***", "MODE": "fast"}))
+
+			fetched, err := testClient.GetJob(context.Background(), job.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fetched.EnvVariables).To(Equal(map[string]string{"MY_TOKEN": "***", "MODE": "fast"}))
+
+			claimed, err := testClient.ClaimNextJob(context.Background(), "redact-executor", "127.0.0.1", []string{capability})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(claimed).NotTo(BeNil())
+			Expect(claimed.ID).To(Equal(job.ID))
+			Expect(claimed.EnvVariables).To(Equal(map[string]string{"MY_TOKEN": "t0ken", "MODE": "fast"}))
+
+			Expect(testClient.CompleteJob(context.Background(), job.ID, &models.CompleteRequest{ExecutorID: "redact-executor"})).To(Succeed())
+		})
+	})
+
+	Describe("Long-Polling Claims", func() {
+		It("should hand a job submitted during the wait to the waiting claim", func() {
+			capability := "longpoll-" + uuid.New().String()[:8]
+
+			type claimResult struct {
+				job *models.Job
+				err error
+			}
+			results := make(chan claimResult, 1)
+			start := time.Now()
+			go func() {
+				job, err := testClient.ClaimNextJobWithWait(context.Background(), "long-polling-executor", "127.0.0.1", []string{capability}, 15*time.Second)
+				results <- claimResult{job, err}
+			}()
+
+			time.Sleep(500 * time.Millisecond)
+			job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:                 "longpoll",
+				BinaryURL:            getBinaryURL("success"),
+				BinarySHA256:         successBinarySHA256,
+				Priority:             models.PriorityForeground,
+				RequiredCapabilities: []string{capability},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			var result claimResult
+			Eventually(results, 10*time.Second).Should(Receive(&result))
+			Expect(result.err).NotTo(HaveOccurred())
+			Expect(result.job).NotTo(BeNil())
+			Expect(result.job.ID).To(Equal(job.ID))
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+
+			Expect(testClient.CompleteJob(context.Background(), job.ID, &models.CompleteRequest{ExecutorID: "long-polling-executor"})).To(Succeed())
+		})
+
+		It("should return no job once the wait is over", func() {
+			capability := "longpoll-" + uuid.New().String()[:8]
+
+			start := time.Now()
+			claimed, err := testClient.ClaimNextJobWithWait(context.Background(), "long-polling-executor", "127.0.0.1", []string{capability}, 2*time.Second)
+			Expect(client.IsNoJobsAvailable(err)).To(BeTrue())
+			Expect(claimed).To(BeNil())
+			Expect(time.Since(start)).To(BeNumerically(">=", 2*time.Second))
+		})
+	})
+
+	Describe("Job Events", func() {
+		It("should stream the status changes of matching jobs", func() {
+			jobType := "events-" + uuid.New().String()[:8]
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			events, err := testClient.SubscribeEvents(ctx, &client.EventFilter{Type: jobType})
+			Expect(err).NotTo(HaveOccurred())
+
+			// No executor has the capability, so the job stays pending until cancelled
+			job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:                 jobType,
+				BinaryURL:            getBinaryURL("success"),
+				BinarySHA256:         successBinarySHA256,
+				Priority:             models.PriorityForeground,
+				RequiredCapabilities: []string{jobType},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			var event models.JobEvent
+			Eventually(events, 10*time.Second).Should(Receive(&event))
+			Expect(event.JobID).To(Equal(job.ID))
+			Expect(event.Status).To(Equal(models.StatusPending))
+			Expect(event.PreviousStatus).To(BeEmpty())
+
+			Expect(testClient.CancelJob(context.Background(), job.ID)).To(Succeed())
+
+			Eventually(events, 10*time.Second).Should(Receive(&event))
+			Expect(event.JobID).To(Equal(job.ID))
+			Expect(event.Status).To(Equal(models.StatusCancelled))
+			Expect(event.PreviousStatus).To(Equal(models.StatusPending))
+		})
+	})
+
+	Describe("Metrics", func() {
+		It("should record job duration and wait time", func() {
+			job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:         "metrics",
+				BinaryURL:    getBinaryURL("success"),
+				BinarySHA256: successBinarySHA256,
+				Priority:     models.PriorityForeground,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			execCtx, execCancel := context.WithCancel(context.Background())
+			defer execCancel()
+
+			exec, err := executor.New(&executor.Config{
+				ServerURL:         serverURL,
+				Name:              "test-executor-metrics",
+				CacheDir:          filepath.Join(createTempDir(), "cache"),
+				WorkDir:           filepath.Join(createTempDir(), "work"),
+				MaxJobs:           1,
+				PollInterval:      1,
+				MaxCacheSize:      100,
+				HeartbeatInterval: 2,
+				NetworkTimeout:    60,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			go exec.Run(execCtx)
+
+			waitForJob(job.ID, 30*time.Second, models.StatusCompleted)
+
+			resp, err := http.Get(serverURL + "/api/v1/metrics")
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			body, err := io.ReadAll(resp.Body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(body)).To(MatchRegexp(`executr_job_duration_seconds_count\{priority="foreground",status="completed",type="metrics"\} [1-9]`))
+			Expect(string(body)).To(MatchRegexp(`executr_job_wait_time_seconds_count\{priority="foreground",type="metrics"\} [1-9]`))
+		})
+	})
+
+	Describe("Job Reprioritization", func() {
+		It("should change the priority of pending jobs only", func() {
+			// Scheduled far ahead so that no executor claims it meanwhile
+			scheduledAt := time.Now().Add(time.Hour)
+			job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:         "reprioritized",
+				BinaryURL:    getBinaryURL("success"),
+				BinarySHA256: successBinarySHA256,
+				Priority:     models.PriorityBestEffort,
+				ScheduledAt:  &scheduledAt,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			updated, err := testClient.UpdateJobPriority(context.Background(), job.ID, models.PriorityForeground)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated.Priority).To(Equal(models.PriorityForeground))
+
+			_, err = testClient.UpdateJobPriority(context.Background(), job.ID, "urgent")
+			Expect(client.IsBadRequest(err)).To(BeTrue())
+
+			Expect(testClient.CancelJob(context.Background(), job.ID)).To(Succeed())
+
+			_, err = testClient.UpdateJobPriority(context.Background(), job.ID, models.PriorityBackground)
+			Expect(client.IsConflict(err)).To(BeTrue())
+		})
+	})
+
+	Describe("Idempotent Submission", func() {
+		It("should return the existing job when a key is reused", func() {
+			key := uuid.New().String()
+			submission := &models.JobSubmission{
+				Type:         "idempotent",
+				BinaryURL:    getBinaryURL("success"),
+				BinarySHA256: successBinarySHA256,
+				Priority:     models.PriorityBestEffort,
+			}
+
+			first, err := testClient.SubmitJobWithKey(context.Background(), submission, key)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(first.IdempotencyKey).To(Equal(key))
+
+			second, err := testClient.SubmitJobWithKey(context.Background(), submission, key)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(second.ID).To(Equal(first.ID))
+
+			other, err := testClient.SubmitJobWithKey(context.Background(), submission, uuid.New().String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(other.ID).NotTo(Equal(first.ID))
+		})
+
+		It("should reject a key reused for a different submission", func() {
+			key := uuid.New().String()
+			submission := &models.JobSubmission{
+				Type:         "idempotent",
+				BinaryURL:    getBinaryURL("success"),
+				BinarySHA256: successBinarySHA256,
+				Priority:     models.PriorityBestEffort,
+			}
+
+			_, err := testClient.SubmitJobWithKey(context.Background(), submission, key)
+			Expect(err).NotTo(HaveOccurred())
+
+			changed := *submission
+			changed.Arguments = []string{"--other"}
+			_, err = testClient.SubmitJobWithKey(context.Background(), &changed, key)
+			Expect(client.IsConflict(err)).To(BeTrue())
+		})
+
+		It("should scope keys to the submitter's API key", func() {
+			key := uuid.New().String()
+			submission := &models.JobSubmission{
+				Type:         "idempotent",
+				BinaryURL:    getBinaryURL("success"),
+				BinarySHA256: successBinarySHA256,
+				Priority:     models.PriorityBestEffort,
+			}
+
+			first, err := client.New(serverURL, client.WithAPIKey("submitter-a")).SubmitJobWithKey(context.Background(), submission, key)
+			Expect(err).NotTo(HaveOccurred())
+			second, err := client.New(serverURL, client.WithAPIKey("submitter-b")).SubmitJobWithKey(context.Background(), submission, key)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(second.ID).NotTo(Equal(first.ID))
+		})
+	})
+
+	Describe("Dry Run Submission", func() {
+		It("should validate the binary without creating a job", func() {
+			jobType := "dry-run-" + uuid.New().String()[:8]
+			submission := &models.JobSubmission{
+				Type:         jobType,
+				BinaryURL:    getBinaryURL("success"),
+				BinarySHA256: successBinarySHA256,
+				Priority:     models.PriorityBestEffort,
+			}
+
+			result, err := testClient.ValidateJob(context.Background(), submission)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Valid).To(BeTrue())
+			Expect(result.SHA256Matches).To(BeTrue())
+			Expect(result.BinarySHA256).To(Equal(successBinarySHA256))
+
+			submission.BinarySHA256 = failureBinarySHA256
+			result, err = testClient.ValidateJob(context.Background(), submission)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Valid).To(BeFalse())
+			Expect(result.BinaryReachable).To(BeTrue())
+			Expect(result.SHA256Matches).To(BeFalse())
+			Expect(result.BinarySHA256).To(Equal(successBinarySHA256))
+
+			submission.BinaryURL = getBinaryURL("does-not-exist")
+			result, err = testClient.ValidateJob(context.Background(), submission)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Valid).To(BeFalse())
+			Expect(result.BinaryReachable).To(BeFalse())
+			Expect(result.Errors).NotTo(BeEmpty())
+
+			jobs, err := testClient.ListJobs(context.Background(), &client.ListJobsFilter{Type: jobType})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(jobs).To(BeEmpty())
+		})
+	})
+
+	Describe("Server-Side Hashing", func() {
+		It("should calculate the SHA256 of jobs submitted without one", func() {
+			health, err := testClient.Health(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(health.ServerSideHashing).To(BeTrue())
+
+			job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:      "server-hashed",
+				BinaryURL: getBinaryURL("success"),
+				Priority:  models.PriorityBestEffort,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.BinarySHA256).To(Equal(successBinarySHA256))
+
+			_, err = testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:      "server-hashed",
+				BinaryURL: getBinaryURL("does-not-exist"),
+				Priority:  models.PriorityBestEffort,
+			})
+			Expect(client.IsBadRequest(err)).To(BeTrue())
+
+			Expect(testClient.CancelJob(context.Background(), job.ID)).To(Succeed())
+		})
+	})
+
+	Describe("Job Labels", func() {
+		It("should filter and group jobs by label", func() {
+			team := "team-" + uuid.New().String()[:8]
+			for _, env := range []string{"staging", "staging", "production"} {
+				_, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+					Type:         "labelled",
+					BinaryURL:    getBinaryURL("success"),
+					BinarySHA256: successBinarySHA256,
+					Priority:     models.PriorityBestEffort,
+					Labels:       map[string]string{"team": team, "env": env},
+				})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			jobs, err := testClient.ListJobs(context.Background(), &client.ListJobsFilter{
+				Labels: map[string]string{"team": team},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(jobs).To(HaveLen(3))
+
+			jobs, err = testClient.ListJobs(context.Background(), &client.ListJobsFilter{
+				Labels: map[string]string{"team": team, "env": "staging"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(jobs).To(HaveLen(2))
+			Expect(jobs[0].Labels).To(Equal(map[string]string{"team": team, "env": "staging"}))
+
+			resp, err := http.Get(serverURL + "/api/v1/admin/stats?group_by_label=team")
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			var stats struct {
+				JobsByLabel map[string]map[string]int64 `json:"jobs_by_label"`
+			}
+			Expect(json.NewDecoder(resp.Body).Decode(&stats)).To(Succeed())
+			Expect(stats.JobsByLabel[team]).To(HaveKeyWithValue("pending", int64(3)))
+		})
+	})
+
+	Describe("Queue Age", func() {
+		It("should report how long the oldest pending job has been waiting", func() {
+			capability := "queue-age-" + uuid.New().String()[:8]
+			job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:                 "queue-age",
+				BinaryURL:            getBinaryURL("success"),
+				BinarySHA256:         successBinarySHA256,
+				Priority:             models.PriorityBestEffort,
+				RequiredCapabilities: []string{capability},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			// Nobody has the capability, so the job keeps waiting
+			conn, err := pgx.Connect(context.Background(), dbURL)
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close(context.Background())
+			_, err = conn.Exec(context.Background(), "UPDATE jobs SET created_at = NOW() - INTERVAL '1 hour' WHERE id = $1", job.ID)
+			Expect(err).NotTo(HaveOccurred())
+
+			// The gauge is refreshed in the background, without requesting
+			// the statistics
+			Eventually(func() float64 {
+				return testutil.ToFloat64(metrics.OldestPendingAge.WithLabelValues("best_effort"))
+			}, 15*time.Second, 500*time.Millisecond).Should(BeNumerically(">=", 3600))
+
+			metricsResp, err := http.Get(serverURL + "/api/v1/metrics")
+			Expect(err).NotTo(HaveOccurred())
+			defer metricsResp.Body.Close()
+			body, err := io.ReadAll(metricsResp.Body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(body)).To(MatchRegexp(`executr_oldest_pending_age_seconds\{priority="foreground"\} [0-9.e+]+`))
+
+			resp, err := http.Get(serverURL + "/api/v1/admin/stats")
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			var stats struct {
+				OldestPendingAge map[string]float64 `json:"oldest_pending_age_seconds"`
+			}
+			Expect(json.NewDecoder(resp.Body).Decode(&stats)).To(Succeed())
+			Expect(stats.OldestPendingAge).To(HaveKey("foreground"))
+			Expect(stats.OldestPendingAge["best_effort"]).To(BeNumerically(">=", 3600))
+
+			Expect(testClient.CancelJob(context.Background(), job.ID)).To(Succeed())
+		})
+	})
+
+	Describe("Completion Estimates", func() {
+		It("should estimate completion from earlier jobs of the same type", func() {
+			jobType := "estimated-" + uuid.New().String()[:8]
+			submit := func(capabilities ...string) *models.Job {
+				job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+					Type:                 jobType,
+					BinaryURL:            getBinaryURL("success"),
+					BinarySHA256:         successBinarySHA256,
+					Priority:             models.PriorityForeground,
+					RequiredCapabilities: capabilities,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				return job
+			}
+
+			// Nobody has the capability, so the jobs keep waiting
+			capability := "estimated-" + uuid.New().String()[:8]
+			pending := submit(capability)
+			job, err := testClient.GetJob(context.Background(), pending.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.EstimatedCompletion).To(BeNil())
+			Expect(testClient.CancelJob(context.Background(), pending.ID)).To(Succeed())
+
+			waitForJob(submit().ID, 30*time.Second, models.StatusCompleted)
+
+			before := time.Now()
+			pending = submit(capability)
+			job, err = testClient.GetJob(context.Background(), pending.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.EstimatedCompletion).NotTo(BeNil())
+			Expect(*job.EstimatedCompletion).To(BeTemporally(">=", before))
+
+			Expect(testClient.CancelJob(context.Background(), pending.ID)).To(Succeed())
+		})
+	})
+
+	Describe("Job Stdin", func() {
+		It("should feed stdin to the binary", func() {
+			stdin := []byte("name: stdin\nitems:\n  - one\n  - two\n")
+			job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:         "stdin",
+				BinaryURL:    getBinaryURL("cat"),
+				BinarySHA256: calculateFileSHA256("testdata/binaries/cat"),
+				Priority:     models.PriorityForeground,
+				Stdin:        stdin,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.Stdin).To(Equal(stdin))
+
+			completed := waitForJob(job.ID, 30*time.Second, models.StatusCompleted)
+			Expect(completed.Stdout).To(Equal(string(stdin)))
+		})
+	})
+
+	Describe("Job Input Files", func() {
+		It("should provide inline and downloaded input files in the working directory", func() {
+			config := []byte("threshold: 42\n")
+			sum := sha256.Sum256(config)
+			job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:         "input-files",
+				BinaryURL:    getBinaryURL("cat"),
+				BinarySHA256: calculateFileSHA256("testdata/binaries/cat"),
+				Arguments:    []string{"config.yaml", "data/script.go"},
+				Priority:     models.PriorityForeground,
+				InputFiles: map[string]models.InputFile{
+					"config.yaml":    {Content: config, SHA256: hex.EncodeToString(sum[:])},
+					"data/script.go": {URL: getBinaryURL("success") + ".go"},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			script, err := os.ReadFile("testdata/binaries/success.go")
+			Expect(err).NotTo(HaveOccurred())
+
+			completed := waitForJob(job.ID, 30*time.Second, models.StatusCompleted)
+			Expect(completed.Stdout).To(Equal(string(config) + string(script)))
+		})
+
+		It("should fail a job whose input file doesn't match its SHA256", func() {
+			job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:         "input-files",
+				BinaryURL:    getBinaryURL("cat"),
+				BinarySHA256: calculateFileSHA256("testdata/binaries/cat"),
+				Arguments:    []string{"config.yaml"},
+				Priority:     models.PriorityForeground,
+				InputFiles: map[string]models.InputFile{
+					"config.yaml": {Content: []byte("threshold: 42\n"), SHA256: strings.Repeat("0", 64)},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			failed := waitForJob(job.ID, 30*time.Second, models.StatusFailed, models.StatusDeadLetter)
+			Expect(failed.ErrorMessage).To(Equal("input files could not be provided"))
+		})
+	})
+
+	Describe("Job Artifacts", func() {
+		It("should collect files matching the output globs", func() {
+			// Input files are in the working directory, so they can be
+			// collected like files the binary wrote
+			report := []byte("id,value\n1,42\n")
+			job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:         "artifacts",
+				BinaryURL:    getBinaryURL("cat"),
+				BinarySHA256: calculateFileSHA256("testdata/binaries/cat"),
+				Arguments:    []string{"out/report.csv"},
+				Priority:     models.PriorityForeground,
+				InputFiles: map[string]models.InputFile{
+					"out/report.csv": {Content: report},
+					"out/notes.txt":  {Content: []byte("not collected")},
+				},
+				OutputGlobs: []string{"out/*.csv"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.OutputGlobs).To(Equal([]string{"out/*.csv"}))
+
+			waitForJob(job.ID, 30*time.Second, models.StatusCompleted)
+
+			artifacts, err := testClient.ListArtifacts(context.Background(), job.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(artifacts).To(HaveLen(1))
+			sum := sha256.Sum256(report)
+			Expect(artifacts[0].Name).To(Equal("out/report.csv"))
+			Expect(artifacts[0].Size).To(Equal(int64(len(report))))
+			Expect(artifacts[0].SHA256).To(Equal(hex.EncodeToString(sum[:])))
+
+			resp, err := http.Get(artifacts[0].URL)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			body, err := io.ReadAll(resp.Body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(body).To(Equal(report))
+		})
+
+		It("should reject output globs outside the working directory", func() {
+			_, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:         "artifacts",
+				BinaryURL:    getBinaryURL("success"),
+				BinarySHA256: successBinarySHA256,
+				Priority:     models.PriorityForeground,
+				OutputGlobs:  []string{"../*"},
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Job Results", func() {
+		It("should attach the result a binary reports", func() {
+			job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:         "result",
+				BinaryURL:    getBinaryURL("result"),
+				BinarySHA256: calculateFileSHA256("testdata/binaries/result"),
+				Arguments:    []string{"2"},
+				Priority:     models.PriorityForeground,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			completed := waitForJob(job.ID, 30*time.Second, models.StatusCompleted)
+			Expect(string(completed.ResultJSON)).To(MatchJSON(`{"processed":2,"failed":0}`))
+		})
+
+		It("should keep the result of a partially successful job", func() {
+			job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:         "result",
+				BinaryURL:    getBinaryURL("result"),
+				BinarySHA256: calculateFileSHA256("testdata/binaries/result"),
+				Arguments:    []string{"3"},
+				Priority:     models.PriorityForeground,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			failed := waitForJob(job.ID, 30*time.Second, models.StatusFailed)
+			Expect(failed.ExitCode).NotTo(BeNil())
+			Expect(*failed.ExitCode).To(Equal(1))
+			Expect(string(failed.ResultJSON)).To(MatchJSON(`{"processed":2,"failed":1}`))
+		})
+	})
+
+	Describe("Job Type Limits", func() {
+		It("should never run more jobs of a type at once than its limit", func() {
+			jobType := "limited-" + uuid.New().String()[:8]
+			limit, err := testClient.SetJobTypeLimit(context.Background(), jobType, 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(limit.Type).To(Equal(jobType))
+			Expect(limit.MaxRunning).To(Equal(1))
+			defer testClient.DeleteJobTypeLimit(context.Background(), jobType)
+
+			var jobIDs []uuid.UUID
+			for range 3 {
+				job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+					Type:         jobType,
+					BinaryURL:    getBinaryURL("longrunning"),
+					BinarySHA256: calculateFileSHA256("testdata/binaries/longrunning"),
+					Arguments:    []string{"1s"},
+					Priority:     models.PriorityForeground,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				jobIDs = append(jobIDs, job.ID)
+			}
+
+			execCtx, execCancel := context.WithCancel(context.Background())
+			defer execCancel()
+
+			// Both executors have room for all jobs, only the limit holds
+			// them back
+			for range 2 {
+				exec, err := executor.New(&executor.Config{
+					ServerURL:         serverURL,
+					Name:              "limit-executor",
+					CacheDir:          filepath.Join(createTempDir(), "cache"),
+					WorkDir:           filepath.Join(createTempDir(), "work"),
+					MaxJobs:           3,
+					PollInterval:      1,
+					MaxCacheSize:      100,
+					HeartbeatInterval: 2,
+					NetworkTimeout:    60,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				go func() {
+					exec.Run(execCtx)
+				}()
+			}
+
+			Eventually(func(g Gomega) {
+				jobs, err := testClient.ListJobs(context.Background(), &client.ListJobsFilter{Type: jobType})
+				g.Expect(err).NotTo(HaveOccurred())
+
+				running, completed := 0, 0
+				for _, job := range jobs {
+					switch job.Status {
+					case models.StatusRunning:
+						running++
+					case models.StatusCompleted:
+						completed++
+					}
+				}
+				// Exceeding the limit fails the test right away
+				Expect(running).To(BeNumerically("<=", 1))
+				g.Expect(completed).To(Equal(len(jobIDs)))
+			}, 60*time.Second, 100*time.Millisecond).Should(Succeed())
+
+			// The jobs ran one after the other
+			var previous *models.Job
+			for _, id := range jobIDs {
+				job, err := testClient.GetJob(context.Background(), id)
+				Expect(err).NotTo(HaveOccurred())
+				if previous != nil {
+					Expect(job.StartedAt.Before(*previous.CompletedAt)).To(BeFalse())
+				}
+				previous = job
+			}
+		})
+
+		It("should hold back all jobs of a type with a limit of 0", func() {
+			jobType := "held-" + uuid.New().String()[:8]
+			_, err := testClient.SetJobTypeLimit(context.Background(), jobType, 0)
+			Expect(err).NotTo(HaveOccurred())
+
+			job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:         jobType,
+				BinaryURL:    getBinaryURL("success"),
+				BinarySHA256: successBinarySHA256,
+				Priority:     models.PriorityForeground,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			execCtx, execCancel := context.WithCancel(context.Background())
+			defer execCancel()
+
+			exec, err := executor.New(&executor.Config{
+				ServerURL:         serverURL,
+				Name:              "held-executor",
+				CacheDir:          filepath.Join(createTempDir(), "cache"),
+				WorkDir:           filepath.Join(createTempDir(), "work"),
+				MaxJobs:           1,
+				PollInterval:      1,
+				MaxCacheSize:      100,
+				HeartbeatInterval: 2,
+				NetworkTimeout:    60,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			go func() {
+				exec.Run(execCtx)
+			}()
+
+			Consistently(func() models.Status {
+				held, err := testClient.GetJob(context.Background(), job.ID)
+				Expect(err).NotTo(HaveOccurred())
+				return held.Status
+			}, 3*time.Second, 250*time.Millisecond).Should(Equal(models.StatusPending))
+
+			limits, err := testClient.ListJobTypeLimits(context.Background())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(limits).To(ContainElement(And(HaveField("Type", jobType), HaveField("MaxRunning", 0), HaveField("Running", int64(0)))))
+
+			// Removing the limit releases the job
+			err = testClient.DeleteJobTypeLimit(context.Background(), jobType)
+			Expect(err).NotTo(HaveOccurred())
+
+			waitForJob(job.ID, 30*time.Second, models.StatusCompleted)
+
+			err = testClient.DeleteJobTypeLimit(context.Background(), jobType)
+			Expect(client.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should reject negative limits", func() {
+			_, err := testClient.SetJobTypeLimit(context.Background(), "negative-limit", -1)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Fair Scheduling", func() {
+		It("should rotate among job types instead of draining a flood of one type first", func() {
+			fair, err := server.New(&server.Config{
+				DatabaseURL:      dbURL,
+				Port:             0,
+				CleanupInterval:  3600,
+				JobRetention:     172800,
+				HeartbeatTimeout: 6,
+				RetryInterval:    1,
+				LogLevel:         "error",
+				Scheduling:       server.SchedulingFair,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			fairCtx, stopFair := context.WithCancel(context.Background())
+			fairDone := make(chan struct{})
+			go func() {
+				defer close(fairDone)
+				fair.Run(fairCtx)
+			}()
+			defer func() {
+				stopFair()
+				<-fairDone
+			}()
+			fair.WaitReady()
+
+			// Only the executor below can claim the jobs, so they are all
+			// claimed by the fair server one after the other
+			suffix := uuid.New().String()[:8]
+			capability := "fair-" + suffix
+			flood, other, third := "flood-"+suffix, "other-"+suffix, "third-"+suffix
+			for _, jobType := range []string{flood, flood, flood, flood, other, other, third, third} {
+				_, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+					Type:                 jobType,
+					BinaryURL:            getBinaryURL("success"),
+					BinarySHA256:         successBinarySHA256,
+					Priority:             models.PriorityBackground,
+					RequiredCapabilities: []string{capability},
+				})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			execCtx, execCancel := context.WithCancel(context.Background())
+			defer execCancel()
+
+			exec, err := executor.New(&executor.Config{
+				ServerURL:         fmt.Sprintf("http://localhost:%d", fair.Port()),
+				Name:              "fair-executor",
+				CacheDir:          filepath.Join(createTempDir(), "cache"),
+				WorkDir:           filepath.Join(createTempDir(), "work"),
+				MaxJobs:           1,
+				PollInterval:      1,
+				MaxCacheSize:      100,
+				HeartbeatInterval: 2,
+				NetworkTimeout:    60,
+				Capabilities:      []string{capability},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			go func() {
+				exec.Run(execCtx)
+			}()
+
+			var jobs []*models.Job
+			Eventually(func(g Gomega) {
+				jobs = nil
+				for _, jobType := range []string{flood, other, third} {
+					typed, err := testClient.ListJobs(context.Background(), &client.ListJobsFilter{Type: jobType})
+					g.Expect(err).NotTo(HaveOccurred())
+					for _, job := range typed {
+						g.Expect(job.Status).To(Equal(models.StatusCompleted))
+					}
+					jobs = append(jobs, typed...)
+				}
+				g.Expect(jobs).To(HaveLen(8))
+			}, 60*time.Second, 500*time.Millisecond).Should(Succeed())
+
+			sort.Slice(jobs, func(i, j int) bool {
+				return jobs[i].StartedAt.Before(*jobs[j].StartedAt)
+			})
+			var order []string
+			for _, job := range jobs {
+				order = append(order, job.Type)
+			}
+			Expect(order).To(Equal([]string{flood, other, third, flood, other, third, flood, flood}))
+		})
+	})
+
+	Describe("Weighted Priorities", func() {
+		It("should let a lower priority win the lottery over pending higher priority jobs", func() {
+			// With all the weight on best_effort, it is drawn first on
+			// every claim
+			weighted, err := server.New(&server.Config{
+				DatabaseURL:      dbURL,
+				Port:             0,
+				CleanupInterval:  3600,
+				JobRetention:     172800,
+				HeartbeatTimeout: 6,
+				RetryInterval:    1,
+				LogLevel:         "error",
+				PriorityMode:     server.PriorityModeWeighted,
+				PriorityWeights:  []int{0, 0, 1},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			weightedCtx, stopWeighted := context.WithCancel(context.Background())
+			weightedDone := make(chan struct{})
+			go func() {
+				defer close(weightedDone)
+				weighted.Run(weightedCtx)
+			}()
+			defer func() {
+				stopWeighted()
+				<-weightedDone
+			}()
+			weighted.WaitReady()
+
+			capability := "weighted-" + uuid.New().String()[:8]
+			var jobIDs []uuid.UUID
+			for _, priority := range []models.Priority{models.PriorityForeground, models.PriorityBackground, models.PriorityBestEffort} {
+				job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+					Type:                 "weighted-" + string(priority),
+					BinaryURL:            getBinaryURL("success"),
+					BinarySHA256:         successBinarySHA256,
+					Priority:             priority,
+					RequiredCapabilities: []string{capability},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				jobIDs = append(jobIDs, job.ID)
+			}
+
+			execCtx, execCancel := context.WithCancel(context.Background())
+			defer execCancel()
+
+			exec, err := executor.New(&executor.Config{
+				ServerURL:         fmt.Sprintf("http://localhost:%d", weighted.Port()),
+				Name:              "weighted-executor",
+				CacheDir:          filepath.Join(createTempDir(), "cache"),
+				WorkDir:           filepath.Join(createTempDir(), "work"),
+				MaxJobs:           1,
+				PollInterval:      1,
+				MaxCacheSize:      100,
+				HeartbeatInterval: 2,
+				NetworkTimeout:    60,
+				Capabilities:      []string{capability},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			go func() {
+				exec.Run(execCtx)
+			}()
+
+			var jobs []*models.Job
+			for _, id := range jobIDs {
+				jobs = append(jobs, waitForJob(id, 30*time.Second, models.StatusCompleted))
+			}
+			foreground, background, bestEffort := jobs[0], jobs[1], jobs[2]
+
+			// Priorities without weight follow in their strict order
+			Expect(bestEffort.StartedAt.Before(*foreground.StartedAt)).To(BeTrue())
+			Expect(foreground.StartedAt.Before(*background.StartedAt)).To(BeTrue())
+		})
+	})
+
+	Describe("Job Dependencies", func() {
+		It("should run a dependent job only after its parent completes", func() {
+			// The parent has the lower priority, so only the dependency keeps
+			// the child from being claimed first
+			parent, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:         "dependency-parent",
+				BinaryURL:    getBinaryURL("longrunning"),
+				BinarySHA256: calculateFileSHA256("testdata/binaries/longrunning"),
+				Arguments:    []string{"2s"},
+				Priority:     models.PriorityBackground,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			child, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:         "dependency-child",
+				BinaryURL:    getBinaryURL("success"),
+				BinarySHA256: successBinarySHA256,
+				Priority:     models.PriorityForeground,
+				DependsOn:    []uuid.UUID{parent.ID},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(child.DependsOn).To(Equal([]uuid.UUID{parent.ID}))
+
+			execCtx, execCancel := context.WithCancel(context.Background())
+			defer execCancel()
+
+			exec, err := executor.New(&executor.Config{
+				ServerURL:         serverURL,
+				Name:              "dependency-executor",
+				CacheDir:          filepath.Join(createTempDir(), "cache"),
+				WorkDir:           filepath.Join(createTempDir(), "work"),
+				MaxJobs:           1,
+				PollInterval:      1,
+				MaxCacheSize:      100,
+				HeartbeatInterval: 2,
+				NetworkTimeout:    60,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			go func() {
+				exec.Run(execCtx)
+			}()
+
+			waitForJob(child.ID, 30*time.Second, models.StatusCompleted)
+
+			completedParent, err := testClient.GetJob(context.Background(), parent.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(completedParent.Status).To(Equal(models.StatusCompleted))
+			Expect(completedParent.CompletedAt).NotTo(BeNil())
+
+			completedChild, err := testClient.GetJob(context.Background(), child.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(completedChild.DependsOn).To(Equal([]uuid.UUID{parent.ID}))
+			Expect(completedChild.StartedAt).NotTo(BeNil())
+			Expect(completedChild.StartedAt.Before(*completedParent.CompletedAt)).To(BeFalse())
+		})
+
+		It("should skip a dependent job when its parent is cancelled", func() {
+			parent, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:         "dependency-cancelled-parent",
+				BinaryURL:    getBinaryURL("success"),
+				BinarySHA256: successBinarySHA256,
+				Priority:     models.PriorityBackground,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			child, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:         "dependency-skipped-child",
+				BinaryURL:    getBinaryURL("success"),
+				BinarySHA256: successBinarySHA256,
+				Priority:     models.PriorityBackground,
+				DependsOn:    []uuid.UUID{parent.ID},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			err = testClient.CancelJob(context.Background(), parent.ID)
+			Expect(err).NotTo(HaveOccurred())
+
+			skippedChild := waitForJob(child.ID, 10*time.Second, models.StatusSkipped)
+			Expect(skippedChild.ErrorMessage).To(ContainSubstring(parent.ID.String()))
+		})
+
+		It("should reject dependencies on unknown jobs", func() {
+			_, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:         "dependency-unknown",
+				BinaryURL:    getBinaryURL("success"),
+				BinarySHA256: successBinarySHA256,
+				Priority:     models.PriorityBackground,
+				DependsOn:    []uuid.UUID{uuid.New()},
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Job Cancellation", func() {
+		It("should cancel pending jobs", func() {
+			// Submit a job
+			submission := &models.JobSubmission{
+				Type:         "cancel-test",
+				BinaryURL:    getBinaryURL("longrunning"),
+				BinarySHA256: calculateFileSHA256("testdata/binaries/longrunning"),
+				Arguments:    []string{"30s"},
+				Priority:     models.PriorityBackground,
+			}
+
+			job, err := testClient.SubmitJob(context.Background(), submission)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.Status).To(Equal(models.StatusPending))
+
+			// Cancel the job before executor picks it up
+			err = testClient.CancelJob(context.Background(), job.ID)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Verify job is cancelled
+			cancelledJob, err := testClient.GetJob(context.Background(), job.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cancelledJob.Status).To(Equal(models.StatusCancelled))
+
+			// Start executor and verify it doesn't pick up cancelled job
+			execCtx, execCancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer execCancel()
+
+			execConfig := &executor.Config{
+				ServerURL:         serverURL,
+				Name:              "cancel-executor",
+				CacheDir:          filepath.Join(createTempDir(), "cache"),
+				WorkDir:           filepath.Join(createTempDir(), "work"),
+				MaxJobs:           1,
+				PollInterval:      1,
+				MaxCacheSize:      100,
+				HeartbeatInterval: 2,
+				NetworkTimeout:    60,
+			}
+
+			exec, err := executor.New(execConfig)
+			Expect(err).NotTo(HaveOccurred())
+
+			go func() {
+				exec.Run(execCtx)
+			}()
+
+			// Wait and verify job remains cancelled
+			time.Sleep(3 * time.Second)
+			stillCancelled, err := testClient.GetJob(context.Background(), job.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stillCancelled.Status).To(Equal(models.StatusCancelled))
+		})
+
+		It("should stop running jobs that are cancelled", func() {
+			job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:         "cancel-running-test",
+				BinaryURL:    getBinaryURL("longrunning"),
+				BinarySHA256: calculateFileSHA256("testdata/binaries/longrunning"),
+				Arguments:    []string{"60s"},
+				Priority:     models.PriorityForeground,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			execCtx, execCancel := context.WithCancel(context.Background())
+			defer execCancel()
+
+			exec, err := executor.New(&executor.Config{
+				ServerURL:         serverURL,
+				Name:              "cancel-running-executor",
+				CacheDir:          filepath.Join(createTempDir(), "cache"),
+				WorkDir:           filepath.Join(createTempDir(), "work"),
+				MaxJobs:           1,
+				PollInterval:      1,
+				MaxCacheSize:      100,
+				HeartbeatInterval: 1,
+				NetworkTimeout:    60,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			go exec.Run(execCtx)
+
+			running := waitForJob(job.ID, 30*time.Second, models.StatusRunning)
+			Expect(testClient.CancelJob(context.Background(), job.ID)).To(Succeed())
+
+			// The executor can't report a result for it anymore
+			err = testClient.CompleteJob(context.Background(), job.ID, &models.CompleteRequest{ExecutorID: running.ExecutorID})
+			Expect(client.IsConflict(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("cancelled"))
+
+			cancelled, err := testClient.GetJob(context.Background(), job.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cancelled.Status).To(Equal(models.StatusCancelled))
+			Expect(cancelled.Attempts).To(HaveLen(1))
+			Expect(cancelled.Attempts[0].Status).To(Equal("cancelled"))
+
+			// The executor stops the job and frees its only slot
+			next, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:         "cancel-running-test",
+				BinaryURL:    getBinaryURL("success"),
+				BinarySHA256: successBinarySHA256,
+				Priority:     models.PriorityForeground,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			waitForJob(next.ID, 20*time.Second, models.StatusCompleted)
+
+			// It keeps the output the job produced until it was killed
+			stillCancelled, err := testClient.GetJob(context.Background(), job.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stillCancelled.Status).To(Equal(models.StatusCancelled))
+			Expect(stillCancelled.ExitCode).NotTo(BeNil())
+			Expect(*stillCancelled.ExitCode).NotTo(Equal(0))
+			Expect(stillCancelled.Stdout).To(ContainSubstring("Long-running binary started"))
+		})
+
+		It("should reject cancelling jobs that are finished or don't exist", func() {
+			job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:         "cancel-test",
+				BinaryURL:    getBinaryURL("success"),
+				BinarySHA256: successBinarySHA256,
+				Priority:     models.PriorityBackground,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(testClient.CancelJob(context.Background(), job.ID)).To(Succeed())
+
+			err = testClient.CancelJob(context.Background(), job.ID)
+			Expect(client.IsBadRequest(err)).To(BeTrue())
+
+			err = testClient.CancelJob(context.Background(), uuid.New())
+			Expect(client.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should keep the reason a job was cancelled for", func() {
+			jobType := "cancel-reason-" + uuid.New().String()[:8]
+			submit := func() uuid.UUID {
+				job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+					Type:                 jobType,
+					BinaryURL:            getBinaryURL("success"),
+					BinarySHA256:         successBinarySHA256,
+					Priority:             models.PriorityBackground,
+					RequiredCapabilities: []string{jobType},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				return job.ID
+			}
+
+			withBody := submit()
+			Expect(testClient.CancelJobWithReason(context.Background(), withBody, "superseded by a newer report")).To(Succeed())
+
+			job, err := testClient.GetJob(context.Background(), withBody)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.Status).To(Equal(models.StatusCancelled))
+			Expect(job.CancellationReason).To(Equal("superseded by a newer report"))
+
+			withQuery := submit()
+			req, err := http.NewRequest("DELETE", serverURL+"/api/v1/jobs/"+withQuery.String()+"?reason=wrong+input", nil)
+			Expect(err).NotTo(HaveOccurred())
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusNoContent))
+
+			// Cancelled jobs are listed with their reasons
+			jobs, err := testClient.ListJobs(context.Background(), &client.ListJobsFilter{Type: jobType})
+			Expect(err).NotTo(HaveOccurred())
+			reasons := map[uuid.UUID]string{}
+			for _, job := range jobs {
+				reasons[job.ID] = job.CancellationReason
+			}
+			Expect(reasons).To(Equal(map[uuid.UUID]string{
+				withBody:  "superseded by a newer report",
+				withQuery: "wrong input",
+			}))
+		})
+	})
+
+	Describe("Job Timeout", func() {
+		It("should kill the whole process group when a job times out", func() {
+			markerFile := filepath.Join(createTempDir(), "child-marker")
+
+			// Submit a job whose child process writes a marker file after 3s
+			submission := &models.JobSubmission{
+				Type:         "timeout-test",
+				BinaryURL:    getBinaryURL("forker"),
+				BinarySHA256: calculateFileSHA256("testdata/binaries/forker"),
+				Arguments:    []string{markerFile},
+				Priority:     models.PriorityForeground,
+				Timeout:      1,
+			}
+
+			job, err := testClient.SubmitJob(context.Background(), submission)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Start executor
+			execCtx, execCancel := context.WithCancel(context.Background())
+			defer execCancel()
+
+			execConfig := &executor.Config{
+				ServerURL:         serverURL,
+				Name:              "timeout-executor",
+				CacheDir:          filepath.Join(createTempDir(), "cache"),
+				WorkDir:           filepath.Join(createTempDir(), "work"),
+				MaxJobs:           1,
+				PollInterval:      1,
+				MaxCacheSize:      100,
+				HeartbeatInterval: 2,
+				NetworkTimeout:    60,
+			}
+
+			exec, err := executor.New(execConfig)
+			Expect(err).NotTo(HaveOccurred())
+
+			go func() {
+				exec.Run(execCtx)
+			}()
+
+			// Wait for the job to be failed by the timeout
+			failedJob := waitForJob(job.ID, 30*time.Second, models.StatusFailed)
+			Expect(failedJob.ErrorMessage).To(Equal("job exceeded timeout of 1s"))
+
+			// The child would have written the marker by now if it survived
+			Consistently(func() bool {
+				_, err := os.Stat(markerFile)
+				return os.IsNotExist(err)
+			}, 5*time.Second, 500*time.Millisecond).Should(BeTrue())
+		})
+	})
+
+	Describe("Working Directory Quota", func() {
+		It("should kill a job whose working directory exceeds its quota", func() {
+			job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:              "workdir-quota",
+				BinaryURL:         getBinaryURL("diskfill"),
+				BinarySHA256:      calculateFileSHA256("testdata/binaries/diskfill"),
+				Arguments:         []string{"5"},
+				Priority:          models.PriorityForeground,
+				WorkdirQuotaBytes: 1024 * 1024,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(job.WorkdirQuotaBytes).To(Equal(int64(1024 * 1024)))
+
+			// The binary keeps running for a minute unless it is killed
+			failed := waitForJob(job.ID, 30*time.Second, models.StatusFailed, models.StatusDeadLetter)
+			Expect(failed.ErrorMessage).To(Equal("job exceeded working directory quota of 1048576 bytes"))
+		})
+
+		It("should reject a negative quota", func() {
+			_, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:              "workdir-quota",
+				BinaryURL:         getBinaryURL("success"),
+				BinarySHA256:      successBinarySHA256,
+				Priority:          models.PriorityForeground,
+				WorkdirQuotaBytes: -1,
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Executor Drain", func() {
+		It("should finish running jobs but claim no new ones while draining", func() {
+			submission := &models.JobSubmission{
+				Type:         "drain-test",
+				BinaryURL:    getBinaryURL("longrunning"),
+				BinarySHA256: calculateFileSHA256("testdata/binaries/longrunning"),
+				Arguments:    []string{"3s"},
+				Priority:     models.PriorityForeground,
+			}
+
+			job, err := testClient.SubmitJob(context.Background(), submission)
+			Expect(err).NotTo(HaveOccurred())
+
+			execConfig := &executor.Config{
+				ServerURL:         serverURL,
+				Name:              "drain-executor",
+				CacheDir:          filepath.Join(createTempDir(), "cache"),
+				WorkDir:           filepath.Join(createTempDir(), "work"),
+				MaxJobs:           2,
+				PollInterval:      1,
+				MaxCacheSize:      100,
+				HeartbeatInterval: 1,
+				NetworkTimeout:    60,
+			}
+
+			exec, err := executor.New(execConfig)
+			Expect(err).NotTo(HaveOccurred())
+
+			runDone := make(chan error, 1)
+			go func() {
+				runDone <- exec.Run(context.Background())
+			}()
+
+			Eventually(func() models.Status {
+				job, err := testClient.GetJob(context.Background(), job.ID)
+				if err != nil {
+					return ""
+				}
+				return job.Status
+			}, 30*time.Second, 200*time.Millisecond).Should(Equal(models.StatusRunning))
+
+			exec.Drain()
+			Expect(exec.Draining()).To(BeTrue())
+
+			// A job submitted after the drain must not be picked up
+			pending, err := testClient.SubmitJob(context.Background(), submission)
+			Expect(err).NotTo(HaveOccurred())
+			defer testClient.CancelJob(context.Background(), pending.ID)
+
+			Eventually(runDone, 30*time.Second).Should(Receive(BeNil()))
+
+			completed, err := testClient.GetJob(context.Background(), job.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(completed.Status).To(Equal(models.StatusCompleted))
+
+			notClaimed, err := testClient.GetJob(context.Background(), pending.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(notClaimed.Status).To(Equal(models.StatusPending))
+		})
+
+		It("should fail jobs still running when the drain timeout expires", func() {
+			submission := &models.JobSubmission{
+				Type:         "drain-timeout-test",
+				BinaryURL:    getBinaryURL("longrunning"),
+				BinarySHA256: calculateFileSHA256("testdata/binaries/longrunning"),
+				Arguments:    []string{"60s"},
+				Priority:     models.PriorityForeground,
+			}
+
+			job, err := testClient.SubmitJob(context.Background(), submission)
+			Expect(err).NotTo(HaveOccurred())
+
+			execConfig := &executor.Config{
+				ServerURL:         serverURL,
+				Name:              "drain-timeout-executor",
+				CacheDir:          filepath.Join(createTempDir(), "cache"),
+				WorkDir:           filepath.Join(createTempDir(), "work"),
+				MaxJobs:           1,
+				PollInterval:      1,
+				MaxCacheSize:      100,
+				HeartbeatInterval: 1,
+				NetworkTimeout:    60,
+				DrainTimeout:      2,
+			}
+
+			exec, err := executor.New(execConfig)
+			Expect(err).NotTo(HaveOccurred())
+
+			runDone := make(chan error, 1)
+			go func() {
+				runDone <- exec.Run(context.Background())
 			}()
 
-			// Wait for job to complete
 			Eventually(func() models.Status {
 				job, err := testClient.GetJob(context.Background(), job.ID)
 				if err != nil {
 					return ""
 				}
 				return job.Status
-			}, 30*time.Second, 500*time.Millisecond).Should(Equal(models.StatusCompleted))
+			}, 30*time.Second, 200*time.Millisecond).Should(Equal(models.StatusRunning))
 
-			// Verify output is truncated
-			completedJob, err := testClient.GetJob(context.Background(), job.ID)
+			exec.Drain()
+			Eventually(runDone, 15*time.Second).Should(Receive(BeNil()))
+
+			failed, err := testClient.GetJob(context.Background(), job.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(failed.Status).To(Equal(models.StatusFailed))
+			Expect(failed.ErrorMessage).To(Equal("job killed: executor drain timeout exceeded"))
+		})
+	})
+
+	Describe("Multiple Executor Coordination", func() {
+		It("should coordinate multiple executors with different names", func() {
+			// Submit multiple jobs
+			var jobIDs []uuid.UUID
+			for i := 0; i < 3; i++ {
+				submission := &models.JobSubmission{
+					Type:         fmt.Sprintf("multi-exec-%d", i),
+					BinaryURL:    getBinaryURL("success"),
+					BinarySHA256: successBinarySHA256,
+					Arguments:    []string{fmt.Sprintf("job-%d", i)},
+					Priority:     models.PriorityBackground,
+				}
+
+				job, err := testClient.SubmitJob(context.Background(), submission)
+				Expect(err).NotTo(HaveOccurred())
+				jobIDs = append(jobIDs, job.ID)
+			}
+
+			// Start multiple executors
+			execCtx, execCancel := context.WithCancel(context.Background())
+			defer execCancel()
+
+			for i := 0; i < 2; i++ {
+				execConfig := &executor.Config{
+					ServerURL:         serverURL,
+					Name:              fmt.Sprintf("executor-%d", i),
+					CacheDir:          filepath.Join(createTempDir(), fmt.Sprintf("cache-%d", i)),
+					WorkDir:           filepath.Join(createTempDir(), fmt.Sprintf("work-%d", i)),
+					MaxJobs:           1,
+					PollInterval:      1,
+					MaxCacheSize:      100,
+					HeartbeatInterval: 2,
+					NetworkTimeout:    60,
+				}
+
+				exec, err := executor.New(execConfig)
+				Expect(err).NotTo(HaveOccurred())
+
+				go func(e *executor.Executor) {
+					e.Run(execCtx)
+				}(exec)
+			}
+
+			// Wait for all jobs to complete
+			Eventually(func() bool {
+				for _, jobID := range jobIDs {
+					job, err := testClient.GetJob(context.Background(), jobID)
+					if err != nil || job.Status != models.StatusCompleted {
+						return false
+					}
+				}
+				return true
+			}, 30*time.Second, 500*time.Millisecond).Should(BeTrue())
+
+			// Verify all jobs completed and have executor IDs with correct format
+			executorIDs := make(map[string]bool)
+			for _, jobID := range jobIDs {
+				job, err := testClient.GetJob(context.Background(), jobID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(job.ExecutorID).NotTo(BeEmpty())
+				
+				// Verify executor ID format: {name}-{suffix}
+				Expect(job.ExecutorID).To(MatchRegexp(`executor-\d-[a-f0-9\-]+`))
+				executorIDs[job.ExecutorID] = true
+			}
+
+			// Should have at least one unique executor ID (could be 1 or 2 depending on scheduling)
+			Expect(len(executorIDs)).To(BeNumerically(">=", 1))
+			Expect(len(executorIDs)).To(BeNumerically("<=", 2))
+
+			// Filtering by executor returns exactly the jobs each one claimed
+			claimed := 0
+			for executorID := range executorIDs {
+				jobs, err := testClient.ListJobs(context.Background(), &client.ListJobsFilter{ExecutorID: executorID})
+				Expect(err).NotTo(HaveOccurred())
+				for _, job := range jobs {
+					Expect(job.ExecutorID).To(Equal(executorID))
+				}
+				claimed += len(jobs)
+			}
+			Expect(claimed).To(Equal(len(jobIDs)))
+		})
+	})
+
+	Describe("Executor Registration", func() {
+		It("should list idle executors and remove them on shutdown", func() {
+			execCtx, execCancel := context.WithCancel(context.Background())
+			defer execCancel()
+
+			execConfig := &executor.Config{
+				ServerURL:         serverURL,
+				Name:              "registration-executor",
+				CacheDir:          filepath.Join(createTempDir(), "cache"),
+				WorkDir:           filepath.Join(createTempDir(), "work"),
+				MaxJobs:           3,
+				PollInterval:      1,
+				MaxCacheSize:      100,
+				HeartbeatInterval: 1,
+				NetworkTimeout:    60,
+				Capabilities:      []string{"gpu"},
+				AdvertiseAddr:     "registration-executor:9090",
+			}
+
+			exec, err := executor.New(execConfig)
+			Expect(err).NotTo(HaveOccurred())
+
+			runDone := make(chan error, 1)
+			go func() {
+				runDone <- exec.Run(execCtx)
+			}()
+
+			findExecutor := func() *models.Executor {
+				resp, err := http.Get(serverURL + "/api/v1/admin/executors")
+				if err != nil {
+					return nil
+				}
+				defer resp.Body.Close()
+
+				var executors []*models.Executor
+				if err := json.NewDecoder(resp.Body).Decode(&executors); err != nil {
+					return nil
+				}
+				for _, e := range executors {
+					if e.Name == "registration-executor" {
+						return e
+					}
+				}
+				return nil
+			}
+
+			// Registered and visible without running any job
+			Eventually(findExecutor, 10*time.Second, 200*time.Millisecond).ShouldNot(BeNil())
+			registered := findExecutor()
+			Expect(registered.MaxJobs).To(Equal(3))
+			Expect(registered.Capabilities).To(Equal([]string{"gpu"}))
+			Expect(registered.AdvertiseAddr).To(Equal("registration-executor:9090"))
+
+			// Idle heartbeats carry resource usage
+			Eventually(func() bool {
+				e := findExecutor()
+				return e != nil && e.MemBytes != nil
+			}, 10*time.Second, 500*time.Millisecond).Should(BeTrue())
+
+			execCancel()
+			Eventually(runDone, 15*time.Second).Should(Receive(BeNil()))
+			Expect(findExecutor()).To(BeNil())
+		})
+	})
+
+	Describe("Executor Ownership", func() {
+		It("should reject heartbeats and results from an executor that doesn't own the job", func() {
+			submission := &models.JobSubmission{
+				Type:         "ownership-test",
+				BinaryURL:    getBinaryURL("success"),
+				BinarySHA256: successBinarySHA256,
+				Priority:     models.PriorityForeground,
+			}
+
+			job, err := testClient.SubmitJob(context.Background(), submission)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Claim the job directly as the owning executor
+			owner := "ownership-owner"
+			var claimed *models.Job
+			Eventually(func() bool {
+				claimed, err = testClient.ClaimNextJob(context.Background(), owner, "127.0.0.1", nil)
+				return err == nil && claimed != nil && claimed.ID == job.ID
+			}, 10*time.Second, 200*time.Millisecond).Should(BeTrue())
+
+			// Another executor must not be able to touch it
+			intruder := "ownership-intruder"
+			_, err = testClient.Heartbeat(context.Background(), job.ID, &models.HeartbeatRequest{ExecutorID: intruder})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("not running on this executor"))
+
+			err = testClient.CompleteJob(context.Background(), job.ID, &models.CompleteRequest{
+				ExecutorID: intruder,
+				Stdout:     "hijacked",
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("not running on this executor"))
+
+			err = testClient.FailJob(context.Background(), job.ID, &models.FailRequest{
+				ExecutorID:   intruder,
+				ErrorMessage: "hijacked",
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("not running on this executor"))
+
+			running, err := testClient.GetJob(context.Background(), job.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(running.Status).To(Equal(models.StatusRunning))
+			Expect(running.ExecutorID).To(Equal(owner))
+
+			// The owner can still complete it
+			_, err = testClient.Heartbeat(context.Background(), job.ID, &models.HeartbeatRequest{ExecutorID: owner})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(testClient.CompleteJob(context.Background(), job.ID, &models.CompleteRequest{
+				ExecutorID: owner,
+				Stdout:     "done",
+			})).To(Succeed())
+
+			completed, err := testClient.GetJob(context.Background(), job.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(completed.Status).To(Equal(models.StatusCompleted))
+			Expect(completed.Stdout).To(Equal("done"))
+		})
+	})
+
+	Describe("Output Truncation", func() {
+		It("should truncate large output correctly", func() {
+			// Submit job that generates lots of output
+			submission := &models.JobSubmission{
+				Type:         "output-test",
+				BinaryURL:    getBinaryURL("output"),
+				BinarySHA256: outputBinarySHA256,
+				Arguments:    []string{"10000"}, // Generate 10000 lines
+				Priority:     models.PriorityBackground,
+			}
+
+			job, err := testClient.SubmitJob(context.Background(), submission)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Start executor
+			execCtx, execCancel := context.WithCancel(context.Background())
+			defer execCancel()
+
+			execConfig := &executor.Config{
+				ServerURL:         serverURL,
+				Name:              "output-executor",
+				CacheDir:          filepath.Join(createTempDir(), "cache"),
+				WorkDir:           filepath.Join(createTempDir(), "work"),
+				MaxJobs:           1,
+				PollInterval:      1,
+				MaxCacheSize:      100,
+				HeartbeatInterval: 2,
+				NetworkTimeout:    60,
+			}
+
+			exec, err := executor.New(execConfig)
 			Expect(err).NotTo(HaveOccurred())
+
+			go func() {
+				exec.Run(execCtx)
+			}()
+
+			// Wait for job to complete
+			completedJob := waitForJob(job.ID, 30*time.Second, models.StatusCompleted)
+
+			// Verify output is truncated
 			
 			// Output should be truncated to ~1MB
 			Expect(len(completedJob.Stdout)).To(BeNumerically("<=", 1024*1024+1000)) // Allow some margin
@@ -495,6 +2304,123 @@ var _ = Describe("Executr E2E Tests", func() {
 		})
 	})
 
+	Describe("Retry Backoff", func() {
+		It("should not retry a job before its backoff elapses", func() {
+			// Submit a failing job with a long backoff
+			submission := &models.JobSubmission{
+				Type:             "retry-backoff",
+				BinaryURL:        getBinaryURL("failure"),
+				BinarySHA256:     failureBinarySHA256,
+				Arguments:        []string{"1"},
+				Priority:         models.PriorityBackground,
+				MaxRetries:       3,
+				RetryBackoffBase: 3600,
+			}
+
+			job, err := testClient.SubmitJob(context.Background(), submission)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Start executor
+			execCtx, execCancel := context.WithCancel(context.Background())
+			defer execCancel()
+
+			execConfig := &executor.Config{
+				ServerURL:         serverURL,
+				Name:              "retry-backoff-executor",
+				CacheDir:          filepath.Join(createTempDir(), "cache"),
+				WorkDir:           filepath.Join(createTempDir(), "work"),
+				MaxJobs:           1,
+				PollInterval:      1,
+				MaxCacheSize:      100,
+				HeartbeatInterval: 2,
+				NetworkTimeout:    60,
+			}
+
+			exec, err := executor.New(execConfig)
+			Expect(err).NotTo(HaveOccurred())
+
+			go func() {
+				exec.Run(execCtx)
+			}()
+
+			// Even the first retry waits for the backoff after the failure
+			Eventually(func() models.Status {
+				job, err := testClient.GetJob(context.Background(), job.ID)
+				if err != nil {
+					return ""
+				}
+				return job.Status
+			}, 30*time.Second, 500*time.Millisecond).Should(Equal(models.StatusFailed))
+
+			failedJob, err := testClient.GetJob(context.Background(), job.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(failedJob.RetryBackoffBase).To(Equal(3600))
+			Expect(failedJob.NextRetryAt).NotTo(BeNil())
+			Expect(*failedJob.NextRetryAt).To(BeTemporally(">", failedJob.CompletedAt.Add(50*time.Minute)))
+
+			// The retry worker runs every second but must wait for the backoff
+			Consistently(func() int {
+				job, err := testClient.GetJob(context.Background(), job.ID)
+				if err != nil {
+					return -1
+				}
+				return job.RetryCount
+			}, 5*time.Second, 500*time.Millisecond).Should(Equal(0))
+		})
+
+		It("should only stream the output of the current attempt of a retried job", func() {
+			capability := "retry-logs-" + uuid.New().String()[:8]
+			job, err := testClient.SubmitJob(context.Background(), &models.JobSubmission{
+				Type:                 "retry-logs",
+				BinaryURL:            getBinaryURL("success"),
+				BinarySHA256:         successBinarySHA256,
+				Priority:             models.PriorityForeground,
+				MaxRetries:           1,
+				RetryBackoffBase:     1,
+				RequiredCapabilities: []string{capability},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			// Act as the executor of both attempts
+			runAttempt := func(output string) {
+				claimed, err := testClient.ClaimNextJob(context.Background(), "retry-logs-executor", "127.0.0.1", []string{capability})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(claimed).NotTo(BeNil())
+				Expect(claimed.ID).To(Equal(job.ID))
+				Expect(testClient.AppendJobOutput(context.Background(), job.ID, &models.OutputRequest{
+					ExecutorID: "retry-logs-executor",
+					Stdout:     output,
+				})).To(Succeed())
+			}
+
+			runAttempt("first attempt\n")
+			Expect(testClient.FailJob(context.Background(), job.ID, &models.FailRequest{
+				ExecutorID:   "retry-logs-executor",
+				ErrorMessage: "failed on purpose",
+				ExitCode:     1,
+			})).To(Succeed())
+
+			waitForJob(job.ID, 30*time.Second, models.StatusPending)
+			runAttempt("second attempt\n")
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			chunks, err := testClient.StreamLogs(ctx, job.ID)
+			Expect(err).NotTo(HaveOccurred())
+
+			var stdout string
+			for chunk := range chunks {
+				stdout += chunk.Data
+				if strings.Contains(stdout, "second attempt") {
+					break
+				}
+			}
+			Expect(stdout).To(Equal("second attempt\n"))
+
+			Expect(testClient.CancelJob(context.Background(), job.ID)).To(Succeed())
+		})
+	})
+
 	Describe("Working Directory Cleanup", func() {
 		It("should clean up job directories after completion", func() {
 			workDir := filepath.Join(createTempDir(), "work")
@@ -538,13 +2464,7 @@ var _ = Describe("Executr E2E Tests", func() {
 			}()
 
 			// Wait for job to complete
-			Eventually(func() models.Status {
-				job, err := testClient.GetJob(context.Background(), job.ID)
-				if err != nil {
-					return ""
-				}
-				return job.Status
-			}, 30*time.Second, 500*time.Millisecond).Should(Equal(models.StatusCompleted))
+			waitForJob(job.ID, 30*time.Second, models.StatusCompleted)
 
 			// Allow cleanup to happen
 			time.Sleep(2 * time.Second)
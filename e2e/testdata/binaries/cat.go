@@ -0,0 +1,31 @@
+// +build ignore
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	// Echo the files named in the arguments to stdout, or stdin without
+	// arguments, for stdin and input file testing
+	if len(os.Args) == 1 {
+		if _, err := io.Copy(os.Stdout, os.Stdin); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to copy stdin: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	for _, name := range os.Args[1:] {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read %s: %v\n", name, err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(data)
+	}
+	os.Exit(0)
+}
\ No newline at end of file
@@ -0,0 +1,39 @@
+// +build ignore
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+func main() {
+	// Write a file of the given size in MB to the working directory and
+	// keep running, for working directory quota testing
+	sizeMB := 10
+	if len(os.Args) > 1 {
+		if n, err := strconv.Atoi(os.Args[1]); err == nil {
+			sizeMB = n
+		}
+	}
+
+	f, err := os.Create("fill.bin")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create file: %v\n", err)
+		os.Exit(1)
+	}
+	chunk := make([]byte, 1024*1024)
+	for i := 0; i < sizeMB; i++ {
+		if _, err := f.Write(chunk); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	f.Close()
+	fmt.Printf("Wrote %d MB\n", sizeMB)
+
+	time.Sleep(60 * time.Second)
+	os.Exit(0)
+}
\ No newline at end of file
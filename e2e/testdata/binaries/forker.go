@@ -0,0 +1,40 @@
+// +build ignore
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// forker spawns a child process that writes a marker file after a delay,
+// then keeps running so it can be killed before the child finishes.
+func main() {
+	if len(os.Args) > 2 && os.Args[1] == "child" {
+		time.Sleep(3 * time.Second)
+		os.WriteFile(os.Args[2], []byte("child survived\n"), 0644)
+		return
+	}
+	
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: forker <marker-file>")
+		os.Exit(2)
+	}
+	
+	self, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to resolve executable: %v\n", err)
+		os.Exit(1)
+	}
+	
+	child := exec.Command(self, "child", os.Args[1])
+	if err := child.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to start child: %v\n", err)
+		os.Exit(1)
+	}
+	
+	fmt.Printf("Started child process %d\n", child.Process.Pid)
+	time.Sleep(60 * time.Second)
+}
\ No newline at end of file
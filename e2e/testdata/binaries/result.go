@@ -0,0 +1,35 @@
+// +build ignore
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+func main() {
+	// Report how many of the units given as the first argument succeeded,
+	// failing unless all of them did, for result reporting testing
+	total := 3
+	if len(os.Args) > 1 {
+		n, err := strconv.Atoi(os.Args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid unit count: %v\n", err)
+			os.Exit(2)
+		}
+		total = n
+	}
+	processed := total - total/3
+
+	result := fmt.Sprintf(`{"processed":%d,"failed":%d}`, processed, total-processed)
+	if err := os.WriteFile(os.Getenv("EXECUTR_RESULT"), []byte(result), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write result: %v\n", err)
+		os.Exit(2)
+	}
+
+	if processed < total {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
\ No newline at end of file
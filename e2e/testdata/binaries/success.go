@@ -21,5 +21,12 @@ func main() {
 		fmt.Printf("TEST_ENV=%s\n", val)
 	}
 	
+	// Print the executr context the executor passes to jobs
+	for _, key := range []string{"EXECUTR_JOB_ID", "EXECUTR_EXECUTOR_ID", "EXECUTR_SERVER_URL"} {
+		if val := os.Getenv(key); val != "" {
+			fmt.Printf("%s=%s\n", key, val)
+		}
+	}
+	
 	os.Exit(0)
 }
\ No newline at end of file
@@ -12,6 +12,25 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const completeJobAttempt = `-- name: CompleteJobAttempt :exec
+UPDATE job_attempts
+SET ended_at = NOW(),
+    status = 'completed'
+WHERE job_id = $1
+  AND executor_id = $2
+  AND ended_at IS NULL
+`
+
+type CompleteJobAttemptParams struct {
+	JobID      uuid.UUID `json:"job_id"`
+	ExecutorID string    `json:"executor_id"`
+}
+
+func (q *Queries) CompleteJobAttempt(ctx context.Context, arg CompleteJobAttemptParams) error {
+	_, err := q.db.Exec(ctx, completeJobAttempt, arg.JobID, arg.ExecutorID)
+	return err
+}
+
 const countJobAttempts = `-- name: CountJobAttempts :one
 SELECT COUNT(*) as attempt_count
 FROM job_attempts
@@ -25,6 +44,27 @@ func (q *Queries) CountJobAttempts(ctx context.Context, jobID uuid.UUID) (int64,
 	return attempt_count, err
 }
 
+const failJobAttempt = `-- name: FailJobAttempt :exec
+UPDATE job_attempts
+SET ended_at = NOW(),
+    status = 'failed',
+    error_message = $3
+WHERE job_id = $1
+  AND executor_id = $2
+  AND ended_at IS NULL
+`
+
+type FailJobAttemptParams struct {
+	JobID        uuid.UUID   `json:"job_id"`
+	ExecutorID   string      `json:"executor_id"`
+	ErrorMessage pgtype.Text `json:"error_message"`
+}
+
+func (q *Queries) FailJobAttempt(ctx context.Context, arg FailJobAttemptParams) error {
+	_, err := q.db.Exec(ctx, failJobAttempt, arg.JobID, arg.ExecutorID, arg.ErrorMessage)
+	return err
+}
+
 const getJobAttempts = `-- name: GetJobAttempts :many
 SELECT id, job_id, executor_id, executor_ip, started_at, ended_at, status, error_message FROM job_attempts
 WHERE job_id = $1
@@ -114,6 +154,25 @@ func (q *Queries) RecordJobAttempt(ctx context.Context, arg RecordJobAttemptPara
 	return i, err
 }
 
+const recordJobRetry = `-- name: RecordJobRetry :exec
+INSERT INTO job_attempts (
+    job_id, executor_id, executor_ip, status, ended_at, error_message
+) VALUES (
+    $1, $2, '', 'retried', NOW(), $3
+)
+`
+
+type RecordJobRetryParams struct {
+	JobID        uuid.UUID   `json:"job_id"`
+	ExecutorID   string      `json:"executor_id"`
+	ErrorMessage pgtype.Text `json:"error_message"`
+}
+
+func (q *Queries) RecordJobRetry(ctx context.Context, arg RecordJobRetryParams) error {
+	_, err := q.db.Exec(ctx, recordJobRetry, arg.JobID, arg.ExecutorID, arg.ErrorMessage)
+	return err
+}
+
 const updateJobAttempt = `-- name: UpdateJobAttempt :exec
 UPDATE job_attempts
 SET ended_at = NOW(),
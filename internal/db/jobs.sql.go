@@ -15,13 +15,21 @@ import (
 const cancelJob = `-- name: CancelJob :one
 UPDATE jobs
 SET status = 'cancelled',
-    completed_at = NOW()
-WHERE id = $1 AND status = 'pending'
-RETURNING id, type, binary_url, binary_sha256, arguments, env_variables, priority, status, executor_id, stdout, stderr, exit_code, error_message, created_at, started_at, completed_at, last_heartbeat, max_retries, retry_count, retry_after
+    completed_at = NOW(),
+    cancellation_reason = $1
+WHERE id = $2 AND status IN ('pending', 'running')
+RETURNING id, type, binary_url, binary_sha256, arguments, env_variables, priority, status, executor_id, stdout, stderr, exit_code, error_message, created_at, started_at, completed_at, last_heartbeat, max_retries, retry_count, timeout_seconds, stdout_buffer, stderr_buffer, stdout_url, stderr_url, max_output_bytes, signature_url, public_key, required_capabilities, retry_backoff_base, next_retry_at, scheduled_at, labels, idempotency_key, stdin, input_files, output_globs, workdir_quota_bytes, cpu_millicores, mem_limit_bytes, trace_parent, cancellation_reason, result_json, idempotency_scope, idempotency_hash
 `
 
-func (q *Queries) CancelJob(ctx context.Context, id uuid.UUID) (Job, error) {
-	row := q.db.QueryRow(ctx, cancelJob, id)
+type CancelJobParams struct {
+	Reason pgtype.Text `json:"reason"`
+	ID     uuid.UUID   `json:"id"`
+}
+
+// A running job keeps its executor_id, so the executor learns about the
+// cancellation from its next heartbeat
+func (q *Queries) CancelJob(ctx context.Context, arg CancelJobParams) (Job, error) {
+	row := q.db.QueryRow(ctx, cancelJob, arg.Reason, arg.ID)
 	var i Job
 	err := row.Scan(
 		&i.ID,
@@ -43,11 +51,59 @@ func (q *Queries) CancelJob(ctx context.Context, id uuid.UUID) (Job, error) {
 		&i.LastHeartbeat,
 		&i.MaxRetries,
 		&i.RetryCount,
-		&i.RetryAfter,
+		&i.TimeoutSeconds,
+		&i.StdoutBuffer,
+		&i.StderrBuffer,
+		&i.StdoutUrl,
+		&i.StderrUrl,
+		&i.MaxOutputBytes,
+		&i.SignatureUrl,
+		&i.PublicKey,
+		&i.RequiredCapabilities,
+		&i.RetryBackoffBase,
+		&i.NextRetryAt,
+		&i.ScheduledAt,
+		&i.Labels,
+		&i.IdempotencyKey,
+		&i.Stdin,
+		&i.InputFiles,
+		&i.OutputGlobs,
+		&i.WorkdirQuotaBytes,
+		&i.CpuMillicores,
+		&i.MemLimitBytes,
+		&i.TraceParent,
+		&i.CancellationReason,
+		&i.ResultJson,
+		&i.IdempotencyScope,
+		&i.IdempotencyHash,
 	)
 	return i, err
 }
 
+const cancelJobsByCriteria = `-- name: CancelJobsByCriteria :execrows
+UPDATE jobs
+SET status = 'cancelled',
+    completed_at = NOW(),
+    cancellation_reason = $1
+WHERE status IN ('pending', 'running')
+  AND ($2::text IS NULL OR type = $2)
+  AND ($3::text IS NULL OR status = $3)
+`
+
+type CancelJobsByCriteriaParams struct {
+	Reason pgtype.Text `json:"reason"`
+	Type   pgtype.Text `json:"type"`
+	Status pgtype.Text `json:"status"`
+}
+
+func (q *Queries) CancelJobsByCriteria(ctx context.Context, arg CancelJobsByCriteriaParams) (int64, error) {
+	result, err := q.db.Exec(ctx, cancelJobsByCriteria, arg.Reason, arg.Type, arg.Status)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const claimNextJob = `-- name: ClaimNextJob :one
 UPDATE jobs
 SET status = 'running',
@@ -57,21 +113,41 @@ SET status = 'running',
 WHERE id = (
     SELECT id FROM jobs
     WHERE status = 'pending'
+      AND (scheduled_at IS NULL OR scheduled_at <= NOW())
+      AND NOT EXISTS (
+          SELECT 1 FROM job_dependencies d
+          JOIN jobs p ON p.id = d.depends_on
+          WHERE d.job_id = jobs.id AND p.status <> 'completed'
+      )
+      AND required_capabilities <@ $2::text[]
+      AND NOT EXISTS (
+          SELECT 1 FROM job_type_limits l
+          WHERE l.job_type = jobs.type
+            AND l.max_running <= (
+                SELECT COUNT(*) FROM jobs r
+                WHERE r.type = l.job_type AND r.status = 'running'
+            )
+      )
     ORDER BY 
-        CASE priority
-            WHEN 'foreground' THEN 1
-            WHEN 'background' THEN 2
-            WHEN 'best_effort' THEN 3
-        END,
+        array_position($3::text[], priority),
         created_at
     FOR UPDATE SKIP LOCKED
     LIMIT 1
 )
-RETURNING id, type, binary_url, binary_sha256, arguments, env_variables, priority, status, executor_id, stdout, stderr, exit_code, error_message, created_at, started_at, completed_at, last_heartbeat, max_retries, retry_count, retry_after
+RETURNING id, type, binary_url, binary_sha256, arguments, env_variables, priority, status, executor_id, stdout, stderr, exit_code, error_message, created_at, started_at, completed_at, last_heartbeat, max_retries, retry_count, timeout_seconds, stdout_buffer, stderr_buffer, stdout_url, stderr_url, max_output_bytes, signature_url, public_key, required_capabilities, retry_backoff_base, next_retry_at, scheduled_at, labels, idempotency_key, stdin, input_files, output_globs, workdir_quota_bytes, cpu_millicores, mem_limit_bytes, trace_parent, cancellation_reason, result_json, idempotency_scope, idempotency_hash
 `
 
-func (q *Queries) ClaimNextJob(ctx context.Context, executorID pgtype.Text) (Job, error) {
-	row := q.db.QueryRow(ctx, claimNextJob, executorID)
+type ClaimNextJobParams struct {
+	ExecutorID   pgtype.Text `json:"executor_id"`
+	Capabilities []string    `json:"capabilities"`
+	Priorities   []string    `json:"priorities"`
+}
+
+// Takes the oldest job of the first priority in priorities that has one.
+// Run it in a transaction after LockJobTypeLimits, otherwise concurrent
+// claims can exceed the limits of job types
+func (q *Queries) ClaimNextJob(ctx context.Context, arg ClaimNextJobParams) (Job, error) {
+	row := q.db.QueryRow(ctx, claimNextJob, arg.ExecutorID, arg.Capabilities, arg.Priorities)
 	var i Job
 	err := row.Scan(
 		&i.ID,
@@ -93,17 +169,145 @@ func (q *Queries) ClaimNextJob(ctx context.Context, executorID pgtype.Text) (Job
 		&i.LastHeartbeat,
 		&i.MaxRetries,
 		&i.RetryCount,
-		&i.RetryAfter,
+		&i.TimeoutSeconds,
+		&i.StdoutBuffer,
+		&i.StderrBuffer,
+		&i.StdoutUrl,
+		&i.StderrUrl,
+		&i.MaxOutputBytes,
+		&i.SignatureUrl,
+		&i.PublicKey,
+		&i.RequiredCapabilities,
+		&i.RetryBackoffBase,
+		&i.NextRetryAt,
+		&i.ScheduledAt,
+		&i.Labels,
+		&i.IdempotencyKey,
+		&i.Stdin,
+		&i.InputFiles,
+		&i.OutputGlobs,
+		&i.WorkdirQuotaBytes,
+		&i.CpuMillicores,
+		&i.MemLimitBytes,
+		&i.TraceParent,
+		&i.CancellationReason,
+		&i.ResultJson,
+		&i.IdempotencyScope,
+		&i.IdempotencyHash,
+	)
+	return i, err
+}
+
+const claimNextJobFair = `-- name: ClaimNextJobFair :one
+UPDATE jobs
+SET status = 'running',
+    executor_id = $1,
+    started_at = NOW(),
+    last_heartbeat = NOW()
+WHERE id = (
+    SELECT jobs.id FROM jobs
+    LEFT JOIN job_type_claims c ON c.job_type = jobs.type
+    WHERE status = 'pending'
+      AND (scheduled_at IS NULL OR scheduled_at <= NOW())
+      AND NOT EXISTS (
+          SELECT 1 FROM job_dependencies d
+          JOIN jobs p ON p.id = d.depends_on
+          WHERE d.job_id = jobs.id AND p.status <> 'completed'
+      )
+      AND required_capabilities <@ $2::text[]
+      AND NOT EXISTS (
+          SELECT 1 FROM job_type_limits l
+          WHERE l.job_type = jobs.type
+            AND l.max_running <= (
+                SELECT COUNT(*) FROM jobs r
+                WHERE r.type = l.job_type AND r.status = 'running'
+            )
+      )
+    ORDER BY 
+        array_position($3::text[], priority),
+        c.last_claimed_at NULLS FIRST,
+        created_at
+    FOR UPDATE OF jobs SKIP LOCKED
+    LIMIT 1
+)
+RETURNING id, type, binary_url, binary_sha256, arguments, env_variables, priority, status, executor_id, stdout, stderr, exit_code, error_message, created_at, started_at, completed_at, last_heartbeat, max_retries, retry_count, timeout_seconds, stdout_buffer, stderr_buffer, stdout_url, stderr_url, max_output_bytes, signature_url, public_key, required_capabilities, retry_backoff_base, next_retry_at, scheduled_at, labels, idempotency_key, stdin, input_files, output_globs, workdir_quota_bytes, cpu_millicores, mem_limit_bytes, trace_parent, cancellation_reason, result_json, idempotency_scope, idempotency_hash
+`
+
+type ClaimNextJobFairParams struct {
+	ExecutorID   pgtype.Text `json:"executor_id"`
+	Capabilities []string    `json:"capabilities"`
+	Priorities   []string    `json:"priorities"`
+}
+
+// Like ClaimNextJob, but within a priority it takes the oldest job of the
+// type that was claimed least recently, so that no type monopolizes the
+// executors. Run it in the same transaction as RecordJobTypeClaim.
+func (q *Queries) ClaimNextJobFair(ctx context.Context, arg ClaimNextJobFairParams) (Job, error) {
+	row := q.db.QueryRow(ctx, claimNextJobFair, arg.ExecutorID, arg.Capabilities, arg.Priorities)
+	var i Job
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.BinaryUrl,
+		&i.BinarySha256,
+		&i.Arguments,
+		&i.EnvVariables,
+		&i.Priority,
+		&i.Status,
+		&i.ExecutorID,
+		&i.Stdout,
+		&i.Stderr,
+		&i.ExitCode,
+		&i.ErrorMessage,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.LastHeartbeat,
+		&i.MaxRetries,
+		&i.RetryCount,
+		&i.TimeoutSeconds,
+		&i.StdoutBuffer,
+		&i.StderrBuffer,
+		&i.StdoutUrl,
+		&i.StderrUrl,
+		&i.MaxOutputBytes,
+		&i.SignatureUrl,
+		&i.PublicKey,
+		&i.RequiredCapabilities,
+		&i.RetryBackoffBase,
+		&i.NextRetryAt,
+		&i.ScheduledAt,
+		&i.Labels,
+		&i.IdempotencyKey,
+		&i.Stdin,
+		&i.InputFiles,
+		&i.OutputGlobs,
+		&i.WorkdirQuotaBytes,
+		&i.CpuMillicores,
+		&i.MemLimitBytes,
+		&i.TraceParent,
+		&i.CancellationReason,
+		&i.ResultJson,
+		&i.IdempotencyScope,
+		&i.IdempotencyHash,
 	)
 	return i, err
 }
 
 const cleanupOldJobs = `-- name: CleanupOldJobs :exec
 DELETE FROM jobs
-WHERE status IN ('completed', 'failed', 'cancelled')
+WHERE status IN ('completed', 'failed', 'cancelled', 'dead_letter', 'skipped')
   AND completed_at < NOW() - $1::interval
+  AND NOT (status = 'failed' AND retry_count < max_retries)
+  AND NOT EXISTS (
+      SELECT 1 FROM job_dependencies d
+      JOIN jobs c ON c.id = d.job_id
+      WHERE d.depends_on = jobs.id AND c.status IN ('pending', 'running')
+  )
 `
 
+// Only deletes finished jobs. Failed jobs waiting for a retry and jobs whose
+// dependents haven't finished yet are kept, whatever their age.
 func (q *Queries) CleanupOldJobs(ctx context.Context, dollar_1 pgtype.Interval) error {
 	_, err := q.db.Exec(ctx, cleanupOldJobs, dollar_1)
 	return err
@@ -115,16 +319,23 @@ SET status = 'completed',
     stdout = $2,
     stderr = $3,
     exit_code = $4,
+    stdout_url = $5,
+    stderr_url = $6,
+    result_json = $8,
     completed_at = NOW()
-WHERE id = $1 AND status = 'running'
-RETURNING id, type, binary_url, binary_sha256, arguments, env_variables, priority, status, executor_id, stdout, stderr, exit_code, error_message, created_at, started_at, completed_at, last_heartbeat, max_retries, retry_count, retry_after
+WHERE id = $1 AND executor_id = $7 AND status = 'running'
+RETURNING id, type, binary_url, binary_sha256, arguments, env_variables, priority, status, executor_id, stdout, stderr, exit_code, error_message, created_at, started_at, completed_at, last_heartbeat, max_retries, retry_count, timeout_seconds, stdout_buffer, stderr_buffer, stdout_url, stderr_url, max_output_bytes, signature_url, public_key, required_capabilities, retry_backoff_base, next_retry_at, scheduled_at, labels, idempotency_key, stdin, input_files, output_globs, workdir_quota_bytes, cpu_millicores, mem_limit_bytes, trace_parent, cancellation_reason, result_json, idempotency_scope, idempotency_hash
 `
 
 type CompleteJobParams struct {
-	ID       uuid.UUID   `json:"id"`
-	Stdout   pgtype.Text `json:"stdout"`
-	Stderr   pgtype.Text `json:"stderr"`
-	ExitCode pgtype.Int4 `json:"exit_code"`
+	ID         uuid.UUID   `json:"id"`
+	Stdout     pgtype.Text `json:"stdout"`
+	Stderr     pgtype.Text `json:"stderr"`
+	ExitCode   pgtype.Int4 `json:"exit_code"`
+	StdoutUrl  pgtype.Text `json:"stdout_url"`
+	StderrUrl  pgtype.Text `json:"stderr_url"`
+	ExecutorID pgtype.Text `json:"executor_id"`
+	ResultJson []byte      `json:"result_json"`
 }
 
 func (q *Queries) CompleteJob(ctx context.Context, arg CompleteJobParams) (Job, error) {
@@ -133,6 +344,10 @@ func (q *Queries) CompleteJob(ctx context.Context, arg CompleteJobParams) (Job,
 		arg.Stdout,
 		arg.Stderr,
 		arg.ExitCode,
+		arg.StdoutUrl,
+		arg.StderrUrl,
+		arg.ExecutorID,
+		arg.ResultJson,
 	)
 	var i Job
 	err := row.Scan(
@@ -155,27 +370,103 @@ func (q *Queries) CompleteJob(ctx context.Context, arg CompleteJobParams) (Job,
 		&i.LastHeartbeat,
 		&i.MaxRetries,
 		&i.RetryCount,
-		&i.RetryAfter,
+		&i.TimeoutSeconds,
+		&i.StdoutBuffer,
+		&i.StderrBuffer,
+		&i.StdoutUrl,
+		&i.StderrUrl,
+		&i.MaxOutputBytes,
+		&i.SignatureUrl,
+		&i.PublicKey,
+		&i.RequiredCapabilities,
+		&i.RetryBackoffBase,
+		&i.NextRetryAt,
+		&i.ScheduledAt,
+		&i.Labels,
+		&i.IdempotencyKey,
+		&i.Stdin,
+		&i.InputFiles,
+		&i.OutputGlobs,
+		&i.WorkdirQuotaBytes,
+		&i.CpuMillicores,
+		&i.MemLimitBytes,
+		&i.TraceParent,
+		&i.CancellationReason,
+		&i.ResultJson,
+		&i.IdempotencyScope,
+		&i.IdempotencyHash,
 	)
 	return i, err
 }
 
+const countJobs = `-- name: CountJobs :one
+SELECT COUNT(*) FROM jobs
+WHERE ($1::text IS NULL OR status = $1)
+  AND ($2::text IS NULL OR type = $2)
+  AND ($3::text IS NULL OR priority = $3)
+  AND ($4::jsonb IS NULL OR labels @> $4)
+  AND ($5::text IS NULL OR executor_id = $5)
+`
+
+type CountJobsParams struct {
+	Status     pgtype.Text `json:"status"`
+	Type       pgtype.Text `json:"type"`
+	Priority   pgtype.Text `json:"priority"`
+	Labels     []byte      `json:"labels"`
+	ExecutorID pgtype.Text `json:"executor_id"`
+}
+
+func (q *Queries) CountJobs(ctx context.Context, arg CountJobsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countJobs,
+		arg.Status,
+		arg.Type,
+		arg.Priority,
+		arg.Labels,
+		arg.ExecutorID,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createJob = `-- name: CreateJob :one
 INSERT INTO jobs (
-    type, binary_url, binary_sha256, arguments, env_variables, priority
+    type, binary_url, binary_sha256, arguments, env_variables, priority,
+    timeout_seconds, max_output_bytes, signature_url, public_key,
+    required_capabilities, max_retries, retry_backoff_base, scheduled_at, labels, idempotency_key, stdin, input_files, output_globs, workdir_quota_bytes,
+    cpu_millicores, mem_limit_bytes, trace_parent, idempotency_scope, idempotency_hash
 ) VALUES (
-    $1, $2, $3, $4, $5, $6
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25
 )
-RETURNING id, type, binary_url, binary_sha256, arguments, env_variables, priority, status, executor_id, stdout, stderr, exit_code, error_message, created_at, started_at, completed_at, last_heartbeat, max_retries, retry_count, retry_after
+RETURNING id, type, binary_url, binary_sha256, arguments, env_variables, priority, status, executor_id, stdout, stderr, exit_code, error_message, created_at, started_at, completed_at, last_heartbeat, max_retries, retry_count, timeout_seconds, stdout_buffer, stderr_buffer, stdout_url, stderr_url, max_output_bytes, signature_url, public_key, required_capabilities, retry_backoff_base, next_retry_at, scheduled_at, labels, idempotency_key, stdin, input_files, output_globs, workdir_quota_bytes, cpu_millicores, mem_limit_bytes, trace_parent, cancellation_reason, result_json, idempotency_scope, idempotency_hash
 `
 
 type CreateJobParams struct {
-	Type         string   `json:"type"`
-	BinaryUrl    string   `json:"binary_url"`
-	BinarySha256 string   `json:"binary_sha256"`
-	Arguments    []string `json:"arguments"`
-	EnvVariables []byte   `json:"env_variables"`
-	Priority     string   `json:"priority"`
+	Type                 string             `json:"type"`
+	BinaryUrl            string             `json:"binary_url"`
+	BinarySha256         string             `json:"binary_sha256"`
+	Arguments            []string           `json:"arguments"`
+	EnvVariables         []byte             `json:"env_variables"`
+	Priority             string             `json:"priority"`
+	TimeoutSeconds       int32              `json:"timeout_seconds"`
+	MaxOutputBytes       int32              `json:"max_output_bytes"`
+	SignatureUrl         pgtype.Text        `json:"signature_url"`
+	PublicKey            pgtype.Text        `json:"public_key"`
+	RequiredCapabilities []string           `json:"required_capabilities"`
+	MaxRetries           int32              `json:"max_retries"`
+	RetryBackoffBase     int32              `json:"retry_backoff_base"`
+	ScheduledAt          pgtype.Timestamptz `json:"scheduled_at"`
+	Labels               []byte             `json:"labels"`
+	IdempotencyKey       pgtype.Text        `json:"idempotency_key"`
+	Stdin                []byte             `json:"stdin"`
+	InputFiles           []byte             `json:"input_files"`
+	OutputGlobs          []string           `json:"output_globs"`
+	WorkdirQuotaBytes    int64              `json:"workdir_quota_bytes"`
+	CpuMillicores        int32              `json:"cpu_millicores"`
+	MemLimitBytes        int64              `json:"mem_limit_bytes"`
+	TraceParent          pgtype.Text        `json:"trace_parent"`
+	IdempotencyScope     string             `json:"idempotency_scope"`
+	IdempotencyHash      pgtype.Text        `json:"idempotency_hash"`
 }
 
 func (q *Queries) CreateJob(ctx context.Context, arg CreateJobParams) (Job, error) {
@@ -186,6 +477,25 @@ func (q *Queries) CreateJob(ctx context.Context, arg CreateJobParams) (Job, erro
 		arg.Arguments,
 		arg.EnvVariables,
 		arg.Priority,
+		arg.TimeoutSeconds,
+		arg.MaxOutputBytes,
+		arg.SignatureUrl,
+		arg.PublicKey,
+		arg.RequiredCapabilities,
+		arg.MaxRetries,
+		arg.RetryBackoffBase,
+		arg.ScheduledAt,
+		arg.Labels,
+		arg.IdempotencyKey,
+		arg.Stdin,
+		arg.InputFiles,
+		arg.OutputGlobs,
+		arg.WorkdirQuotaBytes,
+		arg.CpuMillicores,
+		arg.MemLimitBytes,
+		arg.TraceParent,
+		arg.IdempotencyScope,
+		arg.IdempotencyHash,
 	)
 	var i Job
 	err := row.Scan(
@@ -208,7 +518,31 @@ func (q *Queries) CreateJob(ctx context.Context, arg CreateJobParams) (Job, erro
 		&i.LastHeartbeat,
 		&i.MaxRetries,
 		&i.RetryCount,
-		&i.RetryAfter,
+		&i.TimeoutSeconds,
+		&i.StdoutBuffer,
+		&i.StderrBuffer,
+		&i.StdoutUrl,
+		&i.StderrUrl,
+		&i.MaxOutputBytes,
+		&i.SignatureUrl,
+		&i.PublicKey,
+		&i.RequiredCapabilities,
+		&i.RetryBackoffBase,
+		&i.NextRetryAt,
+		&i.ScheduledAt,
+		&i.Labels,
+		&i.IdempotencyKey,
+		&i.Stdin,
+		&i.InputFiles,
+		&i.OutputGlobs,
+		&i.WorkdirQuotaBytes,
+		&i.CpuMillicores,
+		&i.MemLimitBytes,
+		&i.TraceParent,
+		&i.CancellationReason,
+		&i.ResultJson,
+		&i.IdempotencyScope,
+		&i.IdempotencyHash,
 	)
 	return i, err
 }
@@ -229,9 +563,17 @@ SET status = 'failed',
     stderr = $3,
     exit_code = $4,
     error_message = $5,
-    completed_at = NOW()
-WHERE id = $1 AND status = 'running'
-RETURNING id, type, binary_url, binary_sha256, arguments, env_variables, priority, status, executor_id, stdout, stderr, exit_code, error_message, created_at, started_at, completed_at, last_heartbeat, max_retries, retry_count, retry_after
+    stdout_url = $6,
+    stderr_url = $7,
+    result_json = $9,
+    completed_at = NOW(),
+    -- Exponential backoff from the failure, the exponent is capped so the
+    -- interval can't overflow
+    next_retry_at = CASE WHEN retry_count < max_retries
+        THEN NOW() + INTERVAL '1 second' * retry_backoff_base * POWER(2, LEAST(retry_count, 20))
+    END
+WHERE id = $1 AND executor_id = $8 AND status = 'running'
+RETURNING id, type, binary_url, binary_sha256, arguments, env_variables, priority, status, executor_id, stdout, stderr, exit_code, error_message, created_at, started_at, completed_at, last_heartbeat, max_retries, retry_count, timeout_seconds, stdout_buffer, stderr_buffer, stdout_url, stderr_url, max_output_bytes, signature_url, public_key, required_capabilities, retry_backoff_base, next_retry_at, scheduled_at, labels, idempotency_key, stdin, input_files, output_globs, workdir_quota_bytes, cpu_millicores, mem_limit_bytes, trace_parent, cancellation_reason, result_json, idempotency_scope, idempotency_hash
 `
 
 type FailJobParams struct {
@@ -240,6 +582,10 @@ type FailJobParams struct {
 	Stderr       pgtype.Text `json:"stderr"`
 	ExitCode     pgtype.Int4 `json:"exit_code"`
 	ErrorMessage pgtype.Text `json:"error_message"`
+	StdoutUrl    pgtype.Text `json:"stdout_url"`
+	StderrUrl    pgtype.Text `json:"stderr_url"`
+	ExecutorID   pgtype.Text `json:"executor_id"`
+	ResultJson   []byte      `json:"result_json"`
 }
 
 func (q *Queries) FailJob(ctx context.Context, arg FailJobParams) (Job, error) {
@@ -249,6 +595,10 @@ func (q *Queries) FailJob(ctx context.Context, arg FailJobParams) (Job, error) {
 		arg.Stderr,
 		arg.ExitCode,
 		arg.ErrorMessage,
+		arg.StdoutUrl,
+		arg.StderrUrl,
+		arg.ExecutorID,
+		arg.ResultJson,
 	)
 	var i Job
 	err := row.Scan(
@@ -271,19 +621,45 @@ func (q *Queries) FailJob(ctx context.Context, arg FailJobParams) (Job, error) {
 		&i.LastHeartbeat,
 		&i.MaxRetries,
 		&i.RetryCount,
-		&i.RetryAfter,
+		&i.TimeoutSeconds,
+		&i.StdoutBuffer,
+		&i.StderrBuffer,
+		&i.StdoutUrl,
+		&i.StderrUrl,
+		&i.MaxOutputBytes,
+		&i.SignatureUrl,
+		&i.PublicKey,
+		&i.RequiredCapabilities,
+		&i.RetryBackoffBase,
+		&i.NextRetryAt,
+		&i.ScheduledAt,
+		&i.Labels,
+		&i.IdempotencyKey,
+		&i.Stdin,
+		&i.InputFiles,
+		&i.OutputGlobs,
+		&i.WorkdirQuotaBytes,
+		&i.CpuMillicores,
+		&i.MemLimitBytes,
+		&i.TraceParent,
+		&i.CancellationReason,
+		&i.ResultJson,
+		&i.IdempotencyScope,
+		&i.IdempotencyHash,
 	)
 	return i, err
 }
 
 const findStaleJobs = `-- name: FindStaleJobs :many
-SELECT id, type, binary_url, binary_sha256, arguments, env_variables, priority, status, executor_id, stdout, stderr, exit_code, error_message, created_at, started_at, completed_at, last_heartbeat, max_retries, retry_count, retry_after FROM jobs
+SELECT id, type, binary_url, binary_sha256, arguments, env_variables, priority, status, executor_id, stdout, stderr, exit_code, error_message, created_at, started_at, completed_at, last_heartbeat, max_retries, retry_count, timeout_seconds, stdout_buffer, stderr_buffer, stdout_url, stderr_url, max_output_bytes, signature_url, public_key, required_capabilities, retry_backoff_base, next_retry_at, scheduled_at, labels, idempotency_key, stdin, input_files, output_globs, workdir_quota_bytes, cpu_millicores, mem_limit_bytes, trace_parent, cancellation_reason, result_json, idempotency_scope, idempotency_hash FROM jobs
 WHERE status = 'running'
-  AND last_heartbeat < NOW() - INTERVAL '15 seconds'
+  AND COALESCE(last_heartbeat, started_at, created_at) < NOW() - $1::interval
 `
 
-func (q *Queries) FindStaleJobs(ctx context.Context) ([]Job, error) {
-	rows, err := q.db.Query(ctx, findStaleJobs)
+// A running job without a heartbeat, e.g. one whose executor crashed right
+// after claiming it, is measured from when it was started
+func (q *Queries) FindStaleJobs(ctx context.Context, timeout pgtype.Interval) ([]Job, error) {
+	rows, err := q.db.Query(ctx, findStaleJobs, timeout)
 	if err != nil {
 		return nil, err
 	}
@@ -311,7 +687,31 @@ func (q *Queries) FindStaleJobs(ctx context.Context) ([]Job, error) {
 			&i.LastHeartbeat,
 			&i.MaxRetries,
 			&i.RetryCount,
-			&i.RetryAfter,
+			&i.TimeoutSeconds,
+			&i.StdoutBuffer,
+			&i.StderrBuffer,
+			&i.StdoutUrl,
+			&i.StderrUrl,
+			&i.MaxOutputBytes,
+			&i.SignatureUrl,
+			&i.PublicKey,
+			&i.RequiredCapabilities,
+			&i.RetryBackoffBase,
+			&i.NextRetryAt,
+			&i.ScheduledAt,
+			&i.Labels,
+			&i.IdempotencyKey,
+			&i.Stdin,
+			&i.InputFiles,
+			&i.OutputGlobs,
+			&i.WorkdirQuotaBytes,
+			&i.CpuMillicores,
+			&i.MemLimitBytes,
+			&i.TraceParent,
+			&i.CancellationReason,
+			&i.ResultJson,
+			&i.IdempotencyScope,
+			&i.IdempotencyHash,
 		); err != nil {
 			return nil, err
 		}
@@ -324,7 +724,7 @@ func (q *Queries) FindStaleJobs(ctx context.Context) ([]Job, error) {
 }
 
 const getJob = `-- name: GetJob :one
-SELECT id, type, binary_url, binary_sha256, arguments, env_variables, priority, status, executor_id, stdout, stderr, exit_code, error_message, created_at, started_at, completed_at, last_heartbeat, max_retries, retry_count, retry_after FROM jobs
+SELECT id, type, binary_url, binary_sha256, arguments, env_variables, priority, status, executor_id, stdout, stderr, exit_code, error_message, created_at, started_at, completed_at, last_heartbeat, max_retries, retry_count, timeout_seconds, stdout_buffer, stderr_buffer, stdout_url, stderr_url, max_output_bytes, signature_url, public_key, required_capabilities, retry_backoff_base, next_retry_at, scheduled_at, labels, idempotency_key, stdin, input_files, output_globs, workdir_quota_bytes, cpu_millicores, mem_limit_bytes, trace_parent, cancellation_reason, result_json, idempotency_scope, idempotency_hash FROM jobs
 WHERE id = $1
 `
 
@@ -351,33 +751,183 @@ func (q *Queries) GetJob(ctx context.Context, id uuid.UUID) (Job, error) {
 		&i.LastHeartbeat,
 		&i.MaxRetries,
 		&i.RetryCount,
-		&i.RetryAfter,
+		&i.TimeoutSeconds,
+		&i.StdoutBuffer,
+		&i.StderrBuffer,
+		&i.StdoutUrl,
+		&i.StderrUrl,
+		&i.MaxOutputBytes,
+		&i.SignatureUrl,
+		&i.PublicKey,
+		&i.RequiredCapabilities,
+		&i.RetryBackoffBase,
+		&i.NextRetryAt,
+		&i.ScheduledAt,
+		&i.Labels,
+		&i.IdempotencyKey,
+		&i.Stdin,
+		&i.InputFiles,
+		&i.OutputGlobs,
+		&i.WorkdirQuotaBytes,
+		&i.CpuMillicores,
+		&i.MemLimitBytes,
+		&i.TraceParent,
+		&i.CancellationReason,
+		&i.ResultJson,
+		&i.IdempotencyScope,
+		&i.IdempotencyHash,
+	)
+	return i, err
+}
+
+const getJobByIdempotencyKey = `-- name: GetJobByIdempotencyKey :one
+SELECT id, type, binary_url, binary_sha256, arguments, env_variables, priority, status, executor_id, stdout, stderr, exit_code, error_message, created_at, started_at, completed_at, last_heartbeat, max_retries, retry_count, timeout_seconds, stdout_buffer, stderr_buffer, stdout_url, stderr_url, max_output_bytes, signature_url, public_key, required_capabilities, retry_backoff_base, next_retry_at, scheduled_at, labels, idempotency_key, stdin, input_files, output_globs, workdir_quota_bytes, cpu_millicores, mem_limit_bytes, trace_parent, cancellation_reason, result_json, idempotency_scope, idempotency_hash FROM jobs
+WHERE idempotency_scope = $1 AND idempotency_key = $2
+`
+
+type GetJobByIdempotencyKeyParams struct {
+	IdempotencyScope string      `json:"idempotency_scope"`
+	IdempotencyKey   pgtype.Text `json:"idempotency_key"`
+}
+
+func (q *Queries) GetJobByIdempotencyKey(ctx context.Context, arg GetJobByIdempotencyKeyParams) (Job, error) {
+	row := q.db.QueryRow(ctx, getJobByIdempotencyKey, arg.IdempotencyScope, arg.IdempotencyKey)
+	var i Job
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.BinaryUrl,
+		&i.BinarySha256,
+		&i.Arguments,
+		&i.EnvVariables,
+		&i.Priority,
+		&i.Status,
+		&i.ExecutorID,
+		&i.Stdout,
+		&i.Stderr,
+		&i.ExitCode,
+		&i.ErrorMessage,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.LastHeartbeat,
+		&i.MaxRetries,
+		&i.RetryCount,
+		&i.TimeoutSeconds,
+		&i.StdoutBuffer,
+		&i.StderrBuffer,
+		&i.StdoutUrl,
+		&i.StderrUrl,
+		&i.MaxOutputBytes,
+		&i.SignatureUrl,
+		&i.PublicKey,
+		&i.RequiredCapabilities,
+		&i.RetryBackoffBase,
+		&i.NextRetryAt,
+		&i.ScheduledAt,
+		&i.Labels,
+		&i.IdempotencyKey,
+		&i.Stdin,
+		&i.InputFiles,
+		&i.OutputGlobs,
+		&i.WorkdirQuotaBytes,
+		&i.CpuMillicores,
+		&i.MemLimitBytes,
+		&i.TraceParent,
+		&i.CancellationReason,
+		&i.ResultJson,
+		&i.IdempotencyScope,
+		&i.IdempotencyHash,
 	)
 	return i, err
 }
 
+const getJobOutput = `-- name: GetJobOutput :one
+SELECT stdout, stderr, stdout_url, stderr_url, exit_code FROM jobs
+WHERE id = $1
+`
+
+type GetJobOutputRow struct {
+	Stdout    pgtype.Text `json:"stdout"`
+	Stderr    pgtype.Text `json:"stderr"`
+	StdoutUrl pgtype.Text `json:"stdout_url"`
+	StderrUrl pgtype.Text `json:"stderr_url"`
+	ExitCode  pgtype.Int4 `json:"exit_code"`
+}
+
+func (q *Queries) GetJobOutput(ctx context.Context, id uuid.UUID) (GetJobOutputRow, error) {
+	row := q.db.QueryRow(ctx, getJobOutput, id)
+	var i GetJobOutputRow
+	err := row.Scan(
+		&i.Stdout,
+		&i.Stderr,
+		&i.StdoutUrl,
+		&i.StderrUrl,
+		&i.ExitCode,
+	)
+	return i, err
+}
+
+const getJobStatus = `-- name: GetJobStatus :one
+SELECT status FROM jobs
+WHERE id = $1
+`
+
+func (q *Queries) GetJobStatus(ctx context.Context, id uuid.UUID) (string, error) {
+	row := q.db.QueryRow(ctx, getJobStatus, id)
+	var status string
+	err := row.Scan(&status)
+	return status, err
+}
+
 const listJobs = `-- name: ListJobs :many
-SELECT id, type, binary_url, binary_sha256, arguments, env_variables, priority, status, executor_id, stdout, stderr, exit_code, error_message, created_at, started_at, completed_at, last_heartbeat, max_retries, retry_count, retry_after FROM jobs
+SELECT id, type, binary_url, binary_sha256, arguments, env_variables, priority, status, executor_id, stdout, stderr, exit_code, error_message, created_at, started_at, completed_at, last_heartbeat, max_retries, retry_count, timeout_seconds, stdout_buffer, stderr_buffer, stdout_url, stderr_url, max_output_bytes, signature_url, public_key, required_capabilities, retry_backoff_base, next_retry_at, scheduled_at, labels, idempotency_key, stdin, input_files, output_globs, workdir_quota_bytes, cpu_millicores, mem_limit_bytes, trace_parent, cancellation_reason, result_json, idempotency_scope, idempotency_hash FROM jobs
 WHERE ($1::text IS NULL OR status = $1)
   AND ($2::text IS NULL OR type = $2)
   AND ($3::text IS NULL OR priority = $3)
-ORDER BY created_at DESC
-LIMIT $4 OFFSET $5
+  AND ($4::jsonb IS NULL OR labels @> $4)
+  AND ($5::text IS NULL OR executor_id = $5)
+ORDER BY
+    CASE WHEN $6::text = 'created_at' AND $7::text = 'asc' THEN created_at END ASC,
+    CASE WHEN $6::text = 'created_at' AND $7::text = 'desc' THEN created_at END DESC,
+    CASE WHEN $6::text = 'completed_at' AND $7::text = 'asc' THEN completed_at END ASC,
+    CASE WHEN $6::text = 'completed_at' AND $7::text = 'desc' THEN completed_at END DESC,
+    CASE WHEN $6::text = 'priority' AND $7::text = 'asc' THEN
+        CASE priority WHEN 'foreground' THEN 1 WHEN 'background' THEN 2 WHEN 'best_effort' THEN 3 END
+    END ASC,
+    CASE WHEN $6::text = 'priority' AND $7::text = 'desc' THEN
+        CASE priority WHEN 'foreground' THEN 1 WHEN 'background' THEN 2 WHEN 'best_effort' THEN 3 END
+    END DESC,
+    CASE WHEN $6::text = 'type' AND $7::text = 'asc' THEN type END ASC,
+    CASE WHEN $6::text = 'type' AND $7::text = 'desc' THEN type END DESC,
+    CASE WHEN $6::text = 'status' AND $7::text = 'asc' THEN status END ASC,
+    CASE WHEN $6::text = 'status' AND $7::text = 'desc' THEN status END DESC,
+    created_at DESC,
+    id
+LIMIT $8 OFFSET $9
 `
 
 type ListJobsParams struct {
-	Column1 string `json:"column_1"`
-	Column2 string `json:"column_2"`
-	Column3 string `json:"column_3"`
-	Limit   int32  `json:"limit"`
-	Offset  int32  `json:"offset"`
+	Status     pgtype.Text `json:"status"`
+	Type       pgtype.Text `json:"type"`
+	Priority   pgtype.Text `json:"priority"`
+	Labels     []byte      `json:"labels"`
+	ExecutorID pgtype.Text `json:"executor_id"`
+	SortBy     string      `json:"sort_by"`
+	SortOrder  string      `json:"sort_order"`
+	Limit      int32       `json:"limit"`
+	Offset     int32       `json:"offset"`
 }
 
 func (q *Queries) ListJobs(ctx context.Context, arg ListJobsParams) ([]Job, error) {
 	rows, err := q.db.Query(ctx, listJobs,
-		arg.Column1,
-		arg.Column2,
-		arg.Column3,
+		arg.Status,
+		arg.Type,
+		arg.Priority,
+		arg.Labels,
+		arg.ExecutorID,
+		arg.SortBy,
+		arg.SortOrder,
 		arg.Limit,
 		arg.Offset,
 	)
@@ -408,7 +958,31 @@ func (q *Queries) ListJobs(ctx context.Context, arg ListJobsParams) ([]Job, erro
 			&i.LastHeartbeat,
 			&i.MaxRetries,
 			&i.RetryCount,
-			&i.RetryAfter,
+			&i.TimeoutSeconds,
+			&i.StdoutBuffer,
+			&i.StderrBuffer,
+			&i.StdoutUrl,
+			&i.StderrUrl,
+			&i.MaxOutputBytes,
+			&i.SignatureUrl,
+			&i.PublicKey,
+			&i.RequiredCapabilities,
+			&i.RetryBackoffBase,
+			&i.NextRetryAt,
+			&i.ScheduledAt,
+			&i.Labels,
+			&i.IdempotencyKey,
+			&i.Stdin,
+			&i.InputFiles,
+			&i.OutputGlobs,
+			&i.WorkdirQuotaBytes,
+			&i.CpuMillicores,
+			&i.MemLimitBytes,
+			&i.TraceParent,
+			&i.CancellationReason,
+			&i.ResultJson,
+			&i.IdempotencyScope,
+			&i.IdempotencyHash,
 		); err != nil {
 			return nil, err
 		}
@@ -420,21 +994,134 @@ func (q *Queries) ListJobs(ctx context.Context, arg ListJobsParams) ([]Job, erro
 	return items, nil
 }
 
-const resetStaleJob = `-- name: ResetStaleJob :exec
+const recordCancelledJobResult = `-- name: RecordCancelledJobResult :execrows
+UPDATE jobs
+SET stdout = $2,
+    stderr = $3,
+    exit_code = $4,
+    stdout_url = $5,
+    stderr_url = $6
+WHERE id = $1 AND executor_id = $7 AND status = 'cancelled'
+`
+
+type RecordCancelledJobResultParams struct {
+	ID         uuid.UUID   `json:"id"`
+	Stdout     pgtype.Text `json:"stdout"`
+	Stderr     pgtype.Text `json:"stderr"`
+	ExitCode   pgtype.Int4 `json:"exit_code"`
+	StdoutUrl  pgtype.Text `json:"stdout_url"`
+	StderrUrl  pgtype.Text `json:"stderr_url"`
+	ExecutorID pgtype.Text `json:"executor_id"`
+}
+
+// Keeps the output a job produced until its executor stopped it after it
+// was cancelled while running
+func (q *Queries) RecordCancelledJobResult(ctx context.Context, arg RecordCancelledJobResultParams) (int64, error) {
+	result, err := q.db.Exec(ctx, recordCancelledJobResult,
+		arg.ID,
+		arg.Stdout,
+		arg.Stderr,
+		arg.ExitCode,
+		arg.StdoutUrl,
+		arg.StderrUrl,
+		arg.ExecutorID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const requeueJob = `-- name: RequeueJob :one
+INSERT INTO jobs (
+    type, binary_url, binary_sha256, arguments, env_variables,
+    priority, max_retries, timeout_seconds, max_output_bytes,
+    signature_url, public_key, required_capabilities, retry_backoff_base, labels, stdin, input_files, output_globs, workdir_quota_bytes,
+    cpu_millicores, mem_limit_bytes
+)
+SELECT type, binary_url, binary_sha256, arguments, env_variables,
+       priority, max_retries, timeout_seconds, max_output_bytes,
+       signature_url, public_key, required_capabilities, retry_backoff_base, labels, stdin, input_files, output_globs, workdir_quota_bytes,
+       cpu_millicores, mem_limit_bytes
+FROM jobs
+WHERE jobs.id = $1 AND jobs.status IN ('completed', 'failed', 'cancelled', 'dead_letter', 'skipped')
+RETURNING id, type, binary_url, binary_sha256, arguments, env_variables, priority, status, executor_id, stdout, stderr, exit_code, error_message, created_at, started_at, completed_at, last_heartbeat, max_retries, retry_count, timeout_seconds, stdout_buffer, stderr_buffer, stdout_url, stderr_url, max_output_bytes, signature_url, public_key, required_capabilities, retry_backoff_base, next_retry_at, scheduled_at, labels, idempotency_key, stdin, input_files, output_globs, workdir_quota_bytes, cpu_millicores, mem_limit_bytes, trace_parent, cancellation_reason, result_json, idempotency_scope, idempotency_hash;
+`
+
+func (q *Queries) RequeueJob(ctx context.Context, id uuid.UUID) (Job, error) {
+	row := q.db.QueryRow(ctx, requeueJob, id)
+	var i Job
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.BinaryUrl,
+		&i.BinarySha256,
+		&i.Arguments,
+		&i.EnvVariables,
+		&i.Priority,
+		&i.Status,
+		&i.ExecutorID,
+		&i.Stdout,
+		&i.Stderr,
+		&i.ExitCode,
+		&i.ErrorMessage,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.LastHeartbeat,
+		&i.MaxRetries,
+		&i.RetryCount,
+		&i.TimeoutSeconds,
+		&i.StdoutBuffer,
+		&i.StderrBuffer,
+		&i.StdoutUrl,
+		&i.StderrUrl,
+		&i.MaxOutputBytes,
+		&i.SignatureUrl,
+		&i.PublicKey,
+		&i.RequiredCapabilities,
+		&i.RetryBackoffBase,
+		&i.NextRetryAt,
+		&i.ScheduledAt,
+		&i.Labels,
+		&i.IdempotencyKey,
+		&i.Stdin,
+		&i.InputFiles,
+		&i.OutputGlobs,
+		&i.WorkdirQuotaBytes,
+		&i.CpuMillicores,
+		&i.MemLimitBytes,
+		&i.TraceParent,
+		&i.CancellationReason,
+		&i.ResultJson,
+		&i.IdempotencyScope,
+		&i.IdempotencyHash,
+	)
+	return i, err
+}
+
+const resetStaleJob = `-- name: ResetStaleJob :execrows
 UPDATE jobs
 SET status = 'pending',
     executor_id = NULL,
     started_at = NULL,
-    last_heartbeat = NULL
+    last_heartbeat = NULL,
+    stdout_buffer = NULL,
+    stderr_buffer = NULL,
+    stdout_url = NULL,
+    stderr_url = NULL
 WHERE id = $1 AND status = 'running'
 `
 
-func (q *Queries) ResetStaleJob(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.Exec(ctx, resetStaleJob, id)
-	return err
+func (q *Queries) ResetStaleJob(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, resetStaleJob, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
-const updateHeartbeat = `-- name: UpdateHeartbeat :exec
+const updateHeartbeat = `-- name: UpdateHeartbeat :execrows
 UPDATE jobs
 SET last_heartbeat = NOW()
 WHERE id = $1 AND executor_id = $2 AND status = 'running'
@@ -445,18 +1132,85 @@ type UpdateHeartbeatParams struct {
 	ExecutorID pgtype.Text `json:"executor_id"`
 }
 
-func (q *Queries) UpdateHeartbeat(ctx context.Context, arg UpdateHeartbeatParams) error {
-	_, err := q.db.Exec(ctx, updateHeartbeat, arg.ID, arg.ExecutorID)
-	return err
+func (q *Queries) UpdateHeartbeat(ctx context.Context, arg UpdateHeartbeatParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateHeartbeat, arg.ID, arg.ExecutorID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateJobPriority = `-- name: UpdateJobPriority :one
+UPDATE jobs
+SET priority = $2
+WHERE id = $1 AND status = 'pending'
+RETURNING id, type, binary_url, binary_sha256, arguments, env_variables, priority, status, executor_id, stdout, stderr, exit_code, error_message, created_at, started_at, completed_at, last_heartbeat, max_retries, retry_count, timeout_seconds, stdout_buffer, stderr_buffer, stdout_url, stderr_url, max_output_bytes, signature_url, public_key, required_capabilities, retry_backoff_base, next_retry_at, scheduled_at, labels, idempotency_key, stdin, input_files, output_globs, workdir_quota_bytes, cpu_millicores, mem_limit_bytes, trace_parent, cancellation_reason, result_json, idempotency_scope, idempotency_hash
+`
+
+type UpdateJobPriorityParams struct {
+	ID       uuid.UUID `json:"id"`
+	Priority string    `json:"priority"`
+}
+
+func (q *Queries) UpdateJobPriority(ctx context.Context, arg UpdateJobPriorityParams) (Job, error) {
+	row := q.db.QueryRow(ctx, updateJobPriority, arg.ID, arg.Priority)
+	var i Job
+	err := row.Scan(
+		&i.ID,
+		&i.Type,
+		&i.BinaryUrl,
+		&i.BinarySha256,
+		&i.Arguments,
+		&i.EnvVariables,
+		&i.Priority,
+		&i.Status,
+		&i.ExecutorID,
+		&i.Stdout,
+		&i.Stderr,
+		&i.ExitCode,
+		&i.ErrorMessage,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.CompletedAt,
+		&i.LastHeartbeat,
+		&i.MaxRetries,
+		&i.RetryCount,
+		&i.TimeoutSeconds,
+		&i.StdoutBuffer,
+		&i.StderrBuffer,
+		&i.StdoutUrl,
+		&i.StderrUrl,
+		&i.MaxOutputBytes,
+		&i.SignatureUrl,
+		&i.PublicKey,
+		&i.RequiredCapabilities,
+		&i.RetryBackoffBase,
+		&i.NextRetryAt,
+		&i.ScheduledAt,
+		&i.Labels,
+		&i.IdempotencyKey,
+		&i.Stdin,
+		&i.InputFiles,
+		&i.OutputGlobs,
+		&i.WorkdirQuotaBytes,
+		&i.CpuMillicores,
+		&i.MemLimitBytes,
+		&i.TraceParent,
+		&i.CancellationReason,
+		&i.ResultJson,
+		&i.IdempotencyScope,
+		&i.IdempotencyHash,
+	)
+	return i, err
 }
 
 const updateJobStatus = `-- name: UpdateJobStatus :one
 UPDATE jobs
 SET status = $2,
     started_at = CASE WHEN $2 = 'running' THEN COALESCE(started_at, NOW()) ELSE started_at END,
-    completed_at = CASE WHEN $2 IN ('completed', 'failed', 'cancelled') THEN NOW() ELSE completed_at END
+    completed_at = CASE WHEN $2 IN ('completed', 'failed', 'cancelled', 'dead_letter', 'skipped') THEN NOW() ELSE completed_at END
 WHERE id = $1
-RETURNING id, type, binary_url, binary_sha256, arguments, env_variables, priority, status, executor_id, stdout, stderr, exit_code, error_message, created_at, started_at, completed_at, last_heartbeat, max_retries, retry_count, retry_after
+RETURNING id, type, binary_url, binary_sha256, arguments, env_variables, priority, status, executor_id, stdout, stderr, exit_code, error_message, created_at, started_at, completed_at, last_heartbeat, max_retries, retry_count, timeout_seconds, stdout_buffer, stderr_buffer, stdout_url, stderr_url, max_output_bytes, signature_url, public_key, required_capabilities, retry_backoff_base, next_retry_at, scheduled_at, labels, idempotency_key, stdin, input_files, output_globs, workdir_quota_bytes, cpu_millicores, mem_limit_bytes, trace_parent, cancellation_reason, result_json, idempotency_scope, idempotency_hash
 `
 
 type UpdateJobStatusParams struct {
@@ -487,7 +1241,31 @@ func (q *Queries) UpdateJobStatus(ctx context.Context, arg UpdateJobStatusParams
 		&i.LastHeartbeat,
 		&i.MaxRetries,
 		&i.RetryCount,
-		&i.RetryAfter,
+		&i.TimeoutSeconds,
+		&i.StdoutBuffer,
+		&i.StderrBuffer,
+		&i.StdoutUrl,
+		&i.StderrUrl,
+		&i.MaxOutputBytes,
+		&i.SignatureUrl,
+		&i.PublicKey,
+		&i.RequiredCapabilities,
+		&i.RetryBackoffBase,
+		&i.NextRetryAt,
+		&i.ScheduledAt,
+		&i.Labels,
+		&i.IdempotencyKey,
+		&i.Stdin,
+		&i.InputFiles,
+		&i.OutputGlobs,
+		&i.WorkdirQuotaBytes,
+		&i.CpuMillicores,
+		&i.MemLimitBytes,
+		&i.TraceParent,
+		&i.CancellationReason,
+		&i.ResultJson,
+		&i.IdempotencyScope,
+		&i.IdempotencyHash,
 	)
 	return i, err
 }
@@ -9,27 +9,73 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-type Job struct {
-	ID            uuid.UUID          `json:"id"`
-	Type          string             `json:"type"`
-	BinaryUrl     string             `json:"binary_url"`
-	BinarySha256  string             `json:"binary_sha256"`
-	Arguments     []string           `json:"arguments"`
-	EnvVariables  []byte             `json:"env_variables"`
-	Priority      string             `json:"priority"`
-	Status        string             `json:"status"`
-	ExecutorID    pgtype.Text        `json:"executor_id"`
-	Stdout        pgtype.Text        `json:"stdout"`
-	Stderr        pgtype.Text        `json:"stderr"`
-	ExitCode      pgtype.Int4        `json:"exit_code"`
-	ErrorMessage  pgtype.Text        `json:"error_message"`
-	CreatedAt     pgtype.Timestamptz `json:"created_at"`
-	StartedAt     pgtype.Timestamptz `json:"started_at"`
-	CompletedAt   pgtype.Timestamptz `json:"completed_at"`
+type Executor struct {
+	ID            string             `json:"id"`
+	CpuPercent    pgtype.Float8      `json:"cpu_percent"`
+	MemBytes      pgtype.Int8        `json:"mem_bytes"`
 	LastHeartbeat pgtype.Timestamptz `json:"last_heartbeat"`
-	MaxRetries    int32              `json:"max_retries"`
-	RetryCount    int32              `json:"retry_count"`
-	RetryAfter    pgtype.Timestamp   `json:"retry_after"`
+	Name          string             `json:"name"`
+	Capabilities  []string           `json:"capabilities"`
+	MaxJobs       int32              `json:"max_jobs"`
+	RegisteredAt  pgtype.Timestamptz `json:"registered_at"`
+	AdvertiseAddr string             `json:"advertise_addr"`
+}
+
+type Job struct {
+	ID                   uuid.UUID          `json:"id"`
+	Type                 string             `json:"type"`
+	BinaryUrl            string             `json:"binary_url"`
+	BinarySha256         string             `json:"binary_sha256"`
+	Arguments            []string           `json:"arguments"`
+	EnvVariables         []byte             `json:"env_variables"`
+	Priority             string             `json:"priority"`
+	Status               string             `json:"status"`
+	ExecutorID           pgtype.Text        `json:"executor_id"`
+	Stdout               pgtype.Text        `json:"stdout"`
+	Stderr               pgtype.Text        `json:"stderr"`
+	ExitCode             pgtype.Int4        `json:"exit_code"`
+	ErrorMessage         pgtype.Text        `json:"error_message"`
+	CreatedAt            pgtype.Timestamptz `json:"created_at"`
+	StartedAt            pgtype.Timestamptz `json:"started_at"`
+	CompletedAt          pgtype.Timestamptz `json:"completed_at"`
+	LastHeartbeat        pgtype.Timestamptz `json:"last_heartbeat"`
+	MaxRetries           int32              `json:"max_retries"`
+	RetryCount           int32              `json:"retry_count"`
+	TimeoutSeconds       int32              `json:"timeout_seconds"`
+	StdoutBuffer         pgtype.Text        `json:"stdout_buffer"`
+	StderrBuffer         pgtype.Text        `json:"stderr_buffer"`
+	StdoutUrl            pgtype.Text        `json:"stdout_url"`
+	StderrUrl            pgtype.Text        `json:"stderr_url"`
+	MaxOutputBytes       int32              `json:"max_output_bytes"`
+	SignatureUrl         pgtype.Text        `json:"signature_url"`
+	PublicKey            pgtype.Text        `json:"public_key"`
+	RequiredCapabilities []string           `json:"required_capabilities"`
+	RetryBackoffBase     int32              `json:"retry_backoff_base"`
+	NextRetryAt          pgtype.Timestamptz `json:"next_retry_at"`
+	ScheduledAt          pgtype.Timestamptz `json:"scheduled_at"`
+	Labels               []byte             `json:"labels"`
+	IdempotencyKey       pgtype.Text        `json:"idempotency_key"`
+	Stdin                []byte             `json:"stdin"`
+	InputFiles           []byte             `json:"input_files"`
+	OutputGlobs          []string           `json:"output_globs"`
+	WorkdirQuotaBytes    int64              `json:"workdir_quota_bytes"`
+	CpuMillicores        int32              `json:"cpu_millicores"`
+	MemLimitBytes        int64              `json:"mem_limit_bytes"`
+	TraceParent          pgtype.Text        `json:"trace_parent"`
+	CancellationReason   pgtype.Text        `json:"cancellation_reason"`
+	ResultJson           []byte             `json:"result_json"`
+	IdempotencyScope     string             `json:"idempotency_scope"`
+	IdempotencyHash      pgtype.Text        `json:"idempotency_hash"`
+}
+
+type JobArtifact struct {
+	JobID     uuid.UUID          `json:"job_id"`
+	Name      string             `json:"name"`
+	Size      int64              `json:"size"`
+	Sha256    string             `json:"sha256"`
+	Url       pgtype.Text        `json:"url"`
+	Content   []byte             `json:"content"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
 }
 
 type JobAttempt struct {
@@ -42,3 +88,49 @@ type JobAttempt struct {
 	Status       string             `json:"status"`
 	ErrorMessage pgtype.Text        `json:"error_message"`
 }
+
+type JobDependency struct {
+	JobID     uuid.UUID `json:"job_id"`
+	DependsOn uuid.UUID `json:"depends_on"`
+}
+
+type JobSchedule struct {
+	ID        uuid.UUID          `json:"id"`
+	CronSpec  string             `json:"cron_spec"`
+	Template  []byte             `json:"template"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	LastRunAt pgtype.Timestamptz `json:"last_run_at"`
+	NextRunAt pgtype.Timestamptz `json:"next_run_at"`
+	Disabled  bool               `json:"disabled"`
+	LastError pgtype.Text        `json:"last_error"`
+}
+
+type JobTypeClaim struct {
+	JobType       string             `json:"job_type"`
+	LastClaimedAt pgtype.Timestamptz `json:"last_claimed_at"`
+}
+
+type JobTypeDuration struct {
+	JobType    string             `json:"job_type"`
+	AvgSeconds float64            `json:"avg_seconds"`
+	Samples    int64              `json:"samples"`
+	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
+}
+
+type JobTypeLimit struct {
+	JobType    string             `json:"job_type"`
+	MaxRunning int32              `json:"max_running"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
+}
+
+type JobsArchive struct {
+	ID          uuid.UUID          `json:"id"`
+	Type        string             `json:"type"`
+	Status      string             `json:"status"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	CompletedAt pgtype.Timestamptz `json:"completed_at"`
+	ArchivedAt  pgtype.Timestamptz `json:"archived_at"`
+	Job         []byte             `json:"job"`
+	Attempts    []byte             `json:"attempts"`
+}
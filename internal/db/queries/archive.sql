@@ -0,0 +1,31 @@
+-- name: ArchiveJob :exec
+INSERT INTO jobs_archive (
+    id, type, status, created_at, completed_at, job, attempts
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+);
+
+-- name: DeleteJobs :exec
+DELETE FROM jobs
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: GetExpiredJobs :many
+-- Locks a batch of the jobs CleanupOldJobs deletes, so they can be archived
+-- and deleted in one transaction. Keep both conditions in sync.
+SELECT * FROM jobs
+WHERE status IN ('completed', 'failed', 'cancelled', 'dead_letter', 'skipped')
+  AND completed_at < NOW() - sqlc.arg(retention)::interval
+  AND NOT (status = 'failed' AND retry_count < max_retries)
+  AND NOT EXISTS (
+      SELECT 1 FROM job_dependencies d
+      JOIN jobs c ON c.id = d.job_id
+      WHERE d.depends_on = jobs.id AND c.status IN ('pending', 'running')
+  )
+ORDER BY completed_at
+LIMIT sqlc.arg(batch_size)
+FOR UPDATE SKIP LOCKED;
+
+-- name: GetJobAttemptsForJobs :many
+SELECT * FROM job_attempts
+WHERE job_id = ANY(sqlc.arg(job_ids)::uuid[])
+ORDER BY job_id, started_at;
\ No newline at end of file
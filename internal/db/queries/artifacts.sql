@@ -0,0 +1,21 @@
+-- name: GetJobArtifact :one
+SELECT name, size, sha256, url, content FROM job_artifacts
+WHERE job_id = $1 AND name = $2;
+
+-- name: ListJobArtifacts :many
+SELECT name, size, sha256, url, created_at FROM job_artifacts
+WHERE job_id = $1
+ORDER BY name;
+
+-- name: RecordJobArtifact :exec
+INSERT INTO job_artifacts (job_id, name, size, sha256, url)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (job_id, name) DO UPDATE
+SET size = EXCLUDED.size, sha256 = EXCLUDED.sha256, url = EXCLUDED.url, content = NULL, created_at = NOW();
+
+-- name: UploadJobArtifact :execrows
+INSERT INTO job_artifacts (job_id, name, size, sha256, content)
+SELECT id, sqlc.arg(name), sqlc.arg(size), sqlc.arg(sha256), sqlc.arg(content)::bytea FROM jobs
+WHERE jobs.id = sqlc.arg(job_id) AND jobs.status = 'running' AND jobs.executor_id = sqlc.arg(executor_id)
+ON CONFLICT (job_id, name) DO UPDATE
+SET size = EXCLUDED.size, sha256 = EXCLUDED.sha256, url = NULL, content = EXCLUDED.content, created_at = NOW();
\ No newline at end of file
@@ -0,0 +1,32 @@
+-- name: AddJobDependencies :exec
+INSERT INTO job_dependencies (job_id, depends_on)
+SELECT sqlc.arg(job_id)::uuid, unnest(sqlc.arg(depends_on)::uuid[]);
+
+-- name: CountJobsByIDs :one
+SELECT COUNT(*) FROM jobs
+WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: GetJobDependencies :many
+SELECT depends_on FROM job_dependencies
+WHERE job_id = $1
+ORDER BY depends_on;
+
+-- name: HasUnmetDependencies :one
+SELECT EXISTS (
+    SELECT 1 FROM job_dependencies d
+    JOIN jobs p ON p.id = d.depends_on
+    WHERE d.job_id = $1 AND p.status <> 'completed'
+) AS unmet;
+
+-- name: SkipJobsWithUnmetDependencies :many
+UPDATE jobs
+SET status = 'skipped',
+    completed_at = NOW(),
+    error_message = 'dependency ' || p.id || ' ended with status ' || p.status
+FROM job_dependencies d
+JOIN jobs p ON p.id = d.depends_on
+WHERE d.job_id = jobs.id
+  AND jobs.status = 'pending'
+  AND (p.status IN ('cancelled', 'dead_letter', 'skipped')
+       OR (p.status = 'failed' AND p.retry_count >= p.max_retries))
+RETURNING jobs.id;
\ No newline at end of file
@@ -0,0 +1,31 @@
+-- name: CountPendingJobsAhead :one
+-- Counts the due pending jobs that are claimed before the given one when
+-- priorities are taken in the given order
+SELECT COUNT(*) AS ahead
+FROM jobs j, jobs target
+WHERE target.id = sqlc.arg(id)
+  AND j.status = 'pending'
+  AND (j.scheduled_at IS NULL OR j.scheduled_at <= NOW())
+  AND (array_position(sqlc.arg(priorities)::text[], j.priority), j.created_at)
+    < (array_position(sqlc.arg(priorities)::text[], target.priority), target.created_at);
+
+-- name: GetJobTypeDuration :one
+SELECT avg_seconds FROM job_type_durations
+WHERE job_type = $1;
+
+-- name: RecordJobTypeDuration :exec
+-- Plain average of the first 20 samples, then an exponential moving average
+-- that follows changes in how long the type runs
+INSERT INTO job_type_durations (job_type, avg_seconds, samples)
+VALUES (sqlc.arg(job_type), sqlc.arg(seconds)::float8, 1)
+ON CONFLICT (job_type) DO UPDATE
+SET avg_seconds = job_type_durations.avg_seconds
+        + (EXCLUDED.avg_seconds - job_type_durations.avg_seconds) / LEAST(job_type_durations.samples + 1, 20),
+    samples = job_type_durations.samples + 1,
+    updated_at = NOW();
+
+-- name: SumActiveExecutorSlots :one
+-- Executors that didn't register how many jobs they run count as one slot
+SELECT COALESCE(SUM(GREATEST(max_jobs, 1)), 0)::bigint AS slots
+FROM executors
+WHERE last_heartbeat > NOW() - sqlc.arg(timeout)::interval;
\ No newline at end of file
@@ -0,0 +1,35 @@
+-- name: CleanupStaleExecutors :many
+DELETE FROM executors
+WHERE last_heartbeat < NOW() - $1::interval
+RETURNING id;
+
+-- name: DeregisterExecutor :execrows
+DELETE FROM executors
+WHERE id = $1;
+
+-- name: ExecutorHeartbeat :execrows
+UPDATE executors
+SET last_heartbeat = NOW(),
+    cpu_percent = COALESCE(sqlc.narg('cpu_percent'), cpu_percent),
+    mem_bytes = COALESCE(sqlc.narg('mem_bytes'), mem_bytes)
+WHERE id = sqlc.arg('id');
+
+-- name: RecordExecutorUsage :exec
+INSERT INTO executors (id, cpu_percent, mem_bytes, last_heartbeat)
+VALUES ($1, $2, $3, NOW())
+ON CONFLICT (id) DO UPDATE
+SET cpu_percent = EXCLUDED.cpu_percent,
+    mem_bytes = EXCLUDED.mem_bytes,
+    last_heartbeat = EXCLUDED.last_heartbeat;
+
+-- name: RegisterExecutor :one
+INSERT INTO executors (id, name, capabilities, max_jobs, advertise_addr, registered_at, last_heartbeat)
+VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+ON CONFLICT (id) DO UPDATE
+SET name = EXCLUDED.name,
+    capabilities = EXCLUDED.capabilities,
+    max_jobs = EXCLUDED.max_jobs,
+    advertise_addr = EXCLUDED.advertise_addr,
+    registered_at = EXCLUDED.registered_at,
+    last_heartbeat = EXCLUDED.last_heartbeat
+RETURNING *;
\ No newline at end of file
@@ -6,6 +6,30 @@ INSERT INTO job_attempts (
 )
 RETURNING *;
 
+-- name: RecordJobRetry :exec
+INSERT INTO job_attempts (
+    job_id, executor_id, executor_ip, status, ended_at, error_message
+) VALUES (
+    $1, $2, '', 'retried', NOW(), $3
+);
+
+-- name: CompleteJobAttempt :exec
+UPDATE job_attempts
+SET ended_at = NOW(),
+    status = 'completed'
+WHERE job_id = $1
+  AND executor_id = $2
+  AND ended_at IS NULL;
+
+-- name: FailJobAttempt :exec
+UPDATE job_attempts
+SET ended_at = NOW(),
+    status = 'failed',
+    error_message = $3
+WHERE job_id = $1
+  AND executor_id = $2
+  AND ended_at IS NULL;
+
 -- name: UpdateJobAttempt :exec
 UPDATE job_attempts
 SET ended_at = NOW(),
@@ -0,0 +1,24 @@
+-- name: DeleteJobTypeLimit :execrows
+DELETE FROM job_type_limits
+WHERE job_type = $1;
+
+-- name: ListJobTypeLimits :many
+SELECT l.job_type, l.max_running, l.created_at, l.updated_at,
+       (SELECT COUNT(*) FROM jobs j WHERE j.type = l.job_type AND j.status = 'running')::bigint AS running
+FROM job_type_limits l
+ORDER BY l.job_type;
+
+-- name: LockJobTypeLimits :exec
+-- Makes claims wait for each other while limits exist, so that each one
+-- counts the jobs the others started
+SELECT job_type FROM job_type_limits
+ORDER BY job_type
+FOR UPDATE;
+
+-- name: SetJobTypeLimit :one
+INSERT INTO job_type_limits (job_type, max_running)
+VALUES ($1, $2)
+ON CONFLICT (job_type) DO UPDATE
+SET max_running = EXCLUDED.max_running,
+    updated_at = NOW()
+RETURNING *;
\ No newline at end of file
@@ -1,8 +1,11 @@
 -- name: CreateJob :one
 INSERT INTO jobs (
-    type, binary_url, binary_sha256, arguments, env_variables, priority
+    type, binary_url, binary_sha256, arguments, env_variables, priority,
+    timeout_seconds, max_output_bytes, signature_url, public_key,
+    required_capabilities, max_retries, retry_backoff_base, scheduled_at, labels, idempotency_key, stdin, input_files, output_globs, workdir_quota_bytes,
+    cpu_millicores, mem_limit_bytes, trace_parent, idempotency_scope, idempotency_hash
 ) VALUES (
-    $1, $2, $3, $4, $5, $6
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25
 )
 RETURNING *;
 
@@ -10,54 +13,161 @@ RETURNING *;
 SELECT * FROM jobs
 WHERE id = $1;
 
+-- name: GetJobByIdempotencyKey :one
+SELECT * FROM jobs
+WHERE idempotency_scope = $1 AND idempotency_key = $2;
+
+-- name: GetJobStatus :one
+SELECT status FROM jobs
+WHERE id = $1;
+
+-- name: GetJobOutput :one
+SELECT stdout, stderr, stdout_url, stderr_url, exit_code FROM jobs
+WHERE id = $1;
+
 -- name: ListJobs :many
 SELECT * FROM jobs
-WHERE ($1::text IS NULL OR status = $1)
-  AND ($2::text IS NULL OR type = $2)
-  AND ($3::text IS NULL OR priority = $3)
-ORDER BY created_at DESC
-LIMIT $4 OFFSET $5;
+WHERE (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'))
+  AND (sqlc.narg('type')::text IS NULL OR type = sqlc.narg('type'))
+  AND (sqlc.narg('priority')::text IS NULL OR priority = sqlc.narg('priority'))
+  AND (sqlc.narg('labels')::jsonb IS NULL OR labels @> sqlc.narg('labels'))
+  AND (sqlc.narg('executor_id')::text IS NULL OR executor_id = sqlc.narg('executor_id'))
+ORDER BY
+    CASE WHEN sqlc.arg('sort_by')::text = 'created_at' AND sqlc.arg('sort_order')::text = 'asc' THEN created_at END ASC,
+    CASE WHEN sqlc.arg('sort_by')::text = 'created_at' AND sqlc.arg('sort_order')::text = 'desc' THEN created_at END DESC,
+    CASE WHEN sqlc.arg('sort_by')::text = 'completed_at' AND sqlc.arg('sort_order')::text = 'asc' THEN completed_at END ASC,
+    CASE WHEN sqlc.arg('sort_by')::text = 'completed_at' AND sqlc.arg('sort_order')::text = 'desc' THEN completed_at END DESC,
+    CASE WHEN sqlc.arg('sort_by')::text = 'priority' AND sqlc.arg('sort_order')::text = 'asc' THEN
+        CASE priority WHEN 'foreground' THEN 1 WHEN 'background' THEN 2 WHEN 'best_effort' THEN 3 END
+    END ASC,
+    CASE WHEN sqlc.arg('sort_by')::text = 'priority' AND sqlc.arg('sort_order')::text = 'desc' THEN
+        CASE priority WHEN 'foreground' THEN 1 WHEN 'background' THEN 2 WHEN 'best_effort' THEN 3 END
+    END DESC,
+    CASE WHEN sqlc.arg('sort_by')::text = 'type' AND sqlc.arg('sort_order')::text = 'asc' THEN type END ASC,
+    CASE WHEN sqlc.arg('sort_by')::text = 'type' AND sqlc.arg('sort_order')::text = 'desc' THEN type END DESC,
+    CASE WHEN sqlc.arg('sort_by')::text = 'status' AND sqlc.arg('sort_order')::text = 'asc' THEN status END ASC,
+    CASE WHEN sqlc.arg('sort_by')::text = 'status' AND sqlc.arg('sort_order')::text = 'desc' THEN status END DESC,
+    created_at DESC,
+    id
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: CountJobs :one
+SELECT COUNT(*) FROM jobs
+WHERE (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'))
+  AND (sqlc.narg('type')::text IS NULL OR type = sqlc.narg('type'))
+  AND (sqlc.narg('priority')::text IS NULL OR priority = sqlc.narg('priority'))
+  AND (sqlc.narg('labels')::jsonb IS NULL OR labels @> sqlc.narg('labels'))
+  AND (sqlc.narg('executor_id')::text IS NULL OR executor_id = sqlc.narg('executor_id'));
 
 -- name: UpdateJobStatus :one
 UPDATE jobs
 SET status = $2,
     started_at = CASE WHEN $2 = 'running' THEN COALESCE(started_at, NOW()) ELSE started_at END,
-    completed_at = CASE WHEN $2 IN ('completed', 'failed', 'cancelled') THEN NOW() ELSE completed_at END
+    completed_at = CASE WHEN $2 IN ('completed', 'failed', 'cancelled', 'dead_letter', 'skipped') THEN NOW() ELSE completed_at END
 WHERE id = $1
 RETURNING *;
 
+-- name: UpdateJobPriority :one
+UPDATE jobs
+SET priority = $2
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
+
 -- name: CancelJob :one
+-- A running job keeps its executor_id, so the executor learns about the
+-- cancellation from its next heartbeat
 UPDATE jobs
 SET status = 'cancelled',
-    completed_at = NOW()
-WHERE id = $1 AND status = 'pending'
+    completed_at = NOW(),
+    cancellation_reason = sqlc.narg('reason')
+WHERE id = sqlc.arg('id') AND status IN ('pending', 'running')
 RETURNING *;
 
+-- name: CancelJobsByCriteria :execrows
+UPDATE jobs
+SET status = 'cancelled',
+    completed_at = NOW(),
+    cancellation_reason = sqlc.narg('reason')
+WHERE status IN ('pending', 'running')
+  AND (sqlc.narg('type')::text IS NULL OR type = sqlc.narg('type'))
+  AND (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'));
+
 -- name: DeleteJob :exec
 DELETE FROM jobs WHERE id = $1;
 
 -- name: ClaimNextJob :one
+-- Takes the oldest job of the first priority in priorities that has one.
+-- Run it in a transaction after LockJobTypeLimits, otherwise concurrent
+-- claims can exceed the limits of job types
 UPDATE jobs
 SET status = 'running',
-    executor_id = $1,
+    executor_id = sqlc.arg(executor_id),
     started_at = NOW(),
     last_heartbeat = NOW()
 WHERE id = (
     SELECT id FROM jobs
     WHERE status = 'pending'
+      AND (scheduled_at IS NULL OR scheduled_at <= NOW())
+      AND NOT EXISTS (
+          SELECT 1 FROM job_dependencies d
+          JOIN jobs p ON p.id = d.depends_on
+          WHERE d.job_id = jobs.id AND p.status <> 'completed'
+      )
+      AND required_capabilities <@ sqlc.arg(capabilities)::text[]
+      AND NOT EXISTS (
+          SELECT 1 FROM job_type_limits l
+          WHERE l.job_type = jobs.type
+            AND l.max_running <= (
+                SELECT COUNT(*) FROM jobs r
+                WHERE r.type = l.job_type AND r.status = 'running'
+            )
+      )
     ORDER BY 
-        CASE priority
-            WHEN 'foreground' THEN 1
-            WHEN 'background' THEN 2
-            WHEN 'best_effort' THEN 3
-        END,
+        array_position(sqlc.arg(priorities)::text[], priority),
         created_at
     FOR UPDATE SKIP LOCKED
     LIMIT 1
 )
 RETURNING *;
 
--- name: UpdateHeartbeat :exec
+-- name: ClaimNextJobFair :one
+-- Like ClaimNextJob, but within a priority it takes the oldest job of the
+-- type that was claimed least recently, so that no type monopolizes the
+-- executors. Run it in the same transaction as RecordJobTypeClaim.
+UPDATE jobs
+SET status = 'running',
+    executor_id = sqlc.arg(executor_id),
+    started_at = NOW(),
+    last_heartbeat = NOW()
+WHERE id = (
+    SELECT jobs.id FROM jobs
+    LEFT JOIN job_type_claims c ON c.job_type = jobs.type
+    WHERE status = 'pending'
+      AND (scheduled_at IS NULL OR scheduled_at <= NOW())
+      AND NOT EXISTS (
+          SELECT 1 FROM job_dependencies d
+          JOIN jobs p ON p.id = d.depends_on
+          WHERE d.job_id = jobs.id AND p.status <> 'completed'
+      )
+      AND required_capabilities <@ sqlc.arg(capabilities)::text[]
+      AND NOT EXISTS (
+          SELECT 1 FROM job_type_limits l
+          WHERE l.job_type = jobs.type
+            AND l.max_running <= (
+                SELECT COUNT(*) FROM jobs r
+                WHERE r.type = l.job_type AND r.status = 'running'
+            )
+      )
+    ORDER BY 
+        array_position(sqlc.arg(priorities)::text[], priority),
+        c.last_claimed_at NULLS FIRST,
+        created_at
+    FOR UPDATE OF jobs SKIP LOCKED
+    LIMIT 1
+)
+RETURNING *;
+
+-- name: UpdateHeartbeat :execrows
 UPDATE jobs
 SET last_heartbeat = NOW()
 WHERE id = $1 AND executor_id = $2 AND status = 'running';
@@ -68,8 +178,11 @@ SET status = 'completed',
     stdout = $2,
     stderr = $3,
     exit_code = $4,
+    stdout_url = $5,
+    stderr_url = $6,
+    result_json = $8,
     completed_at = NOW()
-WHERE id = $1 AND status = 'running'
+WHERE id = $1 AND executor_id = $7 AND status = 'running'
 RETURNING *;
 
 -- name: FailJob :one
@@ -79,24 +192,72 @@ SET status = 'failed',
     stderr = $3,
     exit_code = $4,
     error_message = $5,
-    completed_at = NOW()
-WHERE id = $1 AND status = 'running'
+    stdout_url = $6,
+    stderr_url = $7,
+    result_json = $9,
+    completed_at = NOW(),
+    -- Exponential backoff from the failure, the exponent is capped so the
+    -- interval can't overflow
+    next_retry_at = CASE WHEN retry_count < max_retries
+        THEN NOW() + INTERVAL '1 second' * retry_backoff_base * POWER(2, LEAST(retry_count, 20))
+    END
+WHERE id = $1 AND executor_id = $8 AND status = 'running'
 RETURNING *;
 
+-- name: RecordCancelledJobResult :execrows
+-- Keeps the output a job produced until its executor stopped it after it
+-- was cancelled while running
+UPDATE jobs
+SET stdout = $2,
+    stderr = $3,
+    exit_code = $4,
+    stdout_url = $5,
+    stderr_url = $6
+WHERE id = $1 AND executor_id = $7 AND status = 'cancelled';
+
 -- name: FindStaleJobs :many
+-- A running job without a heartbeat, e.g. one whose executor crashed right
+-- after claiming it, is measured from when it was started
 SELECT * FROM jobs
 WHERE status = 'running'
-  AND last_heartbeat < NOW() - INTERVAL '15 seconds';
+  AND COALESCE(last_heartbeat, started_at, created_at) < NOW() - sqlc.arg('timeout')::interval;
+
+-- name: RequeueJob :one
+INSERT INTO jobs (
+    type, binary_url, binary_sha256, arguments, env_variables,
+    priority, max_retries, timeout_seconds, max_output_bytes,
+    signature_url, public_key, required_capabilities, retry_backoff_base, labels, stdin, input_files, output_globs, workdir_quota_bytes,
+    cpu_millicores, mem_limit_bytes
+)
+SELECT type, binary_url, binary_sha256, arguments, env_variables,
+       priority, max_retries, timeout_seconds, max_output_bytes,
+       signature_url, public_key, required_capabilities, retry_backoff_base, labels, stdin, input_files, output_globs, workdir_quota_bytes,
+       cpu_millicores, mem_limit_bytes
+FROM jobs
+WHERE jobs.id = $1 AND jobs.status IN ('completed', 'failed', 'cancelled', 'dead_letter', 'skipped')
+RETURNING *;
 
--- name: ResetStaleJob :exec
+-- name: ResetStaleJob :execrows
 UPDATE jobs
 SET status = 'pending',
     executor_id = NULL,
     started_at = NULL,
-    last_heartbeat = NULL
+    last_heartbeat = NULL,
+    stdout_buffer = NULL,
+    stderr_buffer = NULL,
+    stdout_url = NULL,
+    stderr_url = NULL
 WHERE id = $1 AND status = 'running';
 
 -- name: CleanupOldJobs :exec
+-- Only deletes finished jobs. Failed jobs waiting for a retry and jobs whose
+-- dependents haven't finished yet are kept, whatever their age.
 DELETE FROM jobs
-WHERE status IN ('completed', 'failed', 'cancelled')
-  AND completed_at < NOW() - $1::interval;
\ No newline at end of file
+WHERE status IN ('completed', 'failed', 'cancelled', 'dead_letter', 'skipped')
+  AND completed_at < NOW() - $1::interval
+  AND NOT (status = 'failed' AND retry_count < max_retries)
+  AND NOT EXISTS (
+      SELECT 1 FROM job_dependencies d
+      JOIN jobs c ON c.id = d.job_id
+      WHERE d.depends_on = jobs.id AND c.status IN ('pending', 'running')
+  );
\ No newline at end of file
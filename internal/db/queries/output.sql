@@ -0,0 +1,16 @@
+-- name: AppendJobOutput :execrows
+UPDATE jobs
+SET stdout_buffer = COALESCE(stdout_buffer, '') || sqlc.arg('stdout')::text,
+    stderr_buffer = COALESCE(stderr_buffer, '') || sqlc.arg('stderr')::text
+WHERE id = sqlc.arg('id')
+  AND executor_id = sqlc.arg('executor_id')
+  AND status = 'running';
+
+-- name: GetJobOutputSince :one
+SELECT status,
+    stdout,
+    stderr,
+    SUBSTRING(COALESCE(stdout_buffer, '') FROM sqlc.arg('stdout_offset')::int + 1)::text AS stdout_chunk,
+    SUBSTRING(COALESCE(stderr_buffer, '') FROM sqlc.arg('stderr_offset')::int + 1)::text AS stderr_chunk
+FROM jobs
+WHERE id = sqlc.arg('id');
\ No newline at end of file
@@ -2,7 +2,7 @@
 SELECT * FROM jobs
 WHERE status = 'failed' 
   AND retry_count < max_retries
-  AND (retry_after IS NULL OR retry_after < NOW())
+  AND (next_retry_at IS NULL OR next_retry_at <= NOW())
 ORDER BY priority, created_at
 LIMIT 10;
 
@@ -10,23 +10,29 @@ LIMIT 10;
 UPDATE jobs 
 SET retry_count = retry_count + 1,
     status = 'pending',
-    retry_after = NOW() + INTERVAL '1 minute' * POWER(2, retry_count), -- Exponential backoff
+    next_retry_at = NULL,
     error_message = NULL,
     stdout = NULL,
     stderr = NULL,
+    stdout_buffer = NULL,
+    stderr_buffer = NULL,
+    stdout_url = NULL,
+    stderr_url = NULL,
     exit_code = NULL,
     started_at = NULL,
     completed_at = NULL,
     executor_id = NULL,
-    last_heartbeat = NULL
+    last_heartbeat = NULL,
+    result_json = NULL
 WHERE id = $1
   AND status = 'failed'
   AND retry_count < max_retries;
 
--- name: CreateJobWithRetries :one
-INSERT INTO jobs (
-    type, binary_url, binary_sha256, arguments, env_variables, 
-    priority, status, max_retries
-) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8
-) RETURNING *;
\ No newline at end of file
+-- name: MoveExhaustedJobsToDeadLetter :many
+UPDATE jobs
+SET status = 'dead_letter',
+    error_message = COALESCE(error_message, 'Job failed') || ' (retries exhausted after ' || retry_count || ' retries)'
+WHERE status = 'failed'
+  AND max_retries > 0
+  AND retry_count >= max_retries
+RETURNING *;
\ No newline at end of file
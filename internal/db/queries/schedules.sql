@@ -0,0 +1,31 @@
+-- name: CreateSchedule :one
+INSERT INTO job_schedules (cron_spec, template, next_run_at)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: DeleteSchedule :execrows
+DELETE FROM job_schedules
+WHERE id = $1;
+
+-- name: DisableSchedule :exec
+UPDATE job_schedules
+SET disabled = TRUE,
+    last_error = sqlc.arg(last_error)
+WHERE id = sqlc.arg(id);
+
+-- name: GetDueSchedules :many
+SELECT * FROM job_schedules
+WHERE next_run_at <= NOW()
+  AND NOT disabled
+ORDER BY next_run_at
+FOR UPDATE SKIP LOCKED;
+
+-- name: ListSchedules :many
+SELECT * FROM job_schedules
+ORDER BY created_at;
+
+-- name: MarkScheduleRun :exec
+UPDATE job_schedules
+SET last_run_at = NOW(),
+    next_run_at = $2
+WHERE id = $1;
\ No newline at end of file
@@ -0,0 +1,11 @@
+-- name: CleanupJobTypeClaims :exec
+-- Types that weren't claimed within the retention are forgotten; they count
+-- as never served, which puts them first just the same
+DELETE FROM job_type_claims
+WHERE last_claimed_at < NOW() - $1::interval;
+
+-- name: RecordJobTypeClaim :exec
+INSERT INTO job_type_claims (job_type, last_claimed_at)
+VALUES ($1, clock_timestamp())
+ON CONFLICT (job_type) DO UPDATE
+SET last_claimed_at = EXCLUDED.last_claimed_at;
\ No newline at end of file
@@ -3,23 +3,42 @@ SELECT status, COUNT(*) as count
 FROM jobs
 GROUP BY status;
 
+-- name: CountJobsByLabel :many
+SELECT (labels->>sqlc.arg(key)::text)::text as value, status, COUNT(*) as count
+FROM jobs
+WHERE labels->>sqlc.arg(key)::text IS NOT NULL
+GROUP BY 1, 2;
+
 -- name: CountPendingJobsByPriority :many
 SELECT priority, COUNT(*) as count
 FROM jobs
 WHERE status = 'pending'
 GROUP BY priority;
 
+-- name: GetOldestPendingAgeByPriority :many
+-- Jobs scheduled for later only start waiting once they are due
+SELECT priority, EXTRACT(EPOCH FROM NOW() - MIN(GREATEST(created_at, scheduled_at)))::float8 as age_seconds
+FROM jobs
+WHERE status = 'pending'
+  AND (scheduled_at IS NULL OR scheduled_at <= NOW())
+GROUP BY priority;
+
 -- name: GetActiveExecutors :many
-SELECT DISTINCT 
-    j.executor_id,
-    j.id as job_id,
-    j.type as job_type,
-    j.last_heartbeat,
-    (SELECT COUNT(*) FROM jobs WHERE executor_id = j.executor_id AND status = 'completed') as jobs_completed
-FROM jobs j
-WHERE j.status = 'running' 
-   AND j.last_heartbeat > NOW() - INTERVAL '30 seconds'
-ORDER BY j.last_heartbeat DESC;
+SELECT
+    e.id as executor_id,
+    e.name,
+    e.capabilities,
+    e.max_jobs,
+    e.cpu_percent,
+    e.mem_bytes,
+    e.registered_at,
+    e.last_heartbeat,
+    e.advertise_addr,
+    ARRAY(SELECT id::text FROM jobs WHERE executor_id = e.id AND status = 'running' ORDER BY started_at)::text[] as running_job_ids,
+    (SELECT COUNT(*) FROM jobs WHERE executor_id = e.id AND status = 'completed') as jobs_completed
+FROM executors e
+WHERE e.last_heartbeat > NOW() - INTERVAL '30 seconds'
+ORDER BY e.last_heartbeat DESC;
 
 -- name: GetJobRetryCount :one
 SELECT COUNT(*) as retry_count
@@ -12,6 +12,39 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countJobsByLabel = `-- name: CountJobsByLabel :many
+SELECT (labels->>$1::text)::text as value, status, COUNT(*) as count
+FROM jobs
+WHERE labels->>$1::text IS NOT NULL
+GROUP BY 1, 2
+`
+
+type CountJobsByLabelRow struct {
+	Value  string `json:"value"`
+	Status string `json:"status"`
+	Count  int64  `json:"count"`
+}
+
+func (q *Queries) CountJobsByLabel(ctx context.Context, key string) ([]CountJobsByLabelRow, error) {
+	rows, err := q.db.Query(ctx, countJobsByLabel, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []CountJobsByLabelRow{}
+	for rows.Next() {
+		var i CountJobsByLabelRow
+		if err := rows.Scan(&i.Value, &i.Status, &i.Count); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const countJobsByStatus = `-- name: CountJobsByStatus :many
 SELECT status, COUNT(*) as count
 FROM jobs
@@ -76,23 +109,34 @@ func (q *Queries) CountPendingJobsByPriority(ctx context.Context) ([]CountPendin
 }
 
 const getActiveExecutors = `-- name: GetActiveExecutors :many
-SELECT DISTINCT 
-    j.executor_id,
-    j.id as job_id,
-    j.type as job_type,
-    j.last_heartbeat,
-    (SELECT COUNT(*) FROM jobs WHERE executor_id = j.executor_id AND status = 'completed') as jobs_completed
-FROM jobs j
-WHERE j.status = 'running' 
-   AND j.last_heartbeat > NOW() - INTERVAL '30 seconds'
-ORDER BY j.last_heartbeat DESC
+SELECT
+    e.id as executor_id,
+    e.name,
+    e.capabilities,
+    e.max_jobs,
+    e.cpu_percent,
+    e.mem_bytes,
+    e.registered_at,
+    e.last_heartbeat,
+    e.advertise_addr,
+    ARRAY(SELECT id::text FROM jobs WHERE executor_id = e.id AND status = 'running' ORDER BY started_at)::text[] as running_job_ids,
+    (SELECT COUNT(*) FROM jobs WHERE executor_id = e.id AND status = 'completed') as jobs_completed
+FROM executors e
+WHERE e.last_heartbeat > NOW() - INTERVAL '30 seconds'
+ORDER BY e.last_heartbeat DESC
 `
 
 type GetActiveExecutorsRow struct {
-	ExecutorID    pgtype.Text        `json:"executor_id"`
-	JobID         uuid.UUID          `json:"job_id"`
-	JobType       string             `json:"job_type"`
+	ExecutorID    string             `json:"executor_id"`
+	Name          string             `json:"name"`
+	Capabilities  []string           `json:"capabilities"`
+	MaxJobs       int32              `json:"max_jobs"`
+	CpuPercent    pgtype.Float8      `json:"cpu_percent"`
+	MemBytes      pgtype.Int8        `json:"mem_bytes"`
+	RegisteredAt  pgtype.Timestamptz `json:"registered_at"`
 	LastHeartbeat pgtype.Timestamptz `json:"last_heartbeat"`
+	AdvertiseAddr string             `json:"advertise_addr"`
+	RunningJobIds []string           `json:"running_job_ids"`
 	JobsCompleted int64              `json:"jobs_completed"`
 }
 
@@ -107,9 +151,15 @@ func (q *Queries) GetActiveExecutors(ctx context.Context) ([]GetActiveExecutorsR
 		var i GetActiveExecutorsRow
 		if err := rows.Scan(
 			&i.ExecutorID,
-			&i.JobID,
-			&i.JobType,
+			&i.Name,
+			&i.Capabilities,
+			&i.MaxJobs,
+			&i.CpuPercent,
+			&i.MemBytes,
+			&i.RegisteredAt,
 			&i.LastHeartbeat,
+			&i.AdvertiseAddr,
+			&i.RunningJobIds,
 			&i.JobsCompleted,
 		); err != nil {
 			return nil, err
@@ -134,3 +184,37 @@ func (q *Queries) GetJobRetryCount(ctx context.Context, jobID uuid.UUID) (int64,
 	err := row.Scan(&retry_count)
 	return retry_count, err
 }
+
+const getOldestPendingAgeByPriority = `-- name: GetOldestPendingAgeByPriority :many
+SELECT priority, EXTRACT(EPOCH FROM NOW() - MIN(GREATEST(created_at, scheduled_at)))::float8 as age_seconds
+FROM jobs
+WHERE status = 'pending'
+  AND (scheduled_at IS NULL OR scheduled_at <= NOW())
+GROUP BY priority
+`
+
+type GetOldestPendingAgeByPriorityRow struct {
+	Priority   string  `json:"priority"`
+	AgeSeconds float64 `json:"age_seconds"`
+}
+
+// Jobs scheduled for later only start waiting once they are due
+func (q *Queries) GetOldestPendingAgeByPriority(ctx context.Context) ([]GetOldestPendingAgeByPriorityRow, error) {
+	rows, err := q.db.Query(ctx, getOldestPendingAgeByPriority)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetOldestPendingAgeByPriorityRow{}
+	for rows.Next() {
+		var i GetOldestPendingAgeByPriorityRow
+		if err := rows.Scan(&i.Priority, &i.AgeSeconds); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
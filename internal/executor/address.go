@@ -0,0 +1,98 @@
+package executor
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"time"
+)
+
+// discoverIP determines the address reported with job claims as
+// executor_ip: AdvertiseIP when configured, else the address of the
+// interface used to reach the server, else the first non-loopback interface
+// address. The host name is the last resort. It also returns where the
+// address came from.
+func (e *Executor) discoverIP() (string, string) {
+	if e.cfg.AdvertiseIP != "" {
+		return e.cfg.AdvertiseIP, "configured"
+	}
+
+	ip, err := outboundIP(e.cfg.ServerURL)
+	if err == nil {
+		return ip.String(), "outbound interface"
+	}
+	slog.Debug("Can't determine outbound interface address", "error", err)
+
+	addrs, err := net.InterfaceAddrs()
+	if err == nil {
+		if ip := firstInterfaceIP(addrs); ip != nil {
+			return ip.String(), "interface"
+		}
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "unknown", "none"
+	}
+	return hostname, "hostname"
+}
+
+// outboundIP returns the local address the system routes traffic to the
+// server from. Connecting a UDP socket selects the route without sending
+// anything.
+func outboundIP(serverURL string) (net.IP, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server URL: %w", err)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("server URL %q has no host", serverURL)
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	conn, err := net.DialTimeout("udp", net.JoinHostPort(u.Hostname(), port), 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	addr, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok || addr.IP.IsUnspecified() {
+		return nil, fmt.Errorf("no local address for %s", serverURL)
+	}
+	return addr.IP, nil
+}
+
+// firstInterfaceIP picks the first usable address of addrs, preferring IPv4.
+// Loopback, link-local and unspecified addresses are skipped.
+func firstInterfaceIP(addrs []net.Addr) net.IP {
+	var firstIPv6 net.IP
+	for _, addr := range addrs {
+		var ip net.IP
+		switch a := addr.(type) {
+		case *net.IPNet:
+			ip = a.IP
+		case *net.IPAddr:
+			ip = a.IP
+		}
+		if ip == nil || ip.IsLoopback() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() {
+			continue
+		}
+		if ip.To4() != nil {
+			return ip
+		}
+		if firstIPv6 == nil {
+			firstIPv6 = ip
+		}
+	}
+	return firstIPv6
+}
\ No newline at end of file
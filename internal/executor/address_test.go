@@ -0,0 +1,59 @@
+package executor
+
+import (
+	"net"
+	"testing"
+)
+
+func TestFirstInterfaceIP(t *testing.T) {
+	ipNet := func(s string) net.Addr {
+		ip, n, err := net.ParseCIDR(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n.IP = ip
+		return n
+	}
+
+	tests := []struct {
+		name  string
+		addrs []net.Addr
+		want  string
+	}{
+		{"none", nil, ""},
+		{"only loopback", []net.Addr{ipNet("127.0.0.1/8"), ipNet("::1/128")}, ""},
+		{"skips loopback", []net.Addr{ipNet("127.0.0.1/8"), ipNet("10.0.0.5/24")}, "10.0.0.5"},
+		{"skips link-local", []net.Addr{ipNet("169.254.1.1/16"), ipNet("fe80::1/64"), ipNet("192.168.1.10/24")}, "192.168.1.10"},
+		{"prefers IPv4", []net.Addr{ipNet("2001:db8::5/64"), ipNet("10.0.0.5/24")}, "10.0.0.5"},
+		{"falls back to IPv6", []net.Addr{ipNet("127.0.0.1/8"), ipNet("2001:db8::5/64")}, "2001:db8::5"},
+		{"IPAddr", []net.Addr{&net.IPAddr{IP: net.ParseIP("10.1.2.3")}}, "10.1.2.3"},
+	}
+
+	for _, tt := range tests {
+		got := firstInterfaceIP(tt.addrs)
+		if (got == nil && tt.want != "") || (got != nil && got.String() != tt.want) {
+			t.Errorf("%s: got %v, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOutboundIP(t *testing.T) {
+	ip, err := outboundIP("http://127.0.0.1:8080")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ip.IsLoopback() {
+		t.Fatalf("expected a loopback address to reach a local server, got %s", ip)
+	}
+
+	if _, err := outboundIP("not a url"); err == nil {
+		t.Fatal("expected an error for a URL without a host")
+	}
+}
+
+func TestDiscoverIPPrefersConfiguredAddress(t *testing.T) {
+	e := &Executor{cfg: &Config{AdvertiseIP: "10.9.8.7", ServerURL: "http://127.0.0.1:8080"}}
+	if ip, source := e.discoverIP(); ip != "10.9.8.7" || source != "configured" {
+		t.Fatalf("got %q from %s", ip, source)
+	}
+}
\ No newline at end of file
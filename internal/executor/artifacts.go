@@ -0,0 +1,148 @@
+package executor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/draganm/executr/pkg/models"
+)
+
+// DefaultMaxArtifactsMB is the default size limit of all artifacts of a job
+// together
+const DefaultMaxArtifactsMB = 500
+
+// findArtifacts returns the regular files in jobDir matching globs, as
+// sorted paths relative to jobDir. Symbolic links are skipped, also in
+// parent directories, so a job can't make the executor upload files from
+// outside its working directory.
+func findArtifacts(jobDir string, globs []string) []string {
+	root, err := filepath.EvalSymlinks(jobDir)
+	if err != nil {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	var names []string
+	for _, glob := range globs {
+		if !filepath.IsLocal(glob) {
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(root, glob))
+		if err != nil {
+			continue
+		}
+		for _, match := range matches {
+			name, err := filepath.Rel(root, match)
+			if err != nil || seen[name] {
+				continue
+			}
+			if resolved, err := filepath.EvalSymlinks(match); err != nil || resolved != match {
+				continue
+			}
+			if info, err := os.Lstat(match); err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// collectArtifacts uploads the files matching a job's output globs after
+// its binary exited, to the output store when one is configured and to the
+// server otherwise. It returns the artifacts in the output store, which are
+// recorded with the job's result. Files that would exceed the total size
+// limit or fail to upload are logged and skipped. Uploaded files are
+// removed right away to free disk space; the rest go with the working
+// directory.
+func (e *Executor) collectArtifacts(ctx context.Context, job *models.Job, jobDir string) []models.Artifact {
+	if len(job.OutputGlobs) == 0 {
+		return nil
+	}
+
+	maxTotal := int64(e.cfg.MaxArtifactsMB)
+	if maxTotal <= 0 {
+		maxTotal = DefaultMaxArtifactsMB
+	}
+	maxTotal *= 1024 * 1024
+	remaining := maxTotal
+
+	var artifacts []models.Artifact
+	for _, name := range findArtifacts(jobDir, job.OutputGlobs) {
+		path := filepath.Join(jobDir, name)
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if info.Size() > remaining {
+			slog.Warn("Skipping artifact, job artifacts exceed the total size limit",
+				"job_id", job.ID,
+				"name", name,
+				"size", info.Size(),
+				"limit", maxTotal,
+			)
+			continue
+		}
+
+		artifact, err := e.uploadArtifact(ctx, job.ID, path, filepath.ToSlash(name))
+		if err != nil {
+			slog.Warn("Failed to upload artifact",
+				"job_id", job.ID,
+				"name", name,
+				"error", err,
+			)
+			continue
+		}
+		remaining -= artifact.Size
+		if e.outputStore != nil {
+			artifacts = append(artifacts, *artifact)
+		}
+		os.Remove(path)
+	}
+	return artifacts
+}
+
+// uploadArtifact uploads a single artifact. Artifacts sent to the server
+// are recorded by the upload itself.
+func (e *Executor) uploadArtifact(ctx context.Context, jobID uuid.UUID, path, name string) (*models.Artifact, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash artifact: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	if e.outputStore == nil {
+		return e.client.UploadArtifact(ctx, jobID, e.executorID, name, f, size)
+	}
+
+	url, err := e.outputStore.Put(ctx, fmt.Sprintf("jobs/%s/artifacts/%s", jobID, name), f, size)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Artifact{
+		Name:   name,
+		Size:   size,
+		SHA256: hex.EncodeToString(h.Sum(nil)),
+		URL:    url,
+	}, nil
+}
\ No newline at end of file
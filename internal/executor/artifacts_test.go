@@ -0,0 +1,84 @@
+package executor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/draganm/executr/pkg/client"
+	"github.com/draganm/executr/pkg/models"
+)
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindArtifacts(t *testing.T) {
+	outside := filepath.Join(t.TempDir(), "secret.csv")
+	writeFile(t, outside, []byte("secret"))
+
+	jobDir := t.TempDir()
+	writeFile(t, filepath.Join(jobDir, "out", "a.csv"), []byte("a"))
+	writeFile(t, filepath.Join(jobDir, "out", "b.csv"), []byte("b"))
+	writeFile(t, filepath.Join(jobDir, "report.txt"), []byte("report"))
+	writeFile(t, filepath.Join(jobDir, "out", "nested", "c.csv"), []byte("c"))
+	if err := os.Symlink(outside, filepath.Join(jobDir, "out", "link.csv")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Dir(outside), filepath.Join(jobDir, "linked")); err != nil {
+		t.Fatal(err)
+	}
+
+	got := findArtifacts(jobDir, []string{"out/*.csv", "report.txt", "out/*", "linked/*", "../*", "missing"})
+	want := []string{"out/a.csv", "out/b.csv", "report.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestCollectArtifactsUploadsToServer(t *testing.T) {
+	mock := client.NewMockClient()
+	job, err := mock.SubmitJob(context.Background(), &models.JobSubmission{
+		Type:        "test",
+		BinaryURL:   "https://example.com/binary",
+		OutputGlobs: []string{"*.txt"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mock.ClaimNextJob(context.Background(), "executor-1", "127.0.0.1", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	jobDir := t.TempDir()
+	writeFile(t, filepath.Join(jobDir, "small.txt"), []byte("small"))
+	writeFile(t, filepath.Join(jobDir, "large.txt"), make([]byte, 1024*1024+1))
+
+	e := &Executor{cfg: &Config{MaxArtifactsMB: 1}, client: mock, executorID: "executor-1"}
+	if stored := e.collectArtifacts(context.Background(), job, jobDir); stored != nil {
+		t.Fatalf("expected no artifacts to report without an output store, got %v", stored)
+	}
+
+	artifacts, err := mock.ListArtifacts(context.Background(), job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte("small"))
+	if len(artifacts) != 1 || artifacts[0].Name != "small.txt" || artifacts[0].Size != 5 || artifacts[0].SHA256 != hex.EncodeToString(sum[:]) {
+		t.Fatalf("unexpected artifacts: %+v", artifacts)
+	}
+
+	if _, err := os.Stat(filepath.Join(jobDir, "small.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected uploaded artifact to be removed, got %v", err)
+	}
+}
\ No newline at end of file
@@ -1,25 +1,76 @@
 package executor
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/draganm/executr/internal/metrics"
 	"github.com/draganm/executr/internal/utils"
 )
 
+// ErrSignatureVerification is returned by GetBinary when a binary's detached
+// signature cannot be verified
+var ErrSignatureVerification = errors.New("signature verification failed")
+
+// ErrInsufficientDiskSpace is returned by GetBinary when evicting cached
+// binaries doesn't free enough disk space for a download
+var ErrInsufficientDiskSpace = errors.New("insufficient disk space")
+
+// maxSignatureSize bounds the size of a downloaded signature file
+const maxSignatureSize = 64 * 1024
+
+// staleDownloadAge is how long a partial download may go unmodified before
+// the cache removes it. Interrupted downloads are kept so that a later
+// request for the binary can resume them, but binaries that are never
+// requested again would leave them behind for good.
+const staleDownloadAge = time.Hour
+
+// BinarySignature describes a minisign detached signature for a binary
+type BinarySignature struct {
+	URL       string // URL of the .minisig file
+	PublicKey string // minisign public key (bare base64 or .pub file contents)
+}
+
 type BinaryCache struct {
-	cacheDir     string
-	maxSizeMB    int
-	mu           sync.RWMutex
-	entries      map[string]*cacheEntry
+	cacheDir      string
+	maxSizeMB     int
+	minFreeDiskMB int // free space kept on the cache filesystem, 0 disables the check
+	mu            sync.RWMutex
+	entries       map[string]*cacheEntry
+	downloader    *utils.BinaryDownloader
+	credentials   *utils.DownloadCredentials // sent with size and signature requests too
+	downloads     singleflight.Group // in-flight downloads by SHA256
+	flightsMu     sync.Mutex
+	flights       map[string]*downloadFlight // contexts of in-flight downloads by downloads key
+	downloadSlots chan struct{}      // bounds downloads of different binaries running at once, nil for no limit
+	downloadTimeout time.Duration    // bounds each download, 0 for no limit
+	sources       map[string]BinarySource // by URL scheme
+	executorID    string             // labels the cache metrics
+	indexMu       sync.Mutex         // serializes index writes
+}
+
+// downloadFlight is the context a shared download runs on, cancelled once all
+// callers waiting for it have given up
+type downloadFlight struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	waiters int
 }
 
 type cacheEntry struct {
@@ -27,35 +78,105 @@ type cacheEntry struct {
 	path       string
 	size       int64
 	lastAccess time.Time
+	digests    []string // source digests, e.g. of images, known to point to this binary
+}
+
+// SetMaxConcurrentDownloads limits how many binaries are downloaded at the
+// same time; further downloads wait for one to finish. Cache hits and
+// callers sharing a download of the same binary don't count. 0 removes the
+// limit.
+func (c *BinaryCache) SetMaxConcurrentDownloads(n int) {
+	if n <= 0 {
+		c.downloadSlots = nil
+		return
+	}
+	c.downloadSlots = make(chan struct{}, n)
 }
 
-func NewBinaryCache(cacheDir string, maxSizeMB int) (*BinaryCache, error) {
+// SetDownloadTimeout bounds how long a binary download may take. 0 removes
+// the limit.
+func (c *BinaryCache) SetDownloadTimeout(timeout time.Duration) {
+	c.downloadTimeout = timeout
+}
+
+// SetDownloadCredentials authenticates the cache's requests to URLs that have
+// credentials in creds
+func (c *BinaryCache) SetDownloadCredentials(creds *utils.DownloadCredentials) {
+	c.credentials = creds
+	c.downloader.SetCredentials(creds)
+	for _, source := range c.sources {
+		if s, ok := source.(*httpSource); ok {
+			s.credentials = creds
+		}
+	}
+}
+
+// RegisterSource makes the cache fetch binaries whose URL has the given
+// scheme, e.g. "s3", from source. It can also replace the built-in sources
+// for http, https, oci and file.
+func (c *BinaryCache) RegisterSource(scheme string, source BinarySource) {
+	c.sources[scheme] = source
+}
+
+// source returns the source of binaryURL by its scheme
+func (c *BinaryCache) source(binaryURL string) (BinarySource, error) {
+	scheme, _, ok := strings.Cut(binaryURL, "://")
+	source, found := c.sources[strings.ToLower(scheme)]
+	if !ok || !found {
+		return nil, fmt.Errorf("unsupported binary URL %q", binaryURL)
+	}
+	return source, nil
+}
+
+func NewBinaryCache(cacheDir string, maxSizeMB, minFreeDiskMB int) (*BinaryCache, error) {
 	// Create cache directory if it doesn't exist
 	if err := os.MkdirAll(cacheDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create cache directory: %w", err)
 	}
 	
 	cache := &BinaryCache{
-		cacheDir:  cacheDir,
-		maxSizeMB: maxSizeMB,
-		entries:   make(map[string]*cacheEntry),
+		cacheDir:      cacheDir,
+		maxSizeMB:     maxSizeMB,
+		minFreeDiskMB: minFreeDiskMB,
+		entries:       make(map[string]*cacheEntry),
+		flights:       make(map[string]*downloadFlight),
+		downloader:    utils.NewBinaryDownloader(),
+	}
+	httpSrc := &httpSource{downloader: cache.downloader}
+	cache.sources = map[string]BinarySource{
+		"http":  httpSrc,
+		"https": httpSrc,
+		"oci":   &ociSource{puller: utils.NewOCIPuller()},
+		"file":  &fileSource{},
 	}
 	
 	// Load existing cache entries
 	if err := cache.loadEntries(); err != nil {
 		slog.Warn("Failed to load cache entries", "error", err)
 	}
+	cache.removeStaleDownloads(time.Now())
+	cache.saveIndex()
 	
 	return cache, nil
 }
 
+// loadEntries scans the cache directory. Access times come from the cache
+// index, falling back to the file modification time for binaries the index
+// doesn't know.
 func (c *BinaryCache) loadEntries() error {
 	entries, err := os.ReadDir(c.cacheDir)
 	if err != nil {
 		return err
 	}
 	
+	index := c.readIndex()
+	
 	for _, entry := range entries {
+		// Skip in-progress downloads
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		
 		if !entry.IsDir() {
 			info, err := entry.Info()
 			if err != nil {
@@ -64,11 +185,18 @@ func (c *BinaryCache) loadEntries() error {
 			
 			// Cache files are named by their SHA256 hash
 			sha256Hash := entry.Name()
+			lastAccess := info.ModTime()
+			var digests []string
+			if recorded, ok := index[sha256Hash]; ok {
+				lastAccess = recorded.LastAccess
+				digests = recorded.Digests
+			}
 			c.entries[sha256Hash] = &cacheEntry{
 				sha256:     sha256Hash,
 				path:       filepath.Join(c.cacheDir, sha256Hash),
 				size:       info.Size(),
-				lastAccess: info.ModTime(),
+				lastAccess: lastAccess,
+				digests:    digests,
 			}
 		}
 	}
@@ -76,65 +204,248 @@ func (c *BinaryCache) loadEntries() error {
 	return nil
 }
 
-func (c *BinaryCache) GetBinary(binaryURL, expectedSHA256 string) (string, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	
-	// Check if binary is already in cache
-	if entry, exists := c.entries[expectedSHA256]; exists {
-		// Verify the cached binary still has correct SHA256
-		if err := c.verifySHA256(entry.path, expectedSHA256); err == nil {
-			// Update last access time
-			entry.lastAccess = time.Now()
-			os.Chtimes(entry.path, time.Now(), time.Now())
-			
-			slog.Debug("Binary found in cache", 
-				"sha256", expectedSHA256,
-				"path", entry.path,
-			)
-			return entry.path, nil
+// GetBinary returns the path of the cached binary with the given SHA256,
+// fetching it from the source for the URL's scheme first if needed.
+// Without a SHA256, binaries of sources that report digests, like oci://
+// images, are looked up by digest. Concurrent calls for the same uncached
+// binary share a single download. When sig is not nil, the binary's
+// signature is verified after the SHA256 check; a binary that fails
+// verification is removed from the cache. Cancelling ctx stops waiting for
+// the download; once every caller sharing it has given up, the download is
+// aborted and leaves nothing behind in the cache.
+func (c *BinaryCache) GetBinary(ctx context.Context, binaryURL, expectedSHA256 string, sig *BinarySignature) (string, error) {
+	source, err := c.source(binaryURL)
+	if err != nil {
+		return "", err
+	}
+	
+	sha, digest := expectedSHA256, ""
+	if sha == "" {
+		if digest, err = source.Digest(ctx, binaryURL); err != nil {
+			return "", err
 		}
-		
-		// SHA256 mismatch, remove from cache
+		sha = c.digestSHA256(digest)
+	}
+	
+	path, ok := c.cached(sha)
+	if ok {
+		metrics.BinaryCacheHits.WithLabelValues(c.executorID).Inc()
+	} else {
+		key := expectedSHA256
+		if key == "" {
+			key = digest + "|" + binaryURL
+		}
+		// The cache lock is not held during the transfer, so lookups of
+		// other binaries don't wait for it
+		path, err = c.sharedDownload(ctx, key, func(ctx context.Context) (string, error) {
+			return c.download(ctx, source, binaryURL, digest, expectedSHA256)
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	
+	if err := c.verifySignature(ctx, path, sig); err != nil {
+		c.mu.Lock()
+		if entry, exists := c.entries[filepath.Base(path)]; exists {
+			c.remove(entry)
+		}
+		c.mu.Unlock()
+		c.saveIndex()
+		return "", err
+	}
+	
+	return path, nil
+}
+
+// sharedDownload runs download once for all concurrent callers with the same
+// key. The download doesn't run on any caller's context, so a caller giving
+// up only stops waiting for it; it is cancelled once every caller has given
+// up, or when the download timeout passes.
+func (c *BinaryCache) sharedDownload(ctx context.Context, key string, download func(context.Context) (string, error)) (string, error) {
+	for {
+		flight := c.joinFlight(ctx, key)
+		results := c.downloads.DoChan(key, func() (interface{}, error) {
+			return download(flight.ctx)
+		})
+
+		select {
+		case res := <-results:
+			c.leaveFlight(key, flight)
+			// This caller may have joined a download all of whose earlier
+			// callers gave up; start a new one
+			if errors.Is(res.Err, context.Canceled) && ctx.Err() == nil {
+				continue
+			}
+			if res.Err != nil {
+				return "", res.Err
+			}
+			return res.Val.(string), nil
+		case <-ctx.Done():
+			// The last caller waits for the cancelled download to clean up
+			if c.leaveFlight(key, flight) {
+				<-results
+			}
+			return "", ctx.Err()
+		}
+	}
+}
+
+// joinFlight returns the context of the download for key, creating it for
+// the first caller
+func (c *BinaryCache) joinFlight(ctx context.Context, key string) *downloadFlight {
+	c.flightsMu.Lock()
+	defer c.flightsMu.Unlock()
+
+	flight, ok := c.flights[key]
+	if !ok {
+		flightCtx := context.WithoutCancel(ctx)
+		var cancel context.CancelFunc
+		if c.downloadTimeout > 0 {
+			flightCtx, cancel = context.WithTimeout(flightCtx, c.downloadTimeout)
+		} else {
+			flightCtx, cancel = context.WithCancel(flightCtx)
+		}
+		flight = &downloadFlight{ctx: flightCtx, cancel: cancel}
+		c.flights[key] = flight
+	}
+	flight.waiters++
+	return flight
+}
+
+// leaveFlight cancels the download's context when the last caller leaves
+// and reports whether it did
+func (c *BinaryCache) leaveFlight(key string, flight *downloadFlight) bool {
+	c.flightsMu.Lock()
+	defer c.flightsMu.Unlock()
+
+	flight.waiters--
+	if flight.waiters > 0 {
+		return false
+	}
+	if c.flights[key] == flight {
+		delete(c.flights, key)
+	}
+	flight.cancel()
+	return true
+}
+
+// cached returns the path of a cached binary whose content still matches
+// its SHA256, and records the access. The lock is only held to access the
+// entries, not while the binary is hashed.
+func (c *BinaryCache) cached(expectedSHA256 string) (string, bool) {
+	if expectedSHA256 == "" {
+		return "", false
+	}
+	
+	c.mu.RLock()
+	entry, exists := c.entries[expectedSHA256]
+	c.mu.RUnlock()
+	if !exists {
+		return "", false
+	}
+	
+	// Verify the cached binary still has correct SHA256
+	if err := c.verifySHA256(entry.path, expectedSHA256); err != nil {
 		slog.Warn("Cached binary SHA256 mismatch, removing from cache",
 			"expected", expectedSHA256,
 			"path", entry.path,
 		)
-		delete(c.entries, expectedSHA256)
-		os.Remove(entry.path)
+		c.mu.Lock()
+		// It may have been evicted or replaced meanwhile
+		if c.entries[expectedSHA256] == entry {
+			c.remove(entry)
+		}
+		c.mu.Unlock()
+		c.saveIndex()
+		return "", false
+	}
+	
+	// Update last access time
+	c.mu.Lock()
+	entry.lastAccess = time.Now()
+	c.mu.Unlock()
+	c.saveIndex()
+	
+	slog.Debug("Binary found in cache",
+		"sha256", expectedSHA256,
+		"path", entry.path,
+	)
+	return entry.path, true
+}
+
+// digestSHA256 returns the SHA256 of the cached binary a source digest
+// points to, "" if there is none
+func (c *BinaryCache) digestSHA256(digest string) string {
+	if digest == "" {
+		return ""
 	}
 	
-	// Download binary
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for sha, entry := range c.entries {
+		if slices.Contains(entry.digests, digest) {
+			return sha
+		}
+	}
+	return ""
+}
+
+// download fetches a binary into the cache. Only one download per SHA256,
+// or per digest for binaries without one, runs at a time; callers go
+// through the downloads group.
+func (c *BinaryCache) download(ctx context.Context, source BinarySource, binaryURL, digest, expectedSHA256 string) (string, error) {
+	// An earlier download may have finished after the caller's cache lookup
+	sha := expectedSHA256
+	if sha == "" {
+		sha = c.digestSHA256(digest)
+	}
+	if path, ok := c.cached(sha); ok {
+		return path, nil
+	}
+	
+	if c.downloadSlots != nil {
+		select {
+		case c.downloadSlots <- struct{}{}:
+			defer func() { <-c.downloadSlots }()
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	
+	metrics.BinaryCacheMisses.WithLabelValues(c.executorID).Inc()
 	slog.Info("Downloading binary", 
 		"url", binaryURL,
 		"sha256", expectedSHA256,
 	)
 	
-	cachePath := filepath.Join(c.cacheDir, expectedSHA256)
-	tempPath := cachePath + ".tmp"
-	
-	// Download to temporary file
-	if err := c.downloadBinary(binaryURL, tempPath); err != nil {
-		os.Remove(tempPath)
-		return "", fmt.Errorf("failed to download binary: %w", err)
+	var size int64
+	if c.minFreeDiskMB > 0 {
+		size = source.Size(ctx, binaryURL)
+	}
+	if err := c.ensureDiskSpace(size); err != nil {
+		return "", err
 	}
 	
-	// Verify SHA256
-	if err := c.verifySHA256(tempPath, expectedSHA256); err != nil {
-		os.Remove(tempPath)
-		return "", fmt.Errorf("SHA256 verification failed: %w", err)
+	// Binaries with an unknown SHA256 are named by it once fetched
+	fetchPath := filepath.Join(c.cacheDir, expectedSHA256)
+	if expectedSHA256 == "" {
+		fetchPath = filepath.Join(c.cacheDir, ".fetch-"+uuid.New().String())
 	}
 	
-	// Make binary executable
-	if err := os.Chmod(tempPath, 0755); err != nil {
-		os.Remove(tempPath)
-		return "", fmt.Errorf("failed to make binary executable: %w", err)
+	start := time.Now()
+	sha, err := source.Fetch(ctx, binaryURL, digest, fetchPath, expectedSHA256)
+	if err != nil {
+		return "", err
 	}
+	metrics.BinaryDownloadDuration.WithLabelValues(c.executorID).Observe(time.Since(start).Seconds())
 	
-	// Move to final location
-	if err := os.Rename(tempPath, cachePath); err != nil {
-		os.Remove(tempPath)
-		return "", fmt.Errorf("failed to move binary to cache: %w", err)
+	cachePath := filepath.Join(c.cacheDir, sha)
+	if fetchPath != cachePath {
+		if err := os.Rename(fetchPath, cachePath); err != nil {
+			os.Remove(fetchPath)
+			return "", fmt.Errorf("failed to move binary into the cache: %w", err)
+		}
 	}
 	
 	// Get file info
@@ -143,42 +454,38 @@ func (c *BinaryCache) GetBinary(binaryURL, expectedSHA256 string) (string, error
 		return "", fmt.Errorf("failed to stat cached binary: %w", err)
 	}
 	
+	c.mu.Lock()
+	
 	// Add to cache entries
-	c.entries[expectedSHA256] = &cacheEntry{
-		sha256:     expectedSHA256,
+	// The same binary may already be cached from another image
+	var digests []string
+	if existing, ok := c.entries[sha]; ok {
+		digests = existing.digests
+	}
+	if digest != "" && !slices.Contains(digests, digest) {
+		digests = append(digests, digest)
+	}
+	c.entries[sha] = &cacheEntry{
+		sha256:     sha,
 		path:       cachePath,
 		size:       info.Size(),
 		lastAccess: time.Now(),
+		digests:    digests,
 	}
 	
 	// Perform LRU eviction if needed
 	c.evictIfNeeded()
+	c.mu.Unlock()
+	c.saveIndex()
 	
 	slog.Info("Binary cached successfully",
-		"sha256", expectedSHA256,
+		"sha256", sha,
 		"size", info.Size(),
 	)
 	
 	return cachePath, nil
 }
 
-func (c *BinaryCache) downloadBinary(url, destPath string) error {
-	// Create temporary file
-	out, err := os.Create(destPath)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-	
-	// Download with retry logic
-	downloader := &utils.Downloader{
-		MaxRetries: 3,
-		RetryDelay: time.Second,
-	}
-	
-	return downloader.Download(url, out)
-}
-
 func (c *BinaryCache) verifySHA256(filePath, expectedSHA256 string) error {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -199,17 +506,124 @@ func (c *BinaryCache) verifySHA256(filePath, expectedSHA256 string) error {
 	return nil
 }
 
+// verifySignature checks the minisign signature of the binary at filePath.
+// It is a no-op when sig is nil.
+func (c *BinaryCache) verifySignature(ctx context.Context, filePath string, sig *BinarySignature) error {
+	if sig == nil {
+		return nil
+	}
+	
+	signature, err := c.fetchSignature(ctx, sig.URL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSignatureVerification, err)
+	}
+	
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	
+	if err := utils.VerifyMinisign(file, signature, sig.PublicKey); err != nil {
+		slog.Warn("Binary signature verification failed",
+			"path", filePath,
+			"signature_url", sig.URL,
+			"error", err,
+		)
+		return fmt.Errorf("%w: %v", ErrSignatureVerification, err)
+	}
+	
+	slog.Debug("Binary signature verified",
+		"path", filePath,
+		"signature_url", sig.URL,
+	)
+	return nil
+}
+
+func (c *BinaryCache) fetchSignature(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create signature request: %w", err)
+	}
+	c.credentials.Apply(req)
+	
+	client := &http.Client{CheckRedirect: c.credentials.CheckRedirect}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download signature: %w", err)
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download signature: HTTP %d", resp.StatusCode)
+	}
+	
+	signature, err := io.ReadAll(io.LimitReader(resp.Body, maxSignatureSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signature: %w", err)
+	}
+	if len(signature) > maxSignatureSize {
+		return nil, fmt.Errorf("signature exceeds %d bytes", maxSignatureSize)
+	}
+	return signature, nil
+}
+
+// remove drops an entry from the cache and deletes its file
+func (c *BinaryCache) remove(entry *cacheEntry) {
+	delete(c.entries, entry.sha256)
+	if err := os.Remove(entry.path); err != nil && !os.IsNotExist(err) {
+		slog.Warn("Failed to remove cached binary",
+			"path", entry.path,
+			"error", err,
+		)
+	}
+}
+
 func (c *BinaryCache) evictIfNeeded() {
+	c.evictTo(int64(c.maxSizeMB) * 1024 * 1024)
+	c.removeStaleDownloads(time.Now())
+}
+
+// removeStaleDownloads deletes partial downloads that haven't been written
+// to for staleDownloadAge. Running downloads keep writing and are left alone.
+func (c *BinaryCache) removeStaleDownloads(now time.Time) {
+	entries, err := os.ReadDir(c.cacheDir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), ".download-") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || now.Sub(info.ModTime()) < staleDownloadAge {
+			continue
+		}
+
+		path := filepath.Join(c.cacheDir, entry.Name())
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			slog.Warn("Failed to remove stale partial download",
+				"path", path,
+				"error", err,
+			)
+			continue
+		}
+		slog.Debug("Removed stale partial download", "path", path, "size", info.Size())
+	}
+}
+
+// evictTo removes least recently used binaries until the cache holds at
+// most maxBytes and returns the removed entries. The caller must hold the
+// lock.
+func (c *BinaryCache) evictTo(maxBytes int64) []*cacheEntry {
 	// Calculate total cache size
 	var totalSize int64
 	for _, entry := range c.entries {
 		totalSize += entry.size
 	}
 	
-	maxBytes := int64(c.maxSizeMB) * 1024 * 1024
-	
 	if totalSize <= maxBytes {
-		return
+		return nil
 	}
 	
 	slog.Info("Cache size exceeded, performing LRU eviction",
@@ -217,18 +631,9 @@ func (c *BinaryCache) evictIfNeeded() {
 		"max_size", maxBytes,
 	)
 	
-	// Sort entries by last access time (oldest first)
-	entries := make([]*cacheEntry, 0, len(c.entries))
-	for _, entry := range c.entries {
-		entries = append(entries, entry)
-	}
-	
-	sort.Slice(entries, func(i, j int) bool {
-		return entries[i].lastAccess.Before(entries[j].lastAccess)
-	})
-	
 	// Evict oldest entries until we're under the limit
-	for _, entry := range entries {
+	var evicted []*cacheEntry
+	for _, entry := range c.lruEntries() {
 		if totalSize <= maxBytes {
 			break
 		}
@@ -248,10 +653,71 @@ func (c *BinaryCache) evictIfNeeded() {
 		
 		delete(c.entries, entry.sha256)
 		totalSize -= entry.size
+		evicted = append(evicted, entry)
 	}
 	
 	slog.Info("Cache eviction complete",
 		"new_size", totalSize,
 		"entries", len(c.entries),
 	)
+	return evicted
+}
+
+// lruEntries returns the cache entries, least recently used first. The
+// caller must hold the lock.
+func (c *BinaryCache) lruEntries() []*cacheEntry {
+	entries := make([]*cacheEntry, 0, len(c.entries))
+	for _, entry := range c.entries {
+		entries = append(entries, entry)
+	}
+	
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].lastAccess.Before(entries[j].lastAccess)
+	})
+	return entries
+}
+
+// ensureDiskSpace evicts least recently used binaries until the cache
+// filesystem has room for size bytes on top of minFreeDiskMB. Other data
+// can fill a shared disk, so this is checked before every download rather
+// than derived from the cache size.
+func (c *BinaryCache) ensureDiskSpace(size int64) error {
+	if c.minFreeDiskMB <= 0 {
+		return nil
+	}
+	
+	free, err := freeDiskSpace(c.cacheDir)
+	if err != nil {
+		if !errors.Is(err, errors.ErrUnsupported) {
+			slog.Warn("Failed to check free disk space", "path", c.cacheDir, "error", err)
+		}
+		return nil
+	}
+	
+	needed := size + int64(c.minFreeDiskMB)*1024*1024
+	if free >= needed {
+		return nil
+	}
+	
+	slog.Info("Low disk space, evicting cached binaries",
+		"free", free,
+		"needed", needed,
+	)
+	
+	c.mu.Lock()
+	for _, entry := range c.lruEntries() {
+		if free >= needed {
+			break
+		}
+		c.remove(entry)
+		free += entry.size
+	}
+	c.mu.Unlock()
+	c.saveIndex()
+	
+	if free < needed {
+		return fmt.Errorf("%w: need %d MB free in %s, %d MB available",
+			ErrInsufficientDiskSpace, needed/(1024*1024), c.cacheDir, free/(1024*1024))
+	}
+	return nil
 }
\ No newline at end of file
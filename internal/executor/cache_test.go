@@ -0,0 +1,656 @@
+package executor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/draganm/executr/internal/metrics"
+)
+
+func TestGetBinaryCancelledDownloadLeavesNothingBehind(t *testing.T) {
+	halfSent := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "2048")
+		w.Write([]byte(strings.Repeat("x", 1024)))
+		w.(http.Flusher).Flush()
+		close(halfSent)
+
+		// Never send the rest
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	cache, err := NewBinaryCache(dir, 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-halfSent
+		cancel()
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := cache.GetBinary(ctx, srv.URL, strings.Repeat("0", 64), nil)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected an error from a cancelled download")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("download was not cancelled")
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if f.Name() != cacheIndexFile {
+			t.Errorf("unexpected file left in cache: %s", f.Name())
+		}
+	}
+	if len(cache.entries) != 0 {
+		t.Errorf("expected no cache entries, got %d", len(cache.entries))
+	}
+}
+
+func TestGetBinaryConcurrentCallsShareDownload(t *testing.T) {
+	content := []byte("#!/bin/sh\necho cached\n")
+	sum := sha256.Sum256(content)
+	sha := hex.EncodeToString(sum[:])
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		// Slow enough for all callers to pile up behind the download
+		time.Sleep(200 * time.Millisecond)
+		w.Write(content)
+	}))
+	defer srv.Close()
+
+	cache, err := NewBinaryCache(t.TempDir(), 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const jobs = 8
+	var wg sync.WaitGroup
+	paths := make([]string, jobs)
+	errs := make([]error, jobs)
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			paths[i], errs[i] = cache.GetBinary(context.Background(), srv.URL, sha, nil)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < jobs; i++ {
+		if errs[i] != nil {
+			t.Fatalf("job %d: %v", i, errs[i])
+		}
+		if paths[i] != paths[0] {
+			t.Fatalf("job %d got %s, job 0 got %s", i, paths[i], paths[0])
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Fatalf("expected a single download, got %d", n)
+	}
+}
+
+func TestGetBinarySharedDownloadSurvivesCancelledCaller(t *testing.T) {
+	content := []byte("#!/bin/sh\necho shared\n")
+	sum := sha256.Sum256(content)
+	sha := hex.EncodeToString(sum[:])
+
+	var requests atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		w.Write(content)
+	}))
+	defer srv.Close()
+
+	cache, err := NewBinaryCache(t.TempDir(), 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := cache.GetBinary(ctx, srv.URL, sha, nil)
+		first <- err
+	}()
+	<-started
+
+	second := make(chan error, 1)
+	go func() {
+		_, err := cache.GetBinary(context.Background(), srv.URL, sha, nil)
+		second <- err
+	}()
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		cache.flightsMu.Lock()
+		waiters := 0
+		if flight, ok := cache.flights[sha]; ok {
+			waiters = flight.waiters
+		}
+		cache.flightsMu.Unlock()
+		if waiters == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("second caller didn't join the download")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The first caller stops waiting, the download goes on for the second
+	cancel()
+	select {
+	case err := <-first:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected the cancelled caller to fail with context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancelled caller kept waiting for the download")
+	}
+
+	close(release)
+	select {
+	case err := <-second:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("download didn't finish")
+	}
+	if n := requests.Load(); n != 1 {
+		t.Fatalf("expected a single download, got %d", n)
+	}
+}
+
+// writeCachedBinary puts content into a cache directory the way the cache
+// stores it and returns its SHA256
+func writeCachedBinary(tb testing.TB, dir string, content []byte) string {
+	tb.Helper()
+	sum := sha256.Sum256(content)
+	sha := hex.EncodeToString(sum[:])
+	if err := os.WriteFile(filepath.Join(dir, sha), content, 0755); err != nil {
+		tb.Fatal(err)
+	}
+	return sha
+}
+
+// startBlockedDownload makes cache download a binary from a server that
+// doesn't respond until the returned function is called
+func startBlockedDownload(tb testing.TB, cache *BinaryCache) func() {
+	tb.Helper()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cache.GetBinary(ctx, srv.URL, strings.Repeat("b", 64), nil)
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(10 * time.Second):
+		tb.Fatal("download did not start")
+	}
+
+	return func() {
+		cancel()
+		close(release)
+		<-done
+		srv.Close()
+	}
+}
+
+func TestGetBinaryHitDoesNotWaitForDownload(t *testing.T) {
+	dir := t.TempDir()
+	sha := writeCachedBinary(t, dir, []byte("#!/bin/sh\necho a\n"))
+
+	cache, err := NewBinaryCache(dir, 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := startBlockedDownload(t, cache)
+	defer stop()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := cache.GetBinary(context.Background(), "http://unused.invalid", sha, nil)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cache hit waited for an unrelated download")
+	}
+}
+
+// BenchmarkGetBinaryHitDuringDownload measures cache hits while another
+// binary is being downloaded. Hits don't wait for the download, so this
+// finishes instead of blocking.
+func BenchmarkGetBinaryHitDuringDownload(b *testing.B) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	dir := b.TempDir()
+	sha := writeCachedBinary(b, dir, []byte("#!/bin/sh\necho a\n"))
+
+	cache, err := NewBinaryCache(dir, 100, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	stop := startBlockedDownload(b, cache)
+	defer stop()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := cache.GetBinary(context.Background(), "http://unused.invalid", sha, nil); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func TestCacheIndexKeepsAccessTimesAcrossRestarts(t *testing.T) {
+	dir := t.TempDir()
+	sha := writeCachedBinary(t, dir, []byte("#!/bin/sh\necho a\n"))
+
+	// A modification time that is clearly not the access time
+	modTime := time.Now().Add(-24 * time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, sha), modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := NewBinaryCache(dir, 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.GetBinary(context.Background(), "http://unused.invalid", sha, nil); err != nil {
+		t.Fatal(err)
+	}
+	accessed := cache.entries[sha].lastAccess
+
+	restarted, err := NewBinaryCache(dir, 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := restarted.entries[sha].lastAccess; !got.Equal(accessed) {
+		t.Fatalf("got last access %s, want %s", got, accessed)
+	}
+}
+
+func TestCorruptCacheIndexFallsBackToFileTimes(t *testing.T) {
+	dir := t.TempDir()
+	sha := writeCachedBinary(t, dir, []byte("#!/bin/sh\necho a\n"))
+
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(filepath.Join(dir, sha), modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, cacheIndexFile), []byte("{not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := NewBinaryCache(dir, 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := cache.entries[sha]
+	if !ok {
+		t.Fatal("expected the cached binary to be loaded")
+	}
+	if !entry.lastAccess.Equal(modTime) {
+		t.Fatalf("got last access %s, want %s", entry.lastAccess, modTime)
+	}
+
+	// The index is rewritten from the directory scan
+	data, err := os.ReadFile(filepath.Join(dir, cacheIndexFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var index map[string]cacheIndexEntry
+	if err := json.Unmarshal(data, &index); err != nil {
+		t.Fatalf("index was not rewritten: %v", err)
+	}
+	if _, ok := index[sha]; !ok {
+		t.Fatal("expected the binary in the rewritten index")
+	}
+}
+
+func TestGetBinaryFailsWithoutFreeDiskSpace(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := freeDiskSpace(dir); err != nil {
+		t.Skipf("free disk space not available: %v", err)
+	}
+	cached := writeCachedBinary(t, dir, []byte("#!/bin/sh\necho a\n"))
+
+	var downloads atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			downloads.Add(1)
+		}
+		w.Write([]byte("#!/bin/sh\necho b\n"))
+	}))
+	defer srv.Close()
+
+	// No disk has a petabyte to spare
+	cache, err := NewBinaryCache(dir, 100, 1<<30)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = cache.GetBinary(context.Background(), srv.URL, strings.Repeat("0", 64), nil)
+	if !errors.Is(err, ErrInsufficientDiskSpace) {
+		t.Fatalf("expected insufficient disk space, got %v", err)
+	}
+	if n := downloads.Load(); n != 0 {
+		t.Fatalf("expected no download, got %d", n)
+	}
+	if _, ok := cache.entries[cached]; ok {
+		t.Fatal("expected cached binaries to be evicted to make room")
+	}
+}
+
+func TestPruneRemovesBinariesNotUsedRecently(t *testing.T) {
+	dir := t.TempDir()
+	old := writeCachedBinary(t, dir, []byte("#!/bin/sh\necho old\n"))
+	recent := writeCachedBinary(t, dir, []byte("#!/bin/sh\necho recent\n"))
+
+	modTime := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, old), modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := NewBinaryCache(dir, 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pruned := cache.Prune(24 * time.Hour)
+	if len(pruned) != 1 || pruned[0].SHA256 != old {
+		t.Fatalf("expected only %s to be pruned, got %+v", old, pruned)
+	}
+	if _, err := os.Stat(filepath.Join(dir, old)); !os.IsNotExist(err) {
+		t.Fatalf("expected the pruned binary to be deleted, got %v", err)
+	}
+
+	listed := cache.List()
+	if len(listed) != 1 || listed[0].SHA256 != recent {
+		t.Fatalf("expected only %s to be left, got %+v", recent, listed)
+	}
+}
+
+func TestVerifyRemovesCorruptBinaries(t *testing.T) {
+	dir := t.TempDir()
+	intact := writeCachedBinary(t, dir, []byte("#!/bin/sh\necho intact\n"))
+	corrupt := writeCachedBinary(t, dir, []byte("#!/bin/sh\necho corrupt\n"))
+	if err := os.WriteFile(filepath.Join(dir, corrupt), []byte("garbage"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := NewBinaryCache(dir, 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	removed := cache.Verify()
+	if len(removed) != 1 || removed[0].SHA256 != corrupt {
+		t.Fatalf("expected only %s to be removed, got %+v", corrupt, removed)
+	}
+
+	listed := cache.List()
+	if len(listed) != 1 || listed[0].SHA256 != intact {
+		t.Fatalf("expected only %s to be left, got %+v", intact, listed)
+	}
+}
+
+func TestGetBinaryReportsCacheMetrics(t *testing.T) {
+	content := []byte("#!/bin/sh\necho metrics\n")
+	sum := sha256.Sum256(content)
+	sha := hex.EncodeToString(sum[:])
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(content)
+	}))
+	defer srv.Close()
+
+	cache, err := NewBinaryCache(t.TempDir(), 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.executorID = "metrics-test"
+
+	for i := 0; i < 2; i++ {
+		if _, err := cache.GetBinary(context.Background(), srv.URL, sha, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := testutil.ToFloat64(metrics.BinaryCacheMisses.WithLabelValues("metrics-test")); got != 1 {
+		t.Errorf("got %v misses, want 1", got)
+	}
+	if got := testutil.ToFloat64(metrics.BinaryCacheHits.WithLabelValues("metrics-test")); got != 1 {
+		t.Errorf("got %v hits, want 1", got)
+	}
+	if got := testutil.ToFloat64(metrics.BinaryCacheSize.WithLabelValues("metrics-test")); got != float64(len(content)) {
+		t.Errorf("got cache size %v, want %d", got, len(content))
+	}
+	if n := testutil.CollectAndCount(metrics.BinaryDownloadDuration, "executr_binary_download_duration_seconds"); n == 0 {
+		t.Error("expected the download duration to be observed")
+	}
+}
+
+func TestGetBinaryLimitsConcurrentDownloads(t *testing.T) {
+	const limit = 2
+	const binaries = 6
+
+	var inFlight, maxInFlight atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		// Slow enough for downloads to overlap without the limit
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer srv.Close()
+
+	cache, err := NewBinaryCache(t.TempDir(), 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.SetMaxConcurrentDownloads(limit)
+
+	var wg sync.WaitGroup
+	errs := make([]error, binaries)
+	for i := 0; i < binaries; i++ {
+		path := "/binary-" + string(rune('a'+i))
+		sum := sha256.Sum256([]byte(path))
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = cache.GetBinary(context.Background(), srv.URL+path, hex.EncodeToString(sum[:]), nil)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("binary %d: %v", i, err)
+		}
+	}
+	if n := maxInFlight.Load(); n > limit {
+		t.Fatalf("expected at most %d concurrent downloads, got %d", limit, n)
+	}
+}
+
+// digestSource serves binaries identified by a digest that can change, like
+// an image tag pointing to a new image
+type digestSource struct {
+	mu      sync.Mutex
+	digest  string
+	content []byte
+	fetches int
+}
+
+func (s *digestSource) Digest(ctx context.Context, binaryURL string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.digest, nil
+}
+
+func (s *digestSource) Size(ctx context.Context, binaryURL string) int64 {
+	return 0
+}
+
+func (s *digestSource) Fetch(ctx context.Context, binaryURL, digest, destPath, expectedSHA256 string) (string, error) {
+	s.mu.Lock()
+	s.fetches++
+	content := s.content
+	s.mu.Unlock()
+
+	if err := os.WriteFile(destPath, content, 0755); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func TestGetBinaryCachesBinariesByDigest(t *testing.T) {
+	dir := t.TempDir()
+	source := &digestSource{digest: "sha256:aaaa#/tool", content: []byte("v1")}
+
+	cache, err := NewBinaryCache(dir, 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.RegisterSource("fake", source)
+
+	first, err := cache.GetBinary(context.Background(), "fake://tool:latest", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.GetBinary(context.Background(), "fake://tool:latest", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if source.fetches != 1 {
+		t.Fatalf("expected 1 fetch, got %d", source.fetches)
+	}
+
+	// The digest survives restarts in the cache index
+	cache, err = NewBinaryCache(dir, 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.RegisterSource("fake", source)
+	if path, err := cache.GetBinary(context.Background(), "fake://tool:latest", "", nil); err != nil || path != first {
+		t.Fatalf("got %s, %v, want %s", path, err, first)
+	}
+	if source.fetches != 1 {
+		t.Fatalf("expected a cache hit after restart, got %d fetches", source.fetches)
+	}
+
+	// A new digest is fetched again
+	source.digest, source.content = "sha256:bbbb#/tool", []byte("v2")
+	second, err := cache.GetBinary(context.Background(), "fake://tool:latest", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source.fetches != 2 || second == first {
+		t.Fatalf("expected the new binary to be fetched, got %d fetches", source.fetches)
+	}
+	if content, _ := os.ReadFile(second); string(content) != "v2" {
+		t.Fatalf("got %q, want v2", content)
+	}
+}
+
+func TestGetBinaryRejectsUnsupportedSchemes(t *testing.T) {
+	cache, err := NewBinaryCache(t.TempDir(), 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.GetBinary(context.Background(), "ftp://example.com/tool", strings.Repeat("a", 64), nil); err == nil {
+		t.Fatal("expected error for an unsupported scheme")
+	}
+}
+
+func TestNewBinaryCacheRemovesStalePartialDownloads(t *testing.T) {
+	dir := t.TempDir()
+	stale := filepath.Join(dir, ".download-"+strings.Repeat("a", 64))
+	fresh := filepath.Join(dir, ".download-"+strings.Repeat("b", 64))
+	for _, path := range []string{stale, fresh} {
+		if err := os.WriteFile(path, []byte("partial"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-2 * staleDownloadAge)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewBinaryCache(dir, 100, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("expected stale partial download to be removed, got %v", err)
+	}
+	if _, err := os.Stat(fresh); err != nil {
+		t.Errorf("expected recent partial download to be kept for resuming: %v", err)
+	}
+}
\ No newline at end of file
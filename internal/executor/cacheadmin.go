@@ -0,0 +1,97 @@
+package executor
+
+import (
+	"log/slog"
+	"time"
+)
+
+// CachedBinary describes a binary in the cache
+type CachedBinary struct {
+	SHA256     string    `json:"sha256"`
+	Size       int64     `json:"size"`
+	LastAccess time.Time `json:"last_access"`
+}
+
+// List returns the cached binaries, least recently used first
+func (c *BinaryCache) List() []CachedBinary {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return describeEntries(c.lruEntries())
+}
+
+// Prune removes the binaries that weren't used within olderThan and returns
+// them
+func (c *BinaryCache) Prune(olderThan time.Duration) []CachedBinary {
+	cutoff := time.Now().Add(-olderThan)
+
+	c.mu.Lock()
+	var pruned []*cacheEntry
+	for _, entry := range c.lruEntries() {
+		if !entry.lastAccess.Before(cutoff) {
+			break
+		}
+		c.remove(entry)
+		pruned = append(pruned, entry)
+	}
+	c.mu.Unlock()
+	c.saveIndex()
+
+	return describeEntries(pruned)
+}
+
+// PruneToSize removes least recently used binaries until the cache holds at
+// most maxSizeMB and returns them
+func (c *BinaryCache) PruneToSize(maxSizeMB int) []CachedBinary {
+	c.mu.Lock()
+	evicted := c.evictTo(int64(maxSizeMB) * 1024 * 1024)
+	c.mu.Unlock()
+	c.saveIndex()
+
+	return describeEntries(evicted)
+}
+
+// Verify re-hashes every cached binary, removes the ones whose content no
+// longer matches their SHA256 and returns them. Like cache hits, hashing
+// happens without holding the lock.
+func (c *BinaryCache) Verify() []CachedBinary {
+	c.mu.RLock()
+	entries := c.lruEntries()
+	c.mu.RUnlock()
+
+	var corrupt []*cacheEntry
+	for _, entry := range entries {
+		err := c.verifySHA256(entry.path, entry.sha256)
+		if err == nil {
+			continue
+		}
+
+		slog.Warn("Cached binary is corrupt, removing from cache",
+			"sha256", entry.sha256,
+			"path", entry.path,
+			"error", err,
+		)
+		c.mu.Lock()
+		if c.entries[entry.sha256] == entry {
+			c.remove(entry)
+			corrupt = append(corrupt, entry)
+		}
+		c.mu.Unlock()
+	}
+	if len(corrupt) > 0 {
+		c.saveIndex()
+	}
+
+	return describeEntries(corrupt)
+}
+
+func describeEntries(entries []*cacheEntry) []CachedBinary {
+	binaries := make([]CachedBinary, 0, len(entries))
+	for _, entry := range entries {
+		binaries = append(binaries, CachedBinary{
+			SHA256:     entry.sha256,
+			Size:       entry.size,
+			LastAccess: entry.lastAccess,
+		})
+	}
+	return binaries
+}
\ No newline at end of file
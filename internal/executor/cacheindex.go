@@ -0,0 +1,101 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/draganm/executr/internal/metrics"
+)
+
+// cacheIndexFile records the size and last access time of each cached
+// binary. File times are not a reliable source for that: modification is
+// not access, and not every filesystem keeps access times.
+const cacheIndexFile = ".index.json"
+
+type cacheIndexEntry struct {
+	Size       int64     `json:"size"`
+	LastAccess time.Time `json:"last_access"`
+	Digests    []string  `json:"digests,omitempty"` // e.g. image digests resolving to the binary
+}
+
+// readIndex returns the recorded cache index. A missing or corrupt index
+// yields nil, in which case file modification times are used instead.
+func (c *BinaryCache) readIndex() map[string]cacheIndexEntry {
+	data, err := os.ReadFile(filepath.Join(c.cacheDir, cacheIndexFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("Failed to read cache index", "error", err)
+		}
+		return nil
+	}
+
+	var index map[string]cacheIndexEntry
+	if err := json.Unmarshal(data, &index); err != nil {
+		slog.Warn("Cache index is corrupt, using file times", "error", err)
+		return nil
+	}
+	return index
+}
+
+// saveIndex writes the cache index. The file is replaced atomically, so a
+// crash leaves either the old or the new index behind. It runs after every
+// change to the entries, so it also reports the cache size.
+func (c *BinaryCache) saveIndex() {
+	c.indexMu.Lock()
+	defer c.indexMu.Unlock()
+
+	c.mu.RLock()
+	index := make(map[string]cacheIndexEntry, len(c.entries))
+	var totalSize int64
+	for sha, entry := range c.entries {
+		index[sha] = cacheIndexEntry{Size: entry.size, LastAccess: entry.lastAccess, Digests: entry.digests}
+		totalSize += entry.size
+	}
+	c.mu.RUnlock()
+	c.reportSize(totalSize)
+
+	if err := writeFileAtomic(filepath.Join(c.cacheDir, cacheIndexFile), index); err != nil {
+		slog.Warn("Failed to save cache index", "error", err)
+	}
+}
+
+// reportSize sets the cache size metric. Caches that don't belong to an
+// executor, like the ones opened by the cache command, aren't reported.
+func (c *BinaryCache) reportSize(totalSize int64) {
+	if c.executorID == "" {
+		return
+	}
+	metrics.BinaryCacheSize.WithLabelValues(c.executorID).Set(float64(totalSize))
+}
+
+// writeFileAtomic writes v as JSON to a temporary file and renames it to path
+func writeFileAtomic(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
\ No newline at end of file
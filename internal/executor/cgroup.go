@@ -0,0 +1,32 @@
+package executor
+
+import (
+	"errors"
+	"log/slog"
+)
+
+// cgroup creates the cgroup enforcing the job's CPU and memory limits. It
+// returns nil, and the job runs without limits, when the job has none, no
+// CgroupParent is configured or cgroups v2 are not available.
+func (r *JobRunner) cgroup() *jobCgroup {
+	if r.CgroupParent == "" || (r.CPUMillicores <= 0 && r.MemLimitBytes <= 0) {
+		return nil
+	}
+
+	c, err := newJobCgroup(r.CgroupParent, r.JobID, r.CPUMillicores, r.MemLimitBytes)
+	if errors.Is(err, errors.ErrUnsupported) {
+		slog.Warn("cgroups v2 not available, running job without resource limits",
+			"job_id", r.JobID,
+			"cgroup_parent", r.CgroupParent,
+		)
+		return nil
+	}
+	if err != nil {
+		slog.Warn("Failed to create job cgroup, running job without resource limits",
+			"job_id", r.JobID,
+			"error", err,
+		)
+		return nil
+	}
+	return c
+}
\ No newline at end of file
@@ -0,0 +1,145 @@
+//go:build linux
+
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// cgroupCPUPeriod is the cpu.max period in microseconds; a job gets
+	// millicores/1000 of it per period
+	cgroupCPUPeriod = 100000
+	// cgroupMinCPUQuota is the smallest cpu.max quota the kernel accepts
+	cgroupMinCPUQuota = 1000
+)
+
+// jobCgroup is a cgroup v2 directory holding the processes of a single job
+type jobCgroup struct {
+	path string
+}
+
+// newJobCgroup creates a cgroup for a job below parent and applies its
+// limits. It returns errors.ErrUnsupported when parent is not a cgroup v2
+// directory. Parent must not contain processes itself, as cgroup v2 only
+// allows controllers to be delegated from cgroups without processes.
+func newJobCgroup(parent, jobID string, cpuMillicores int, memLimitBytes int64) (*jobCgroup, error) {
+	if _, err := os.Stat(filepath.Join(parent, "cgroup.controllers")); err != nil {
+		return nil, errors.ErrUnsupported
+	}
+
+	var controllers []string
+	if cpuMillicores > 0 {
+		controllers = append(controllers, "+cpu")
+	}
+	if memLimitBytes > 0 {
+		controllers = append(controllers, "+memory")
+	}
+	if err := os.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte(strings.Join(controllers, " ")), 0644); err != nil {
+		return nil, fmt.Errorf("failed to enable cgroup controllers: %w", err)
+	}
+
+	c := &jobCgroup{path: filepath.Join(parent, "job-"+jobID)}
+	if err := os.Mkdir(c.path, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cgroup: %w", err)
+	}
+
+	if cpuMillicores > 0 {
+		quota := max(cpuMillicores*cgroupCPUPeriod/1000, cgroupMinCPUQuota)
+		if err := c.write("cpu.max", fmt.Sprintf("%d %d", quota, cgroupCPUPeriod)); err != nil {
+			c.remove()
+			return nil, err
+		}
+	}
+	if memLimitBytes > 0 {
+		if err := c.write("memory.max", strconv.FormatInt(memLimitBytes, 10)); err != nil {
+			c.remove()
+			return nil, err
+		}
+		// Keep the job from swapping instead of hitting the limit; the file
+		// only exists with swap accounting
+		c.write("memory.swap.max", "0")
+	}
+
+	return c, nil
+}
+
+func (c *jobCgroup) write(file, value string) error {
+	if err := os.WriteFile(filepath.Join(c.path, file), []byte(value), 0644); err != nil {
+		return fmt.Errorf("failed to set cgroup %s: %w", file, err)
+	}
+	return nil
+}
+
+// start starts cmd inside the cgroup, so the limits apply to the job from
+// its first instruction on. It returns errors.ErrUnsupported when the kernel
+// can't start processes in a cgroup (before Linux 5.7) or the cgroup can't be
+// opened; as cmd can't be started again, the caller then starts a new
+// command and adds it.
+func (c *jobCgroup) start(cmd *exec.Cmd) error {
+	dir, err := os.Open(c.path)
+	if err != nil {
+		return errors.ErrUnsupported
+	}
+	defer dir.Close()
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(dir.Fd())
+
+	// Kernels without clone3 or without its cgroup argument reject the call
+	err = cmd.Start()
+	if errors.Is(err, syscall.ENOSYS) || errors.Is(err, syscall.E2BIG) {
+		return errors.ErrUnsupported
+	}
+	return err
+}
+
+// add moves a process into the cgroup. Processes it starts afterwards are
+// placed there as well.
+func (c *jobCgroup) add(pid int) error {
+	return c.write("cgroup.procs", strconv.Itoa(pid))
+}
+
+// oomKilled reports whether the kernel killed a process of the job for
+// exceeding the memory limit
+func (c *jobCgroup) oomKilled() bool {
+	data, err := os.ReadFile(filepath.Join(c.path, "memory.events"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if count, ok := strings.CutPrefix(line, "oom_kill "); ok {
+			n, _ := strconv.Atoi(count)
+			return n > 0
+		}
+	}
+	return false
+}
+
+// remove kills processes left in the cgroup and deletes it
+func (c *jobCgroup) remove() {
+	// cgroup.kill needs Linux 5.14; without it leftovers keep the cgroup busy
+	c.write("cgroup.kill", "1")
+
+	// Killed processes leave the cgroup asynchronously
+	var err error
+	for i := 0; i < 20; i++ {
+		if err = os.Remove(c.path); err == nil || errors.Is(err, os.ErrNotExist) {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	slog.Warn("Failed to remove job cgroup", "path", c.path, "error", err)
+}
\ No newline at end of file
@@ -0,0 +1,34 @@
+//go:build linux
+
+package executor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewJobCgroupWithoutCgroupV2(t *testing.T) {
+	_, err := newJobCgroup(t.TempDir(), "job", 500, 64*1024*1024)
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("expected errors.ErrUnsupported, got %v", err)
+	}
+}
+
+func TestJobCgroupOOMKilled(t *testing.T) {
+	c := &jobCgroup{path: t.TempDir()}
+	events := filepath.Join(c.path, "memory.events")
+
+	for content, want := range map[string]bool{
+		"low 0\nhigh 0\nmax 3\noom 1\noom_kill 0\n": false,
+		"low 0\nhigh 0\nmax 3\noom 1\noom_kill 1\n": true,
+	} {
+		if err := os.WriteFile(events, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if got := c.oomKilled(); got != want {
+			t.Errorf("%q: got %v, want %v", content, got, want)
+		}
+	}
+}
\ No newline at end of file
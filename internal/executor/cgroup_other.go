@@ -0,0 +1,24 @@
+//go:build !linux
+
+package executor
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// jobCgroup is not supported on this platform; jobs run without resource
+// limits
+type jobCgroup struct{}
+
+func newJobCgroup(parent, jobID string, cpuMillicores int, memLimitBytes int64) (*jobCgroup, error) {
+	return nil, errors.ErrUnsupported
+}
+
+func (c *jobCgroup) start(cmd *exec.Cmd) error { return errors.ErrUnsupported }
+
+func (c *jobCgroup) add(pid int) error { return nil }
+
+func (c *jobCgroup) oomKilled() bool { return false }
+
+func (c *jobCgroup) remove() {}
\ No newline at end of file
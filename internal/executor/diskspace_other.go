@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd)
+
+package executor
+
+import "errors"
+
+// freeDiskSpace is not supported on this platform; the free space guard of
+// the binary cache is skipped
+func freeDiskSpace(path string) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
\ No newline at end of file
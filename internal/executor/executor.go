@@ -2,35 +2,112 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/draganm/executr/internal/models"
+	"github.com/draganm/executr/internal/tracing"
+	"github.com/draganm/executr/internal/utils"
 	"github.com/draganm/executr/pkg/client"
+	"github.com/draganm/executr/pkg/models"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Config struct {
 	ServerURL         string
+	APIKey            string
 	Name              string
 	CacheDir          string
 	WorkDir           string
 	MaxJobs           int
 	PollInterval      int
+	ClaimWait         int // seconds a claim waits on the server for a job; 0 only polls every PollInterval
 	MaxCacheSize      int
+	MinFreeDiskMB     int    // free disk space kept on the cache filesystem, 0 disables the check
+	MaxDownloads      int    // binaries downloaded at the same time, 0 for no limit
+	DownloadTimeout   int    // seconds a binary download may take, 0 for no limit
+	BinaryAuthConfig  string // download credentials file by URL prefix (see utils.LoadDownloadCredentials), for binaries, signatures and input files
+	MaxInputFileMB    int    // size limit of each input file, DefaultMaxInputFileMB when 0
+	MaxInputFilesMB   int    // size limit of all input files of a job, DefaultMaxInputFilesMB when 0
+	MaxArtifactsMB    int    // size limit of all artifacts of a job, DefaultMaxArtifactsMB when 0
 	HeartbeatInterval int
 	NetworkTimeout    int
+	Jitter            float64  // fraction by which poll and heartbeat intervals are randomized, e.g. 0.2 for ±20%
+	BreakerThreshold  int      // failed claims in a row that stop polling for BreakerCooldown; 0 disables
+	BreakerCooldown   int      // seconds, DefaultBreakerCooldown when 0
+	MaxOutputSize     int      // bytes per stream for jobs without max_output_bytes
+	RequireSignatures bool     // fail jobs whose binary has no verified signature
+	Capabilities      []string // advertised when claiming, e.g. gpu, avx512
+	DrainTimeout      int      // seconds to wait for running jobs when draining; 0 waits indefinitely
+	MetricsAddr       string   // serves Prometheus metrics on /metrics when set, e.g. :9090
+	AdvertiseAddr     string   // registered address of the metrics listener, defaults to the host name and metrics port
+	AdvertiseIP       string   // reported with claims as executor_ip, discovered from the route to the server when empty
+	CgroupParent      string   // cgroup v2 directory jobs with CPU or memory limits run below (Linux only); empty ignores the limits
+	InheritEnv        []string // host environment variables passed to jobs, e.g. DefaultInheritEnv; empty passes none
+	SecretsDir        string   // directory of secret files resolving secret:// env variables, unless SecretResolver is set
+	
+	// SecretResolver resolves secret:// env variables, e.g. from Vault.
+	// Jobs referencing secrets fail when neither it nor SecretsDir is set.
+	SecretResolver SecretResolver
+	
+	// AllowedBinaryRoots are the directories file:// binaries may be
+	// copied from, e.g. an NFS mount. file:// binaries are rejected when
+	// it is empty.
+	AllowedBinaryRoots []string
+	
+	// BinarySources fetch binaries of further URL schemes, keyed by scheme
+	// (e.g. "s3"), or replace the built-in http, https, oci and file
+	// sources
+	BinarySources map[string]BinarySource
+	
+	// TracerProvider records a span for each job execution, continuing the
+	// trace the job was submitted in, and for the requests to the server.
+	// nil uses the global provider, which records nothing unless set with
+	// otel.SetTracerProvider.
+	TracerProvider trace.TracerProvider
+	
+	// Version is the build of the executor, logged at startup
+	Version string
+	
+	// Optional S3-compatible storage for full job output
+	OutputStoreURL       string
+	OutputStoreBucket    string
+	OutputStoreRegion    string
+	OutputStoreAccessKey string
+	OutputStoreSecretKey string
 }
 
+// DefaultInheritEnv lists the host environment variables the executor
+// command passes to jobs unless configured otherwise. Job env variables
+// override them.
+var DefaultInheritEnv = []string{"PATH", "HOME", "TMPDIR"}
+
+// DefaultBreakerCooldown is how many seconds the executor stops polling
+// after BreakerThreshold failed claims, unless configured otherwise
+const DefaultBreakerCooldown = 30
+
 type Executor struct {
 	cfg        *Config
 	client     client.Client
-	cache      *BinaryCache
-	executorID string
+	breaker    *utils.CircuitBreaker // guards job claims
+	cache       *BinaryCache
+	downloader  *utils.BinaryDownloader // fetches input files
+	outputStore OutputStore             // nil when output is sent inline
+	secrets     SecretResolver          // nil when no secret backend is configured
+	tracer      trace.Tracer
+	executorID  string
+	executorIP  string // reported with claims, see discoverIP
 	
 	// Job tracking
 	runningJobs sync.Map
@@ -40,41 +117,125 @@ type Executor struct {
 	ctx    context.Context
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
+	
+	// Drain coordination
+	drainCh      chan struct{}
+	drainOnce    sync.Once
+	draining     atomic.Bool
+	drainExpired atomic.Bool // set when running jobs were killed by the drain timeout
+}
+
+// ExpandHome replaces a leading ~/ in path with the user's home directory
+func ExpandHome(path string) (string, error) {
+	if !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, path[2:]), nil
 }
 
 func New(cfg *Config) (*Executor, error) {
-	// Expand home directory in cache dir
-	if cfg.CacheDir[:2] == "~/" {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get home directory: %w", err)
+	if cfg.Jitter < 0 || cfg.Jitter >= 1 {
+		return nil, fmt.Errorf("jitter must be at least 0 and less than 1, got %v", cfg.Jitter)
+	}
+	if cfg.AdvertiseIP != "" && net.ParseIP(cfg.AdvertiseIP) == nil {
+		return nil, fmt.Errorf("invalid advertise IP %q", cfg.AdvertiseIP)
+	}
+	for _, root := range cfg.AllowedBinaryRoots {
+		if !filepath.IsAbs(root) {
+			return nil, fmt.Errorf("allowed binary root %q must be an absolute path", root)
 		}
-		cfg.CacheDir = filepath.Join(home, cfg.CacheDir[2:])
 	}
 	
+	// Expand home directory in cache dir
+	cacheDir, err := ExpandHome(cfg.CacheDir)
+	if err != nil {
+		return nil, err
+	}
+	cfg.CacheDir = cacheDir
+	
 	// Generate unique executor ID
 	executorID := fmt.Sprintf("%s-%s", cfg.Name, uuid.New().String()[:8])
 	
-	// Create client
-	c := client.New(cfg.ServerURL)
+	// Create client; the user agent attributes its claims and heartbeats in
+	// the server's access logs to this executor
+	c := client.New(cfg.ServerURL,
+		client.WithAPIKey(cfg.APIKey),
+		client.WithTracerProvider(cfg.TracerProvider),
+		client.WithUserAgent(fmt.Sprintf("%s (executor %s)", utils.DefaultUserAgent, cfg.Name)),
+	)
 	
 	// Create binary cache
-	cache, err := NewBinaryCache(cfg.CacheDir, cfg.MaxCacheSize)
+	cache, err := NewBinaryCache(cfg.CacheDir, cfg.MaxCacheSize, cfg.MinFreeDiskMB)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create binary cache: %w", err)
 	}
+	cache.executorID = executorID
+	cache.SetMaxConcurrentDownloads(cfg.MaxDownloads)
+	cache.SetDownloadTimeout(time.Duration(cfg.DownloadTimeout) * time.Second)
+
+	// Credentials for downloads from authenticated artifact registries
+	var downloadCredentials *utils.DownloadCredentials
+	if cfg.BinaryAuthConfig != "" {
+		if downloadCredentials, err = utils.LoadDownloadCredentials(cfg.BinaryAuthConfig); err != nil {
+			return nil, err
+		}
+		slog.Info("Authenticated downloads enabled", "url_prefixes", downloadCredentials.Len())
+	}
+	cache.SetDownloadCredentials(downloadCredentials)
+	cache.RegisterSource("file", &fileSource{roots: cfg.AllowedBinaryRoots})
+	for scheme, source := range cfg.BinarySources {
+		cache.RegisterSource(scheme, source)
+	}
+	downloader := utils.NewBinaryDownloader()
+	downloader.SetCredentials(downloadCredentials)
+	cache.saveIndex() // reports the size of binaries cached by earlier runs
 	
 	// Create work directory
 	if err := os.MkdirAll(cfg.WorkDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create work directory: %w", err)
 	}
 	
+	// Create output store if configured
+	var outputStore OutputStore
+	if cfg.OutputStoreURL != "" {
+		outputStore, err = utils.NewS3Store(
+			cfg.OutputStoreURL,
+			cfg.OutputStoreBucket,
+			cfg.OutputStoreRegion,
+			cfg.OutputStoreAccessKey,
+			cfg.OutputStoreSecretKey,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create output store: %w", err)
+		}
+	}
+	
+	// Resolve secrets from files unless another backend is given
+	secrets := cfg.SecretResolver
+	if secrets == nil && cfg.SecretsDir != "" {
+		secretsDir, err := ExpandHome(cfg.SecretsDir)
+		if err != nil {
+			return nil, err
+		}
+		secrets = &FileSecretResolver{Dir: secretsDir}
+	}
+	
 	return &Executor{
-		cfg:        cfg,
-		client:     c,
-		cache:      cache,
-		executorID: executorID,
-		jobSem:     make(chan struct{}, cfg.MaxJobs),
+		cfg:         cfg,
+		client:      c,
+		breaker:     newClaimBreaker(cfg),
+		cache:       cache,
+		downloader:  downloader,
+		outputStore: outputStore,
+		secrets:     secrets,
+		tracer:      tracing.Tracer(cfg.TracerProvider),
+		executorID:  executorID,
+		jobSem:      make(chan struct{}, cfg.MaxJobs),
+		drainCh:     make(chan struct{}),
 	}, nil
 }
 
@@ -85,29 +246,212 @@ func (e *Executor) Run(ctx context.Context) error {
 	slog.Info("Starting executor", 
 		"executor_id", e.executorID,
 		"name", e.cfg.Name,
+		"version", e.cfg.Version,
 		"max_jobs", e.cfg.MaxJobs,
 		"cache_dir", e.cfg.CacheDir,
 		"work_dir", e.cfg.WorkDir,
 	)
 	
+	// An unreachable server is retried, one that doesn't speak our API
+	// version never will be
+	if err := e.client.Ping(e.ctx); client.IsIncompatibleVersion(err) {
+		return err
+	}
+	e.clampMaxOutputSize()
+	
+	var source string
+	e.executorIP, source = e.discoverIP()
+	slog.Info("Reporting executor address", "executor_ip", e.executorIP, "source", source)
+	
 	// Clean up orphaned job directories from previous runs
 	e.cleanupOrphanedDirectories()
 	
+	stopMetrics := e.serveMetrics()
+	defer stopMetrics()
+	
+	// Register with the server and keep the registration alive until all
+	// jobs are done; a failed registration is retried on the next heartbeat
+	registered := e.register()
+	registrationCtx, stopRegistration := context.WithCancel(context.Background())
+	registrationDone := make(chan struct{})
+	go func() {
+		defer close(registrationDone)
+		e.sendExecutorHeartbeats(registrationCtx, registered)
+	}()
+	
 	// Start polling for jobs
 	e.wg.Add(1)
 	go e.pollForJobs()
 	
-	// Wait for shutdown signal
-	<-e.ctx.Done()
-	slog.Info("Shutting down executor, waiting for running jobs to complete...")
+	// Wait for shutdown or drain signal
+	select {
+	case <-e.ctx.Done():
+		slog.Info("Shutting down executor, waiting for running jobs to complete...")
+	case <-e.drainCh:
+		e.waitForDrain()
+	}
 	
 	// Wait for all jobs to complete
 	e.wg.Wait()
 	
+	stopRegistration()
+	<-registrationDone
+	e.deregister()
+	
 	slog.Info("Executor shutdown complete")
 	return nil
 }
 
+// clampMaxOutputSize lowers MaxOutputSize to the server's output ceiling, so
+// jobs don't produce output the server won't keep. It is left as is when the
+// server doesn't report a ceiling or can't be reached.
+func (e *Executor) clampMaxOutputSize() {
+	health, err := e.client.Health(e.ctx)
+	if err != nil || health.MaxOutputBytesLimit <= 0 {
+		return
+	}
+	
+	maxOutputSize := e.cfg.MaxOutputSize
+	if maxOutputSize <= 0 {
+		maxOutputSize = DefaultMaxOutputSize
+	}
+	if maxOutputSize > health.MaxOutputBytesLimit {
+		slog.Warn("max-output-size exceeds the server's output limit, using the server's limit",
+			"max_output_size", maxOutputSize,
+			"server_limit", health.MaxOutputBytesLimit,
+		)
+		e.cfg.MaxOutputSize = health.MaxOutputBytesLimit
+	}
+}
+
+// Drain stops the executor from claiming new jobs while letting running jobs
+// finish. Run returns once all running jobs are done, or fails the remaining
+// jobs once DrainTimeout has passed. Calling Drain more than once is a no-op.
+func (e *Executor) Drain() {
+	e.drainOnce.Do(func() {
+		slog.Info("Draining executor, no new jobs will be claimed", "executor_id", e.executorID)
+		e.draining.Store(true)
+		close(e.drainCh)
+	})
+}
+
+// Draining reports whether the executor has been asked to drain
+func (e *Executor) Draining() bool {
+	return e.draining.Load()
+}
+
+// waitForDrain waits for running jobs to finish, killing them once the drain
+// timeout expires
+func (e *Executor) waitForDrain() {
+	done := make(chan struct{})
+	go func() {
+		e.wg.Wait()
+		close(done)
+	}()
+	
+	var timeout <-chan time.Time
+	if e.cfg.DrainTimeout > 0 {
+		timer := time.NewTimer(time.Duration(e.cfg.DrainTimeout) * time.Second)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	
+	slog.Info("Waiting for running jobs to finish",
+		"running_jobs", e.runningJobCount(),
+		"drain_timeout", e.cfg.DrainTimeout,
+	)
+	
+	select {
+	case <-done:
+	case <-e.ctx.Done():
+		slog.Info("Shutting down executor while draining")
+	case <-timeout:
+		slog.Warn("Drain timeout exceeded, failing remaining jobs",
+			"running_jobs", e.runningJobCount(),
+			"drain_timeout", e.cfg.DrainTimeout,
+		)
+		e.drainExpired.Store(true)
+		e.cancel()
+	}
+}
+
+// runningJobCount returns the number of jobs currently executing
+func (e *Executor) runningJobCount() int {
+	count := 0
+	e.runningJobs.Range(func(_, _ any) bool {
+		count++
+		return true
+	})
+	return count
+}
+
+// register registers the executor with the server and reports whether it
+// succeeded
+func (e *Executor) register() bool {
+	_, err := e.client.RegisterExecutor(context.Background(), &models.ExecutorRegistration{
+		ExecutorID:    e.executorID,
+		Name:          e.cfg.Name,
+		Capabilities:  e.cfg.Capabilities,
+		MaxJobs:       e.cfg.MaxJobs,
+		AdvertiseAddr: e.advertiseAddr(),
+	})
+	if err != nil {
+		slog.Warn("Failed to register executor", "executor_id", e.executorID, "error", err)
+		return false
+	}
+	
+	slog.Info("Registered executor", "executor_id", e.executorID)
+	return true
+}
+
+// deregister removes the executor's registration on shutdown
+func (e *Executor) deregister() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	
+	if err := e.client.DeregisterExecutor(ctx, e.executorID); err != nil && !errors.Is(err, client.ErrExecutorNotRegistered) {
+		slog.Warn("Failed to deregister executor", "executor_id", e.executorID, "error", err)
+		return
+	}
+	slog.Info("Deregistered executor", "executor_id", e.executorID)
+}
+
+// sendExecutorHeartbeats keeps the executor's registration alive, even while
+// it is idle, re-registering when the server no longer knows it
+func (e *Executor) sendExecutorHeartbeats(ctx context.Context, registered bool) {
+	interval := time.Duration(e.cfg.HeartbeatInterval) * time.Second
+	timer := time.NewTimer(e.jittered(interval))
+	defer timer.Stop()
+	
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			timer.Reset(e.jittered(interval))
+			if !registered {
+				registered = e.register()
+				continue
+			}
+			
+			heartbeat := &models.ExecutorHeartbeatRequest{}
+			heartbeat.CPUPercent, heartbeat.MemBytes = sampleUsage()
+			err := e.client.ExecutorHeartbeat(ctx, e.executorID, heartbeat)
+			switch {
+			case errors.Is(err, client.ErrExecutorNotRegistered):
+				slog.Warn("Executor registration lost, registering again", "executor_id", e.executorID)
+				registered = e.register()
+			case err != nil:
+				if ctx.Err() == nil {
+					slog.Error("Failed to send executor heartbeat", "error", err)
+				}
+			default:
+				slog.Debug("Executor heartbeat sent")
+			}
+		}
+	}
+}
+
 func (e *Executor) cleanupOrphanedDirectories() {
 	entries, err := os.ReadDir(e.cfg.WorkDir)
 	if err != nil {
@@ -135,8 +479,9 @@ func (e *Executor) cleanupOrphanedDirectories() {
 func (e *Executor) pollForJobs() {
 	defer e.wg.Done()
 	
-	pollTicker := time.NewTicker(time.Duration(e.cfg.PollInterval) * time.Second)
-	defer pollTicker.Stop()
+	pollInterval := time.Duration(e.cfg.PollInterval) * time.Second
+	pollTimer := time.NewTimer(e.jittered(pollInterval))
+	defer pollTimer.Stop()
 	
 	networkFailureStart := time.Time{}
 	
@@ -144,13 +489,37 @@ func (e *Executor) pollForJobs() {
 		select {
 		case <-e.ctx.Done():
 			return
-		case <-pollTicker.C:
+		case <-e.drainCh:
+			return
+		case <-pollTimer.C:
+			pollTimer.Reset(e.jittered(pollInterval))
+			if e.Draining() {
+				return
+			}
+			
 			// Try to claim a job if we have capacity
 			select {
 			case e.jobSem <- struct{}{}:
+				// Don't poll a server that keeps failing
+				if !e.breaker.Allow() {
+					<-e.jobSem
+					continue
+				}
+				
 				job, err := e.claimJob()
+				if client.IsNoJobsAvailable(err) {
+					// The server is fine, it just has no work for us
+					<-e.jobSem
+					networkFailureStart = time.Time{}
+					e.breaker.Success()
+					continue
+				}
 				if err != nil {
 					<-e.jobSem // Release semaphore
+					if e.ctx.Err() != nil {
+						return
+					}
+					e.breaker.Failure()
 					
 					// Track network failures
 					if networkFailureStart.IsZero() {
@@ -168,14 +537,10 @@ func (e *Executor) pollForJobs() {
 				
 				// Reset network failure tracking on success
 				networkFailureStart = time.Time{}
+				e.breaker.Success()
 				
-				if job != nil {
-					e.wg.Add(1)
-					go e.executeJob(job)
-				} else {
-					// No job available, release semaphore
-					<-e.jobSem
-				}
+				e.wg.Add(1)
+				go e.executeJob(job)
 			default:
 				// At capacity, skip this poll
 				slog.Debug("At maximum job capacity, skipping poll")
@@ -184,51 +549,77 @@ func (e *Executor) pollForJobs() {
 	}
 }
 
-func (e *Executor) claimJob() (*models.Job, error) {
-	// Get executor's IP address
-	executorIP := e.getExecutorIP()
+// newClaimBreaker creates the circuit breaker that stops job claims while
+// the server keeps failing. Only claims go through it: job results are
+// still reported, as losing them would run the jobs again.
+func newClaimBreaker(cfg *Config) *utils.CircuitBreaker {
+	cooldown := cfg.BreakerCooldown
+	if cooldown <= 0 {
+		cooldown = DefaultBreakerCooldown
+	}
 	
-	job, err := e.client.ClaimNextJob(context.Background(), e.executorID, executorIP)
+	breaker := utils.NewCircuitBreaker(cfg.BreakerThreshold, time.Duration(cooldown)*time.Second)
+	breaker.OnStateChange(func(from, to utils.BreakerState) {
+		switch to {
+		case utils.BreakerOpen:
+			slog.Warn("Circuit open, backing off", "cooldown", cooldown, "failures", cfg.BreakerThreshold)
+		case utils.BreakerHalfOpen:
+			slog.Info("Circuit half-open, probing server")
+		case utils.BreakerClosed:
+			slog.Info("Circuit closed, server is reachable again")
+		}
+	})
+	return breaker
+}
+
+func (e *Executor) claimJob() (*models.Job, error) {
+	// Long-polling dispatches a submitted job right away instead of at the
+	// next poll
+	wait := time.Duration(e.cfg.ClaimWait) * time.Second
+	job, err := e.client.ClaimNextJobWithWait(e.ctx, e.executorID, e.executorIP, e.cfg.Capabilities, wait)
 	if err != nil {
 		return nil, err
 	}
 	
-	if job != nil {
-		slog.Info("Claimed job", 
-			"job_id", job.ID,
-			"type", job.Type,
-			"priority", job.Priority,
-		)
-	}
+	slog.Info("Claimed job", 
+		"job_id", job.ID,
+		"type", job.Type,
+		"priority", job.Priority,
+	)
 	
 	return job, nil
 }
 
-func (e *Executor) getExecutorIP() string {
-	// For now, return a placeholder. In production, we'd get the actual IP
-	// This could be enhanced to get the actual IP address
-	hostname, err := os.Hostname()
-	if err != nil {
-		return "unknown"
-	}
-	return hostname
-}
-
 func (e *Executor) executeJob(job *models.Job) {
 	defer e.wg.Done()
 	defer func() { <-e.jobSem }()
 	
 	slog.Info("Starting job execution", "job_id", job.ID)
 	
+	// Continue the trace the job was submitted in
+	ctx, span := e.tracer.Start(tracing.WithTraceParent(e.ctx, job.TraceParent), "execute job",
+		trace.WithAttributes(
+			attribute.String("executr.job_id", job.ID.String()),
+			attribute.String("executr.job_type", job.Type),
+		),
+	)
+	defer span.End()
+	
+	// Results are reported even when the executor shuts down meanwhile
+	reportCtx := context.WithoutCancel(ctx)
+	
 	// Store job in running jobs map
 	jobIDStr := job.ID.String()
 	e.runningJobs.Store(jobIDStr, job)
 	defer e.runningJobs.Delete(jobIDStr)
 	
-	// Start heartbeat
-	heartbeatCtx, cancelHeartbeat := context.WithCancel(e.ctx)
+	// Start heartbeat; it stops the job when the server reports that it was
+	// cancelled
+	runCtx, cancelRun := context.WithCancelCause(ctx)
+	defer cancelRun(nil)
+	heartbeatCtx, cancelHeartbeat := context.WithCancel(ctx)
 	defer cancelHeartbeat()
-	go e.sendHeartbeats(heartbeatCtx, jobIDStr)
+	go e.sendHeartbeats(heartbeatCtx, jobIDStr, cancelRun)
 	
 	// Create job working directory
 	jobDir := filepath.Join(e.cfg.WorkDir, jobIDStr)
@@ -237,7 +628,7 @@ func (e *Executor) executeJob(job *models.Job) {
 			"job_id", job.ID,
 			"error", err,
 		)
-		e.failJob(jobIDStr, &models.JobResult{
+		e.failJob(reportCtx, jobIDStr, &models.JobResult{
 			ExitCode: -1,
 			Stderr:   fmt.Sprintf("Failed to create job directory: %v", err),
 		})
@@ -255,30 +646,162 @@ func (e *Executor) executeJob(job *models.Job) {
 		}
 	}()
 	
+	// Provide the job's input files before the binary runs
+	if err := e.writeInputFiles(ctx, jobDir, job.InputFiles); err != nil {
+		slog.Error("Failed to prepare input files",
+			"job_id", job.ID,
+			"error", err,
+		)
+		e.failJob(reportCtx, jobIDStr, &models.JobResult{
+			ExitCode:     -1,
+			Stderr:       fmt.Sprintf("Failed to prepare input files: %v", err),
+			ErrorMessage: ErrInputFiles.Error(),
+		})
+		return
+	}
+	
+	// Resolve secret references, the values only go to the job's environment
+	envVars, err := e.resolveEnv(ctx, job.EnvVariables)
+	if err != nil {
+		slog.Error("Failed to resolve secrets",
+			"job_id", job.ID,
+			"error", err,
+		)
+		e.failJob(reportCtx, jobIDStr, &models.JobResult{
+			ExitCode:     -1,
+			Stderr:       fmt.Sprintf("Failed to resolve secrets: %v", err),
+			ErrorMessage: ErrSecrets.Error(),
+		})
+		return
+	}
+	
+	// Verify the binary signature when the job provides one
+	var sig *BinarySignature
+	if job.SignatureURL != "" {
+		sig = &BinarySignature{
+			URL:       job.SignatureURL,
+			PublicKey: job.PublicKey,
+		}
+	} else if e.cfg.RequireSignatures {
+		slog.Error("Job has no binary signature and signatures are required",
+			"job_id", job.ID,
+		)
+		e.failJob(reportCtx, jobIDStr, &models.JobResult{
+			ExitCode:     -1,
+			Stderr:       "Binary signature required but job has no signature_url",
+			ErrorMessage: ErrSignatureVerification.Error(),
+		})
+		return
+	}
+	
 	// Get binary from cache or download
-	binaryPath, err := e.cache.GetBinary(job.BinaryURL, job.BinarySHA256)
+	binaryPath, err := e.cache.GetBinary(ctx, job.BinaryURL, job.BinarySHA256, sig)
 	if err != nil {
 		slog.Error("Failed to get binary",
 			"job_id", job.ID,
 			"error", err,
 		)
-		e.failJob(jobIDStr, &models.JobResult{
+		result := &models.JobResult{
 			ExitCode: -1,
 			Stderr:   fmt.Sprintf("Failed to get binary: %v", err),
-		})
+		}
+		if errors.Is(err, ErrSignatureVerification) {
+			result.ErrorMessage = ErrSignatureVerification.Error()
+		}
+		if errors.Is(err, ErrInsufficientDiskSpace) {
+			result.ErrorMessage = ErrInsufficientDiskSpace.Error()
+		}
+		e.failJob(reportCtx, jobIDStr, result)
 		return
 	}
 	
+	// Resolve the output limit for this job
+	maxOutputSize := job.MaxOutputBytes
+	if maxOutputSize <= 0 {
+		maxOutputSize = e.cfg.MaxOutputSize
+	}
+	if maxOutputSize <= 0 {
+		maxOutputSize = DefaultMaxOutputSize
+	}
+	
+	// Stream output to the server while the job runs
+	streamer := newOutputStreamer(e.client, job.ID, e.executorID, maxOutputSize)
+	streamCtx, cancelStream := context.WithCancel(ctx)
+	defer cancelStream()
+	streamDone := make(chan struct{})
+	go func() {
+		defer close(streamDone)
+		streamer.Run(streamCtx)
+	}()
+	
 	// Execute the job
 	runner := &JobRunner{
-		JobID:      jobIDStr,
-		BinaryPath: binaryPath,
-		Arguments:  job.Arguments,
-		EnvVars:    job.EnvVariables,
-		WorkDir:    jobDir,
+		JobID:         jobIDStr,
+		BinaryPath:    binaryPath,
+		Arguments:     job.Arguments,
+		EnvVars:       envVars,
+		InheritEnv:    e.cfg.InheritEnv,
+		WorkDir:       jobDir,
+		ExecutorID:    e.executorID,
+		ServerURL:     e.cfg.ServerURL,
+		Timeout:       time.Duration(job.Timeout) * time.Second,
+		Stdin:         job.Stdin,
+		MaxOutputSize: maxOutputSize,
+		WorkDirQuota:  job.WorkdirQuotaBytes,
+		CgroupParent:  e.cfg.CgroupParent,
+		CPUMillicores: job.CPUMillicores,
+		MemLimitBytes: job.MemLimitBytes,
+		StdoutWriter:  streamer.Stdout(),
+		StderrWriter:  streamer.Stderr(),
+		ResultFile:    filepath.Join(jobDir, resultFileName),
 	}
 	
-	result := runner.Execute(e.ctx)
+	// Capture full output to files when it goes to an output store
+	var capture *outputCapture
+	if e.outputStore != nil {
+		capture, err = newOutputCapture(filepath.Join(e.cfg.WorkDir, jobIDStr+".output"))
+		if err != nil {
+			slog.Warn("Failed to capture output for output store, sending it inline",
+				"job_id", job.ID,
+				"error", err,
+			)
+		} else {
+			defer capture.Close()
+			runner.StdoutWriter = io.MultiWriter(runner.StdoutWriter, capture.stdout)
+			runner.StderrWriter = io.MultiWriter(runner.StderrWriter, capture.stderr)
+		}
+	}
+	
+	result := runner.Execute(runCtx)
+	if result.ExitCode != 0 && e.drainExpired.Load() {
+		result.ErrorMessage = "job killed: executor drain timeout exceeded"
+	}
+	span.SetAttributes(attribute.Int("executr.exit_code", result.ExitCode))
+	
+	cancelStream()
+	<-streamDone
+	
+	cancelled := errors.Is(context.Cause(runCtx), ErrJobCancelled)
+	
+	// Push any remaining live output before reporting the result; the
+	// server doesn't take live output of cancelled jobs anymore
+	if !cancelled {
+		streamer.Flush(true)
+	}
+	
+	if capture != nil {
+		e.uploadOutput(job.ID, capture, result)
+	}
+	
+	// A cancelled job keeps its status, only its output is reported
+	if cancelled {
+		span.SetStatus(codes.Error, ErrJobCancelled.Error())
+		e.reportCancelled(reportCtx, job.ID, result)
+		return
+	}
+	
+	// Collect the files the job left behind
+	artifacts := e.collectArtifacts(reportCtx, job, jobDir)
 	
 	// Report result to server
 	if result.ExitCode == 0 {
@@ -286,9 +809,13 @@ func (e *Executor) executeJob(job *models.Job) {
 			ExecutorID: e.executorID,
 			Stdout:     result.Stdout,
 			Stderr:     result.Stderr,
+			StdoutURL:  result.StdoutURL,
+			StderrURL:  result.StderrURL,
 			ExitCode:   result.ExitCode,
+			Artifacts:  artifacts,
+			ResultJSON: result.ResultJSON,
 		}
-		if err := e.client.CompleteJob(context.Background(), job.ID, completeReq); err != nil {
+		if err := e.client.CompleteJob(reportCtx, job.ID, completeReq); err != nil {
 			slog.Error("Failed to report job completion",
 				"job_id", job.ID,
 				"error", err,
@@ -300,14 +827,23 @@ func (e *Executor) executeJob(job *models.Job) {
 			)
 		}
 	} else {
+		errorMessage := "Job failed with non-zero exit code"
+		if result.ErrorMessage != "" {
+			errorMessage = result.ErrorMessage
+		}
 		failReq := &models.FailRequest{
 			ExecutorID:   e.executorID,
-			ErrorMessage: "Job failed with non-zero exit code",
+			ErrorMessage: errorMessage,
 			Stdout:       result.Stdout,
 			Stderr:       result.Stderr,
+			StdoutURL:    result.StdoutURL,
+			StderrURL:    result.StderrURL,
 			ExitCode:     result.ExitCode,
+			Artifacts:    artifacts,
+			ResultJSON:   result.ResultJSON,
 		}
-		if err := e.client.FailJob(context.Background(), job.ID, failReq); err != nil {
+		span.SetStatus(codes.Error, errorMessage)
+		if err := e.client.FailJob(reportCtx, job.ID, failReq); err != nil {
 			slog.Error("Failed to report job failure",
 				"job_id", job.ID,
 				"error", err,
@@ -321,48 +857,93 @@ func (e *Executor) executeJob(job *models.Job) {
 	}
 }
 
-func (e *Executor) sendHeartbeats(ctx context.Context, jobID string) {
-	ticker := time.NewTicker(time.Duration(e.cfg.HeartbeatInterval) * time.Second)
-	defer ticker.Stop()
+// reportCancelled sends the output of a job that was stopped because it was
+// cancelled while running
+func (e *Executor) reportCancelled(ctx context.Context, jobID uuid.UUID, result *models.JobResult) {
+	err := e.client.ReportCancelled(ctx, jobID, &models.CancelledRequest{
+		ExecutorID: e.executorID,
+		Stdout:     result.Stdout,
+		Stderr:     result.Stderr,
+		StdoutURL:  result.StdoutURL,
+		StderrURL:  result.StderrURL,
+		ExitCode:   result.ExitCode,
+	})
+	if err != nil {
+		slog.Error("Failed to report cancelled job",
+			"job_id", jobID,
+			"error", err,
+		)
+		return
+	}
+	slog.Info("Stopped cancelled job",
+		"job_id", jobID,
+		"exit_code", result.ExitCode,
+	)
+}
+
+// ErrJobCancelled stops a job that was cancelled on the server while it ran
+var ErrJobCancelled = errors.New("job cancelled")
+
+// sendHeartbeats keeps a running job alive on the server and calls stop with
+// ErrJobCancelled once the server reports that the job was cancelled
+func (e *Executor) sendHeartbeats(ctx context.Context, jobID string, stop context.CancelCauseFunc) {
+	interval := time.Duration(e.cfg.HeartbeatInterval) * time.Second
+	timer := time.NewTimer(e.jittered(interval))
+	defer timer.Stop()
 	
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
+		case <-timer.C:
+			timer.Reset(e.jittered(interval))
 			jobUUID, err := uuid.Parse(jobID)
 			if err != nil {
 				slog.Error("Invalid job ID", "job_id", jobID, "error", err)
 				continue
 			}
-			if err := e.client.Heartbeat(context.Background(), jobUUID, e.executorID); err != nil {
+			heartbeat := &models.HeartbeatRequest{ExecutorID: e.executorID}
+			heartbeat.CPUPercent, heartbeat.MemBytes = sampleUsage()
+			resp, err := e.client.Heartbeat(context.WithoutCancel(ctx), jobUUID, heartbeat)
+			if err != nil {
 				slog.Error("Failed to send heartbeat",
 					"job_id", jobID,
 					"error", err,
 				)
-			} else {
-				slog.Debug("Heartbeat sent", "job_id", jobID)
+				continue
+			}
+			if resp.CancelRequested {
+				slog.Info("Job was cancelled, stopping it", "job_id", jobID)
+				stop(ErrJobCancelled)
+				return
 			}
+			slog.Debug("Heartbeat sent", "job_id", jobID)
 		}
 	}
 }
 
-func (e *Executor) failJob(jobID string, result *models.JobResult) {
+func (e *Executor) failJob(ctx context.Context, jobID string, result *models.JobResult) {
 	jobUUID, err := uuid.Parse(jobID)
 	if err != nil {
 		slog.Error("Invalid job ID", "job_id", jobID, "error", err)
 		return
 	}
 	
+	errorMessage := result.Stderr
+	if result.ErrorMessage != "" {
+		errorMessage = result.ErrorMessage
+	}
+	
 	failReq := &models.FailRequest{
 		ExecutorID:   e.executorID,
-		ErrorMessage: result.Stderr,
+		ErrorMessage: errorMessage,
 		Stdout:       result.Stdout,
 		Stderr:       result.Stderr,
 		ExitCode:     result.ExitCode,
 	}
 	
-	if err := e.client.FailJob(context.Background(), jobUUID, failReq); err != nil {
+	trace.SpanFromContext(ctx).SetStatus(codes.Error, errorMessage)
+	if err := e.client.FailJob(ctx, jobUUID, failReq); err != nil {
 		slog.Error("Failed to report job failure",
 			"job_id", jobID,
 			"error", err,
@@ -0,0 +1,73 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draganm/executr/pkg/client"
+	"github.com/draganm/executr/pkg/models"
+)
+
+func TestHeartbeatStopsCancelledJob(t *testing.T) {
+	mock := client.NewMockClient()
+	mock.HeartbeatFunc = func(ctx context.Context, jobID uuid.UUID, heartbeat *models.HeartbeatRequest) (*models.HeartbeatResponse, error) {
+		return &models.HeartbeatResponse{CancelRequested: true}, nil
+	}
+	e := &Executor{
+		cfg:        &Config{HeartbeatInterval: 1},
+		client:     mock,
+		executorID: "test-executor",
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.sendHeartbeats(context.Background(), uuid.NewString(), cancel)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("heartbeats didn't stop after the job was cancelled")
+	}
+	if !errors.Is(context.Cause(ctx), ErrJobCancelled) {
+		t.Fatalf("expected the job to be stopped with ErrJobCancelled, got %v", context.Cause(ctx))
+	}
+}
+
+func TestMaxOutputSizeIsClampedToServerLimit(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxOutputSize int
+		serverLimit   int
+		want          int
+	}{
+		{"above the limit", 128 * 1024 * 1024, 64 * 1024 * 1024, 64 * 1024 * 1024},
+		{"default above the limit", 0, 1024, 1024},
+		{"below the limit", 1024, 64 * 1024 * 1024, 1024},
+		{"limit not reported", 128 * 1024 * 1024, 0, 128 * 1024 * 1024},
+	}
+	for _, tt := range tests {
+		mock := client.NewMockClient()
+		mock.HealthFunc = func(ctx context.Context) (*client.HealthResponse, error) {
+			return &client.HealthResponse{Status: "healthy", MaxOutputBytesLimit: tt.serverLimit}, nil
+		}
+		e := &Executor{
+			ctx:    context.Background(),
+			cfg:    &Config{MaxOutputSize: tt.maxOutputSize},
+			client: mock,
+		}
+
+		e.clampMaxOutputSize()
+		if e.cfg.MaxOutputSize != tt.want {
+			t.Errorf("%s: MaxOutputSize = %d, want %d", tt.name, e.cfg.MaxOutputSize, tt.want)
+		}
+	}
+}
\ No newline at end of file
@@ -0,0 +1,97 @@
+package executor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/draganm/executr/internal/utils"
+	"github.com/draganm/executr/pkg/models"
+)
+
+const (
+	// DefaultMaxInputFileMB is the default size limit of each input file
+	DefaultMaxInputFileMB = 100
+	// DefaultMaxInputFilesMB is the default size limit of all input files
+	// of a job together
+	DefaultMaxInputFilesMB = 500
+)
+
+// ErrInputFiles is reported when a job's input files can't be provided,
+// e.g. because a download failed, a file is too large or its SHA256 doesn't
+// match
+var ErrInputFiles = errors.New("input files could not be provided")
+
+// writeInputFiles writes a job's input files into its working directory,
+// downloading those given by URL. They are removed together with the
+// working directory.
+func (e *Executor) writeInputFiles(ctx context.Context, jobDir string, files map[string]models.InputFile) error {
+	maxFile := int64(e.cfg.MaxInputFileMB)
+	if maxFile <= 0 {
+		maxFile = DefaultMaxInputFileMB
+	}
+	maxFile *= 1024 * 1024
+	maxTotal := int64(e.cfg.MaxInputFilesMB)
+	if maxTotal <= 0 {
+		maxTotal = DefaultMaxInputFilesMB
+	}
+	maxTotal *= 1024 * 1024
+	remaining := maxTotal
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		file := files[name]
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("input file %q is outside the working directory", name)
+		}
+		path := filepath.Join(jobDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for input file %q: %w", name, err)
+		}
+
+		if remaining <= 0 {
+			return fmt.Errorf("input files exceed the total size limit of %d bytes", maxTotal)
+		}
+		limit := min(maxFile, remaining)
+		if file.URL != "" {
+			err := e.downloader.Download(ctx, file.URL, path, &utils.DownloadOptions{
+				SHA256:  file.SHA256,
+				MaxSize: limit,
+				Mode:    0644,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to download input file %q: %w", name, err)
+			}
+		} else {
+			if int64(len(file.Content)) > limit {
+				return fmt.Errorf("input file %q exceeds the size limit of %d bytes", name, limit)
+			}
+			if file.SHA256 != "" {
+				sum := sha256.Sum256(file.Content)
+				if hex.EncodeToString(sum[:]) != file.SHA256 {
+					return fmt.Errorf("input file %q does not match its SHA256", name)
+				}
+			}
+			if err := os.WriteFile(path, file.Content, 0644); err != nil {
+				return fmt.Errorf("failed to write input file %q: %w", name, err)
+			}
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to stat input file %q: %w", name, err)
+		}
+		remaining -= info.Size()
+	}
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,75 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/draganm/executr/internal/utils"
+	"github.com/draganm/executr/pkg/models"
+)
+
+func newInputFilesExecutor(maxFileMB, maxFilesMB int) *Executor {
+	return &Executor{
+		cfg:        &Config{MaxInputFileMB: maxFileMB, MaxInputFilesMB: maxFilesMB},
+		downloader: utils.NewBinaryDownloader(),
+	}
+}
+
+func TestWriteInputFiles(t *testing.T) {
+	remote := []byte("id,value\n1,42\n")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(remote)
+	}))
+	defer ts.Close()
+	sum := sha256.Sum256(remote)
+
+	jobDir := t.TempDir()
+	err := newInputFilesExecutor(0, 0).writeInputFiles(context.Background(), jobDir, map[string]models.InputFile{
+		"config.yaml":    {Content: []byte("key: value\n")},
+		"data/input.csv": {URL: ts.URL, SHA256: hex.EncodeToString(sum[:])},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, want := range map[string][]byte{
+		"config.yaml":    []byte("key: value\n"),
+		"data/input.csv": remote,
+	} {
+		got, err := os.ReadFile(filepath.Join(jobDir, name))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestWriteInputFilesRejectsInvalidFiles(t *testing.T) {
+	large := bytes.Repeat([]byte("x"), 700*1024)
+
+	tests := []struct {
+		name  string
+		files map[string]models.InputFile
+	}{
+		{"escaping path", map[string]models.InputFile{"../outside": {Content: []byte("x")}}},
+		{"sha256 mismatch", map[string]models.InputFile{"input": {Content: []byte("x"), SHA256: "deadbeef"}}},
+		{"file too large", map[string]models.InputFile{"input": {Content: append(large, large...)}}},
+		{"total too large", map[string]models.InputFile{"a": {Content: large}, "b": {Content: large}}},
+	}
+
+	for _, tt := range tests {
+		err := newInputFilesExecutor(1, 1).writeInputFiles(context.Background(), t.TempDir(), tt.files)
+		if err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
\ No newline at end of file
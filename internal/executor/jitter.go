@@ -0,0 +1,25 @@
+package executor
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// DefaultJitter is the fraction by which the CLI randomizes poll and
+// heartbeat intervals
+const DefaultJitter = 0.2
+
+// jitter randomizes interval by up to ±fraction of it, so executors started
+// together don't hit the server in lockstep
+func jitter(interval time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return interval
+	}
+	delta := (rand.Float64()*2 - 1) * fraction * float64(interval)
+	return interval + time.Duration(delta)
+}
+
+// jittered randomizes interval by the configured jitter
+func (e *Executor) jittered(interval time.Duration) time.Duration {
+	return jitter(interval, e.cfg.Jitter)
+}
\ No newline at end of file
@@ -0,0 +1,27 @@
+package executor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJitterStaysWithinFraction(t *testing.T) {
+	interval := 10 * time.Second
+	varied := false
+	for i := 0; i < 1000; i++ {
+		got := jitter(interval, 0.2)
+		if got < 8*time.Second || got > 12*time.Second {
+			t.Fatalf("got %s, want within 20%% of %s", got, interval)
+		}
+		if got != interval {
+			varied = true
+		}
+	}
+	if !varied {
+		t.Fatal("expected the interval to be randomized")
+	}
+
+	if got := jitter(interval, 0); got != interval {
+		t.Fatalf("got %s without jitter, want %s", got, interval)
+	}
+}
\ No newline at end of file
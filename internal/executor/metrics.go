@@ -0,0 +1,69 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// serveMetrics exposes the executor's Prometheus metrics, such as binary
+// cache hits and misses, and a /healthz endpoint on MetricsAddr. The
+// returned function stops the listener.
+func (e *Executor) serveMetrics() func() {
+	if e.cfg.MetricsAddr == "" {
+		return func() {}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	srv := &http.Server{
+		Addr:              e.cfg.MetricsAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		slog.Info("Serving executor metrics", "addr", e.cfg.MetricsAddr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Metrics server failed", "error", err)
+		}
+	}()
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}
+}
+
+// advertiseAddr returns the address registered with the server for the
+// metrics listener, so scrapers can discover the executor. Without an
+// explicit AdvertiseAddr, a wildcard listen address is combined with the
+// host name.
+func (e *Executor) advertiseAddr() string {
+	if e.cfg.AdvertiseAddr != "" || e.cfg.MetricsAddr == "" {
+		return e.cfg.AdvertiseAddr
+	}
+
+	host, port, err := net.SplitHostPort(e.cfg.MetricsAddr)
+	if err != nil {
+		slog.Warn("Can't derive advertise address from metrics address", "metrics_addr", e.cfg.MetricsAddr, "error", err)
+		return ""
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		if host, err = os.Hostname(); err != nil {
+			slog.Warn("Can't derive advertise address, failed to get host name", "error", err)
+			return ""
+		}
+	}
+	return net.JoinHostPort(host, port)
+}
\ No newline at end of file
@@ -0,0 +1,33 @@
+package executor
+
+import (
+	"net"
+	"os"
+	"testing"
+)
+
+func TestAdvertiseAddr(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		metricsAddr, advertiseAddr string
+		want                       string
+	}{
+		{"", "", ""},
+		{":9090", "", net.JoinHostPort(hostname, "9090")},
+		{"0.0.0.0:9090", "", net.JoinHostPort(hostname, "9090")},
+		{"[::]:9090", "", net.JoinHostPort(hostname, "9090")},
+		{"10.0.0.5:9090", "", "10.0.0.5:9090"},
+		{":9090", "worker-1.internal:9090", "worker-1.internal:9090"},
+	}
+
+	for _, tt := range tests {
+		e := &Executor{cfg: &Config{MetricsAddr: tt.metricsAddr, AdvertiseAddr: tt.advertiseAddr}}
+		if got := e.advertiseAddr(); got != tt.want {
+			t.Errorf("metrics %q, advertise %q: got %q, want %q", tt.metricsAddr, tt.advertiseAddr, got, tt.want)
+		}
+	}
+}
\ No newline at end of file
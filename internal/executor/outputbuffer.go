@@ -0,0 +1,146 @@
+package executor
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// outputBuffer captures the output of a job in constant memory. It keeps the
+// first maxHeadLines lines (at most maxSize bytes) and a window of the last
+// maxSize bytes after them, which is all that is needed to produce the same
+// head and tail result as truncating the full output.
+type outputBuffer struct {
+	maxSize int
+
+	head      []byte
+	headLines int
+	headDone  bool
+	tail      ringBuffer
+
+	total    int // bytes written
+	newlines int
+}
+
+func newOutputBuffer(maxSize int) *outputBuffer {
+	return &outputBuffer{maxSize: maxSize}
+}
+
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	b.total += n
+	b.newlines += bytes.Count(p, []byte{'\n'})
+
+	for !b.headDone && len(p) > 0 {
+		end := len(p)
+		newline := false
+		if i := bytes.IndexByte(p, '\n'); i >= 0 {
+			end = i + 1
+			newline = true
+		}
+		if room := b.maxSize - len(b.head); end > room {
+			end = room
+			newline = false
+		}
+
+		b.head = append(b.head, p[:end]...)
+		p = p[end:]
+		if newline {
+			b.headLines++
+		}
+		b.headDone = b.headLines == maxHeadLines || len(b.head) == b.maxSize
+	}
+
+	if len(p) > 0 {
+		if b.tail.buf == nil {
+			b.tail.buf = make([]byte, b.maxSize)
+		}
+		b.tail.Write(p)
+	}
+
+	return n, nil
+}
+
+// WriteString is used to append executor messages to the output
+func (b *outputBuffer) WriteString(s string) (int, error) {
+	return b.Write([]byte(s))
+}
+
+// String returns the output, truncated to maxSize bytes. Output that exceeds
+// the limit keeps its first maxHeadLines lines, a truncation marker and as
+// many whole lines from the end as fit.
+func (b *outputBuffer) String() string {
+	if b.total <= b.maxSize {
+		return string(b.head) + string(b.tail.Bytes())
+	}
+
+	// The output has no more than maxHeadLines lines, or its first
+	// maxHeadLines lines alone exceed the limit. Either way the head holds
+	// the first maxSize bytes.
+	if b.headLines < maxHeadLines {
+		return trimPartialRune(string(b.head))
+	}
+
+	// Drop the newline that ends the head
+	result := string(b.head[:len(b.head)-1])
+	result += fmt.Sprintf("\n... [OUTPUT TRUNCATED - Total %d bytes, %d lines] ...\n",
+		b.total, b.newlines+1)
+
+	remaining := b.maxSize - len(result)
+	if remaining <= 0 {
+		return truncateBytes(result, b.maxSize)
+	}
+
+	lines := strings.Split(string(b.tail.Bytes()), "\n")
+	if b.total-len(b.head) > b.maxSize {
+		// The first line lost its beginning to the window; it could not
+		// have fit anyway
+		lines = lines[1:]
+	}
+
+	// Add as many lines from the end as fit
+	first := len(lines)
+	tailSize := 0
+	for i := len(lines) - 1; i >= 0; i-- {
+		lineSize := len(lines[i]) + 1 // +1 for newline
+		if tailSize+lineSize > remaining {
+			break
+		}
+		first = i
+		tailSize += lineSize
+	}
+
+	return result + strings.Join(lines[first:], "\n")
+}
+
+// ringBuffer keeps the last len(buf) bytes written to it
+type ringBuffer struct {
+	buf  []byte
+	pos  int // next write position
+	full bool
+}
+
+func (r *ringBuffer) Write(p []byte) {
+	size := len(r.buf)
+	if len(p) >= size {
+		copy(r.buf, p[len(p)-size:])
+		r.pos = 0
+		r.full = true
+		return
+	}
+
+	n := copy(r.buf[r.pos:], p)
+	copy(r.buf, p[n:])
+	if r.pos+len(p) >= size {
+		r.full = true
+	}
+	r.pos = (r.pos + len(p)) % size
+}
+
+// Bytes returns the buffered bytes in the order they were written
+func (r *ringBuffer) Bytes() []byte {
+	if !r.full {
+		return r.buf[:r.pos]
+	}
+	return append(append([]byte{}, r.buf[r.pos:]...), r.buf[:r.pos]...)
+}
\ No newline at end of file
@@ -0,0 +1,124 @@
+package executor
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// truncateInMemory is how output was truncated when it was buffered in
+// full; outputBuffer must produce the same result
+func truncateInMemory(output string, maxSize int) string {
+	if len(output) <= maxSize {
+		return output
+	}
+
+	lines := strings.Split(output, "\n")
+	if len(lines) <= maxHeadLines {
+		return truncateBytes(output, maxSize)
+	}
+
+	result := strings.Join(lines[:maxHeadLines], "\n")
+	result += fmt.Sprintf("\n... [OUTPUT TRUNCATED - Total %d bytes, %d lines] ...\n",
+		len(output), len(lines))
+
+	remaining := maxSize - len(result)
+	if remaining <= 0 {
+		return truncateBytes(result, maxSize)
+	}
+
+	tailLines := []string{}
+	tailSize := 0
+	for i := len(lines) - 1; i >= maxHeadLines; i-- {
+		lineSize := len(lines[i]) + 1
+		if tailSize+lineSize > remaining {
+			break
+		}
+		tailLines = append([]string{lines[i]}, tailLines...)
+		tailSize += lineSize
+	}
+
+	return result + strings.Join(tailLines, "\n")
+}
+
+func TestOutputBufferMatchesInMemoryTruncation(t *testing.T) {
+	var numbered strings.Builder
+	for i := 0; i < 3*maxHeadLines; i++ {
+		fmt.Fprintf(&numbered, "line %d %s\n", i, strings.Repeat("x", i%50))
+	}
+
+	outputs := map[string]string{
+		"empty":          "",
+		"short":          "hello\nworld\n",
+		"single line":    strings.Repeat("a", 50000),
+		"numbered lines": numbered.String(),
+		"long last line": numbered.String() + strings.Repeat("z", 30000),
+		"newlines only":  strings.Repeat("\n", 5000),
+	}
+
+	for name, output := range outputs {
+		for _, maxSize := range []int{100, 4000, 20000, 40000, 100000} {
+			want := truncateInMemory(output, maxSize)
+
+			// Odd chunk sizes exercise the head boundary and the ring wrap
+			for _, chunk := range []int{1, 7, 4096, len(output) + 1} {
+				b := newOutputBuffer(maxSize)
+				for rest := output; len(rest) > 0; {
+					n := min(chunk, len(rest))
+					b.WriteString(rest[:n])
+					rest = rest[n:]
+				}
+				if got := b.String(); got != want {
+					t.Errorf("%s, maxSize %d, chunk %d: got %d bytes, want %d bytes",
+						name, maxSize, chunk, len(got), len(want))
+				}
+			}
+		}
+	}
+}
+
+func TestExecuteKeepsOutputMemoryBounded(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("requires /bin/sh")
+	}
+
+	const outputSize = 100 << 20
+
+	runner := &JobRunner{
+		JobID:      "memory-test",
+		BinaryPath: "/bin/sh",
+		Arguments:  []string{"-c", fmt.Sprintf("yes executr | head -c %d", outputSize)},
+		EnvVars:    map[string]string{"PATH": "/usr/bin:/bin"},
+		WorkDir:    t.TempDir(),
+		Timeout:    time.Minute,
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	result := runner.Execute(t.Context())
+
+	runtime.ReadMemStats(&after)
+
+	if result.ExitCode != 0 {
+		t.Fatalf("unexpected exit code %d: %s", result.ExitCode, result.Stderr)
+	}
+	if !strings.Contains(result.Stdout, fmt.Sprintf("[OUTPUT TRUNCATED - Total %d bytes", outputSize)) {
+		t.Fatal("expected the output to be truncated")
+	}
+	if len(result.Stdout) > DefaultMaxOutputSize {
+		t.Fatalf("output is %d bytes, limit is %d", len(result.Stdout), DefaultMaxOutputSize)
+	}
+
+	// Head, tail window and the result for both streams, with some slack
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 16*DefaultMaxOutputSize {
+		t.Fatalf("allocated %d MB for %d MB of output", allocated>>20, outputSize>>20)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,94 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+
+	"github.com/draganm/executr/pkg/models"
+)
+
+// OutputStore persists full, untruncated job output outside the server
+// database. Only a URL to the stored object is reported back to the server.
+type OutputStore interface {
+	// Put stores size bytes read from r under key and returns the object URL
+	Put(ctx context.Context, key string, r io.Reader, size int64) (string, error)
+}
+
+// outputCapture writes the full output of a job to files in a scratch
+// directory so it can be uploaded to an OutputStore after the job finishes
+type outputCapture struct {
+	dir    string
+	stdout *os.File
+	stderr *os.File
+}
+
+func newOutputCapture(dir string) (*outputCapture, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to create stdout file: %w", err)
+	}
+
+	stderr, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		stdout.Close()
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to create stderr file: %w", err)
+	}
+
+	return &outputCapture{dir: dir, stdout: stdout, stderr: stderr}, nil
+}
+
+// Close closes the capture files and removes the scratch directory
+func (c *outputCapture) Close() {
+	c.stdout.Close()
+	c.stderr.Close()
+	if err := os.RemoveAll(c.dir); err != nil {
+		slog.Warn("Failed to remove output directory", "path", c.dir, "error", err)
+	}
+}
+
+// uploadOutput uploads captured output to the output store and replaces the
+// inline output in result with object URLs. Streams that fail to upload keep
+// their inline (truncated) output.
+func (e *Executor) uploadOutput(jobID uuid.UUID, capture *outputCapture, result *models.JobResult) {
+	upload := func(f *os.File, name string) (string, bool) {
+		size, err := f.Seek(0, io.SeekEnd)
+		if err == nil {
+			_, err = f.Seek(0, io.SeekStart)
+		}
+		if err != nil {
+			slog.Warn("Failed to read captured output", "job_id", jobID, "stream", name, "error", err)
+			return "", false
+		}
+		if size == 0 {
+			return "", false
+		}
+
+		url, err := e.outputStore.Put(context.Background(), fmt.Sprintf("jobs/%s/%s", jobID, name), f, size)
+		if err != nil {
+			slog.Warn("Failed to upload job output", "job_id", jobID, "stream", name, "error", err)
+			return "", false
+		}
+		return url, true
+	}
+
+	if url, ok := upload(capture.stdout, "stdout"); ok {
+		result.StdoutURL = url
+		result.Stdout = ""
+	}
+	if url, ok := upload(capture.stderr, "stderr"); ok {
+		result.StderrURL = url
+		result.Stderr = ""
+	}
+}
\ No newline at end of file
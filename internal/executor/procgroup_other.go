@@ -0,0 +1,9 @@
+//go:build !unix
+
+package executor
+
+import "os/exec"
+
+// setProcessGroup is a no-op on platforms without process groups; context
+// cancellation kills only the direct child.
+func setProcessGroup(cmd *exec.Cmd) {}
\ No newline at end of file
@@ -0,0 +1,34 @@
+//go:build unix
+
+package executor
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts the command in its own process group and makes
+// context cancellation kill the whole group, so subprocesses spawned by the
+// job binary don't outlive it.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return killProcessGroup(cmd)
+	}
+}
+
+// killProcessGroup sends SIGKILL to every process in the command's group
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+
+	// A negative PID addresses the process group led by the child
+	err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	if errors.Is(err, syscall.ESRCH) {
+		return os.ErrProcessDone
+	}
+	return err
+}
\ No newline at end of file
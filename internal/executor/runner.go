@@ -3,25 +3,81 @@ package executor
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log/slog"
+	"os"
 	"os/exec"
-	"strings"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
 
-	"github.com/draganm/executr/internal/models"
+	"github.com/draganm/executr/pkg/models"
 )
 
 const (
-	maxOutputSize = 1024 * 1024 // 1MB
-	maxHeadLines  = 500
+	DefaultMaxOutputSize = 1024 * 1024 // 1MB
+	maxHeadLines         = 500
 )
 
+// ResultEnvVar names the file a job may write a structured JSON result to,
+// e.g. how many of its units of work succeeded
+const ResultEnvVar = "EXECUTR_RESULT"
+
+// Variables telling a job about the context it runs in, unless its own env
+// variables set them
+const (
+	JobIDEnvVar      = "EXECUTR_JOB_ID"
+	ExecutorIDEnvVar = "EXECUTR_EXECUTOR_ID"
+	ServerURLEnvVar  = "EXECUTR_SERVER_URL"
+)
+
+// resultFileName is the result file in a job's working directory
+const resultFileName = ".executr-result.json"
+
+// maxResultBytes limits the size of a job's result file
+const maxResultBytes = 1024 * 1024
+
 type JobRunner struct {
 	JobID      string
 	BinaryPath string
 	Arguments  []string
 	EnvVars    map[string]string
+	InheritEnv []string // host variables passed to the job unless EnvVars sets them
 	WorkDir    string
+
+	// ExecutorID and ServerURL are passed to the job as
+	// $EXECUTR_EXECUTOR_ID and $EXECUTR_SERVER_URL, next to its
+	// $EXECUTR_JOB_ID, e.g. for callbacks and logging
+	ExecutorID string
+	ServerURL  string
+
+	Timeout    time.Duration // 0 means no timeout
+	Stdin      []byte        // fed to the binary's standard input
+	
+	// MaxOutputSize limits stdout and stderr separately; 0 means DefaultMaxOutputSize
+	MaxOutputSize int
+	
+	// WorkDirQuota limits the size of WorkDir in bytes while the job runs,
+	// including its input files; 0 means unlimited
+	WorkDirQuota int64
+	
+	// CPUMillicores and MemLimitBytes are enforced by running the job in a
+	// cgroup below CgroupParent (Linux only); 0 means unlimited
+	CgroupParent  string
+	CPUMillicores int
+	MemLimitBytes int64
+	
+	// Optional writers that receive output as it is produced
+	StdoutWriter io.Writer
+	StderrWriter io.Writer
+	
+	// ResultFile is passed to the job as $EXECUTR_RESULT and read as its
+	// structured result after it exits; empty disables results
+	ResultFile string
 }
 
 func (r *JobRunner) Execute(ctx context.Context) *models.JobResult {
@@ -30,33 +86,129 @@ func (r *JobRunner) Execute(ctx context.Context) *models.JobResult {
 		"binary", r.BinaryPath,
 		"work_dir", r.WorkDir,
 		"args", r.Arguments,
+		"timeout", r.Timeout,
 	)
 	
-	// Create command with arguments passed separately
-	cmd := exec.CommandContext(ctx, r.BinaryPath, r.Arguments...)
+	// Enforce the per-job wall-clock timeout if one is configured
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+	
+	// Kill the job when its working directory outgrows the quota
+	if r.WorkDirQuota > 0 {
+		var cancel context.CancelCauseFunc
+		ctx, cancel = context.WithCancelCause(ctx)
+		defer cancel(nil)
+		go r.watchWorkDir(ctx, cancel)
+	}
 	
-	// Set working directory
-	cmd.Dir = r.WorkDir
+	// The job runs in WorkDir, against which it would resolve a relative
+	// result file path
+	resultFile := r.ResultFile
+	if resultFile != "" {
+		if abs, err := filepath.Abs(resultFile); err == nil {
+			resultFile = abs
+		}
+	}
 	
-	// Replace environment completely with job's env variables
-	if len(r.EnvVars) > 0 {
-		env := make([]string, 0, len(r.EnvVars))
-		for key, value := range r.EnvVars {
+	// Replace the environment with the inherited host variables, the
+	// executr context and the job's env variables, in increasing
+	// precedence; nothing else leaks from the host
+	contextEnv := r.contextEnv()
+	env := make([]string, 0, len(r.InheritEnv)+len(contextEnv)+len(r.EnvVars))
+	for _, key := range r.InheritEnv {
+		if _, overridden := r.EnvVars[key]; overridden || key == "" {
+			continue
+		}
+		if _, overridden := contextEnv[key]; overridden {
+			continue
+		}
+		if value, ok := os.LookupEnv(key); ok {
 			env = append(env, fmt.Sprintf("%s=%s", key, value))
 		}
+	}
+	for key, value := range contextEnv {
+		if _, overridden := r.EnvVars[key]; overridden {
+			continue
+		}
+		env = append(env, fmt.Sprintf("%s=%s", key, value))
+	}
+	for key, value := range r.EnvVars {
+		if key == ResultEnvVar && resultFile != "" {
+			continue
+		}
+		env = append(env, fmt.Sprintf("%s=%s", key, value))
+	}
+	if resultFile != "" {
+		env = append(env, fmt.Sprintf("%s=%s", ResultEnvVar, resultFile))
+	}
+	
+	maxSize := r.MaxOutputSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxOutputSize
+	}
+	
+	// Capture stdout and stderr, keeping only what survives truncation
+	stdout := newOutputBuffer(maxSize)
+	stderr := newOutputBuffer(maxSize)
+	var stdoutWriter, stderrWriter io.Writer = stdout, stderr
+	if r.StdoutWriter != nil {
+		stdoutWriter = io.MultiWriter(stdout, r.StdoutWriter)
+	}
+	if r.StderrWriter != nil {
+		stderrWriter = io.MultiWriter(stderr, r.StderrWriter)
+	}
+	
+	// newCmd creates the command with arguments passed separately. Starting
+	// it in a cgroup may take a second command, as each starts only once.
+	newCmd := func() *exec.Cmd {
+		cmd := exec.CommandContext(ctx, r.BinaryPath, r.Arguments...)
+		
+		// Run in a separate process group so cancellation also kills subprocesses
+		setProcessGroup(cmd)
+		
+		cmd.Dir = r.WorkDir
 		cmd.Env = env
-	} else {
-		// Use empty environment if no env vars specified
-		cmd.Env = []string{}
+		if len(r.Stdin) > 0 {
+			cmd.Stdin = bytes.NewReader(r.Stdin)
+		}
+		cmd.Stdout = stdoutWriter
+		cmd.Stderr = stderrWriter
+		return cmd
 	}
 	
-	// Capture stdout and stderr
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	// Place the job in a cgroup enforcing its resource limits
+	cgroup := r.cgroup()
+	if cgroup != nil {
+		defer cgroup.remove()
+	}
 	
-	// Run the command
-	err := cmd.Run()
+	// Run the command, inside the cgroup from its start so neither the job
+	// nor its children run unlimited
+	cmd := newCmd()
+	var err error
+	if cgroup != nil {
+		err = cgroup.start(cmd)
+		if errors.Is(err, errors.ErrUnsupported) {
+			// Older kernels can only move the job in after it started
+			cmd = newCmd()
+			if err = cmd.Start(); err == nil {
+				if err := cgroup.add(cmd.Process.Pid); err != nil {
+					slog.Warn("Failed to move job into its cgroup, running without resource limits",
+						"job_id", r.JobID,
+						"error", err,
+					)
+				}
+			}
+		}
+	} else {
+		err = cmd.Start()
+	}
+	if err == nil {
+		err = cmd.Wait()
+	}
 	
 	// Get exit code
 	exitCode := 0
@@ -70,14 +222,53 @@ func (r *JobRunner) Execute(ctx context.Context) *models.JobResult {
 		}
 	}
 	
-	// Truncate output if necessary
-	stdoutStr := truncateOutput(stdout.String())
-	stderrStr := truncateOutput(stderr.String())
+	// Pick up the structured result, a broken one is reported in stderr
+	var resultJSON json.RawMessage
+	if resultFile != "" {
+		resultJSON, err = readResultFile(resultFile)
+		if err != nil {
+			slog.Warn("Ignoring invalid job result", "job_id", r.JobID, "error", err)
+			stderr.WriteString(fmt.Sprintf("\nInvalid result in $%s: %v", ResultEnvVar, err))
+		}
+	}
+	
+	stdoutStr := stdout.String()
+	stderrStr := stderr.String()
 	
 	result := &models.JobResult{
-		Stdout:   stdoutStr,
-		Stderr:   stderrStr,
-		ExitCode: exitCode,
+		Stdout:     stdoutStr,
+		Stderr:     stderrStr,
+		ExitCode:   exitCode,
+		ResultJSON: resultJSON,
+	}
+	
+	if r.Timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		result.ErrorMessage = fmt.Sprintf("job exceeded timeout of %ds", int(r.Timeout.Seconds()))
+		if result.ExitCode == 0 {
+			result.ExitCode = -1
+		}
+		slog.Warn("Job exceeded timeout",
+			"job_id", r.JobID,
+			"timeout", r.Timeout,
+		)
+	}
+	
+	if cgroup != nil && cgroup.oomKilled() {
+		result.ErrorMessage = fmt.Sprintf("job was killed for exceeding its memory limit of %d bytes", r.MemLimitBytes)
+		if result.ExitCode == 0 {
+			result.ExitCode = -1
+		}
+		slog.Warn("Job exceeded memory limit",
+			"job_id", r.JobID,
+			"mem_limit_bytes", r.MemLimitBytes,
+		)
+	}
+	
+	if errors.Is(context.Cause(ctx), errWorkDirQuotaExceeded) {
+		result.ErrorMessage = fmt.Sprintf("job exceeded working directory quota of %d bytes", r.WorkDirQuota)
+		if result.ExitCode == 0 {
+			result.ExitCode = -1
+		}
 	}
 	
 	slog.Info("Job execution completed",
@@ -90,48 +281,78 @@ func (r *JobRunner) Execute(ctx context.Context) *models.JobResult {
 	return result
 }
 
-func truncateOutput(output string) string {
-	if len(output) <= maxOutputSize {
-		return output
+// contextEnv returns the variables telling the job about the context it
+// runs in; unknown values are left out
+func (r *JobRunner) contextEnv() map[string]string {
+	env := make(map[string]string, 3)
+	for key, value := range map[string]string{
+		JobIDEnvVar:      r.JobID,
+		ExecutorIDEnvVar: r.ExecutorID,
+		ServerURLEnvVar:  r.ServerURL,
+	} {
+		if value != "" {
+			env[key] = value
+		}
 	}
-	
-	lines := strings.Split(output, "\n")
-	
-	// If we have fewer lines than maxHeadLines, just truncate by bytes
-	if len(lines) <= maxHeadLines {
-		return output[:maxOutputSize]
+	return env
+}
+
+// readResultFile reads the structured result a job wrote to path. A job
+// that wrote none, or an empty file, has no result.
+func readResultFile(path string) (json.RawMessage, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
 	}
-	
-	// Keep first maxHeadLines
-	result := strings.Join(lines[:maxHeadLines], "\n")
-	
-	// Add truncation marker
-	truncMarker := fmt.Sprintf("\n... [OUTPUT TRUNCATED - Total %d bytes, %d lines] ...\n", 
-		len(output), len(lines))
-	result += truncMarker
-	
-	// Calculate how much space we have left
-	remaining := maxOutputSize - len(result)
-	if remaining <= 0 {
-		return result[:maxOutputSize]
+	if err != nil {
+		return nil, err
 	}
+	defer f.Close()
 	
-	// Add as many lines from the end as fit
-	tailLines := []string{}
-	tailSize := 0
-	
-	for i := len(lines) - 1; i >= maxHeadLines; i-- {
-		lineSize := len(lines[i]) + 1 // +1 for newline
-		if tailSize+lineSize > remaining {
+	data, err := io.ReadAll(io.LimitReader(f, maxResultBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxResultBytes {
+		return nil, fmt.Errorf("result is larger than %d bytes", maxResultBytes)
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+	if !json.Valid(data) {
+		return nil, errors.New("result is not valid JSON")
+	}
+	return data, nil
+}
+
+// truncateOutput truncates output to maxSize bytes the same way job output
+// is truncated while it is captured
+func truncateOutput(output string, maxSize int) string {
+	b := newOutputBuffer(maxSize)
+	b.WriteString(output)
+	return b.String()
+}
+
+// truncateBytes cuts s to at most maxSize bytes without splitting a
+// multi-byte rune, as the output ends up in a text column that must hold
+// valid UTF-8
+func truncateBytes(s string, maxSize int) string {
+	if len(s) <= maxSize {
+		return s
+	}
+	return trimPartialRune(s[:maxSize])
+}
+
+// trimPartialRune removes a rune that was cut in half from the end of s
+func trimPartialRune(s string) string {
+	// A cut rune leaves at most UTFMax-1 bytes of an incomplete sequence
+	for i := 0; i < utf8.UTFMax-1 && len(s) > 0; i++ {
+		r, size := utf8.DecodeLastRuneInString(s)
+		if r != utf8.RuneError || size != 1 {
 			break
 		}
-		tailLines = append([]string{lines[i]}, tailLines...)
-		tailSize += lineSize
+		s = s[:len(s)-1]
 	}
-	
-	if len(tailLines) > 0 {
-		result += strings.Join(tailLines, "\n")
-	}
-	
-	return result
+	return s
 }
\ No newline at end of file
@@ -0,0 +1,257 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestTruncateOutputKeepsValidUTF8(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+	}{
+		{"single line", strings.Repeat("😀", 1000)},
+		{"mixed widths", strings.Repeat("aé€😀", 500)},
+		// More than maxHeadLines lines, so the head alone exceeds the limit
+		{"many lines", strings.Repeat("😀😀😀\n", 2*maxHeadLines)},
+	}
+
+	for _, tt := range tests {
+		// Hit every offset within a 4-byte rune
+		for maxSize := 3000; maxSize < 3004; maxSize++ {
+			got := truncateOutput(tt.output, maxSize)
+			if !utf8.ValidString(got) {
+				t.Errorf("%s, maxSize %d: result is not valid UTF-8", tt.name, maxSize)
+			}
+			if len(got) > maxSize || len(got) < maxSize-utf8.UTFMax+1 {
+				t.Errorf("%s, maxSize %d: got %d bytes", tt.name, maxSize, len(got))
+			}
+		}
+	}
+}
+
+func TestTruncateOutputKeepsHeadAndTail(t *testing.T) {
+	var lines []string
+	for i := 0; i < 2*maxHeadLines; i++ {
+		lines = append(lines, strings.Repeat("é", i%7))
+	}
+	lines[0] = "first"
+	lines[len(lines)-1] = "last"
+	output := strings.Join(lines, "\n")
+
+	got := truncateOutput(output, len(output)-10)
+	if !strings.HasPrefix(got, "first\n") || !strings.HasSuffix(got, "\nlast") {
+		t.Fatalf("expected head and tail to be kept, got %q...%q", got[:10], got[len(got)-10:])
+	}
+	if !strings.Contains(got, "[OUTPUT TRUNCATED") {
+		t.Fatal("expected a truncation marker")
+	}
+	if !utf8.ValidString(got) {
+		t.Fatal("result is not valid UTF-8")
+	}
+}
+
+func TestExecuteFeedsStdin(t *testing.T) {
+	cat, err := exec.LookPath("cat")
+	if err != nil {
+		t.Skip("cat not available")
+	}
+
+	runner := &JobRunner{
+		JobID:      "stdin",
+		BinaryPath: cat,
+		WorkDir:    t.TempDir(),
+		Stdin:      []byte("config: value\n"),
+	}
+	result := runner.Execute(context.Background())
+	if result.ExitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", result.ExitCode, result.Stderr)
+	}
+	if result.Stdout != "config: value\n" {
+		t.Fatalf("expected stdin to be echoed, got %q", result.Stdout)
+	}
+}
+
+func TestExecuteKillsJobOverWorkDirQuota(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+
+	runner := &JobRunner{
+		JobID:        "quota",
+		BinaryPath:   sh,
+		Arguments:    []string{"-c", "head -c 2097152 /dev/zero > large; sleep 30"},
+		EnvVars:      map[string]string{"PATH": "/usr/bin:/bin"},
+		WorkDir:      t.TempDir(),
+		WorkDirQuota: 1024 * 1024,
+	}
+
+	start := time.Now()
+	result := runner.Execute(context.Background())
+	if time.Since(start) > 10*time.Second {
+		t.Fatalf("job was not killed in time")
+	}
+	if result.ExitCode == 0 {
+		t.Fatal("expected a non-zero exit code")
+	}
+	if result.ErrorMessage != "job exceeded working directory quota of 1048576 bytes" {
+		t.Fatalf("unexpected error message %q", result.ErrorMessage)
+	}
+}
+
+func TestExecuteWithoutCgroupSupportRunsUnlimited(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+
+	runner := &JobRunner{
+		JobID:         "cgroup",
+		BinaryPath:    sh,
+		Arguments:     []string{"-c", "echo ok"},
+		WorkDir:       t.TempDir(),
+		CgroupParent:  t.TempDir(), // not a cgroup v2 directory
+		CPUMillicores: 500,
+		MemLimitBytes: 64 * 1024 * 1024,
+	}
+	result := runner.Execute(context.Background())
+	if result.ExitCode != 0 || result.Stdout != "ok\n" {
+		t.Fatalf("expected the job to run, got exit code %d: %q %q", result.ExitCode, result.Stdout, result.Stderr)
+	}
+}
+
+func TestExecuteInheritsSelectedHostEnv(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+	t.Setenv("EXECUTR_TEST_INHERITED", "host")
+	t.Setenv("EXECUTR_TEST_OVERRIDDEN", "host")
+	t.Setenv("EXECUTR_TEST_HIDDEN", "host")
+
+	runner := &JobRunner{
+		JobID:      "env",
+		BinaryPath: sh,
+		Arguments:  []string{"-c", `echo "$EXECUTR_TEST_INHERITED $EXECUTR_TEST_OVERRIDDEN [$EXECUTR_TEST_HIDDEN]"`},
+		EnvVars:    map[string]string{"EXECUTR_TEST_OVERRIDDEN": "job"},
+		InheritEnv: []string{"EXECUTR_TEST_INHERITED", "EXECUTR_TEST_OVERRIDDEN", "EXECUTR_TEST_UNSET"},
+		WorkDir:    t.TempDir(),
+	}
+	result := runner.Execute(context.Background())
+	if result.ExitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", result.ExitCode, result.Stderr)
+	}
+	if result.Stdout != "host job []\n" {
+		t.Fatalf("unexpected environment %q", result.Stdout)
+	}
+}
+
+func TestExecuteSetsContextEnv(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+	// The executor's own configuration must not leak in through InheritEnv
+	t.Setenv("EXECUTR_SERVER_URL", "http://host:8080")
+
+	runner := &JobRunner{
+		JobID:      "job-1",
+		BinaryPath: sh,
+		Arguments:  []string{"-c", `echo "$EXECUTR_JOB_ID $EXECUTR_EXECUTOR_ID $EXECUTR_SERVER_URL"`},
+		EnvVars:    map[string]string{"EXECUTR_EXECUTOR_ID": "job"},
+		InheritEnv: []string{"EXECUTR_SERVER_URL"},
+		WorkDir:    t.TempDir(),
+		ExecutorID: "worker-1",
+		ServerURL:  "http://executr:8080",
+	}
+	result := runner.Execute(context.Background())
+	if result.ExitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", result.ExitCode, result.Stderr)
+	}
+	if result.Stdout != "job-1 job http://executr:8080\n" {
+		t.Fatalf("unexpected environment %q", result.Stdout)
+	}
+}
+
+func TestExecuteReadsResultFile(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+
+	workDir := t.TempDir()
+	runner := &JobRunner{
+		JobID:      "result",
+		BinaryPath: sh,
+		Arguments:  []string{"-c", `echo '{"processed": 3, "failed": 1}' > "$EXECUTR_RESULT"; exit 1`},
+		EnvVars:    map[string]string{ResultEnvVar: "/somewhere/else"},
+		WorkDir:    workDir,
+		ResultFile: filepath.Join(workDir, resultFileName),
+	}
+	result := runner.Execute(context.Background())
+	if result.ExitCode != 1 {
+		t.Fatalf("expected exit code 1, got %d: %s", result.ExitCode, result.Stderr)
+	}
+	if string(result.ResultJSON) != `{"processed": 3, "failed": 1}` {
+		t.Fatalf("unexpected result %q", result.ResultJSON)
+	}
+}
+
+func TestExecuteReadsResultFileInRelativeWorkDir(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+
+	t.Chdir(t.TempDir())
+	if err := os.Mkdir("job", 0755); err != nil {
+		t.Fatal(err)
+	}
+	runner := &JobRunner{
+		JobID:      "result",
+		BinaryPath: sh,
+		Arguments:  []string{"-c", `echo '{"processed": 3}' > "$EXECUTR_RESULT"`},
+		WorkDir:    "job",
+		ResultFile: filepath.Join("job", resultFileName),
+	}
+	result := runner.Execute(context.Background())
+	if result.ExitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", result.ExitCode, result.Stderr)
+	}
+	if string(result.ResultJSON) != `{"processed": 3}` {
+		t.Fatalf("unexpected result %q", result.ResultJSON)
+	}
+}
+
+func TestExecuteReportsInvalidResultFile(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+
+	workDir := t.TempDir()
+	runner := &JobRunner{
+		JobID:      "invalid-result",
+		BinaryPath: sh,
+		Arguments:  []string{"-c", `echo 'processed 3' > "$EXECUTR_RESULT"`},
+		WorkDir:    workDir,
+		ResultFile: filepath.Join(workDir, resultFileName),
+	}
+	result := runner.Execute(context.Background())
+	if result.ExitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", result.ExitCode, result.Stderr)
+	}
+	if result.ResultJSON != nil {
+		t.Fatalf("expected no result, got %q", result.ResultJSON)
+	}
+	if !strings.Contains(result.Stderr, "not valid JSON") {
+		t.Fatalf("expected the invalid result in stderr, got %q", result.Stderr)
+	}
+}
\ No newline at end of file
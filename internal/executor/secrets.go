@@ -0,0 +1,70 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/draganm/executr/pkg/models"
+)
+
+// ErrSecrets is reported when a job references secrets that can't be
+// resolved
+var ErrSecrets = errors.New("secrets could not be resolved")
+
+// SecretResolver looks up the secrets referenced by job env variables, e.g.
+// secret://prod/db-pass. Resolved values stay on the executor and are never
+// sent to the server.
+type SecretResolver interface {
+	// Resolve returns the value of the named secret, e.g. prod/db-pass
+	Resolve(ctx context.Context, name string) (string, error)
+}
+
+// FileSecretResolver reads secrets from files below Dir, one file per
+// secret, as written by e.g. Kubernetes secret volumes. A single trailing
+// newline is removed from the value.
+type FileSecretResolver struct {
+	Dir string
+}
+
+// Resolve implements SecretResolver
+func (f *FileSecretResolver) Resolve(ctx context.Context, name string) (string, error) {
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("secret %q is outside the secrets directory", name)
+	}
+	data, err := os.ReadFile(filepath.Join(f.Dir, filepath.FromSlash(name)))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("secret %q not found", name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %q: %w", name, err)
+	}
+	value := strings.TrimSuffix(string(data), "\n")
+	return strings.TrimSuffix(value, "\r"), nil
+}
+
+// resolveEnv returns the job's env variables with secret references
+// replaced by their values. Errors name the variable and secret, never a
+// value.
+func (e *Executor) resolveEnv(ctx context.Context, env map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string, len(env))
+	for key, value := range env {
+		name, ok := models.SecretRef(value)
+		if !ok {
+			resolved[key] = value
+			continue
+		}
+		if e.secrets == nil {
+			return nil, fmt.Errorf("env variable %s references secret %q, but no secret backend is configured", key, name)
+		}
+		secret, err := e.secrets.Resolve(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("env variable %s: %w", key, err)
+		}
+		resolved[key] = secret
+	}
+	return resolved, nil
+}
\ No newline at end of file
@@ -0,0 +1,50 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveEnvReadsSecretFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "prod"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "prod", "db-pass"), []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	e := &Executor{secrets: &FileSecretResolver{Dir: dir}}
+	env, err := e.resolveEnv(context.Background(), map[string]string{
+		"DB_PASS": "secret://prod/db-pass",
+		"MODE":    "fast",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env["DB_PASS"] != "s3cret" || env["MODE"] != "fast" {
+		t.Fatalf("unexpected env %v", env)
+	}
+
+	for _, ref := range []string{"secret://prod/missing", "secret://../db-pass"} {
+		if _, err := e.resolveEnv(context.Background(), map[string]string{"DB_PASS": ref}); err == nil {
+			t.Errorf("expected an error for %q", ref)
+		}
+	}
+}
+
+func TestResolveEnvWithoutSecretBackend(t *testing.T) {
+	e := &Executor{}
+	env, err := e.resolveEnv(context.Background(), map[string]string{"MODE": "fast"})
+	if err != nil || env["MODE"] != "fast" {
+		t.Fatalf("expected plain env to pass, got %v, %v", env, err)
+	}
+
+	_, err = e.resolveEnv(context.Background(), map[string]string{"DB_PASS": "secret://prod/db-pass"})
+	if err == nil || !strings.Contains(err.Error(), "no secret backend") {
+		t.Fatalf("expected a missing backend error, got %v", err)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,260 @@
+package executor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/draganm/executr/internal/utils"
+)
+
+// BinarySource fetches job binaries from the locations of one URL scheme,
+// e.g. https:// downloads, oci:// container images or file:// paths. The
+// cache picks the source by the scheme of a job's binary URL; more schemes,
+// like s3://, can be added with Config.BinarySources.
+type BinarySource interface {
+	// Digest identifies the content binaryURL currently points to without
+	// downloading it, e.g. the manifest digest of an image tag. Sources
+	// that can't tell return "", their binaries are only found in the cache
+	// by binary_sha256.
+	Digest(ctx context.Context, binaryURL string) (string, error)
+
+	// Size returns the size of the binary when it is known before the
+	// download, 0 otherwise
+	Size(ctx context.Context, binaryURL string) int64
+
+	// Fetch stores the executable binary at destPath and returns its
+	// SHA256. When expectedSHA256 is set, other content fails the fetch.
+	// digest is what Digest returned for binaryURL, "" when it wasn't
+	// asked; sources then fetch the content it identifies, even when
+	// binaryURL points elsewhere meanwhile. Nothing is left at destPath
+	// when the fetch fails.
+	Fetch(ctx context.Context, binaryURL, digest, destPath, expectedSHA256 string) (string, error)
+}
+
+// httpSource downloads binaries over HTTP(S). Jobs must pin their binary
+// with binary_sha256, the URL alone doesn't identify the content.
+type httpSource struct {
+	downloader  *utils.BinaryDownloader
+	credentials *utils.DownloadCredentials
+}
+
+func (s *httpSource) Digest(ctx context.Context, binaryURL string) (string, error) {
+	return "", nil
+}
+
+// Size asks the server for the size of a binary with a HEAD request
+func (s *httpSource) Size(ctx context.Context, binaryURL string) int64 {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", binaryURL, nil)
+	if err != nil {
+		return 0
+	}
+	s.credentials.Apply(req)
+	client := &http.Client{CheckRedirect: s.credentials.CheckRedirect}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || resp.ContentLength < 0 {
+		return 0
+	}
+	return resp.ContentLength
+}
+
+// Fetch downloads to a temporary file, verifying the SHA256 while
+// streaming, then makes it executable and atomically moves it into place
+func (s *httpSource) Fetch(ctx context.Context, binaryURL, digest, destPath, expectedSHA256 string) (string, error) {
+	if expectedSHA256 == "" {
+		return "", errors.New("binary_sha256 is required for binaries downloaded over HTTP")
+	}
+	err := s.downloader.Download(ctx, binaryURL, destPath, &utils.DownloadOptions{
+		SHA256: expectedSHA256,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to download binary: %w", err)
+	}
+	return expectedSHA256, nil
+}
+
+// ociSource extracts binaries from container images. Tags are resolved to
+// image digests, so a binary is pulled once per image version even when
+// the job has no binary_sha256.
+type ociSource struct {
+	puller *utils.OCIPuller
+}
+
+// Digest returns the manifest digest of the image together with the path
+// of the binary in it
+func (s *ociSource) Digest(ctx context.Context, binaryURL string) (string, error) {
+	ref, err := utils.ParseOCIReference(binaryURL)
+	if err != nil {
+		return "", err
+	}
+	digest, err := s.puller.Resolve(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve image: %w", err)
+	}
+	return digest + "#" + ref.Path, nil
+}
+
+func (s *ociSource) Size(ctx context.Context, binaryURL string) int64 {
+	return 0
+}
+
+// Fetch pulls the image by the manifest digest when one is given, so the
+// binary comes from the image that was looked up in the cache even when
+// the tag was moved since
+func (s *ociSource) Fetch(ctx context.Context, binaryURL, digest, destPath, expectedSHA256 string) (string, error) {
+	ref, err := utils.ParseOCIReference(binaryURL)
+	if err != nil {
+		return "", err
+	}
+	if manifestDigest, _, _ := strings.Cut(digest, "#"); manifestDigest != "" {
+		ref.Reference = manifestDigest
+	}
+
+	return writeBinary(destPath, expectedSHA256, func(w io.Writer) error {
+		if err := s.puller.Extract(ctx, ref, w); err != nil {
+			return fmt.Errorf("failed to pull binary from image: %w", err)
+		}
+		return nil
+	})
+}
+
+// ErrBinaryPathNotAllowed is returned for file:// binaries outside the
+// allowed binary roots
+var ErrBinaryPathNotAllowed = errors.New("binary path is outside the allowed binary roots")
+
+// fileSource copies binaries from the executor's file system, e.g. an NFS
+// mount in air-gapped setups. Only files below one of roots can be read;
+// without roots every file:// URL is rejected. Binaries without
+// binary_sha256 are copied again for every job, since the file may change.
+type fileSource struct {
+	roots []string
+}
+
+// localPath returns the path of a file:// URL, which must not name another
+// host and must be below one of the roots, also after following symbolic
+// links
+func (s *fileSource) localPath(binaryURL string) (string, error) {
+	u, err := url.Parse(binaryURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid binary URL: %w", err)
+	}
+	if u.Host != "" && u.Host != "localhost" {
+		return "", fmt.Errorf("file URL %q names host %q, only local files are supported", binaryURL, u.Host)
+	}
+	if u.Path == "" {
+		return "", fmt.Errorf("file URL %q has no path", binaryURL)
+	}
+
+	// Check the path as given first, so files outside the roots can't
+	// even be probed for existence
+	path := filepath.Clean(filepath.FromSlash(u.Path))
+	if !s.allowed(path, false) {
+		return "", fmt.Errorf("%w: %s", ErrBinaryPathNotAllowed, path)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve binary path: %w", err)
+	}
+	if !s.allowed(resolved, true) {
+		return "", fmt.Errorf("%w: %s links to %s", ErrBinaryPathNotAllowed, path, resolved)
+	}
+	return resolved, nil
+}
+
+// allowed reports whether path is below one of the roots. Roots are
+// compared after following their symbolic links when resolve is set.
+func (s *fileSource) allowed(path string, resolve bool) bool {
+	for _, root := range s.roots {
+		if resolve {
+			var err error
+			if root, err = filepath.EvalSymlinks(root); err != nil {
+				continue
+			}
+		}
+		rel, err := filepath.Rel(root, path)
+		if err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *fileSource) Digest(ctx context.Context, binaryURL string) (string, error) {
+	return "", nil
+}
+
+func (s *fileSource) Size(ctx context.Context, binaryURL string) int64 {
+	path, err := s.localPath(binaryURL)
+	if err != nil {
+		return 0
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+func (s *fileSource) Fetch(ctx context.Context, binaryURL, digest, destPath, expectedSHA256 string) (string, error) {
+	path, err := s.localPath(binaryURL)
+	if err != nil {
+		return "", err
+	}
+	src, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open binary: %w", err)
+	}
+	defer src.Close()
+
+	return writeBinary(destPath, expectedSHA256, func(w io.Writer) error {
+		_, err := io.Copy(w, src)
+		return err
+	})
+}
+
+// writeBinary stores what write produces at destPath, going through a
+// temporary file that is hashed while written and only moved into place,
+// executable, when its SHA256 matches expectedSHA256 (if set)
+func writeBinary(destPath, expectedSHA256 string, write func(w io.Writer) error) (string, error) {
+	tmpPath := filepath.Join(filepath.Dir(destPath), ".download-"+filepath.Base(destPath))
+	tmpFile, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tmpPath)
+
+	hasher := sha256.New()
+	err = write(io.MultiWriter(tmpFile, hasher))
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	sha := hex.EncodeToString(hasher.Sum(nil))
+	if expectedSHA256 != "" && sha != expectedSHA256 {
+		return "", fmt.Errorf("SHA256 mismatch: expected %s, got %s", expectedSHA256, sha)
+	}
+
+	if err := os.Chmod(tmpPath, 0755); err != nil {
+		return "", fmt.Errorf("failed to make binary executable: %w", err)
+	}
+	if err := os.Rename(tmpPath, destPath); err != nil {
+		return "", fmt.Errorf("failed to move binary into place: %w", err)
+	}
+	return sha, nil
+}
\ No newline at end of file
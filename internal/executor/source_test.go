@@ -0,0 +1,107 @@
+package executor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGetBinaryCopiesFileURLs(t *testing.T) {
+	content := []byte("#!/bin/sh\necho local\n")
+	root := t.TempDir()
+	src := filepath.Join(root, "tool")
+	if err := os.WriteFile(src, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(content)
+	sha := hex.EncodeToString(sum[:])
+
+	dir := t.TempDir()
+	cache, err := NewBinaryCache(dir, 100, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.RegisterSource("file", &fileSource{roots: []string{root}})
+
+	for _, expected := range []string{sha, ""} {
+		path, err := cache.GetBinary(context.Background(), "file://"+filepath.ToSlash(src), expected, nil)
+		if err != nil {
+			t.Fatalf("sha %q: unexpected error: %v", expected, err)
+		}
+		if path != filepath.Join(dir, sha) {
+			t.Fatalf("got %s, want the binary named by its SHA256", path)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm()&0100 == 0 {
+			t.Fatalf("cached binary is not executable: %s", info.Mode())
+		}
+	}
+
+	_, err = cache.GetBinary(context.Background(), "file://"+filepath.ToSlash(src), strings.Repeat("0", 64), nil)
+	if err == nil || !strings.Contains(err.Error(), "SHA256 mismatch") {
+		t.Fatalf("expected SHA256 mismatch, got %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".download-") || strings.HasPrefix(entry.Name(), ".fetch-") {
+			t.Fatalf("failed copy left %s behind", entry.Name())
+		}
+	}
+
+	if _, err := cache.GetBinary(context.Background(), "file://fileserver/tool", sha+"1", nil); err == nil {
+		t.Fatal("expected error for a file URL on another host")
+	}
+}
+
+func TestFileSourceRejectsPathsOutsideRoots(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "binaries")
+	if err := os.Mkdir(root, 0755); err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(base, "secret")
+	if err := os.WriteFile(secret, []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(secret, filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+	// A sibling directory sharing the root's name as prefix
+	if err := os.Mkdir(root+"-other", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root+"-other", "tool"), []byte("tool"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	source := &fileSource{roots: []string{root}}
+	for _, path := range []string{
+		root + "/../secret",
+		"/etc/passwd",
+		root,
+		root + "/link",
+		root + "-other/tool",
+	} {
+		_, err := source.Fetch(context.Background(), "file://"+filepath.ToSlash(path), "", filepath.Join(t.TempDir(), "dest"), "")
+		if !errors.Is(err, ErrBinaryPathNotAllowed) {
+			t.Errorf("%s: expected ErrBinaryPathNotAllowed, got %v", path, err)
+		}
+	}
+
+	// No roots, no file:// binaries
+	_, err := (&fileSource{}).Fetch(context.Background(), "file://"+filepath.ToSlash(secret), "", filepath.Join(t.TempDir(), "dest"), "")
+	if !errors.Is(err, ErrBinaryPathNotAllowed) {
+		t.Errorf("expected ErrBinaryPathNotAllowed without roots, got %v", err)
+	}
+}
\ No newline at end of file
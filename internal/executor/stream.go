@@ -0,0 +1,160 @@
+package executor
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/draganm/executr/pkg/client"
+	"github.com/draganm/executr/pkg/models"
+)
+
+// outputFlushInterval is how often live output is pushed to the server
+const outputFlushInterval = 2 * time.Second
+
+// outputStreamer buffers job output and periodically pushes it to the server
+// so it can be followed while the job is running. Live output is capped at
+// maxSize bytes per stream; the final result still carries the full
+// (truncated) output.
+type outputStreamer struct {
+	client     client.Client
+	jobID      uuid.UUID
+	executorID string
+	maxSize    int
+
+	mu        sync.Mutex
+	stdout    []byte
+	stderr    []byte
+	sent      [2]int
+	truncated [2]bool
+}
+
+func newOutputStreamer(c client.Client, jobID uuid.UUID, executorID string, maxSize int) *outputStreamer {
+	return &outputStreamer{
+		client:     c,
+		jobID:      jobID,
+		executorID: executorID,
+		maxSize:    maxSize,
+	}
+}
+
+// Stdout returns a writer that feeds the stdout stream
+func (s *outputStreamer) Stdout() *streamWriter {
+	return &streamWriter{s: s, stream: 0}
+}
+
+// Stderr returns a writer that feeds the stderr stream
+func (s *outputStreamer) Stderr() *streamWriter {
+	return &streamWriter{s: s, stream: 1}
+}
+
+type streamWriter struct {
+	s      *outputStreamer
+	stream int
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	w.s.append(w.stream, p)
+	return len(p), nil
+}
+
+func (s *outputStreamer) append(stream int, p []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buf := &s.stdout
+	if stream == 1 {
+		buf = &s.stderr
+	}
+
+	remaining := s.maxSize - s.sent[stream] - len(*buf)
+	if remaining <= 0 {
+		s.markTruncated(stream, buf)
+		return
+	}
+	if len(p) > remaining {
+		*buf = append(*buf, p[:remaining]...)
+		s.markTruncated(stream, buf)
+		return
+	}
+	*buf = append(*buf, p...)
+}
+
+func (s *outputStreamer) markTruncated(stream int, buf *[]byte) {
+	if s.truncated[stream] {
+		return
+	}
+	s.truncated[stream] = true
+	*buf = append(*buf, "\n... [LIVE OUTPUT TRUNCATED] ...\n"...)
+}
+
+// Run flushes buffered output every outputFlushInterval until ctx is done
+func (s *outputStreamer) Run(ctx context.Context) {
+	ticker := time.NewTicker(outputFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Flush(false)
+		}
+	}
+}
+
+// Flush sends buffered output to the server. Unless final is set, an
+// incomplete trailing UTF-8 sequence is held back for the next flush.
+func (s *outputStreamer) Flush(final bool) {
+	s.mu.Lock()
+	stdout := takeChunk(&s.stdout, final)
+	stderr := takeChunk(&s.stderr, final)
+	s.sent[0] += len(stdout)
+	s.sent[1] += len(stderr)
+	s.mu.Unlock()
+
+	if len(stdout) == 0 && len(stderr) == 0 {
+		return
+	}
+
+	err := s.client.AppendJobOutput(context.Background(), s.jobID, &models.OutputRequest{
+		ExecutorID: s.executorID,
+		Stdout:     string(stdout),
+		Stderr:     string(stderr),
+	})
+	if err != nil {
+		slog.Warn("Failed to send live job output",
+			"job_id", s.jobID,
+			"error", err,
+		)
+	}
+}
+
+// takeChunk removes and returns the sendable prefix of buf
+func takeChunk(buf *[]byte, final bool) []byte {
+	n := len(*buf)
+	if !final {
+		n = completeUTF8Prefix(*buf)
+	}
+	chunk := make([]byte, n)
+	copy(chunk, (*buf)[:n])
+	*buf = append((*buf)[:0], (*buf)[n:]...)
+	return chunk
+}
+
+// completeUTF8Prefix returns the length of b without a trailing partial rune
+func completeUTF8Prefix(b []byte) int {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if utf8.FullRune(b[i:]) {
+				return len(b)
+			}
+			return i
+		}
+	}
+	return len(b)
+}
\ No newline at end of file
@@ -0,0 +1,31 @@
+package executor
+
+import (
+	"log/slog"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/mem"
+)
+
+// sampleUsage returns the host's CPU usage since the previous sample and the
+// memory currently in use. A value is nil when it can't be read.
+func sampleUsage() (*float64, *int64) {
+	var cpuPercent *float64
+	percents, err := cpu.Percent(0, false)
+	if err != nil {
+		slog.Debug("Failed to sample CPU usage", "error", err)
+	} else if len(percents) > 0 {
+		cpuPercent = &percents[0]
+	}
+	
+	var memBytes *int64
+	vm, err := mem.VirtualMemory()
+	if err != nil {
+		slog.Debug("Failed to sample memory usage", "error", err)
+	} else {
+		used := int64(vm.Used)
+		memBytes = &used
+	}
+	
+	return cpuPercent, memBytes
+}
\ No newline at end of file
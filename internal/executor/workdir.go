@@ -0,0 +1,58 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"time"
+)
+
+// workDirCheckInterval is how often a job's working directory is measured
+// against its quota
+const workDirCheckInterval = time.Second
+
+// errWorkDirQuotaExceeded cancels a job whose working directory grew larger
+// than its quota
+var errWorkDirQuotaExceeded = errors.New("working directory quota exceeded")
+
+// watchWorkDir cancels the job with errWorkDirQuotaExceeded once WorkDir
+// holds more than WorkDirQuota bytes. It returns when ctx is done.
+func (r *JobRunner) watchWorkDir(ctx context.Context, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(workDirCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if size := dirSize(r.WorkDir); size > r.WorkDirQuota {
+				slog.Warn("Job exceeded working directory quota",
+					"job_id", r.JobID,
+					"size", size,
+					"quota", r.WorkDirQuota,
+				)
+				cancel(errWorkDirQuotaExceeded)
+				return
+			}
+		}
+	}
+}
+
+// dirSize returns the total size of the regular files below dir. Files that
+// disappear while walking are skipped.
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
\ No newline at end of file
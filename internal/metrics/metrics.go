@@ -38,6 +38,14 @@ var (
 		},
 	)
 
+	JobsDeadLettered = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "executr_jobs_dead_lettered_total",
+			Help: "Total number of jobs moved to dead letter after exhausting retries",
+		},
+		[]string{"type"},
+	)
+
 	JobDuration = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "executr_job_duration_seconds",
@@ -65,6 +73,14 @@ var (
 		[]string{"status", "priority"},
 	)
 
+	OldestPendingAge = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "executr_oldest_pending_age_seconds",
+			Help: "How long the oldest due pending job of each priority has been waiting",
+		},
+		[]string{"priority"},
+	)
+
 	// Executor metrics
 	ExecutorsActive = promauto.NewGauge(
 		prometheus.GaugeOpts{
@@ -89,6 +105,22 @@ var (
 		[]string{"executor"},
 	)
 
+	ExecutorCPUPercent = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "executr_executor_cpu_percent",
+			Help: "Latest CPU usage reported by each executor",
+		},
+		[]string{"executor"},
+	)
+
+	ExecutorMemBytes = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "executr_executor_mem_bytes",
+			Help: "Latest memory usage in bytes reported by each executor",
+		},
+		[]string{"executor"},
+	)
+
 	// Binary cache metrics
 	BinaryCacheHits = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -166,6 +198,14 @@ var (
 		[]string{"method", "endpoint"},
 	)
 
+	RateLimitedRequests = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "executr_rate_limited_requests_total",
+			Help: "Total number of API requests rejected by a rate limit",
+		},
+		[]string{"limit"},
+	)
+
 	// System metrics
 	StaleJobsRecovered = promauto.NewCounter(
 		prometheus.CounterOpts{
@@ -180,6 +220,20 @@ var (
 			Help: "Total number of old jobs cleaned",
 		},
 	)
+
+	JobsArchived = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "executr_jobs_archived_total",
+			Help: "Total number of jobs archived before the retention cleanup deleted them",
+		},
+	)
+
+	JobEventsDropped = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "executr_job_events_dropped_total",
+			Help: "Total number of job events not delivered to slow subscribers",
+		},
+	)
 )
 
 // Helper function to track executor status
@@ -188,7 +242,7 @@ func UpdateExecutorCount(delta float64) {
 }
 
 // Helper function to update queue metrics
-func UpdateQueueMetrics(pending, running, completed, failed, cancelled map[string]int) {
+func UpdateQueueMetrics(pending, running, completed, failed, cancelled, deadLetter, skipped map[string]int) {
 	// Clear existing metrics
 	JobsInQueue.Reset()
 	
@@ -224,4 +278,26 @@ func UpdateQueueMetrics(pending, running, completed, failed, cancelled map[strin
 		total += count
 	}
 	JobsInQueue.WithLabelValues("cancelled", "all").Set(float64(total))
+	
+	// Update dead-lettered jobs
+	total = 0
+	for _, count := range deadLetter {
+		total += count
+	}
+	JobsInQueue.WithLabelValues("dead_letter", "all").Set(float64(total))
+	
+	// Update skipped jobs
+	total = 0
+	for _, count := range skipped {
+		total += count
+	}
+	JobsInQueue.WithLabelValues("skipped", "all").Set(float64(total))
+}
+
+// Helper function to update the age of the oldest pending job per priority;
+// ages should hold every priority, 0 for those without pending jobs
+func UpdateOldestPendingAge(ages map[string]float64) {
+	for priority, age := range ages {
+		OldestPendingAge.WithLabelValues(priority).Set(age)
+	}
 }
\ No newline at end of file
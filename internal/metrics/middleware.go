@@ -15,7 +15,7 @@ func HTTPMiddleware(next http.Handler) http.Handler {
 		start := time.Now()
 		
 		// Normalize the endpoint for metrics (remove IDs)
-		endpoint := normalizeEndpoint(r.URL.Path)
+		endpoint := NormalizeEndpoint(r.URL.Path)
 		
 		// Wrap the response writer to capture status code
 		wrapped := &responseWriter{ResponseWriter: w, statusCode: 200}
@@ -32,8 +32,9 @@ func HTTPMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// normalizeEndpoint removes IDs from paths for consistent metrics
-func normalizeEndpoint(path string) string {
+// NormalizeEndpoint replaces IDs in paths by {id}, so that requests for
+// different jobs or executors share metric labels and span names
+func NormalizeEndpoint(path string) string {
 	parts := strings.Split(path, "/")
 	for i, part := range parts {
 		// Check if part looks like a UUID or numeric ID
@@ -86,6 +87,13 @@ func (w *responseWriter) Write(b []byte) (int, error) {
 	return w.ResponseWriter.Write(b)
 }
 
+// Flush implements http.Flusher so streaming responses work through the middleware
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 // PrometheusHandler returns the Prometheus metrics handler
 func PrometheusHandler() http.Handler {
 	return promhttp.Handler()
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/draganm/executr/internal/utils"
+)
+
+// apiVersionMiddleware advertises the range of API versions the server
+// supports on every response, and rejects requests of clients that speak
+// a version outside of it. Requests without a version, e.g. from curl or
+// clients that predate the negotiation, are served as before.
+func (s *Server) apiVersionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(utils.APIVersionHeader, utils.CurrentAPIVersion.String())
+		w.Header().Set(utils.MinAPIVersionHeader, utils.MinAPIVersion.String())
+
+		requested := r.Header.Get(utils.APIVersionHeader)
+		if requested == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		v, err := utils.ParseAPIVersion(requested)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		if !v.CompatibleWith(utils.MinAPIVersion, utils.CurrentAPIVersion) {
+			s.writeError(w, http.StatusBadRequest, "Incompatible API version", map[string]interface{}{
+				"api_version":     v.String(),
+				"min_api_version": utils.MinAPIVersion.String(),
+				"max_api_version": utils.CurrentAPIVersion.String(),
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
\ No newline at end of file
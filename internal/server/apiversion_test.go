@@ -0,0 +1,47 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/draganm/executr/internal/utils"
+)
+
+func TestAPIVersionMiddleware(t *testing.T) {
+	s := &Server{config: &Config{}}
+	h := s.apiVersionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	newer := utils.APIVersion{Major: utils.CurrentAPIVersion.Major, Minor: utils.CurrentAPIVersion.Minor + 1}
+
+	tests := []struct {
+		version string
+		want    int
+	}{
+		{"", http.StatusNoContent},
+		{utils.CurrentAPIVersion.String(), http.StatusNoContent},
+		{newer.String(), http.StatusBadRequest},
+		{"not-a-version", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs", nil)
+		if tt.version != "" {
+			req.Header.Set(utils.APIVersionHeader, tt.version)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if rec.Code != tt.want {
+			t.Errorf("version %q: got %d, want %d", tt.version, rec.Code, tt.want)
+		}
+		if got := rec.Header().Get(utils.APIVersionHeader); got != utils.CurrentAPIVersion.String() {
+			t.Errorf("version %q: advertised %q", tt.version, got)
+		}
+		if got := rec.Header().Get(utils.MinAPIVersionHeader); got != utils.MinAPIVersion.String() {
+			t.Errorf("version %q: advertised minimum %q", tt.version, got)
+		}
+	}
+}
\ No newline at end of file
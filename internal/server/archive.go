@@ -0,0 +1,246 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/draganm/executr/internal/db"
+	"github.com/draganm/executr/internal/metrics"
+	"github.com/draganm/executr/internal/utils"
+	"github.com/draganm/executr/pkg/models"
+)
+
+// Sinks that keep a record of the jobs deleted by the retention cleanup
+const (
+	ArchiveSinkDB   = "db"   // the append-only jobs_archive table
+	ArchiveSinkFile = "file" // JSON lines appended to ArchiveFile
+	ArchiveSinkS3   = "s3"   // a JSON lines object per cleanup batch
+)
+
+// archiveBatchSize is how many jobs are archived and deleted per transaction
+const archiveBatchSize = 500
+
+// archivedJob is the record kept of a deleted job. Values of sensitive env
+// variables are redacted, as in API responses.
+type archivedJob struct {
+	Job        models.Job          `json:"job"`
+	Attempts   []models.JobAttempt `json:"attempts"`
+	ArchivedAt time.Time           `json:"archived_at"`
+}
+
+// jobArchive stores the records of jobs before the retention cleanup deletes
+// them. Archive is called in the transaction deleting the jobs, with q bound
+// to it, and the jobs are kept when it fails.
+type jobArchive interface {
+	Archive(ctx context.Context, q *db.Queries, jobs []archivedJob) error
+}
+
+// newJobArchive returns the archive configured by cfg, nil when deleted jobs
+// are not archived
+func newJobArchive(cfg *Config) (jobArchive, error) {
+	switch cfg.ArchiveSink {
+	case "":
+		return nil, nil
+	case ArchiveSinkDB:
+		return dbArchive{}, nil
+	case ArchiveSinkFile:
+		if cfg.ArchiveFile == "" {
+			return nil, fmt.Errorf("the %s archive sink requires an archive file", ArchiveSinkFile)
+		}
+		return fileArchive{path: cfg.ArchiveFile}, nil
+	case ArchiveSinkS3:
+		store, err := utils.NewS3Store(
+			cfg.ArchiveS3URL,
+			cfg.ArchiveS3Bucket,
+			cfg.ArchiveS3Region,
+			cfg.ArchiveS3AccessKey,
+			cfg.ArchiveS3SecretKey,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("invalid archive store: %w", err)
+		}
+		return s3Archive{store: store, prefix: cfg.ArchiveS3Prefix}, nil
+	default:
+		return nil, fmt.Errorf("unknown archive sink %q, expected %s, %s or %s", cfg.ArchiveSink, ArchiveSinkDB, ArchiveSinkFile, ArchiveSinkS3)
+	}
+}
+
+// dbArchive inserts the records into the jobs_archive table, so archiving
+// and deleting either both happen or neither does
+type dbArchive struct{}
+
+func (dbArchive) Archive(ctx context.Context, q *db.Queries, jobs []archivedJob) error {
+	for _, record := range jobs {
+		job, err := json.Marshal(record.Job)
+		if err != nil {
+			return fmt.Errorf("failed to marshal job %s: %w", record.Job.ID, err)
+		}
+		attempts, err := json.Marshal(record.Attempts)
+		if err != nil {
+			return fmt.Errorf("failed to marshal attempts of job %s: %w", record.Job.ID, err)
+		}
+
+		params := db.ArchiveJobParams{
+			ID:        record.Job.ID,
+			Type:      record.Job.Type,
+			Status:    string(record.Job.Status),
+			CreatedAt: pgtype.Timestamptz{Time: record.Job.CreatedAt, Valid: true},
+			Job:       job,
+			Attempts:  attempts,
+		}
+		if record.Job.CompletedAt != nil {
+			params.CompletedAt = pgtype.Timestamptz{Time: *record.Job.CompletedAt, Valid: true}
+		}
+		if err := q.ArchiveJob(ctx, params); err != nil {
+			return fmt.Errorf("failed to archive job %s: %w", record.Job.ID, err)
+		}
+	}
+	return nil
+}
+
+// fileArchive appends the records to a file, one JSON object per line. The
+// file is synced before the jobs are deleted, but a failed commit archives
+// them again on the next run.
+type fileArchive struct {
+	path string
+}
+
+func (a fileArchive) Archive(ctx context.Context, q *db.Queries, jobs []archivedJob) error {
+	lines, err := jsonLines(jobs)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open archive file: %w", err)
+	}
+	if _, err := f.Write(lines); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write archive file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync archive file: %w", err)
+	}
+	return f.Close()
+}
+
+// s3Archive uploads each batch of records as a JSON lines object. As with
+// fileArchive, a failed commit archives the jobs again on the next run.
+type s3Archive struct {
+	store  *utils.S3Store
+	prefix string
+}
+
+func (a s3Archive) Archive(ctx context.Context, q *db.Queries, jobs []archivedJob) error {
+	lines, err := jsonLines(jobs)
+	if err != nil {
+		return err
+	}
+
+	// Keys sort by the time they were archived
+	key := path.Join(a.prefix, fmt.Sprintf("jobs-%s-%s.jsonl", time.Now().UTC().Format("20060102T150405Z"), uuid.New()))
+	if _, err := a.store.Put(ctx, key, bytes.NewReader(lines), int64(len(lines))); err != nil {
+		return fmt.Errorf("failed to upload archive: %w", err)
+	}
+	return nil
+}
+
+func jsonLines(jobs []archivedJob) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, record := range jobs {
+		if err := enc.Encode(record); err != nil {
+			return nil, fmt.Errorf("failed to marshal job %s: %w", record.Job.ID, err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// archiveOldJobs archives the jobs the retention cleanup deletes and deletes
+// them, a batch per transaction
+func (s *Server) archiveOldJobs(ctx context.Context) {
+	for {
+		archived, err := s.archiveJobBatch(ctx)
+		if err != nil {
+			slog.Error("Failed to archive old jobs", "error", err)
+			return
+		}
+		if archived > 0 {
+			metrics.JobsArchived.Add(float64(archived))
+			slog.Debug("Archived old jobs", "count", archived)
+		}
+		if archived < archiveBatchSize {
+			metrics.OldJobsCleaned.Inc()
+			return
+		}
+	}
+}
+
+// archiveJobBatch archives and deletes up to archiveBatchSize expired jobs
+// and returns how many there were
+func (s *Server) archiveJobBatch(ctx context.Context) (int, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.queries.WithTx(tx)
+
+	jobs, err := q.GetExpiredJobs(ctx, db.GetExpiredJobsParams{
+		Retention: s.jobRetention(),
+		BatchSize: archiveBatchSize,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get expired jobs: %w", err)
+	}
+	if len(jobs) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]uuid.UUID, len(jobs))
+	for i, job := range jobs {
+		ids[i] = job.ID
+	}
+	attempts, err := q.GetJobAttemptsForJobs(ctx, ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get job attempts: %w", err)
+	}
+	attemptsByJob := make(map[uuid.UUID][]db.JobAttempt)
+	for _, attempt := range attempts {
+		attemptsByJob[attempt.JobID] = append(attemptsByJob[attempt.JobID], attempt)
+	}
+
+	now := time.Now().UTC()
+	records := make([]archivedJob, len(jobs))
+	for i, job := range jobs {
+		records[i] = archivedJob{
+			Job:        s.dbJobToModel(job),
+			Attempts:   dbAttemptsToModels(attemptsByJob[job.ID]),
+			ArchivedAt: now,
+		}
+	}
+
+	if err := s.archive.Archive(ctx, q, records); err != nil {
+		return 0, err
+	}
+	if err := q.DeleteJobs(ctx, ids); err != nil {
+		return 0, fmt.Errorf("failed to delete jobs: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return len(jobs), nil
+}
\ No newline at end of file
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draganm/executr/pkg/models"
+)
+
+func TestFileArchiveAppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.jsonl")
+	archive, err := newJobArchive(&Config{ArchiveSink: ArchiveSinkFile, ArchiveFile: path})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var ids []uuid.UUID
+	for range 2 {
+		id := uuid.New()
+		ids = append(ids, id)
+		record := archivedJob{
+			Job:        models.Job{ID: id, Type: "report", Status: models.StatusCompleted},
+			Attempts:   []models.JobAttempt{{JobID: id, Status: "completed"}},
+			ArchivedAt: time.Now(),
+		}
+		if err := archive.Archive(context.Background(), nil, []archivedJob{record}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer f.Close()
+
+	var got []archivedJob
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record archivedJob
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid line %q: %v", scanner.Text(), err)
+		}
+		got = append(got, record)
+	}
+	if len(got) != 2 || got[0].Job.ID != ids[0] || got[1].Job.ID != ids[1] {
+		t.Fatalf("expected both jobs in order, got %+v", got)
+	}
+	if len(got[1].Attempts) != 1 || got[1].Attempts[0].JobID != ids[1] {
+		t.Fatalf("expected the attempt of the job, got %+v", got[1].Attempts)
+	}
+}
+
+func TestNewRejectsInvalidArchiveSinks(t *testing.T) {
+	for _, cfg := range []*Config{
+		{ArchiveSink: "kafka"},
+		{ArchiveSink: ArchiveSinkFile},
+		{ArchiveSink: ArchiveSinkS3, ArchiveS3URL: "https://s3.example.com"},
+		{ArchiveSink: ArchiveSinkS3, ArchiveS3URL: "ftp://s3.example.com", ArchiveS3Bucket: "jobs"},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("expected an error for %+v", cfg)
+		}
+	}
+}
\ No newline at end of file
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/draganm/executr/internal/db"
+	"github.com/draganm/executr/pkg/models"
+)
+
+// validateOutputGlobs checks the output globs of a submission. Like input
+// files, they must stay within the job's working directory.
+func validateOutputGlobs(globs []string) error {
+	for _, glob := range globs {
+		if !filepath.IsLocal(glob) {
+			return fmt.Errorf("output glob %q must be a relative path within the working directory", glob)
+		}
+		if _, err := filepath.Match(glob, ""); err != nil {
+			return fmt.Errorf("output glob %q is malformed", glob)
+		}
+	}
+	return nil
+}
+
+// outputGlobs turns output globs into the column value
+func outputGlobs(globs []string) []string {
+	if globs == nil {
+		return []string{}
+	}
+	return globs
+}
+
+// artifactURL returns where the server serves an uploaded artifact
+func artifactURL(jobID uuid.UUID, name string) string {
+	u := url.URL{Path: "/api/v1/jobs/" + jobID.String() + "/artifacts/" + name}
+	return u.EscapedPath()
+}
+
+// recordArtifacts stores the artifacts an executor uploaded to object
+// storage. Failures are logged, the job's result is already recorded.
+func (s *Server) recordArtifacts(r *http.Request, jobID uuid.UUID, artifacts []models.Artifact) {
+	for _, artifact := range artifacts {
+		if !filepath.IsLocal(artifact.Name) || artifact.URL == "" {
+			slog.WarnContext(r.Context(), "Ignoring invalid artifact", "job_id", jobID, "name", artifact.Name)
+			continue
+		}
+		err := s.queries.RecordJobArtifact(r.Context(), db.RecordJobArtifactParams{
+			JobID:  jobID,
+			Name:   artifact.Name,
+			Size:   artifact.Size,
+			Sha256: artifact.SHA256,
+			Url:    pgtype.Text{String: artifact.URL, Valid: true},
+		})
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to record artifact", "error", err, "job_id", jobID, "name", artifact.Name)
+		}
+	}
+}
+
+func (s *Server) handleListArtifacts(w http.ResponseWriter, r *http.Request, jobID uuid.UUID) {
+	if _, err := s.queries.GetJob(r.Context(), jobID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.writeError(w, http.StatusNotFound, "Job not found", map[string]interface{}{"job_id": jobID})
+		} else {
+			slog.ErrorContext(r.Context(), "Failed to get job", "error", err, "job_id", jobID)
+			s.writeError(w, http.StatusInternalServerError, "Failed to list artifacts", nil)
+		}
+		return
+	}
+
+	rows, err := s.queries.ListJobArtifacts(r.Context(), jobID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list artifacts", "error", err, "job_id", jobID)
+		s.writeError(w, http.StatusInternalServerError, "Failed to list artifacts", nil)
+		return
+	}
+
+	response := make([]models.Artifact, len(rows))
+	for i, row := range rows {
+		response[i] = models.Artifact{
+			Name:      row.Name,
+			Size:      row.Size,
+			SHA256:    row.Sha256,
+			URL:       artifactURL(jobID, row.Name),
+			CreatedAt: row.CreatedAt.Time,
+		}
+		if row.Url.Valid {
+			response[i].URL = row.Url.String
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) handleArtifact(w http.ResponseWriter, r *http.Request, jobID uuid.UUID, name string) {
+	if !filepath.IsLocal(name) {
+		s.writeError(w, http.StatusBadRequest, "Invalid artifact name", map[string]interface{}{"name": name})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.handleGetArtifact(w, r, jobID, name)
+	case http.MethodPut:
+		s.handleUploadArtifact(w, r, jobID, name)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleGetArtifact serves an artifact uploaded to the server, or redirects
+// to the object store holding it
+func (s *Server) handleGetArtifact(w http.ResponseWriter, r *http.Request, jobID uuid.UUID, name string) {
+	artifact, err := s.queries.GetJobArtifact(r.Context(), db.GetJobArtifactParams{JobID: jobID, Name: name})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.writeError(w, http.StatusNotFound, "Artifact not found", map[string]interface{}{"job_id": jobID, "name": name})
+		} else {
+			slog.ErrorContext(r.Context(), "Failed to get artifact", "error", err, "job_id", jobID, "name", name)
+			s.writeError(w, http.StatusInternalServerError, "Failed to get artifact", nil)
+		}
+		return
+	}
+
+	if artifact.Url.Valid {
+		http.Redirect(w, r, artifact.Url.String, http.StatusFound)
+		return
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(artifact.Content)))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(name)}))
+	w.Write(artifact.Content)
+}
+
+// handleUploadArtifact stores an artifact sent by the executor running the
+// job. Artifacts are bounded by the same limit as job output.
+func (s *Server) handleUploadArtifact(w http.ResponseWriter, r *http.Request, jobID uuid.UUID, name string) {
+	executorID := r.URL.Query().Get("executor_id")
+	if executorID == "" {
+		s.writeError(w, http.StatusBadRequest, "executor_id is required", nil)
+		return
+	}
+
+	limit := s.maxOutputBytesLimit()
+	content, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(limit)))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			s.writeError(w, http.StatusRequestEntityTooLarge, "Artifact is too large", map[string]interface{}{"max_bytes": limit})
+			return
+		}
+		s.writeError(w, http.StatusBadRequest, "Failed to read artifact", nil)
+		return
+	}
+
+	sum := sha256.Sum256(content)
+	artifact := models.Artifact{
+		Name:   name,
+		Size:   int64(len(content)),
+		SHA256: hex.EncodeToString(sum[:]),
+		URL:    artifactURL(jobID, name),
+	}
+
+	rows, err := s.queries.UploadJobArtifact(r.Context(), db.UploadJobArtifactParams{
+		Name:       artifact.Name,
+		Size:       artifact.Size,
+		Sha256:     artifact.SHA256,
+		Content:    content,
+		JobID:      jobID,
+		ExecutorID: pgtype.Text{String: executorID, Valid: true},
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to store artifact", "error", err, "job_id", jobID, "name", name)
+		s.writeError(w, http.StatusInternalServerError, "Failed to store artifact", nil)
+		return
+	}
+	if rows == 0 {
+		s.writeError(w, http.StatusConflict, "Job is not running on this executor", map[string]interface{}{"job_id": jobID})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(artifact)
+}
\ No newline at end of file
@@ -0,0 +1,28 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestValidateOutputGlobs(t *testing.T) {
+	if err := validateOutputGlobs([]string{"out/*.csv", "report.pdf", "logs/**/*.log", "[a-c].txt"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, glob := range []string{"/tmp/*", "../*", "out/../../x", "", "[a-"} {
+		if err := validateOutputGlobs([]string{glob}); err == nil {
+			t.Errorf("expected an error for %q", glob)
+		}
+	}
+}
+
+func TestArtifactURL(t *testing.T) {
+	jobID := uuid.MustParse("550e8400-e29b-41d4-a716-446655440000")
+	got := artifactURL(jobID, "out/my report.csv")
+	want := "/api/v1/jobs/550e8400-e29b-41d4-a716-446655440000/artifacts/out/my%20report.csv"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,166 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Scope is a permission granted to an API key
+type Scope string
+
+const (
+	// ScopeSubmit allows submitting, listing, inspecting, cancelling and
+	// requeueing jobs
+	ScopeSubmit Scope = "submit"
+	// ScopeExecutor allows registering executors, claiming jobs and reporting
+	// their progress
+	ScopeExecutor Scope = "executor"
+	// ScopeAdmin allows everything, including the admin and bulk cancel endpoints
+	ScopeAdmin Scope = "admin"
+)
+
+// APIKeys maps an API key to the scopes it grants
+type APIKeys map[string][]Scope
+
+// LoadAPIKeys reads an API keys file. Each non-empty line holds a key
+// followed by a comma-separated list of scopes, e.g.
+//
+//	# key                scopes
+//	ci-7f3a9c2e          submit
+//	worker-0b41d6aa      executor
+//	ops-5e2c81f4         admin
+//
+// Lines starting with # are ignored.
+func LoadAPIKeys(path string) (APIKeys, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open API keys file: %w", err)
+	}
+	defer f.Close()
+
+	keys := make(APIKeys)
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected <key> <scope>[,<scope>...]", lineNo)
+		}
+
+		var scopes []Scope
+		for _, name := range strings.Split(fields[1], ",") {
+			scope := Scope(strings.TrimSpace(name))
+			switch scope {
+			case ScopeSubmit, ScopeExecutor, ScopeAdmin:
+				scopes = append(scopes, scope)
+			default:
+				return nil, fmt.Errorf("line %d: unknown scope %q", lineNo, name)
+			}
+		}
+		keys[fields[0]] = scopes
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read API keys file: %w", err)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("API keys file %s contains no keys", path)
+	}
+
+	return keys, nil
+}
+
+// allows reports whether key grants the given scope
+func (k APIKeys) allows(key string, scope Scope) bool {
+	for _, s := range k[key] {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// authMiddleware rejects requests without an API key that grants the scope
+// required by the endpoint. Health and metrics endpoints stay open.
+func (s *Server) authMiddleware(keys APIKeys, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope, required := requiredScope(r)
+		if !required {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := apiKeyFromRequest(r)
+		if _, known := keys[key]; key == "" || !known {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="executr"`)
+			s.writeError(w, http.StatusUnauthorized, "Missing or invalid API key", nil)
+			return
+		}
+
+		if !keys.allows(key, scope) {
+			slog.WarnContext(r.Context(), "API key lacks required scope",
+				"path", r.URL.Path,
+				"method", r.Method,
+				"scope", scope,
+			)
+			s.writeError(w, http.StatusForbidden, "API key does not grant the required scope", map[string]interface{}{"scope": scope})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// apiKeyFromRequest returns the key from the Authorization bearer token or
+// the X-API-Key header
+func apiKeyFromRequest(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if token, ok := strings.CutPrefix(auth, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+// requiredScope maps a request to the scope it needs. The second return
+// value is false for endpoints that don't require authentication.
+func requiredScope(r *http.Request) (Scope, bool) {
+	path := r.URL.Path
+
+	switch {
+	case path == "/api/v1/health" || path == "/api/v1/metrics" || path == "/api/v1/version":
+		return "", false
+	case strings.HasPrefix(path, "/api/v1/admin/"), path == "/api/v1/jobs/bulk/cancel",
+		strings.HasPrefix(path, pprofPrefix):
+		return ScopeAdmin, true
+	case path == "/api/v1/jobs/claim",
+		path == "/api/v1/executors",
+		strings.HasPrefix(path, "/api/v1/executors/"):
+		return ScopeExecutor, true
+	}
+
+	// Executor sub-resources of a job
+	if strings.HasPrefix(path, "/api/v1/jobs/") {
+		switch {
+		case strings.HasSuffix(path, "/heartbeat"),
+			strings.HasSuffix(path, "/complete"),
+			strings.HasSuffix(path, "/fail"),
+			strings.HasSuffix(path, "/cancelled"),
+			strings.HasSuffix(path, "/output") && r.Method == http.MethodPut,
+			strings.Contains(path, "/artifacts/") && r.Method == http.MethodPut:
+			return ScopeExecutor, true
+		}
+	}
+
+	return ScopeSubmit, true
+}
\ No newline at end of file
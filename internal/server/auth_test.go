@@ -0,0 +1,93 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeKeysFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "keys")
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadAPIKeys(t *testing.T) {
+	path := writeKeysFile(t, "# comment\n\nci submit\nworker executor\nops submit,admin\n")
+
+	keys, err := LoadAPIKeys(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(keys) != 3 {
+		t.Fatalf("expected 3 keys, got %d", len(keys))
+	}
+	if !keys.allows("ops", ScopeExecutor) {
+		t.Error("admin key should grant every scope")
+	}
+	if keys.allows("ci", ScopeExecutor) {
+		t.Error("submit key should not grant executor scope")
+	}
+
+	for _, bad := range []string{"", "ci superuser\n", "ci\n", "ci submit extra\n"} {
+		if _, err := LoadAPIKeys(writeKeysFile(t, bad)); err == nil {
+			t.Errorf("expected error for keys file %q", bad)
+		}
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	s := &Server{}
+	keys := APIKeys{
+		"ci":     {ScopeSubmit},
+		"worker": {ScopeExecutor},
+	}
+	h := s.authMiddleware(keys, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		method string
+		path   string
+		header string
+		value  string
+		want   int
+	}{
+		{"GET", "/api/v1/health", "", "", http.StatusOK},
+		{"GET", "/api/v1/version", "", "", http.StatusOK},
+		{"GET", "/api/v1/jobs", "", "", http.StatusUnauthorized},
+		{"GET", "/api/v1/jobs", "Authorization", "Bearer nope", http.StatusUnauthorized},
+		{"GET", "/api/v1/jobs", "Authorization", "Bearer ci", http.StatusOK},
+		{"GET", "/api/v1/jobs", "X-API-Key", "ci", http.StatusOK},
+		{"POST", "/api/v1/jobs/claim", "Authorization", "Bearer ci", http.StatusForbidden},
+		{"POST", "/api/v1/jobs/claim", "Authorization", "Bearer worker", http.StatusOK},
+		{"POST", "/api/v1/jobs/abc/complete", "Authorization", "Bearer worker", http.StatusOK},
+		{"PUT", "/api/v1/jobs/abc/cancelled", "Authorization", "Bearer ci", http.StatusForbidden},
+		{"PUT", "/api/v1/jobs/abc/output", "Authorization", "Bearer worker", http.StatusOK},
+		{"GET", "/api/v1/jobs/abc/output", "Authorization", "Bearer worker", http.StatusForbidden},
+		{"GET", "/api/v1/jobs/abc/output", "Authorization", "Bearer ci", http.StatusOK},
+		{"POST", "/api/v1/executors", "Authorization", "Bearer ci", http.StatusForbidden},
+		{"PUT", "/api/v1/executors/w-1/heartbeat", "Authorization", "Bearer worker", http.StatusOK},
+		{"DELETE", "/api/v1/schedules/abc", "Authorization", "Bearer ci", http.StatusOK},
+		{"GET", "/api/v1/schedules", "Authorization", "Bearer worker", http.StatusForbidden},
+		{"GET", "/api/v1/admin/stats", "Authorization", "Bearer ci", http.StatusForbidden},
+		{"GET", "/debug/pprof/heap", "Authorization", "Bearer ci", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.header != "" {
+			req.Header.Set(tt.header, tt.value)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s %s with %s=%q: got %d, want %d", tt.method, tt.path, tt.header, tt.value, rec.Code, tt.want)
+		}
+	}
+}
\ No newline at end of file
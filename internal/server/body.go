@@ -0,0 +1,79 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// DefaultMaxRequestBytes is the default size limit of JSON request bodies
+// that carry no job data, e.g. claims and heartbeats
+const DefaultMaxRequestBytes = 4 * 1024 * 1024
+
+// maxRequestBytes returns the configured size limit of JSON request bodies
+func (s *Server) maxRequestBytes() int64 {
+	if s.config.MaxRequestBytes <= 0 {
+		return DefaultMaxRequestBytes
+	}
+	return s.config.MaxRequestBytes
+}
+
+// maxUploadBytes returns the size limit of JSON request bodies carrying job
+// data, i.e. submissions with stdin and input files and reports with both
+// output streams. Each part is bounded by the output ceiling, and escaping
+// or base64 encoding can double it.
+func (s *Server) maxUploadBytes() int64 {
+	return 4*int64(s.maxOutputBytesLimit()) + s.maxRequestBytes()
+}
+
+// decodeBody decodes a JSON request body into v, reading at most
+// maxRequestBytes. Larger bodies are answered with 413, malformed ones with
+// 400; it returns false in both cases.
+func (s *Server) decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	return s.decodeBodyLimit(w, r, v, s.maxRequestBytes())
+}
+
+// decodeUpload is decodeBody for requests carrying job data, reading at
+// most maxUploadBytes
+func (s *Server) decodeUpload(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	return s.decodeBodyLimit(w, r, v, s.maxUploadBytes())
+}
+
+func (s *Server) decodeBodyLimit(w http.ResponseWriter, r *http.Request, v interface{}, limit int64) bool {
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, limit)).Decode(v)
+	if err == nil {
+		return true
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		s.writeError(w, http.StatusRequestEntityTooLarge, "Request body is too large", map[string]interface{}{"max_bytes": limit})
+		return false
+	}
+	s.writeError(w, http.StatusBadRequest, "Invalid request body", nil)
+	return false
+}
+
+// hasBody reports whether a request has a body, reading ahead when its
+// length is unknown, e.g. with chunked encoding. The body stays intact for
+// decodeBody.
+func hasBody(r *http.Request) bool {
+	if r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+		return false
+	}
+	if r.ContentLength > 0 {
+		return true
+	}
+
+	br := bufio.NewReader(r.Body)
+	if _, err := br.Peek(1); errors.Is(err, io.EOF) {
+		return false
+	}
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{br, r.Body}
+	return true
+}
\ No newline at end of file
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestOversizedRequestBodyIsRejected(t *testing.T) {
+	s, err := New(&Config{MaxRequestBytes: 1024, MaxOutputBytesLimit: 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Submissions may carry job data up to four times the output ceiling
+	// on top of the request limit
+	oversized := `{"type":"big","arguments":["` + strings.Repeat("x", 6000) + `"]}`
+	for _, tt := range []struct {
+		path    string
+		body    string
+		handler http.HandlerFunc
+		limit   float64
+	}{
+		{"/api/v1/jobs", oversized, s.handleJobs, 5120},
+		{"/api/v1/jobs/bulk", "[" + oversized + "]", s.handleBulkJobs, 5120},
+		{"/api/v1/jobs/claim", `{"executor_id":"` + strings.Repeat("x", 2048) + `"}`, s.handleClaimJob, 1024},
+	} {
+		req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+		rec := httptest.NewRecorder()
+		tt.handler(rec, req)
+
+		if rec.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("%s: expected 413, got %d", tt.path, rec.Code)
+		}
+		var resp struct {
+			Error   string                 `json:"error"`
+			Context map[string]interface{} `json:"context"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Error != "Request body is too large" || resp.Context["max_bytes"] != tt.limit {
+			t.Fatalf("%s: unexpected error response %+v", tt.path, resp)
+		}
+	}
+}
+
+func TestSubmissionAboveRequestLimitIsAccepted(t *testing.T) {
+	s, err := New(&Config{MaxRequestBytes: 1024, MaxOutputBytesLimit: 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Decoded despite its size, then rejected for the job type
+	body := `{"type":"` + strings.Repeat("t", MaxJobTypeLength+1) + `","stdin":"` + strings.Repeat("A", 2048) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	s.handleJobs(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rec.Code, rec.Body)
+	}
+	if !strings.Contains(rec.Body.String(), "type") {
+		t.Fatalf("expected a job type error, got %s", rec.Body)
+	}
+}
+
+func TestMalformedRequestBodyIsBadRequest(t *testing.T) {
+	s, err := New(&Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader("{"))
+	rec := httptest.NewRecorder()
+	s.handleJobs(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+}
+
+func TestLongJobTypeIsRejected(t *testing.T) {
+	s, err := New(&Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := `{"type":"` + strings.Repeat("t", MaxJobTypeLength+1) + `","binary_url":"https://example.com/tool","binary_sha256":"abc"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	s.handleJobs(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+}
+
+func TestCancellationReasonFromBodyOfUnknownLength(t *testing.T) {
+	s, err := New(&Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for body, want := range map[string]pgtype.Text{
+		"":                   {},
+		`{"reason":"stale"}`: {String: "stale", Valid: true},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/jobs/cancel", strings.NewReader(body))
+		req.ContentLength = -1 // as with chunked encoding
+		rec := httptest.NewRecorder()
+
+		reason, ok := s.cancellationReason(rec, req)
+		if !ok {
+			t.Fatalf("%q: expected a valid reason, got %d: %s", body, rec.Code, rec.Body)
+		}
+		if reason != want {
+			t.Errorf("%q: got reason %+v, want %+v", body, reason, want)
+		}
+	}
+}
\ No newline at end of file
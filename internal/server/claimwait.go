@@ -0,0 +1,61 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxClaimWait bounds how long a claim may wait for a job. It stays below
+// the client's 30s request timeout.
+const maxClaimWait = 20 * time.Second
+
+// claimRecheckInterval is how often a waiting claim looks for a job without
+// being woken up, e.g. for jobs whose scheduled_at has passed or when a
+// notification was missed while the listener reconnected
+const claimRecheckInterval = 5 * time.Second
+
+// jobSignal wakes up waiting claims when a job may have become claimable,
+// see jobListener
+type jobSignal struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+// wait returns a channel that is closed by the next notify. Get it before
+// looking for a job, so a job that appears in between isn't missed.
+func (j *jobSignal) wait() <-chan struct{} {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.ch == nil {
+		j.ch = make(chan struct{})
+	}
+	return j.ch
+}
+
+// notify wakes up all waiting claims. They race for the job through the
+// claim query, which skips rows locked by another claim.
+func (j *jobSignal) notify() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.ch != nil {
+		close(j.ch)
+		j.ch = nil
+	}
+}
+
+// claimWait parses the wait query parameter of a claim, e.g. 10s. Without
+// it the claim returns immediately.
+func claimWait(r *http.Request) (time.Duration, error) {
+	value := r.URL.Query().Get("wait")
+	if value == "" {
+		return 0, nil
+	}
+
+	wait, err := time.ParseDuration(value)
+	if err != nil || wait < 0 {
+		return 0, fmt.Errorf("invalid wait %q, expected a duration like 10s", value)
+	}
+	return min(wait, maxClaimWait), nil
+}
\ No newline at end of file
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClaimWait(t *testing.T) {
+	tests := []struct {
+		query string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"?wait=10s", 10 * time.Second},
+		{"?wait=0", 0},
+		{"?wait=5m", maxClaimWait},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", "/api/v1/jobs/claim"+tt.query, nil)
+		got, err := claimWait(r)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.query, err)
+		}
+		if got != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.query, got, tt.want)
+		}
+	}
+
+	for _, query := range []string{"?wait=soon", "?wait=-1s"} {
+		r := httptest.NewRequest("POST", "/api/v1/jobs/claim"+query, nil)
+		if _, err := claimWait(r); err == nil {
+			t.Errorf("expected error for %q", query)
+		}
+	}
+}
+
+func TestJobSignalWakesWaiters(t *testing.T) {
+	var s jobSignal
+
+	first := s.wait()
+	second := s.wait()
+	s.notify()
+
+	for _, ch := range []<-chan struct{}{first, second} {
+		select {
+		case <-ch:
+		default:
+			t.Fatal("waiter was not woken up")
+		}
+	}
+
+	select {
+	case <-s.wait():
+		t.Fatal("a new waiter must not see an earlier notification")
+	default:
+	}
+}
\ No newline at end of file
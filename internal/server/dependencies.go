@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+
+	"github.com/draganm/executr/internal/db"
+)
+
+// errUnknownDependency is returned when depends_on references a job that
+// doesn't exist
+var errUnknownDependency = errors.New("depends_on references unknown jobs")
+
+// dedupeDependencies drops repeated job IDs while keeping the original order
+func dedupeDependencies(dependsOn []uuid.UUID) []uuid.UUID {
+	if len(dependsOn) == 0 {
+		return nil
+	}
+
+	seen := make(map[uuid.UUID]bool, len(dependsOn))
+	result := make([]uuid.UUID, 0, len(dependsOn))
+	for _, id := range dependsOn {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// createJob inserts a job together with its dependencies. Jobs with
+// dependencies are created in a transaction so a job never becomes claimable
+// before its dependencies are recorded.
+func (s *Server) createJob(ctx context.Context, params db.CreateJobParams, dependsOn []uuid.UUID) (db.Job, error) {
+	if len(dependsOn) == 0 {
+		return s.queries.CreateJob(ctx, params)
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return db.Job{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.queries.WithTx(tx)
+
+	count, err := q.CountJobsByIDs(ctx, dependsOn)
+	if err != nil {
+		return db.Job{}, fmt.Errorf("failed to look up dependencies: %w", err)
+	}
+	if count != int64(len(dependsOn)) {
+		return db.Job{}, errUnknownDependency
+	}
+
+	job, err := q.CreateJob(ctx, params)
+	if err != nil {
+		return db.Job{}, err
+	}
+
+	err = q.AddJobDependencies(ctx, db.AddJobDependenciesParams{
+		JobID:     job.ID,
+		DependsOn: dependsOn,
+	})
+	if err != nil {
+		return db.Job{}, fmt.Errorf("failed to add dependencies: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return db.Job{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return job, nil
+}
+
+// skipJobsWithUnmetDependencies moves pending jobs whose dependencies can no
+// longer complete to the skipped status. Skipping a job can make its own
+// dependents unrunnable, so this repeats until nothing else is skipped.
+func (s *Server) skipJobsWithUnmetDependencies(ctx context.Context) {
+	for {
+		skipped, err := s.queries.SkipJobsWithUnmetDependencies(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to skip jobs with unmet dependencies", "error", err)
+			return
+		}
+		if len(skipped) == 0 {
+			return
+		}
+
+		for _, id := range skipped {
+			slog.InfoContext(ctx, "Skipped job because a dependency did not complete", "job_id", id)
+		}
+	}
+}
\ No newline at end of file
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/draganm/executr/internal/db"
+	"github.com/draganm/executr/pkg/models"
+)
+
+// estimatedCompletion guesses when a pending or running job completes from
+// the average duration of completed jobs of its type. It returns nil when
+// there's no basis for a guess: no job of the type completed yet, the job
+// waits for dependencies, no executor is active or a running job already
+// took longer than the average.
+func (s *Server) estimatedCompletion(ctx context.Context, job db.Job) (*time.Time, error) {
+	status := models.Status(job.Status)
+	if status != models.StatusPending && status != models.StatusRunning {
+		return nil, nil
+	}
+
+	avgSeconds, err := s.queries.GetJobTypeDuration(ctx, job.Type)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	avg := time.Duration(avgSeconds * float64(time.Second))
+	now := time.Now()
+
+	if status == models.StatusRunning {
+		if !job.StartedAt.Valid {
+			return nil, nil
+		}
+		return runningEstimate(job.StartedAt.Time, avg, now), nil
+	}
+
+	unmet, err := s.queries.HasUnmetDependencies(ctx, job.ID)
+	if err != nil {
+		return nil, err
+	}
+	if unmet {
+		return nil, nil
+	}
+
+	// Executors count as active as long as their jobs would
+	slots, err := s.queries.SumActiveExecutorSlots(ctx, pgtype.Interval{
+		Microseconds: s.heartbeatTimeout().Microseconds(),
+		Valid:        true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Jobs due later are only queued once they are due
+	if job.ScheduledAt.Valid && job.ScheduledAt.Time.After(now) {
+		return pendingEstimate(job.ScheduledAt.Time, avg, 0, slots), nil
+	}
+
+	// Even in the weighted priority mode higher priorities are usually
+	// claimed first
+	priorities := make([]string, len(models.Priorities))
+	for i, priority := range models.Priorities {
+		priorities[i] = string(priority)
+	}
+	ahead, err := s.queries.CountPendingJobsAhead(ctx, db.CountPendingJobsAheadParams{
+		ID:         job.ID,
+		Priorities: priorities,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pendingEstimate(now, avg, ahead, slots), nil
+}
+
+// pendingEstimate assumes the jobs ahead in the queue are shared out among
+// the executor slots and take as long as this job, which starts once they
+// are done. It returns nil without slots.
+func pendingEstimate(now time.Time, avg time.Duration, ahead, slots int64) *time.Time {
+	if slots <= 0 {
+		return nil
+	}
+	wait := time.Duration(float64(avg) * float64(ahead) / float64(slots))
+	eta := now.Add(wait + avg)
+	return &eta
+}
+
+// runningEstimate expects a running job to take the average duration. It
+// returns nil once the job ran longer than that.
+func runningEstimate(startedAt time.Time, avg time.Duration, now time.Time) *time.Time {
+	eta := startedAt.Add(avg)
+	if eta.Before(now) {
+		return nil
+	}
+	return &eta
+}
+
+// recordJobTypeDuration adds how long a completed job ran to the average of
+// its type. Failed jobs are left out as they often end early.
+func (s *Server) recordJobTypeDuration(ctx context.Context, job db.Job) {
+	if !job.StartedAt.Valid || !job.CompletedAt.Valid {
+		return
+	}
+	err := s.queries.RecordJobTypeDuration(ctx, db.RecordJobTypeDurationParams{
+		JobType: job.Type,
+		Seconds: job.CompletedAt.Time.Sub(job.StartedAt.Time).Seconds(),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to record job type duration", "error", err, "job_id", job.ID, "type", job.Type)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPendingEstimate(t *testing.T) {
+	now := time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		ahead, slots int64
+		want         time.Duration
+	}{
+		{0, 1, time.Minute},
+		{0, 4, time.Minute},
+		{3, 1, 4 * time.Minute},
+		{6, 4, 150 * time.Second},
+	}
+	for _, tt := range tests {
+		got := pendingEstimate(now, time.Minute, tt.ahead, tt.slots)
+		if got == nil || !got.Equal(now.Add(tt.want)) {
+			t.Errorf("%d ahead, %d slots: got %v, want %s", tt.ahead, tt.slots, got, now.Add(tt.want))
+		}
+	}
+
+	if got := pendingEstimate(now, time.Minute, 3, 0); got != nil {
+		t.Errorf("expected no estimate without executor slots, got %s", got)
+	}
+}
+
+func TestRunningEstimate(t *testing.T) {
+	startedAt := time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC)
+
+	got := runningEstimate(startedAt, time.Minute, startedAt.Add(20*time.Second))
+	if got == nil || !got.Equal(startedAt.Add(time.Minute)) {
+		t.Errorf("got %v, want %s", got, startedAt.Add(time.Minute))
+	}
+
+	if got := runningEstimate(startedAt, time.Minute, startedAt.Add(2*time.Minute)); got != nil {
+		t.Errorf("expected no estimate for a job past the average, got %s", got)
+	}
+}
\ No newline at end of file
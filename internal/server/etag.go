@@ -0,0 +1,62 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// writeJSONWithETag writes v as JSON with an ETag derived from its encoding.
+// When the request's If-None-Match holds that ETag the body is left out and
+// 304 is returned, so polling clients don't download unchanged jobs again.
+func (s *Server) writeJSONWithETag(w http.ResponseWriter, r *http.Request, v interface{}) {
+	s.writeJSONWithETagOf(w, r, v, nil)
+}
+
+// writeJSONWithETagOf is writeJSONWithETag with the ETag derived from the
+// encoding of etagSource instead, unless it's nil. It's meant for responses
+// with parts that change on their own, without the resource changing.
+func (s *Server) writeJSONWithETagOf(w http.ResponseWriter, r *http.Request, v, etagSource interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to encode response", "error", err)
+		s.writeError(w, http.StatusInternalServerError, "Failed to encode response", nil)
+		return
+	}
+	tagged := body
+	if etagSource != nil {
+		if tagged, err = json.Marshal(etagSource); err != nil {
+			slog.ErrorContext(r.Context(), "Failed to encode response", "error", err)
+			s.writeError(w, http.StatusInternalServerError, "Failed to encode response", nil)
+			return
+		}
+	}
+
+	sum := sha256.Sum256(tagged)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(body, '\n'))
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag.
+// The header may list several ETags, and weak ones compare equal to their
+// strong counterparts.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
\ No newline at end of file
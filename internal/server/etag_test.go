@@ -0,0 +1,72 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/draganm/executr/pkg/models"
+)
+
+func TestUnchangedJobIsNotModified(t *testing.T) {
+	s := &Server{}
+	job := models.Job{Type: "report", Status: models.StatusRunning}
+
+	get := func(etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs/x", nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		s.writeJSONWithETag(rec, req, job)
+		return rec
+	}
+
+	first := get("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" || first.Body.Len() == 0 {
+		t.Fatalf("expected the job with an ETag, got %d %q", first.Code, etag)
+	}
+
+	for _, header := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+		rec := get(header)
+		if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s: expected 304 without body, got %d", header, rec.Code)
+		}
+	}
+
+	job.Status = models.StatusCompleted
+	changed := get(etag)
+	if changed.Code != http.StatusOK || changed.Header().Get("ETag") == etag {
+		t.Fatalf("expected the changed job with a new ETag, got %d", changed.Code)
+	}
+}
+
+func TestETagIgnoresEstimate(t *testing.T) {
+	s := &Server{}
+	job := models.Job{Type: "report", Status: models.StatusPending}
+
+	get := func(eta time.Time, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs/x", nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		response := job
+		response.EstimatedCompletion = &eta
+		rec := httptest.NewRecorder()
+		s.writeJSONWithETagOf(rec, req, response, job)
+		return rec
+	}
+
+	now := time.Now()
+	first := get(now, "")
+	if first.Code != http.StatusOK || !strings.Contains(first.Body.String(), "estimated_completion") {
+		t.Fatalf("expected the job with its estimate, got %d %s", first.Code, first.Body)
+	}
+
+	if rec := get(now.Add(time.Minute), first.Header().Get("ETag")); rec.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 when only the estimate moved, got %d", rec.Code)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,218 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/draganm/executr/internal/metrics"
+	"github.com/draganm/executr/pkg/models"
+)
+
+// jobEventsChannel is the notification channel the jobs table triggers
+// publish status changes on
+const jobEventsChannel = "executr_job_events"
+
+// eventBufferSize bounds how many events are queued for a subscriber. A
+// subscriber that falls further behind misses events instead of holding up
+// the server.
+const eventBufferSize = 256
+
+// eventKeepAliveInterval is how often an idle event stream sends a comment,
+// so proxies don't close it and disconnected subscribers are noticed
+const eventKeepAliveInterval = 15 * time.Second
+
+// eventFilter selects the events a subscriber receives; empty fields match
+// any job
+type eventFilter struct {
+	status  models.Status
+	jobType string
+	labels  map[string]string
+}
+
+func (f eventFilter) matches(event models.JobEvent) bool {
+	if f.status != "" && event.Status != f.status {
+		return false
+	}
+	if f.jobType != "" && event.Type != f.jobType {
+		return false
+	}
+	for key, value := range f.labels {
+		if v, ok := event.Labels[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// eventSubscriber is an open event stream
+type eventSubscriber struct {
+	filter  eventFilter
+	events  chan models.JobEvent
+	dropped int // events missed since the last delivered one, guarded by eventHub.mu
+}
+
+// eventHub fans job events out to the open event streams
+type eventHub struct {
+	mu          sync.Mutex
+	subscribers map[*eventSubscriber]struct{}
+}
+
+func (h *eventHub) subscribe(filter eventFilter) *eventSubscriber {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subscribers == nil {
+		h.subscribers = make(map[*eventSubscriber]struct{})
+	}
+	sub := &eventSubscriber{filter: filter, events: make(chan models.JobEvent, eventBufferSize)}
+	h.subscribers[sub] = struct{}{}
+	return sub
+}
+
+func (h *eventHub) unsubscribe(sub *eventSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subscribers, sub)
+}
+
+// active reports whether anyone is subscribed, and whether any subscriber
+// filters by labels
+func (h *eventHub) active() (subscribed, filtersLabels bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subscribers {
+		if len(sub.filter.labels) > 0 {
+			return true, true
+		}
+	}
+	return len(h.subscribers) > 0, false
+}
+
+// publish queues event for every matching subscriber without blocking;
+// subscribers whose buffer is full miss it
+func (h *eventHub) publish(event models.JobEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subscribers {
+		if !sub.filter.matches(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			sub.dropped++
+			metrics.JobEventsDropped.Inc()
+		}
+	}
+}
+
+// takeDropped returns and resets the number of events sub missed
+func (h *eventHub) takeDropped(sub *eventSubscriber) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	dropped := sub.dropped
+	sub.dropped = 0
+	return dropped
+}
+
+// publishJobEvent decodes a notification from the jobs table triggers and
+// passes it on to the subscribers
+func (s *Server) publishJobEvent(ctx context.Context, payload string) {
+	subscribed, filtersLabels := s.events.active()
+	if !subscribed {
+		return
+	}
+
+	var event models.JobEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		slog.Error("Failed to decode job event", "error", err, "payload", payload)
+		return
+	}
+
+	// The trigger leaves out large label sets
+	if event.Labels == nil && filtersLabels {
+		job, err := s.queries.GetJob(ctx, event.JobID)
+		if err != nil {
+			slog.Error("Failed to get job labels for event", "error", err, "job_id", event.JobID)
+		} else {
+			json.Unmarshal(job.Labels, &event.Labels)
+		}
+	}
+
+	s.events.publish(event)
+}
+
+// handleJobEvents streams job status changes as server-sent events. Each
+// "job" event carries a models.JobEvent; a "dropped" event tells how many
+// events were missed because the subscriber didn't keep up. Events can be
+// filtered with the status, type and label query parameters.
+func (s *Server) handleJobEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.writeError(w, http.StatusInternalServerError, "Streaming not supported", nil)
+		return
+	}
+
+	q := r.URL.Query()
+	filter := eventFilter{
+		status:  models.Status(q.Get("status")),
+		jobType: q.Get("type"),
+	}
+	if filter.status != "" && !filter.status.IsValid() {
+		s.writeError(w, http.StatusBadRequest, "Invalid status", map[string]interface{}{
+			"status":  filter.status,
+			"allowed": models.Statuses,
+		})
+		return
+	}
+	labels, err := parseLabelFilters(q["label"])
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error(), map[string]interface{}{"label": q["label"]})
+		return
+	}
+	filter.labels = labels
+
+	sub := s.events.subscribe(filter)
+	defer s.events.unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.stopping:
+			return
+		case event := <-sub.events:
+			if dropped := s.events.takeDropped(sub); dropped > 0 {
+				if err := writeSSE(w, flusher, "dropped", map[string]int{"count": dropped}); err != nil {
+					return
+				}
+			}
+			if err := writeSSE(w, flusher, "job", event); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
\ No newline at end of file
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/draganm/executr/pkg/models"
+)
+
+func TestEventFilterMatches(t *testing.T) {
+	event := models.JobEvent{
+		JobID:  uuid.New(),
+		Type:   "build",
+		Status: models.StatusCompleted,
+		Labels: map[string]string{"team": "payments", "env": "prod"},
+	}
+
+	tests := []struct {
+		name   string
+		filter eventFilter
+		want   bool
+	}{
+		{"empty", eventFilter{}, true},
+		{"status", eventFilter{status: models.StatusCompleted}, true},
+		{"other status", eventFilter{status: models.StatusFailed}, false},
+		{"type", eventFilter{jobType: "build"}, true},
+		{"other type", eventFilter{jobType: "deploy"}, false},
+		{"labels", eventFilter{labels: map[string]string{"team": "payments", "env": "prod"}}, true},
+		{"other label value", eventFilter{labels: map[string]string{"team": "search"}}, false},
+		{"missing label", eventFilter{labels: map[string]string{"region": "eu"}}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.filter.matches(event); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEventHubDropsEventsForSlowSubscribers(t *testing.T) {
+	var hub eventHub
+	sub := hub.subscribe(eventFilter{})
+	builds := hub.subscribe(eventFilter{jobType: "build"})
+
+	for i := 0; i < eventBufferSize+3; i++ {
+		hub.publish(models.JobEvent{JobID: uuid.New(), Type: "test", Status: models.StatusPending})
+	}
+
+	if len(sub.events) != eventBufferSize {
+		t.Fatalf("expected %d queued events, got %d", eventBufferSize, len(sub.events))
+	}
+	if dropped := hub.takeDropped(sub); dropped != 3 {
+		t.Fatalf("expected 3 dropped events, got %d", dropped)
+	}
+	if dropped := hub.takeDropped(sub); dropped != 0 {
+		t.Fatalf("expected the dropped count to be reset, got %d", dropped)
+	}
+	if len(builds.events) != 0 {
+		t.Fatalf("expected no events for a non-matching filter, got %d", len(builds.events))
+	}
+
+	hub.unsubscribe(sub)
+	if subscribed, _ := hub.active(); !subscribed {
+		t.Fatal("expected the other subscriber to remain")
+	}
+}
\ No newline at end of file
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/draganm/executr/internal/db"
+	"github.com/draganm/executr/internal/metrics"
+	"github.com/draganm/executr/pkg/models"
+)
+
+func (s *Server) handleExecutors(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.handleRegisterExecutor(w, r)
+}
+
+func (s *Server) handleExecutorByID(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/api/v1/executors/")
+	executorID, subPath, _ := strings.Cut(rest, "/")
+	if executorID == "" {
+		http.Error(w, "Executor ID required", http.StatusBadRequest)
+		return
+	}
+
+	switch subPath {
+	case "":
+		if r.Method == http.MethodDelete {
+			s.handleDeregisterExecutor(w, r, executorID)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case "heartbeat":
+		if r.Method == http.MethodPut {
+			s.handleExecutorHeartbeat(w, r, executorID)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	default:
+		http.Error(w, "Not found", http.StatusNotFound)
+	}
+}
+
+func (s *Server) handleRegisterExecutor(w http.ResponseWriter, r *http.Request) {
+	var req models.ExecutorRegistration
+	if !s.decodeBody(w, r, &req) {
+		return
+	}
+
+	if req.ExecutorID == "" || req.Name == "" {
+		s.writeError(w, http.StatusBadRequest, "executor_id and name are required", nil)
+		return
+	}
+
+	if strings.Contains(req.ExecutorID, "/") {
+		s.writeError(w, http.StatusBadRequest, "executor_id must not contain '/'", map[string]interface{}{"executor_id": req.ExecutorID})
+		return
+	}
+
+	if req.AdvertiseAddr != "" {
+		if _, _, err := net.SplitHostPort(req.AdvertiseAddr); err != nil {
+			s.writeError(w, http.StatusBadRequest, "advertise_addr must be host:port", map[string]interface{}{"advertise_addr": req.AdvertiseAddr})
+			return
+		}
+	}
+
+	if req.MaxJobs < 0 {
+		s.writeError(w, http.StatusBadRequest, "max_jobs must not be negative", map[string]interface{}{"max_jobs": req.MaxJobs})
+		return
+	}
+
+	executor, err := s.queries.RegisterExecutor(r.Context(), db.RegisterExecutorParams{
+		ID:            req.ExecutorID,
+		Name:          req.Name,
+		Capabilities:  normalizeCapabilities(req.Capabilities),
+		MaxJobs:       int32(req.MaxJobs),
+		AdvertiseAddr: req.AdvertiseAddr,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to register executor", "error", err, "executor_id", req.ExecutorID)
+		s.writeError(w, http.StatusInternalServerError, "Failed to register executor", nil)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Executor registered",
+		"executor_id", executor.ID,
+		"name", executor.Name,
+		"capabilities", executor.Capabilities,
+		"max_jobs", executor.MaxJobs,
+		"advertise_addr", executor.AdvertiseAddr,
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(dbExecutorToModel(executor))
+}
+
+func (s *Server) handleExecutorHeartbeat(w http.ResponseWriter, r *http.Request, executorID string) {
+	var req models.ExecutorHeartbeatRequest
+	if !s.decodeBody(w, r, &req) {
+		return
+	}
+
+	params := db.ExecutorHeartbeatParams{ID: executorID}
+	if req.CPUPercent != nil {
+		params.CpuPercent = pgtype.Float8{Float64: *req.CPUPercent, Valid: true}
+	}
+	if req.MemBytes != nil {
+		params.MemBytes = pgtype.Int8{Int64: *req.MemBytes, Valid: true}
+	}
+
+	rows, err := s.queries.ExecutorHeartbeat(r.Context(), params)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to update executor heartbeat", "error", err, "executor_id", executorID)
+		s.writeError(w, http.StatusInternalServerError, "Failed to update executor heartbeat", nil)
+		return
+	}
+
+	// The executor re-registers when it is unknown, e.g. after being cleaned up
+	if rows == 0 {
+		s.writeError(w, http.StatusNotFound, "Executor not registered", map[string]interface{}{"executor_id": executorID})
+		return
+	}
+
+	metrics.ExecutorHeartbeats.WithLabelValues(executorID).Inc()
+	if req.CPUPercent != nil {
+		metrics.ExecutorCPUPercent.WithLabelValues(executorID).Set(*req.CPUPercent)
+	}
+	if req.MemBytes != nil {
+		metrics.ExecutorMemBytes.WithLabelValues(executorID).Set(float64(*req.MemBytes))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleDeregisterExecutor(w http.ResponseWriter, r *http.Request, executorID string) {
+	rows, err := s.queries.DeregisterExecutor(r.Context(), executorID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to deregister executor", "error", err, "executor_id", executorID)
+		s.writeError(w, http.StatusInternalServerError, "Failed to deregister executor", nil)
+		return
+	}
+
+	if rows == 0 {
+		s.writeError(w, http.StatusNotFound, "Executor not registered", map[string]interface{}{"executor_id": executorID})
+		return
+	}
+
+	metrics.ExecutorHeartbeats.DeleteLabelValues(executorID)
+	metrics.ExecutorJobsClaimed.DeleteLabelValues(executorID)
+	metrics.ExecutorCPUPercent.DeleteLabelValues(executorID)
+	metrics.ExecutorMemBytes.DeleteLabelValues(executorID)
+
+	slog.InfoContext(r.Context(), "Executor deregistered", "executor_id", executorID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func dbExecutorToModel(executor db.Executor) *models.Executor {
+	result := &models.Executor{
+		ID:            executor.ID,
+		Name:          executor.Name,
+		Capabilities:  executor.Capabilities,
+		MaxJobs:       int(executor.MaxJobs),
+		AdvertiseAddr: executor.AdvertiseAddr,
+		LastHeartbeat: executor.LastHeartbeat.Time,
+	}
+	if executor.CpuPercent.Valid {
+		result.CPUPercent = &executor.CpuPercent.Float64
+	}
+	if executor.MemBytes.Valid {
+		result.MemBytes = &executor.MemBytes.Int64
+	}
+	if executor.RegisteredAt.Valid {
+		result.RegisteredAt = &executor.RegisteredAt.Time
+	}
+	return result
+}
\ No newline at end of file
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/draganm/executr/internal/db"
+	"github.com/draganm/executr/pkg/models"
+)
+
+// idempotencyKeyHeader names the header clients set to make a submission
+// safe to retry
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength bounds the size of stored idempotency keys
+const maxIdempotencyKeyLength = 255
+
+// uniqueViolation is the PostgreSQL error code for a unique constraint
+// violation
+const uniqueViolation = "23505"
+
+// idempotencyKey turns a header value into the column value, NULL when no
+// key was given
+func idempotencyKey(key string) pgtype.Text {
+	return pgtype.Text{String: key, Valid: key != ""}
+}
+
+// idempotencyScope returns the scope the idempotency keys of a request are
+// unique in: a hash of its API key, so that submitters don't collide, or the
+// empty scope for requests without one
+func idempotencyScope(r *http.Request) string {
+	apiKey := apiKeyFromRequest(r)
+	if apiKey == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// submissionHash identifies the content of a submission, to tell a retry
+// from a different submission reusing its idempotency key. It must be taken
+// before the server fills in fields such as binary_sha256.
+func submissionHash(submission *models.JobSubmission) pgtype.Text {
+	body, _ := json.Marshal(submission)
+	sum := sha256.Sum256(body)
+	return pgtype.Text{String: hex.EncodeToString(sum[:]), Valid: true}
+}
+
+// jobByIdempotencyKey returns the job that was created with key in scope,
+// if any. Keys are removed together with their jobs by the retention
+// cleanup.
+func (s *Server) jobByIdempotencyKey(ctx context.Context, scope, key string) (*db.Job, error) {
+	job, err := s.queries.GetJobByIdempotencyKey(ctx, db.GetJobByIdempotencyKeyParams{
+		IdempotencyScope: scope,
+		IdempotencyKey:   idempotencyKey(key),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// isUniqueViolation reports whether err was caused by a unique index, e.g.
+// when two retries of the same submission race each other
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
+
+// writeExistingJob answers a repeated submission with the job the first one
+// created. A key reused for a different submission is a conflict; jobs
+// created before submissions were hashed match any submission.
+func (s *Server) writeExistingJob(w http.ResponseWriter, r *http.Request, job db.Job, hash pgtype.Text) {
+	if job.IdempotencyHash.Valid && job.IdempotencyHash != hash {
+		s.writeError(w, http.StatusConflict, "Idempotency-Key was already used for a different submission", map[string]interface{}{
+			"idempotency_key": job.IdempotencyKey.String,
+		})
+		return
+	}
+
+	response := s.dbJobToModel(job)
+
+	dependsOn, err := s.queries.GetJobDependencies(r.Context(), job.ID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get job dependencies", "error", err, "job_id", job.ID)
+	} else if len(dependsOn) > 0 {
+		response.DependsOn = dependsOn
+	}
+
+	slog.InfoContext(r.Context(), "Returning existing job for idempotency key", "job_id", job.ID, "idempotency_key", job.IdempotencyKey.String)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
\ No newline at end of file
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/draganm/executr/internal/db"
+	"github.com/draganm/executr/pkg/models"
+)
+
+func TestIdempotencyScope(t *testing.T) {
+	request := func(apiKey string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/jobs", nil)
+		if apiKey != "" {
+			r.Header.Set("Authorization", "Bearer "+apiKey)
+		}
+		return r
+	}
+
+	if scope := idempotencyScope(request("")); scope != "" {
+		t.Fatalf("expected the empty scope without an API key, got %q", scope)
+	}
+	a, b := idempotencyScope(request("key-a")), idempotencyScope(request("key-b"))
+	if a == "" || a == b {
+		t.Fatalf("expected distinct scopes per API key, got %q and %q", a, b)
+	}
+	if a == "key-a" {
+		t.Fatal("the API key must not be stored as is")
+	}
+}
+
+func TestReusedKeyWithDifferentSubmission(t *testing.T) {
+	s := &Server{}
+	submission := models.JobSubmission{Type: "report", BinaryURL: "https://example.com/report", Priority: models.PriorityBackground}
+	job := db.Job{
+		Type:            "report",
+		IdempotencyKey:  pgtype.Text{String: "key-1", Valid: true},
+		IdempotencyHash: submissionHash(&submission),
+	}
+
+	changed := submission
+	changed.Arguments = []string{"--all"}
+	if submissionHash(&changed) == submissionHash(&submission) {
+		t.Fatal("expected different submissions to hash differently")
+	}
+
+	rec := httptest.NewRecorder()
+	s.writeExistingJob(rec, httptest.NewRequest(http.MethodPost, "/api/v1/jobs", nil), job, submissionHash(&changed))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a reused key, got %d", rec.Code)
+	}
+}
\ No newline at end of file
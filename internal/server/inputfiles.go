@@ -0,0 +1,52 @@
+package server
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+
+	"github.com/draganm/executr/pkg/models"
+)
+
+// validateInputFiles checks the input files of a submission. Their names
+// must stay within the job's working directory, and inline contents may
+// add up to at most maxInlineBytes.
+func validateInputFiles(files map[string]models.InputFile, maxInlineBytes int) error {
+	inline := 0
+	for name, file := range files {
+		if !filepath.IsLocal(name) {
+			return fmt.Errorf("input file name %q must be a relative path within the working directory", name)
+		}
+		if (file.URL == "") == (file.Content == nil) {
+			return fmt.Errorf("input file %q needs either url or content", name)
+		}
+		if file.URL != "" {
+			u, err := url.Parse(file.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				return fmt.Errorf("input file %q has an invalid url, expected http or https", name)
+			}
+		}
+		if file.SHA256 != "" {
+			if sum, err := hex.DecodeString(file.SHA256); err != nil || len(sum) != 32 {
+				return fmt.Errorf("input file %q has an invalid sha256", name)
+			}
+		}
+		inline += len(file.Content)
+	}
+
+	if inline > maxInlineBytes {
+		return fmt.Errorf("inline input file contents are larger than %d bytes", maxInlineBytes)
+	}
+	return nil
+}
+
+// inputFilesJSON turns input files into the column value
+func inputFilesJSON(files map[string]models.InputFile) []byte {
+	if len(files) == 0 {
+		return []byte("{}")
+	}
+	data, _ := json.Marshal(files)
+	return data
+}
\ No newline at end of file
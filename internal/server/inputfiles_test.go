@@ -0,0 +1,33 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/draganm/executr/pkg/models"
+)
+
+func TestValidateInputFiles(t *testing.T) {
+	valid := map[string]models.InputFile{
+		"config.yaml":    {Content: []byte("key: value")},
+		"data/input.csv": {URL: "https://example.com/input.csv", SHA256: strings.Repeat("ab", 32)},
+		"empty.txt":      {Content: []byte{}},
+	}
+	if err := validateInputFiles(valid, 1024); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, files := range map[string]map[string]models.InputFile{
+		"absolute path":     {"/etc/passwd": {Content: []byte("x")}},
+		"escaping path":     {"../outside": {Content: []byte("x")}},
+		"no source":         {"input": {}},
+		"both sources":      {"input": {URL: "https://example.com/input", Content: []byte("x")}},
+		"unsupported url":   {"input": {URL: "file:///etc/passwd"}},
+		"invalid sha256":    {"input": {URL: "https://example.com/input", SHA256: "deadbeef"}},
+		"too large content": {"input": {Content: make([]byte, 1025)}},
+	} {
+		if err := validateInputFiles(files, 1024); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
\ No newline at end of file
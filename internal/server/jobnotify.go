@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// jobsChannel is the notification channel the jobs table triggers signal
+// on when a job may have become claimable
+const jobsChannel = "executr_jobs"
+
+// listenReconnectDelay is how long the job listener waits before
+// reconnecting after its connection failed
+const listenReconnectDelay = 5 * time.Second
+
+// jobListener wakes up long-polling claims and feeds the job event streams
+// from the jobs table triggers, which also covers jobs changed through other
+// server replicas. Claims recheck on their own every claimRecheckInterval,
+// so notifications missed while the listener is disconnected only delay
+// them; event subscribers miss those events.
+func (s *Server) jobListener(ctx context.Context) {
+	for {
+		err := s.listenForJobs(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Warn("Job notification listener failed, reconnecting", "error", err, "delay", listenReconnectDelay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenReconnectDelay):
+		}
+	}
+}
+
+// listenForJobs listens on a dedicated connection, as a pooled one would be
+// held for good, until the connection fails or ctx is done
+func (s *Server) listenForJobs(ctx context.Context) error {
+	conn, err := pgx.ConnectConfig(ctx, s.pool.Config().ConnConfig)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	for _, channel := range []string{jobsChannel, jobEventsChannel} {
+		if _, err := conn.Exec(ctx, "LISTEN "+channel); err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", channel, err)
+		}
+	}
+	slog.Debug("Listening for job notifications")
+
+	// Jobs may have been submitted while the listener was disconnected
+	s.jobsAvailable.notify()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		switch notification.Channel {
+		case jobsChannel:
+			s.jobsAvailable.notify()
+		case jobEventsChannel:
+			s.publishJobEvent(ctx, notification.Payload)
+		}
+	}
+}
\ No newline at end of file
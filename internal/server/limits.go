@@ -0,0 +1,109 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/draganm/executr/internal/db"
+	"github.com/draganm/executr/pkg/models"
+)
+
+// jobTypeLimitsPath is the admin resource of per-type concurrency limits
+const jobTypeLimitsPath = "/api/v1/admin/job-type-limits"
+
+func (s *Server) handleJobTypeLimits(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limits, err := s.queries.ListJobTypeLimits(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list job type limits", "error", err)
+		s.writeError(w, http.StatusInternalServerError, "Failed to list job type limits", nil)
+		return
+	}
+
+	response := make([]models.JobTypeLimit, len(limits))
+	for i, limit := range limits {
+		response[i] = models.JobTypeLimit{
+			Type:       limit.JobType,
+			MaxRunning: int(limit.MaxRunning),
+			Running:    limit.Running,
+			CreatedAt:  limit.CreatedAt.Time,
+			UpdatedAt:  limit.UpdatedAt.Time,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) handleJobTypeLimitByType(w http.ResponseWriter, r *http.Request) {
+	jobType := strings.TrimPrefix(r.URL.Path, jobTypeLimitsPath+"/")
+	if jobType == "" {
+		s.writeError(w, http.StatusBadRequest, "Job type required", nil)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		s.handleSetJobTypeLimit(w, r, jobType)
+	case http.MethodDelete:
+		s.handleDeleteJobTypeLimit(w, r, jobType)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleSetJobTypeLimit(w http.ResponseWriter, r *http.Request, jobType string) {
+	var req models.JobTypeLimitRequest
+	if !s.decodeBody(w, r, &req) {
+		return
+	}
+
+	if req.MaxRunning < 0 || req.MaxRunning > math.MaxInt32 {
+		s.writeError(w, http.StatusBadRequest, "max_running must be between 0 and 2147483647", map[string]interface{}{"max_running": req.MaxRunning})
+		return
+	}
+
+	limit, err := s.queries.SetJobTypeLimit(r.Context(), db.SetJobTypeLimitParams{
+		JobType:    jobType,
+		MaxRunning: int32(req.MaxRunning),
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to set job type limit", "error", err, "type", jobType)
+		s.writeError(w, http.StatusInternalServerError, "Failed to set job type limit", nil)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Job type limit set", "type", jobType, "max_running", limit.MaxRunning)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.JobTypeLimit{
+		Type:       limit.JobType,
+		MaxRunning: int(limit.MaxRunning),
+		CreatedAt:  limit.CreatedAt.Time,
+		UpdatedAt:  limit.UpdatedAt.Time,
+	})
+}
+
+func (s *Server) handleDeleteJobTypeLimit(w http.ResponseWriter, r *http.Request, jobType string) {
+	rows, err := s.queries.DeleteJobTypeLimit(r.Context(), jobType)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to delete job type limit", "error", err, "type", jobType)
+		s.writeError(w, http.StatusInternalServerError, "Failed to delete job type limit", nil)
+		return
+	}
+
+	if rows == 0 {
+		s.writeError(w, http.StatusNotFound, "Job type limit not found", map[string]interface{}{"type": jobType})
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Job type limit deleted", "type", jobType)
+	w.WriteHeader(http.StatusNoContent)
+}
\ No newline at end of file
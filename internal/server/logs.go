@@ -0,0 +1,187 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/draganm/executr/internal/db"
+	"github.com/draganm/executr/pkg/models"
+)
+
+// logPollInterval is how often a followed log stream checks for new output
+const logPollInterval = time.Second
+
+func (s *Server) handleAppendOutput(w http.ResponseWriter, r *http.Request, jobID uuid.UUID) {
+	var req models.OutputRequest
+	if !s.decodeUpload(w, r, &req) {
+		return
+	}
+
+	if req.ExecutorID == "" {
+		s.writeError(w, http.StatusBadRequest, "executor_id is required", nil)
+		return
+	}
+
+	if req.Stdout == "" && req.Stderr == "" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	rows, err := s.queries.AppendJobOutput(r.Context(), db.AppendJobOutputParams{
+		Stdout:     req.Stdout,
+		Stderr:     req.Stderr,
+		ID:         jobID,
+		ExecutorID: pgtype.Text{String: req.ExecutorID, Valid: true},
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to append job output", "error", err, "job_id", jobID)
+		s.writeError(w, http.StatusInternalServerError, "Failed to append job output", nil)
+		return
+	}
+
+	if rows == 0 {
+		s.writeError(w, http.StatusConflict, "Job is not running on this executor", map[string]interface{}{"job_id": jobID})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetJobOutput returns only the output of a job, which may be too
+// large to fetch together with the job each time
+func (s *Server) handleGetJobOutput(w http.ResponseWriter, r *http.Request, jobID uuid.UUID) {
+	output, err := s.queries.GetJobOutput(r.Context(), jobID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.writeError(w, http.StatusNotFound, "Job not found", map[string]interface{}{"job_id": jobID})
+		} else {
+			slog.ErrorContext(r.Context(), "Failed to get job output", "error", err, "job_id", jobID)
+			s.writeError(w, http.StatusInternalServerError, "Failed to get job output", nil)
+		}
+		return
+	}
+
+	response := models.JobOutput{
+		Stdout:    output.Stdout.String,
+		Stderr:    output.Stderr.String,
+		StdoutURL: output.StdoutUrl.String,
+		StderrURL: output.StderrUrl.String,
+	}
+	if output.ExitCode.Valid {
+		exitCode := int(output.ExitCode.Int32)
+		response.ExitCode = &exitCode
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleStreamLogs streams job output as server-sent events. Each "output"
+// event carries a chunk of stdout or stderr; a final "end" event carries the
+// job status. With follow=true the stream stays open until the job reaches a
+// terminal state.
+func (s *Server) handleStreamLogs(w http.ResponseWriter, r *http.Request, jobID uuid.UUID) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.writeError(w, http.StatusInternalServerError, "Streaming not supported", nil)
+		return
+	}
+
+	ctx := r.Context()
+	follow := r.URL.Query().Get("follow") == "true"
+
+	var stdoutOffset, stderrOffset int32
+	streaming := false
+
+	ticker := time.NewTicker(logPollInterval)
+	defer ticker.Stop()
+
+	for {
+		output, err := s.queries.GetJobOutputSince(ctx, db.GetJobOutputSinceParams{
+			StdoutOffset: stdoutOffset,
+			StderrOffset: stderrOffset,
+			ID:           jobID,
+		})
+		if err != nil {
+			if streaming {
+				if ctx.Err() == nil {
+					slog.ErrorContext(r.Context(), "Failed to read job output", "error", err, "job_id", jobID)
+				}
+				return
+			}
+			if errors.Is(err, sql.ErrNoRows) {
+				s.writeError(w, http.StatusNotFound, "Job not found", map[string]interface{}{"job_id": jobID})
+			} else {
+				slog.ErrorContext(r.Context(), "Failed to read job output", "error", err, "job_id", jobID)
+				s.writeError(w, http.StatusInternalServerError, "Failed to read job output", nil)
+			}
+			return
+		}
+
+		if !streaming {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("Connection", "keep-alive")
+			w.WriteHeader(http.StatusOK)
+			flusher.Flush()
+			streaming = true
+		}
+
+		status := models.Status(output.Status)
+
+		// Jobs that never streamed live output only have their final output
+		if status.IsTerminal() && stdoutOffset == 0 && stderrOffset == 0 &&
+			output.StdoutChunk == "" && output.StderrChunk == "" {
+			output.StdoutChunk = output.Stdout.String
+			output.StderrChunk = output.Stderr.String
+		}
+
+		if output.StdoutChunk != "" {
+			if err := writeSSE(w, flusher, "output", map[string]string{"stream": "stdout", "data": output.StdoutChunk}); err != nil {
+				return
+			}
+			stdoutOffset += int32(utf8.RuneCountInString(output.StdoutChunk))
+		}
+		if output.StderrChunk != "" {
+			if err := writeSSE(w, flusher, "output", map[string]string{"stream": "stderr", "data": output.StderrChunk}); err != nil {
+				return
+			}
+			stderrOffset += int32(utf8.RuneCountInString(output.StderrChunk))
+		}
+
+		if status.IsTerminal() || !follow {
+			writeSSE(w, flusher, "end", map[string]string{"status": output.Status})
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.stopping:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// writeSSE writes a single server-sent event with a JSON payload
+func writeSSE(w http.ResponseWriter, flusher http.Flusher, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	flusher.Flush()
+	return nil
+}
\ No newline at end of file
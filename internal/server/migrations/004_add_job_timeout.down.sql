@@ -0,0 +1,3 @@
+-- Drop per-job execution timeout
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS timeout_seconds;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- Add per-job execution timeout (0 means no timeout)
+ALTER TABLE jobs
+ADD COLUMN timeout_seconds INTEGER NOT NULL DEFAULT 0;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- Drop live output buffers
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS stdout_buffer,
+DROP COLUMN IF EXISTS stderr_buffer;
\ No newline at end of file
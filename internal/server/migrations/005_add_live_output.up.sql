@@ -0,0 +1,4 @@
+-- Buffers for output streamed by the executor while a job is running
+ALTER TABLE jobs
+ADD COLUMN stdout_buffer TEXT,
+ADD COLUMN stderr_buffer TEXT;
\ No newline at end of file
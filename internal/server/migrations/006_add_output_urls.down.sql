@@ -0,0 +1,4 @@
+-- Drop output storage URLs
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS stdout_url,
+DROP COLUMN IF EXISTS stderr_url;
\ No newline at end of file
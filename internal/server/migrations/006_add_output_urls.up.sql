@@ -0,0 +1,4 @@
+-- URLs of job output uploaded to external object storage
+ALTER TABLE jobs
+ADD COLUMN stdout_url TEXT,
+ADD COLUMN stderr_url TEXT;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- Drop per-job output size limit
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS max_output_bytes;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- Per-job output size limit, 0 means the executor default
+ALTER TABLE jobs
+ADD COLUMN max_output_bytes INTEGER NOT NULL DEFAULT 0;
\ No newline at end of file
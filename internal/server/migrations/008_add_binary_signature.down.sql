@@ -0,0 +1,4 @@
+-- Drop binary signature fields
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS signature_url,
+DROP COLUMN IF EXISTS public_key;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- Optional detached minisign signature for the job binary
+ALTER TABLE jobs
+ADD COLUMN signature_url TEXT,
+ADD COLUMN public_key TEXT;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- Drop job capability requirements
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS required_capabilities;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- Capabilities an executor must advertise to claim the job
+ALTER TABLE jobs
+ADD COLUMN required_capabilities TEXT[] NOT NULL DEFAULT '{}';
\ No newline at end of file
@@ -0,0 +1,9 @@
+-- Drop retry tracking
+DELETE FROM job_attempts WHERE status = 'retried';
+
+ALTER TABLE job_attempts
+DROP CONSTRAINT IF EXISTS job_attempts_status_check,
+ADD CONSTRAINT job_attempts_status_check CHECK (status IN ('running', 'completed', 'failed', 'timeout'));
+
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS retries_exhausted;
\ No newline at end of file
@@ -0,0 +1,8 @@
+-- Mark failed jobs whose retries have been used up
+ALTER TABLE jobs
+ADD COLUMN retries_exhausted BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Allow attempts that record a retry of the job
+ALTER TABLE job_attempts
+DROP CONSTRAINT IF EXISTS job_attempts_status_check,
+ADD CONSTRAINT job_attempts_status_check CHECK (status IN ('running', 'completed', 'failed', 'timeout', 'retried'));
\ No newline at end of file
@@ -0,0 +1,13 @@
+-- Restore the fixed retry backoff
+DROP INDEX IF EXISTS idx_jobs_next_retry;
+
+ALTER TABLE jobs
+ADD COLUMN retry_after TIMESTAMP;
+
+UPDATE jobs SET retry_after = next_retry_at WHERE next_retry_at IS NOT NULL;
+
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS retry_backoff_base,
+DROP COLUMN IF EXISTS next_retry_at;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_retry ON jobs(retry_count, retry_after) WHERE status = 'failed' AND retry_count < max_retries;
\ No newline at end of file
@@ -0,0 +1,11 @@
+-- Per-job exponential backoff between retries
+ALTER TABLE jobs
+ADD COLUMN retry_backoff_base INTEGER NOT NULL DEFAULT 60,
+ADD COLUMN next_retry_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE jobs SET next_retry_at = retry_after WHERE retry_after IS NOT NULL;
+
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS retry_after;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_next_retry ON jobs(next_retry_at) WHERE status = 'failed' AND retry_count < max_retries;
\ No newline at end of file
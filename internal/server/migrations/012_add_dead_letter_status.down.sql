@@ -0,0 +1,12 @@
+-- Fold dead-lettered jobs back into failed
+ALTER TABLE jobs
+ADD COLUMN retries_exhausted BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE jobs SET status = 'failed', retries_exhausted = TRUE WHERE status = 'dead_letter';
+
+ALTER TABLE jobs
+DROP CONSTRAINT IF EXISTS jobs_status_check,
+ADD CONSTRAINT jobs_status_check CHECK (status IN ('pending', 'running', 'completed', 'failed', 'cancelled'));
+
+DROP INDEX IF EXISTS idx_jobs_completed_at;
+CREATE INDEX idx_jobs_completed_at ON jobs(completed_at) WHERE status IN ('completed', 'failed', 'cancelled');
\ No newline at end of file
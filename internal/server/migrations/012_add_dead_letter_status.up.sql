@@ -0,0 +1,13 @@
+-- Jobs that exhausted their retries move to a dedicated dead_letter status
+ALTER TABLE jobs
+DROP CONSTRAINT IF EXISTS jobs_status_check,
+ADD CONSTRAINT jobs_status_check CHECK (status IN ('pending', 'running', 'completed', 'failed', 'cancelled', 'dead_letter'));
+
+UPDATE jobs SET status = 'dead_letter' WHERE retries_exhausted;
+
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS retries_exhausted;
+
+-- Include dead-lettered jobs in the cleanup index
+DROP INDEX IF EXISTS idx_jobs_completed_at;
+CREATE INDEX idx_jobs_completed_at ON jobs(completed_at) WHERE status IN ('completed', 'failed', 'cancelled', 'dead_letter');
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- Drop executor usage
+DROP TABLE IF EXISTS executors;
\ No newline at end of file
@@ -0,0 +1,7 @@
+-- Latest resource usage reported by each executor
+CREATE TABLE IF NOT EXISTS executors (
+    id TEXT PRIMARY KEY,
+    cpu_percent DOUBLE PRECISION,
+    mem_bytes BIGINT,
+    last_heartbeat TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
\ No newline at end of file
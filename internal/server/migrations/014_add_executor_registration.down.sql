@@ -0,0 +1,8 @@
+-- Drop executor registration
+DROP INDEX IF EXISTS idx_executors_heartbeat;
+
+ALTER TABLE executors
+DROP COLUMN IF EXISTS name,
+DROP COLUMN IF EXISTS capabilities,
+DROP COLUMN IF EXISTS max_jobs,
+DROP COLUMN IF EXISTS registered_at;
\ No newline at end of file
@@ -0,0 +1,8 @@
+-- Executors register themselves on startup
+ALTER TABLE executors
+ADD COLUMN name TEXT NOT NULL DEFAULT '',
+ADD COLUMN capabilities TEXT[] NOT NULL DEFAULT '{}',
+ADD COLUMN max_jobs INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN registered_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_executors_heartbeat ON executors(last_heartbeat);
\ No newline at end of file
@@ -0,0 +1,11 @@
+-- Drop job dependencies and fold skipped jobs into cancelled
+DROP TABLE IF EXISTS job_dependencies;
+
+UPDATE jobs SET status = 'cancelled' WHERE status = 'skipped';
+
+ALTER TABLE jobs
+DROP CONSTRAINT IF EXISTS jobs_status_check,
+ADD CONSTRAINT jobs_status_check CHECK (status IN ('pending', 'running', 'completed', 'failed', 'cancelled', 'dead_letter'));
+
+DROP INDEX IF EXISTS idx_jobs_completed_at;
+CREATE INDEX idx_jobs_completed_at ON jobs(completed_at) WHERE status IN ('completed', 'failed', 'cancelled', 'dead_letter');
\ No newline at end of file
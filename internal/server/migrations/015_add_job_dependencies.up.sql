@@ -0,0 +1,16 @@
+-- Jobs can depend on other jobs; dependents of jobs that don't complete are skipped
+ALTER TABLE jobs
+DROP CONSTRAINT IF EXISTS jobs_status_check,
+ADD CONSTRAINT jobs_status_check CHECK (status IN ('pending', 'running', 'completed', 'failed', 'cancelled', 'dead_letter', 'skipped'));
+
+CREATE TABLE IF NOT EXISTS job_dependencies (
+    job_id UUID REFERENCES jobs(id) ON DELETE CASCADE NOT NULL,
+    depends_on UUID REFERENCES jobs(id) ON DELETE CASCADE NOT NULL,
+    PRIMARY KEY (job_id, depends_on)
+);
+
+CREATE INDEX idx_job_dependencies_depends_on ON job_dependencies(depends_on);
+
+-- Include skipped jobs in the cleanup index
+DROP INDEX IF EXISTS idx_jobs_completed_at;
+CREATE INDEX idx_jobs_completed_at ON jobs(completed_at) WHERE status IN ('completed', 'failed', 'cancelled', 'dead_letter', 'skipped');
\ No newline at end of file
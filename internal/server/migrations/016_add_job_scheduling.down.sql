@@ -0,0 +1,5 @@
+-- Drop job scheduling
+DROP INDEX IF EXISTS idx_jobs_scheduled_at;
+
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS scheduled_at;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- Jobs can be scheduled to become claimable at a later time
+ALTER TABLE jobs
+ADD COLUMN scheduled_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_scheduled_at ON jobs(scheduled_at) WHERE status = 'pending' AND scheduled_at IS NOT NULL;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- Drop recurring job templates
+DROP TABLE IF EXISTS job_schedules;
\ No newline at end of file
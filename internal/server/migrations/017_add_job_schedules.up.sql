@@ -0,0 +1,11 @@
+-- Recurring job templates submitted on a cron schedule
+CREATE TABLE IF NOT EXISTS job_schedules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    cron_spec TEXT NOT NULL,
+    template JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    next_run_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_job_schedules_next_run ON job_schedules(next_run_at);
\ No newline at end of file
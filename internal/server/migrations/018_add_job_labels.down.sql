@@ -0,0 +1,5 @@
+-- Drop job labels
+DROP INDEX IF EXISTS idx_jobs_labels;
+
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS labels;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- Arbitrary key/value labels for filtering and aggregating jobs
+ALTER TABLE jobs
+ADD COLUMN labels JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_labels ON jobs USING GIN (labels);
\ No newline at end of file
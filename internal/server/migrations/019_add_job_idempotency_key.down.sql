@@ -0,0 +1,5 @@
+-- Drop job idempotency keys
+DROP INDEX IF EXISTS idx_jobs_idempotency_key;
+
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS idempotency_key;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- Idempotency keys let clients retry a submission without creating duplicate jobs
+ALTER TABLE jobs
+ADD COLUMN idempotency_key TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_idempotency_key ON jobs(idempotency_key) WHERE idempotency_key IS NOT NULL;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- Drop executor advertise addresses
+ALTER TABLE executors
+DROP COLUMN IF EXISTS advertise_addr;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- Executors advertise where their metrics and health endpoints can be reached
+ALTER TABLE executors
+ADD COLUMN advertise_addr TEXT NOT NULL DEFAULT '';
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- Drop the job notification triggers
+DROP TRIGGER IF EXISTS jobs_notify_update ON jobs;
+DROP TRIGGER IF EXISTS jobs_notify_insert ON jobs;
+DROP FUNCTION IF EXISTS notify_executr_jobs();
\ No newline at end of file
@@ -0,0 +1,20 @@
+-- Notify listening servers when a job may have become claimable: a pending
+-- job was created or reset, or a job completed and its dependents may be
+-- unblocked. The payload is empty so that identical notifications from one
+-- transaction are delivered once.
+CREATE OR REPLACE FUNCTION notify_executr_jobs() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('executr_jobs', '');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER jobs_notify_insert
+AFTER INSERT ON jobs
+FOR EACH ROW WHEN (NEW.status = 'pending')
+EXECUTE FUNCTION notify_executr_jobs();
+
+CREATE TRIGGER jobs_notify_update
+AFTER UPDATE OF status ON jobs
+FOR EACH ROW WHEN (NEW.status IN ('pending', 'completed') AND NEW.status IS DISTINCT FROM OLD.status)
+EXECUTE FUNCTION notify_executr_jobs();
\ No newline at end of file
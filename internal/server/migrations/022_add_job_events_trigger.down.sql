@@ -0,0 +1,4 @@
+-- Drop the job event triggers
+DROP TRIGGER IF EXISTS jobs_event_update ON jobs;
+DROP TRIGGER IF EXISTS jobs_event_insert ON jobs;
+DROP FUNCTION IF EXISTS notify_executr_job_event();
\ No newline at end of file
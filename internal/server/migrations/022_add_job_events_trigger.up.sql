@@ -0,0 +1,36 @@
+-- Publish job status changes for GET /api/v1/jobs/events. Notification
+-- payloads are limited to 8000 bytes, so large label sets are left out and
+-- looked up by the server when needed.
+CREATE OR REPLACE FUNCTION notify_executr_job_event() RETURNS trigger AS $$
+DECLARE
+    previous_status TEXT;
+    labels JSONB;
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        previous_status := OLD.status;
+    END IF;
+    IF octet_length(NEW.labels::text) <= 4000 THEN
+        labels := NEW.labels;
+    END IF;
+
+    PERFORM pg_notify('executr_job_events', json_build_object(
+        'job_id', NEW.id,
+        'type', NEW.type,
+        'status', NEW.status,
+        'previous_status', previous_status,
+        'labels', labels,
+        'time', NOW()
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER jobs_event_insert
+AFTER INSERT ON jobs
+FOR EACH ROW
+EXECUTE FUNCTION notify_executr_job_event();
+
+CREATE TRIGGER jobs_event_update
+AFTER UPDATE OF status ON jobs
+FOR EACH ROW WHEN (NEW.status IS DISTINCT FROM OLD.status)
+EXECUTE FUNCTION notify_executr_job_event();
\ No newline at end of file
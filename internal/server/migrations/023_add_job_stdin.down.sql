@@ -0,0 +1,3 @@
+-- Drop job stdin
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS stdin;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- Data fed to a job's standard input
+ALTER TABLE jobs
+ADD COLUMN stdin BYTEA;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- Drop job input files
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS input_files;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- Files written into a job's working directory before it runs
+ALTER TABLE jobs
+ADD COLUMN input_files JSONB NOT NULL DEFAULT '{}'::jsonb;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- Drop job artifacts
+DROP TABLE IF EXISTS job_artifacts;
+
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS output_globs;
\ No newline at end of file
@@ -0,0 +1,16 @@
+-- Files in a job's working directory collected after it runs
+ALTER TABLE jobs
+ADD COLUMN output_globs TEXT[] NOT NULL DEFAULT '{}';
+
+-- Artifacts either point to object storage by url or, when uploaded to the
+-- server, keep their content here
+CREATE TABLE IF NOT EXISTS job_artifacts (
+    job_id UUID REFERENCES jobs(id) ON DELETE CASCADE NOT NULL,
+    name TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    sha256 TEXT NOT NULL,
+    url TEXT,
+    content BYTEA,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    PRIMARY KEY (job_id, name)
+);
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- Drop job working directory quotas
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS workdir_quota_bytes;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- Bytes a job's working directory may use while it runs, 0 means unlimited
+ALTER TABLE jobs
+ADD COLUMN workdir_quota_bytes BIGINT NOT NULL DEFAULT 0;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- Drop job resource limits
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS cpu_millicores,
+DROP COLUMN IF EXISTS mem_limit_bytes;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- Resource limits applied through a cgroup while a job runs, 0 means unlimited
+ALTER TABLE jobs
+ADD COLUMN cpu_millicores INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN mem_limit_bytes BIGINT NOT NULL DEFAULT 0;
\ No newline at end of file
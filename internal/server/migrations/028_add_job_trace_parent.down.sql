@@ -0,0 +1,3 @@
+-- Drop job trace context
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS trace_parent;
\ No newline at end of file
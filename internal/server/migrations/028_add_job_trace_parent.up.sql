@@ -0,0 +1,3 @@
+-- W3C traceparent of the span that submitted a job, so executors can continue its trace
+ALTER TABLE jobs
+ADD COLUMN trace_parent TEXT;
\ No newline at end of file
@@ -0,0 +1,6 @@
+-- Drop the cancelled attempt status
+UPDATE job_attempts SET status = 'failed' WHERE status = 'cancelled';
+
+ALTER TABLE job_attempts
+DROP CONSTRAINT IF EXISTS job_attempts_status_check,
+ADD CONSTRAINT job_attempts_status_check CHECK (status IN ('running', 'completed', 'failed', 'timeout', 'retried'));
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- Record attempts that ended because their job was cancelled while running
+ALTER TABLE job_attempts
+DROP CONSTRAINT IF EXISTS job_attempts_status_check,
+ADD CONSTRAINT job_attempts_status_check CHECK (status IN ('running', 'completed', 'failed', 'timeout', 'retried', 'cancelled'));
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- Drop the job archive
+DROP TRIGGER IF EXISTS jobs_archive_append_only ON jobs_archive;
+DROP FUNCTION IF EXISTS reject_jobs_archive_change();
+DROP TABLE IF EXISTS jobs_archive;
\ No newline at end of file
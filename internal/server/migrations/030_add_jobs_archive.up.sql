@@ -0,0 +1,28 @@
+-- Jobs removed by the retention cleanup, kept for auditing when the archive
+-- sink is db. The full job and its attempts are stored as JSON, next to the
+-- columns needed to find them again.
+CREATE TABLE IF NOT EXISTS jobs_archive (
+    id UUID PRIMARY KEY,
+    type TEXT NOT NULL,
+    status TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    archived_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    job JSONB NOT NULL,
+    attempts JSONB NOT NULL DEFAULT '[]'
+);
+
+CREATE INDEX idx_jobs_archive_completed_at ON jobs_archive(completed_at);
+CREATE INDEX idx_jobs_archive_type ON jobs_archive(type);
+
+-- The archive is append-only
+CREATE OR REPLACE FUNCTION reject_jobs_archive_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'jobs_archive is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER jobs_archive_append_only
+BEFORE UPDATE OR DELETE ON jobs_archive
+FOR EACH ROW
+EXECUTE FUNCTION reject_jobs_archive_change();
\ No newline at end of file
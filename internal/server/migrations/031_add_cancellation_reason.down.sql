@@ -0,0 +1,3 @@
+-- Drop job cancellation reasons
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS cancellation_reason;
\ No newline at end of file
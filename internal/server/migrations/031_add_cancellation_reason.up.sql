@@ -0,0 +1,3 @@
+-- Why an operator cancelled a job, kept until the job is cleaned up
+ALTER TABLE jobs
+ADD COLUMN cancellation_reason TEXT;
\ No newline at end of file
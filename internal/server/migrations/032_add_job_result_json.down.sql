@@ -0,0 +1,3 @@
+-- Drop job results
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS result_json;
\ No newline at end of file
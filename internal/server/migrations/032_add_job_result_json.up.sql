@@ -0,0 +1,4 @@
+-- Structured result a job wrote to $EXECUTR_RESULT, e.g. counts of the units of
+-- work that succeeded and failed
+ALTER TABLE jobs
+ADD COLUMN result_json JSONB;
\ No newline at end of file
@@ -0,0 +1,9 @@
+-- Drop per-type concurrency limits
+DROP TRIGGER IF EXISTS jobs_notify_update ON jobs;
+
+CREATE TRIGGER jobs_notify_update
+AFTER UPDATE OF status ON jobs
+FOR EACH ROW WHEN (NEW.status IN ('pending', 'completed') AND NEW.status IS DISTINCT FROM OLD.status)
+EXECUTE FUNCTION notify_executr_jobs();
+
+DROP TABLE IF EXISTS job_type_limits;
\ No newline at end of file
@@ -0,0 +1,21 @@
+-- How many jobs of a type may run at once across all executors
+CREATE TABLE IF NOT EXISTS job_type_limits (
+    job_type TEXT PRIMARY KEY,
+    max_running INTEGER NOT NULL CHECK (max_running >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+-- Jobs held back by a limit may become claimable when the limit changes or
+-- when any running job ends and frees a slot
+CREATE TRIGGER job_type_limits_notify
+AFTER INSERT OR UPDATE OR DELETE ON job_type_limits
+FOR EACH STATEMENT
+EXECUTE FUNCTION notify_executr_jobs();
+
+DROP TRIGGER IF EXISTS jobs_notify_update ON jobs;
+
+CREATE TRIGGER jobs_notify_update
+AFTER UPDATE OF status ON jobs
+FOR EACH ROW WHEN ((NEW.status IN ('pending', 'completed') OR OLD.status = 'running') AND NEW.status IS DISTINCT FROM OLD.status)
+EXECUTE FUNCTION notify_executr_jobs();
\ No newline at end of file
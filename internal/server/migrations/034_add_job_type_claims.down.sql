@@ -0,0 +1,2 @@
+-- Drop the claim times of job types
+DROP TABLE IF EXISTS job_type_claims;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- When a job of each type was last claimed, for fair scheduling across types
+CREATE TABLE IF NOT EXISTS job_type_claims (
+    job_type TEXT PRIMARY KEY,
+    last_claimed_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- Drop the average durations of job types
+DROP TABLE IF EXISTS job_type_durations;
\ No newline at end of file
@@ -0,0 +1,8 @@
+-- Rolling average of how long jobs of each type run, to estimate when jobs
+-- will complete
+CREATE TABLE IF NOT EXISTS job_type_durations (
+    job_type TEXT PRIMARY KEY,
+    avg_seconds DOUBLE PRECISION NOT NULL,
+    samples BIGINT NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
\ No newline at end of file
@@ -0,0 +1,17 @@
+-- Make idempotency keys globally unique again, keeping the newest job of
+-- keys that different submitters used
+DROP INDEX IF EXISTS idx_jobs_idempotency_key;
+
+UPDATE jobs SET idempotency_key = NULL
+WHERE idempotency_key IS NOT NULL
+  AND EXISTS (
+      SELECT 1 FROM jobs newer
+      WHERE newer.idempotency_key = jobs.idempotency_key
+        AND newer.created_at > jobs.created_at
+  );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_idempotency_key ON jobs(idempotency_key) WHERE idempotency_key IS NOT NULL;
+
+ALTER TABLE jobs
+DROP COLUMN IF EXISTS idempotency_hash,
+DROP COLUMN IF EXISTS idempotency_scope;
\ No newline at end of file
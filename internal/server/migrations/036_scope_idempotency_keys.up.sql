@@ -0,0 +1,9 @@
+-- Idempotency keys are unique per submitter rather than globally, and
+-- remember a hash of the submission they were first used with
+ALTER TABLE jobs
+ADD COLUMN idempotency_scope TEXT NOT NULL DEFAULT '',
+ADD COLUMN idempotency_hash TEXT;
+
+DROP INDEX IF EXISTS idx_jobs_idempotency_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_idempotency_key ON jobs(idempotency_scope, idempotency_key) WHERE idempotency_key IS NOT NULL;
\ No newline at end of file
@@ -0,0 +1,4 @@
+-- Drop the disabled state of schedules
+ALTER TABLE job_schedules
+DROP COLUMN IF EXISTS last_error,
+DROP COLUMN IF EXISTS disabled;
\ No newline at end of file
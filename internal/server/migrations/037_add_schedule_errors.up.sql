@@ -0,0 +1,5 @@
+-- Schedules whose cron spec or job template can't be used are disabled,
+-- keeping the error
+ALTER TABLE job_schedules
+ADD COLUMN disabled BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN last_error TEXT;
\ No newline at end of file
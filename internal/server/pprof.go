@@ -0,0 +1,21 @@
+package server
+
+import (
+	"net/http"
+	"net/http/pprof"
+)
+
+// pprofPrefix is where the profiling endpoints are served when EnablePprof
+// is set
+const pprofPrefix = "/debug/pprof/"
+
+// registerPprof serves the net/http/pprof handlers, e.g.
+// /debug/pprof/profile for a CPU profile and /debug/pprof/heap for a heap
+// profile. They require the admin scope when API keys are configured.
+func registerPprof(mux *http.ServeMux) {
+	mux.HandleFunc(pprofPrefix, pprof.Index)
+	mux.HandleFunc(pprofPrefix+"cmdline", pprof.Cmdline)
+	mux.HandleFunc(pprofPrefix+"profile", pprof.Profile)
+	mux.HandleFunc(pprofPrefix+"symbol", pprof.Symbol)
+	mux.HandleFunc(pprofPrefix+"trace", pprof.Trace)
+}
\ No newline at end of file
@@ -0,0 +1,26 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPprofOnlyWhenEnabled(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		s := &Server{config: &Config{EnablePprof: enabled}}
+		mux := http.NewServeMux()
+		s.setupRoutes(mux)
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+
+		want := http.StatusNotFound
+		if enabled {
+			want = http.StatusOK
+		}
+		if rec.Code != want {
+			t.Errorf("enabled=%v: got %d, want %d", enabled, rec.Code, want)
+		}
+	}
+}
\ No newline at end of file
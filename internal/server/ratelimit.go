@@ -0,0 +1,179 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/draganm/executr/internal/metrics"
+)
+
+// rateLimitPeekBytes bounds how much of a request body is read to find the
+// executor ID a request is limited by
+const rateLimitPeekBytes = 64 * 1024
+
+// rateLimitPruneInterval is how often buckets that refilled completely are
+// dropped
+const rateLimitPruneInterval = time.Minute
+
+// rateLimiter is a set of token buckets, one per key, that refill at rate
+// tokens per second up to one second's worth, but at least one token
+type rateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastPrune time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter returns a limiter allowing rate requests per second and
+// key, nil when rate is 0
+func newRateLimiter(rate float64) (*rateLimiter, error) {
+	if rate < 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return nil, fmt.Errorf("rate limit must not be negative, got %v", rate)
+	}
+	if rate == 0 {
+		return nil, nil
+	}
+	return &rateLimiter{
+		rate:    rate,
+		burst:   math.Max(1, math.Ceil(rate)),
+		buckets: make(map[string]*tokenBucket),
+	}, nil
+}
+
+// allow takes a token from key's bucket. When it's empty, it returns how
+// long until the next token is available.
+func (l *rateLimiter) allow(key string, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastPrune) > rateLimitPruneInterval {
+		l.prune(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	return time.Duration((1 - b.tokens) / l.rate * float64(time.Second)), false
+}
+
+// prune drops buckets that have refilled completely, they behave like new
+// ones. Must be called with mu held.
+func (l *rateLimiter) prune(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastPrune = now
+}
+
+// rateLimitMiddleware limits claims and heartbeats per executor and job
+// submissions per API key, falling back to the client address. Requests
+// over the limit are answered with 429 and a Retry-After header. Other
+// endpoints, including health and metrics, aren't limited.
+func (s *Server) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit, limiter := s.rateLimiterFor(r)
+		if limiter == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var key string
+		switch {
+		case limit == "submit":
+			key = apiKeyFromRequest(r)
+		case strings.HasPrefix(r.URL.Path, "/api/v1/executors/"):
+			key, _, _ = strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/v1/executors/"), "/")
+		default:
+			key = executorIDFromBody(r)
+		}
+		if key == "" {
+			key = clientAddr(r)
+		}
+
+		wait, ok := limiter.allow(key, time.Now())
+		if !ok {
+			retryAfter := int(math.Ceil(wait.Seconds()))
+			metrics.RateLimitedRequests.WithLabelValues(limit).Inc()
+			slog.DebugContext(r.Context(), "Rate limit exceeded", "limit", limit, "path", r.URL.Path, "retry_after", retryAfter)
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			s.writeError(w, http.StatusTooManyRequests, "Rate limit exceeded", map[string]interface{}{"retry_after": retryAfter})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimiterFor returns the name and limiter of the limit applying to a
+// request, a nil limiter when it isn't limited. Job and executor heartbeats
+// share the executor's heartbeat bucket.
+func (s *Server) rateLimiterFor(r *http.Request) (string, *rateLimiter) {
+	path := r.URL.Path
+	switch r.Method {
+	case http.MethodPost:
+		switch path {
+		case "/api/v1/jobs/claim":
+			return "claim", s.claimLimiter
+		case "/api/v1/jobs", "/api/v1/jobs/bulk":
+			return "submit", s.submitLimiter
+		}
+	case http.MethodPut:
+		if (strings.HasPrefix(path, "/api/v1/jobs/") || strings.HasPrefix(path, "/api/v1/executors/")) && strings.HasSuffix(path, "/heartbeat") {
+			return "heartbeat", s.heartbeatLimiter
+		}
+	}
+	return "", nil
+}
+
+// executorIDFromBody returns the executor_id of a JSON request body, leaving
+// the body intact for the handler
+func executorIDFromBody(r *http.Request) string {
+	if r.Body == nil {
+		return ""
+	}
+	peeked, _ := io.ReadAll(io.LimitReader(r.Body, rateLimitPeekBytes))
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(peeked), r.Body))
+
+	var req struct {
+		ExecutorID string `json:"executor_id"`
+	}
+	json.Unmarshal(peeked, &req)
+	return req.ExecutorID
+}
+
+// clientAddr returns the host of the request's remote address
+func clientAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
\ No newline at end of file
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterRefills(t *testing.T) {
+	l, err := newRateLimiter(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+
+	// A burst of one second's worth
+	for i := 0; i < 2; i++ {
+		if _, ok := l.allow("executor-1", now); !ok {
+			t.Fatalf("request %d should be allowed", i)
+		}
+	}
+	wait, ok := l.allow("executor-1", now)
+	if ok {
+		t.Fatal("expected the bucket to be empty")
+	}
+	if wait != 500*time.Millisecond {
+		t.Fatalf("expected to wait 500ms, got %s", wait)
+	}
+
+	// Other keys have their own bucket
+	if _, ok := l.allow("executor-2", now); !ok {
+		t.Fatal("expected another executor to be allowed")
+	}
+
+	if _, ok := l.allow("executor-1", now.Add(wait)); !ok {
+		t.Fatal("expected a token after waiting")
+	}
+}
+
+func TestNewRateLimiter(t *testing.T) {
+	if l, err := newRateLimiter(0); l != nil || err != nil {
+		t.Fatalf("expected no limiter for rate 0, got %v, %v", l, err)
+	}
+	if _, err := newRateLimiter(-1); err == nil {
+		t.Fatal("expected an error for a negative rate")
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	s, err := New(&Config{ClaimRatePerSec: 1, SubmitRatePerSec: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := s.rateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The handler still sees the whole body
+		if r.URL.Path == "/api/v1/jobs/claim" {
+			body, err := io.ReadAll(r.Body)
+			if err != nil || !strings.Contains(string(body), "executor_id") {
+				t.Errorf("body was not restored: %q, %v", body, err)
+			}
+		}
+	}))
+
+	claim := func(executorID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/jobs/claim", strings.NewReader(`{"executor_id":"`+executorID+`"}`))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := claim("executor-1"); rec.Code != http.StatusOK {
+		t.Fatalf("expected the first claim to pass, got %d", rec.Code)
+	}
+	rec := claim("executor-1")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("expected Retry-After 1, got %q", rec.Header().Get("Retry-After"))
+	}
+	if rec := claim("executor-2"); rec.Code != http.StatusOK {
+		t.Fatalf("expected another executor's claim to pass, got %d", rec.Code)
+	}
+
+	// Submissions are limited per API key
+	submit := func(key string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader("{}"))
+		req.Header.Set("Authorization", "Bearer "+key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if submit("key-1") != http.StatusOK || submit("key-1") != http.StatusTooManyRequests || submit("key-2") != http.StatusOK {
+		t.Fatal("expected submissions to be limited per API key")
+	}
+
+	// Health checks and reads aren't limited
+	for i := 0; i < 3; i++ {
+		for _, req := range []*http.Request{
+			httptest.NewRequest(http.MethodGet, "/api/v1/health", nil),
+			httptest.NewRequest(http.MethodGet, "/api/v1/jobs", nil),
+		} {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("%s %s: expected no limit, got %d", req.Method, req.URL.Path, rec.Code)
+			}
+		}
+	}
+}
+
+func TestRateLimitMiddlewareHeartbeats(t *testing.T) {
+	s, err := New(&Config{HeartbeatRatePerSec: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := s.rateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	heartbeat := func(path, body string) int {
+		req := httptest.NewRequest(http.MethodPut, path, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	jobHeartbeat := "/api/v1/jobs/550e8400-e29b-41d4-a716-446655440000/heartbeat"
+	if code := heartbeat(jobHeartbeat, `{"executor_id":"executor-1"}`); code != http.StatusOK {
+		t.Fatalf("expected the first heartbeat to pass, got %d", code)
+	}
+	if code := heartbeat(jobHeartbeat, `{"executor_id":"executor-1"}`); code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 once the bucket is empty, got %d", code)
+	}
+
+	// Executor heartbeats are limited by the executor ID in the path
+	if code := heartbeat("/api/v1/executors/executor-2/heartbeat", "{}"); code != http.StatusOK {
+		t.Fatalf("expected the first executor heartbeat to pass, got %d", code)
+	}
+	if code := heartbeat("/api/v1/executors/executor-2/heartbeat", "{}"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 for the executor heartbeat, got %d", code)
+	}
+	if code := heartbeat("/api/v1/executors/executor-1/heartbeat", "{}"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected job and executor heartbeats to share a bucket, got %d", code)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// redactedValue replaces the values of sensitive env variables in responses
+const redactedValue = "***"
+
+// DefaultSensitiveEnvPatterns are the env variable keys the server command
+// redacts unless configured otherwise
+var DefaultSensitiveEnvPatterns = []string{"*_TOKEN", "*_PASSWORD", "*_SECRET"}
+
+// validateSensitiveEnvPatterns checks the syntax of the configured patterns
+func validateSensitiveEnvPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid sensitive env pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// isSensitiveEnv reports whether key matches one of the configured
+// sensitive env patterns, ignoring case
+func (s *Server) isSensitiveEnv(key string) bool {
+	for _, pattern := range s.config.SensitiveEnvPatterns {
+		if ok, _ := path.Match(strings.ToUpper(pattern), strings.ToUpper(key)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// redactEnv returns env with the values of sensitive variables replaced.
+// Responses to executors claiming a job carry the raw values.
+func (s *Server) redactEnv(env map[string]string) map[string]string {
+	var redacted map[string]string
+	for key := range env {
+		if !s.isSensitiveEnv(key) {
+			continue
+		}
+		if redacted == nil {
+			redacted = make(map[string]string, len(env))
+			for k, v := range env {
+				redacted[k] = v
+			}
+		}
+		redacted[key] = redactedValue
+	}
+	if redacted == nil {
+		return env
+	}
+	return redacted
+}
\ No newline at end of file
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRedactEnv(t *testing.T) {
+	s := &Server{config: &Config{SensitiveEnvPatterns: DefaultSensitiveEnvPatterns}}
+
+	env := map[string]string{"MY_TOKEN": "t0ken", "db_password": "pw", "MODE": "fast"}
+	got := s.redactEnv(env)
+	want := map[string]string{"MY_TOKEN": "***", "db_password": "***", "MODE": "fast"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if env["MY_TOKEN"] != "t0ken" {
+		t.Fatal("expected the original env to be left unchanged")
+	}
+
+	if got := (&Server{config: &Config{}}).redactEnv(env); !reflect.DeepEqual(got, env) {
+		t.Fatalf("expected no redaction without patterns, got %v", got)
+	}
+}
+
+func TestNewRejectsInvalidSensitiveEnvPatterns(t *testing.T) {
+	if _, err := New(&Config{SensitiveEnvPatterns: []string{"[A-"}}); err == nil {
+		t.Fatal("expected an error")
+	}
+}
\ No newline at end of file
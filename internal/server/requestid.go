@@ -0,0 +1,41 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/draganm/executr/internal/utils"
+)
+
+// maxRequestIDLength bounds request IDs taken over from clients
+const maxRequestIDLength = 128
+
+// requestIDMiddleware takes the request ID sent by the client, or generates
+// one, echoes it in the response and stores it in the request context so
+// that log lines of the request can be correlated
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(utils.RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+
+		w.Header().Set(utils.RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(utils.WithRequestID(r.Context(), id)))
+	})
+}
+
+// validRequestID reports whether a client's request ID can be logged as is:
+// not empty, not too long and only printable ASCII without spaces
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
\ No newline at end of file
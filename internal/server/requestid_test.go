@@ -0,0 +1,42 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/draganm/executr/internal/utils"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var seen string
+	h := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = utils.RequestIDFromContext(r.Context())
+	}))
+
+	for _, tt := range []struct {
+		sent string
+		kept bool
+	}{
+		{"abc-123", true},
+		{"", false},
+		{"has space", false},
+		{strings.Repeat("x", maxRequestIDLength+1), false},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs", nil)
+		if tt.sent != "" {
+			req.Header.Set(utils.RequestIDHeader, tt.sent)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		got := rec.Header().Get(utils.RequestIDHeader)
+		if got == "" || got != seen {
+			t.Errorf("%q: response ID %q doesn't match context ID %q", tt.sent, got, seen)
+		}
+		if (got == tt.sent) != tt.kept {
+			t.Errorf("%q: got ID %q, expected it kept: %v", tt.sent, got, tt.kept)
+		}
+	}
+}
\ No newline at end of file
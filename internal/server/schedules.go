@@ -0,0 +1,284 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/robfig/cron/v3"
+
+	"github.com/draganm/executr/internal/db"
+	"github.com/draganm/executr/internal/metrics"
+	"github.com/draganm/executr/pkg/models"
+)
+
+// scheduleCheckInterval is how often the schedule worker looks for due
+// schedules. Cron specs have minute resolution.
+const scheduleCheckInterval = 10 * time.Second
+
+func (s *Server) handleSchedules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.handleListSchedules(w, r)
+}
+
+func (s *Server) handleScheduleByID(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/v1/schedules/")
+	scheduleID, err := uuid.Parse(idStr)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid schedule ID", map[string]interface{}{"id": idStr})
+		return
+	}
+
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.handleDeleteSchedule(w, r, scheduleID)
+}
+
+// createSchedule stores a validated submission with a cron spec as a
+// recurring job template
+func (s *Server) createSchedule(w http.ResponseWriter, r *http.Request, submission *models.JobSubmission) {
+	next, err := nextScheduleRun(submission.CronSpec, time.Now().UTC())
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid cron_spec", map[string]interface{}{"cron_spec": submission.CronSpec, "error": err.Error()})
+		return
+	}
+
+	if len(submission.DependsOn) > 0 || submission.ScheduledAt != nil {
+		s.writeError(w, http.StatusBadRequest, "depends_on and scheduled_at can't be combined with cron_spec", nil)
+		return
+	}
+
+	cronSpec := submission.CronSpec
+	template := *submission
+	template.CronSpec = ""
+	templateJSON, _ := json.Marshal(template)
+
+	schedule, err := s.queries.CreateSchedule(r.Context(), db.CreateScheduleParams{
+		CronSpec:  cronSpec,
+		Template:  templateJSON,
+		NextRunAt: pgtype.Timestamptz{Time: next, Valid: true},
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to create schedule", "error", err)
+		s.writeError(w, http.StatusInternalServerError, "Failed to create schedule", nil)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Schedule created",
+		"schedule_id", schedule.ID,
+		"cron_spec", schedule.CronSpec,
+		"type", template.Type,
+		"next_run_at", next,
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(s.scheduleToResponse(schedule))
+}
+
+func (s *Server) handleListSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules, err := s.queries.ListSchedules(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list schedules", "error", err)
+		s.writeError(w, http.StatusInternalServerError, "Failed to list schedules", nil)
+		return
+	}
+
+	response := make([]models.Schedule, len(schedules))
+	for i, schedule := range schedules {
+		response[i] = s.scheduleToResponse(schedule)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) handleDeleteSchedule(w http.ResponseWriter, r *http.Request, scheduleID uuid.UUID) {
+	rows, err := s.queries.DeleteSchedule(r.Context(), scheduleID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to delete schedule", "error", err, "schedule_id", scheduleID)
+		s.writeError(w, http.StatusInternalServerError, "Failed to delete schedule", nil)
+		return
+	}
+
+	if rows == 0 {
+		s.writeError(w, http.StatusNotFound, "Schedule not found", map[string]interface{}{"schedule_id": scheduleID})
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Schedule deleted", "schedule_id", scheduleID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) scheduleWorker(ctx context.Context) {
+	ticker := time.NewTicker(scheduleCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runDueSchedules(ctx)
+		}
+	}
+}
+
+// nextScheduleRun returns the first time after now at which cronSpec fires
+func nextScheduleRun(cronSpec string, now time.Time) (time.Time, error) {
+	spec, err := cron.ParseStandard(cronSpec)
+	if err != nil {
+		return time.Time{}, err
+	}
+	next := spec.Next(now)
+	if next.IsZero() {
+		return time.Time{}, errors.New("cron spec never fires")
+	}
+	return next, nil
+}
+
+// scheduledRun returns the job a due schedule submits and its next run. An
+// error means the schedule can never fire again.
+func scheduledRun(schedule db.JobSchedule, now time.Time) (*models.JobSubmission, time.Time, error) {
+	next, err := nextScheduleRun(schedule.CronSpec, now)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("invalid cron spec: %w", err)
+	}
+
+	var template models.JobSubmission
+	if err := json.Unmarshal(schedule.Template, &template); err != nil {
+		return nil, time.Time{}, fmt.Errorf("invalid job template: %w", err)
+	}
+	return &template, next, nil
+}
+
+// runDueSchedules submits a job for every schedule whose next run has come.
+// A schedule fires at most once per check: ticks missed while the server was
+// down are not backfilled, the next run is computed from the current time.
+// A schedule whose job can't be created is skipped and tried again on the
+// next check, without holding back the others. Schedules with a cron spec
+// or template that can't be used are disabled with the error.
+func (s *Server) runDueSchedules(ctx context.Context) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		slog.Error("Failed to begin transaction", "error", err)
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	// Locks the due schedules so that only one server replica fires them
+	schedules, err := s.queries.WithTx(tx).GetDueSchedules(ctx)
+	if err != nil {
+		slog.Error("Failed to get due schedules", "error", err)
+		return
+	}
+	if len(schedules) == 0 {
+		return
+	}
+
+	now := time.Now().UTC()
+	var submitted []models.JobSubmission
+	for _, schedule := range schedules {
+		template, next, err := scheduledRun(schedule, now)
+		if err != nil {
+			slog.Error("Disabling schedule", "error", err, "schedule_id", schedule.ID)
+			err = s.queries.WithTx(tx).DisableSchedule(ctx, db.DisableScheduleParams{
+				ID:        schedule.ID,
+				LastError: pgtype.Text{String: err.Error(), Valid: true},
+			})
+			if err != nil {
+				slog.Error("Failed to disable schedule", "error", err, "schedule_id", schedule.ID)
+				return
+			}
+			continue
+		}
+
+		// A savepoint, so that a failure only undoes this schedule's run
+		sp, err := tx.Begin(ctx)
+		if err != nil {
+			slog.Error("Failed to begin savepoint", "error", err, "schedule_id", schedule.ID)
+			return
+		}
+		q := s.queries.WithTx(sp)
+
+		job, err := q.CreateJob(ctx, s.createJobParams(ctx, template))
+		if err != nil {
+			slog.Error("Failed to create scheduled job", "error", err, "schedule_id", schedule.ID)
+			sp.Rollback(ctx)
+			continue
+		}
+
+		err = q.MarkScheduleRun(ctx, db.MarkScheduleRunParams{
+			ID:        schedule.ID,
+			NextRunAt: pgtype.Timestamptz{Time: next, Valid: true},
+		})
+		if err != nil {
+			slog.Error("Failed to update schedule", "error", err, "schedule_id", schedule.ID)
+			sp.Rollback(ctx)
+			continue
+		}
+
+		if err := sp.Commit(ctx); err != nil {
+			slog.Error("Failed to release savepoint", "error", err, "schedule_id", schedule.ID)
+			return
+		}
+
+		slog.Info("Submitted scheduled job",
+			"schedule_id", schedule.ID,
+			"job_id", job.ID,
+			"type", job.Type,
+			"next_run_at", next,
+		)
+		submitted = append(submitted, *template)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		slog.Error("Failed to commit scheduled jobs", "error", err)
+		return
+	}
+
+	for _, template := range submitted {
+		metrics.JobsSubmitted.WithLabelValues(template.Type, string(template.Priority)).Inc()
+	}
+}
+
+// scheduleToResponse converts a schedule for API responses, with sensitive
+// env variables of its template redacted
+func (s *Server) scheduleToResponse(schedule db.JobSchedule) models.Schedule {
+	model := dbScheduleToModel(schedule)
+	model.Template.EnvVariables = s.redactEnv(model.Template.EnvVariables)
+	return model
+}
+
+func dbScheduleToModel(schedule db.JobSchedule) models.Schedule {
+	model := models.Schedule{
+		ID:        schedule.ID,
+		CronSpec:  schedule.CronSpec,
+		CreatedAt: schedule.CreatedAt.Time,
+		NextRunAt: schedule.NextRunAt.Time,
+	}
+
+	json.Unmarshal(schedule.Template, &model.Template)
+
+	if schedule.LastRunAt.Valid {
+		model.LastRunAt = &schedule.LastRunAt.Time
+	}
+	model.Disabled = schedule.Disabled
+	model.LastError = schedule.LastError.String
+
+	return model
+}
\ No newline at end of file
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/draganm/executr/internal/db"
+)
+
+func TestNextScheduleRun(t *testing.T) {
+	now := time.Date(2024, 1, 10, 10, 7, 30, 0, time.UTC) // a Wednesday
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2024, 1, 10, 10, 15, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2024, 1, 11, 3, 0, 0, 0, time.UTC)},
+		{"30 9 * * 1", time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 1, 10, 11, 0, 0, 0, time.UTC)},
+		{"@every 15m", now.Add(15 * time.Minute)},
+	}
+	for _, tt := range tests {
+		got, err := nextScheduleRun(tt.spec, now)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.spec, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("%s: got %s, want %s", tt.spec, got, tt.want)
+		}
+	}
+
+	for _, spec := range []string{"", "* * *", "61 * * * *", "@fortnightly", "0 0 30 2 *"} {
+		if _, err := nextScheduleRun(spec, now); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
+
+func TestScheduledRun(t *testing.T) {
+	now := time.Date(2024, 1, 10, 10, 7, 30, 0, time.UTC)
+
+	template, next, err := scheduledRun(db.JobSchedule{
+		CronSpec: "0 * * * *",
+		Template: []byte(`{"type":"report","binary_url":"https://example.com/report"}`),
+	}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if template.Type != "report" || !next.Equal(time.Date(2024, 1, 10, 11, 0, 0, 0, time.UTC)) {
+		t.Errorf("got %+v at %s", template, next)
+	}
+
+	// Schedules that can never fire again are disabled with the error
+	tests := []struct {
+		schedule db.JobSchedule
+		want     string
+	}{
+		{db.JobSchedule{CronSpec: "not a spec", Template: []byte(`{}`)}, "invalid cron spec"},
+		{db.JobSchedule{CronSpec: "0 0 30 2 *", Template: []byte(`{}`)}, "invalid cron spec"},
+		{db.JobSchedule{CronSpec: "0 * * * *", Template: []byte(`{"type":`)}, "invalid job template"},
+	}
+	for _, tt := range tests {
+		_, _, err := scheduledRun(tt.schedule, now)
+		if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+			t.Errorf("%s: expected %q error, got %v", tt.schedule.CronSpec, tt.want, err)
+		}
+	}
+}
\ No newline at end of file
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/draganm/executr/internal/db"
+	"github.com/draganm/executr/pkg/models"
+)
+
+// Orders in which claims hand out the pending jobs of a priority
+const (
+	// SchedulingFIFO hands out the oldest job first
+	SchedulingFIFO = "fifo"
+	// SchedulingFair hands out the oldest job of the type that was claimed
+	// least recently, so that a flood of one type doesn't starve the others
+	SchedulingFair = "fair"
+)
+
+// How claims choose between priorities
+const (
+	// PriorityModeStrict hands out jobs of a lower priority only while no
+	// job of a higher priority is pending
+	PriorityModeStrict = "strict"
+	// PriorityModeWeighted draws the priority of each claim by lottery,
+	// weighted by PriorityWeights, so lower priorities still progress under
+	// constant load of higher ones
+	PriorityModeWeighted = "weighted"
+)
+
+// DefaultPriorityWeights are the shares of foreground, background and
+// best_effort claims in the weighted priority mode
+var DefaultPriorityWeights = []int{70, 25, 5}
+
+// validateScheduling checks the scheduling and priority modes of the
+// configuration
+func validateScheduling(cfg *Config) error {
+	switch cfg.Scheduling {
+	case "", SchedulingFIFO, SchedulingFair:
+	default:
+		return fmt.Errorf("invalid scheduling %q: must be %s or %s", cfg.Scheduling, SchedulingFIFO, SchedulingFair)
+	}
+
+	switch cfg.PriorityMode {
+	case "", PriorityModeStrict:
+		return nil
+	case PriorityModeWeighted:
+	default:
+		return fmt.Errorf("invalid priority mode %q: must be %s or %s", cfg.PriorityMode, PriorityModeStrict, PriorityModeWeighted)
+	}
+
+	if cfg.PriorityWeights == nil {
+		return nil
+	}
+	if len(cfg.PriorityWeights) != len(models.Priorities) {
+		return fmt.Errorf("expected %d priority weights, for %v, got %d", len(models.Priorities), models.Priorities, len(cfg.PriorityWeights))
+	}
+	total := 0
+	for _, weight := range cfg.PriorityWeights {
+		if weight < 0 {
+			return fmt.Errorf("priority weights must not be negative, got %d", weight)
+		}
+		total += weight
+	}
+	if total == 0 {
+		return fmt.Errorf("at least one priority weight must be positive")
+	}
+	return nil
+}
+
+// priorityOrder returns the order in which a claim looks at the priorities.
+// In the weighted mode the first priority is drawn by weight, then the next
+// one from the remaining ones, and so on; priorities with a weight of 0 come
+// last, in their strict order. A claim only falls back to a later priority
+// when the earlier ones have no claimable job.
+func (s *Server) priorityOrder() []string {
+	order := make([]string, 0, len(models.Priorities))
+	if s.config.PriorityMode != PriorityModeWeighted {
+		for _, priority := range models.Priorities {
+			order = append(order, string(priority))
+		}
+		return order
+	}
+
+	weights := s.config.PriorityWeights
+	if weights == nil {
+		weights = DefaultPriorityWeights
+	}
+	remaining := append([]int(nil), weights...)
+	total := 0
+	for _, weight := range remaining {
+		total += weight
+	}
+
+	for total > 0 {
+		pick := rand.IntN(total)
+		for i, weight := range remaining {
+			if pick < weight {
+				order = append(order, string(models.Priorities[i]))
+				total -= weight
+				remaining[i] = 0
+				break
+			}
+			pick -= weight
+		}
+	}
+	for i, weight := range weights {
+		if weight == 0 {
+			order = append(order, string(models.Priorities[i]))
+		}
+	}
+	return order
+}
+
+// claimNextJob claims the next job for an executor, skipping job types that
+// already run as many jobs as their limit allows. The limits are locked
+// while claiming, so claims on all server replicas see each other's jobs.
+func (s *Server) claimNextJob(ctx context.Context, params db.ClaimNextJobParams) (db.Job, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return db.Job{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.queries.WithTx(tx)
+
+	if err := q.LockJobTypeLimits(ctx); err != nil {
+		return db.Job{}, fmt.Errorf("failed to lock job type limits: %w", err)
+	}
+
+	params.Priorities = s.priorityOrder()
+
+	var job db.Job
+	if s.config.Scheduling == SchedulingFair {
+		job, err = q.ClaimNextJobFair(ctx, db.ClaimNextJobFairParams(params))
+		if err != nil {
+			return db.Job{}, err
+		}
+		if err := q.RecordJobTypeClaim(ctx, job.Type); err != nil {
+			return db.Job{}, fmt.Errorf("failed to record claim of job type: %w", err)
+		}
+	} else {
+		job, err = q.ClaimNextJob(ctx, params)
+		if err != nil {
+			return db.Job{}, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return db.Job{}, fmt.Errorf("failed to commit claim: %w", err)
+	}
+	return job, nil
+}
\ No newline at end of file
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewValidatesScheduling(t *testing.T) {
+	for _, cfg := range []*Config{
+		{},
+		{Scheduling: SchedulingFIFO},
+		{Scheduling: SchedulingFair},
+		{PriorityMode: PriorityModeStrict},
+		{PriorityMode: PriorityModeWeighted},
+		{PriorityMode: PriorityModeWeighted, PriorityWeights: []int{1, 0, 0}},
+	} {
+		if _, err := New(cfg); err != nil {
+			t.Errorf("unexpected error for %+v: %v", cfg, err)
+		}
+	}
+	for _, cfg := range []*Config{
+		{Scheduling: "round-robin"},
+		{Scheduling: "FAIR"},
+		{PriorityMode: "lottery"},
+		{PriorityMode: PriorityModeWeighted, PriorityWeights: []int{70, 30}},
+		{PriorityMode: PriorityModeWeighted, PriorityWeights: []int{70, 35, -5}},
+		{PriorityMode: PriorityModeWeighted, PriorityWeights: []int{0, 0, 0}},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("expected an error for %+v", cfg)
+		}
+	}
+}
+
+func TestPriorityOrderStrict(t *testing.T) {
+	s := &Server{config: &Config{}}
+	want := []string{"foreground", "background", "best_effort"}
+	if got := s.priorityOrder(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestPriorityOrderWeighted(t *testing.T) {
+	s := &Server{config: &Config{PriorityMode: PriorityModeWeighted, PriorityWeights: []int{70, 30, 0}}}
+
+	const draws = 10000
+	first := map[string]int{}
+	for i := 0; i < draws; i++ {
+		order := s.priorityOrder()
+		if len(order) != 3 {
+			t.Fatalf("expected all priorities, got %v", order)
+		}
+		// Without a weight best_effort only gets what nothing else wants
+		if order[2] != "best_effort" {
+			t.Fatalf("expected best_effort last, got %v", order)
+		}
+		first[order[0]]++
+	}
+
+	// Far outside of what chance allows with 10000 draws
+	if share := float64(first["foreground"]) / draws; share < 0.65 || share > 0.75 {
+		t.Fatalf("expected foreground first in about 70%% of claims, got %.2f", share)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,28 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/draganm/executr/pkg/models"
+)
+
+// validateSecretRefs checks the secret references among a submission's env
+// variables. Secrets are resolved by executors, the server only checks that
+// each reference names a secret within the secret backend.
+func validateSecretRefs(env map[string]string) error {
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		name, ok := models.SecretRef(env[key])
+		if ok && !filepath.IsLocal(name) {
+			return fmt.Errorf("env variable %s references invalid secret %q", key, name)
+		}
+	}
+	return nil
+}
\ No newline at end of file
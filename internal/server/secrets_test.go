@@ -0,0 +1,16 @@
+package server
+
+import "testing"
+
+func TestValidateSecretRefs(t *testing.T) {
+	env := map[string]string{"DB_PASS": "secret://prod/db-pass", "MODE": "fast"}
+	if err := validateSecretRefs(env); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, ref := range []string{"secret://", "secret:///etc/shadow", "secret://../db-pass"} {
+		if err := validateSecretRefs(map[string]string{"DB_PASS": ref}); err == nil {
+			t.Errorf("expected an error for %q", ref)
+		}
+	}
+}
\ No newline at end of file
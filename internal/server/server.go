@@ -10,7 +10,9 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,10 +24,13 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/draganm/executr/internal/db"
 	"github.com/draganm/executr/internal/metrics"
-	"github.com/draganm/executr/internal/models"
+	"github.com/draganm/executr/internal/tracing"
+	"github.com/draganm/executr/internal/utils"
+	"github.com/draganm/executr/pkg/models"
 )
 
 //go:embed migrations/*.sql
@@ -37,31 +42,203 @@ type Config struct {
 	Port             int
 	CleanupInterval  int // seconds
 	JobRetention     int // seconds
-	HeartbeatTimeout int // seconds
+	HeartbeatTimeout int // seconds, 0 means DefaultHeartbeatTimeout
+	RetryInterval    int // seconds between retry worker runs, 0 means 30
+	ShutdownTimeout  int // seconds for requests and background workers to finish on shutdown, 0 means DefaultShutdownTimeout
 	LogLevel         string
+
+	// MaxOutputBytesLimit is the ceiling for a job's max_output_bytes;
+	// 0 uses DefaultMaxOutputBytesLimit
+	MaxOutputBytesLimit int
+
+	// APIKeysFile enables API key authentication (see LoadAPIKeys);
+	// requests are not authenticated when it is empty
+	APIKeysFile string
+
+	// HashBinaries makes the server download the binary of a submission
+	// without binary_sha256 and store its hash
+	HashBinaries bool
+
+	// BinaryAuthConfig is a download credentials file (see
+	// utils.LoadDownloadCredentials) used when downloading binaries to
+	// validate or hash them
+	BinaryAuthConfig string
+
+	// SensitiveEnvPatterns are globs of env variable keys, e.g. *_TOKEN,
+	// whose values are redacted in responses other than job claims
+	SensitiveEnvPatterns []string
+
+	// Requests per second allowed for each executor's claims and heartbeats
+	// and each API key's submissions; 0 disables the limit
+	ClaimRatePerSec     float64
+	HeartbeatRatePerSec float64
+	SubmitRatePerSec    float64
+
+	// MaxRequestBytes limits the size of JSON request bodies; 0 uses
+	// DefaultMaxRequestBytes. Requests carrying job data may add four times
+	// MaxOutputBytesLimit.
+	MaxRequestBytes int64
+
+	// Version is the build of the server, reported by /api/v1/version and
+	// the health check
+	Version models.VersionInfo
+
+	// ArchiveSink keeps a record of every job the retention cleanup deletes:
+	// ArchiveSinkDB, ArchiveSinkFile or ArchiveSinkS3. Jobs are deleted
+	// without a record when it is empty.
+	ArchiveSink string
+
+	// ArchiveFile is the JSON lines file of the file archive sink
+	ArchiveFile string
+
+	// S3-compatible storage for the s3 archive sink; objects are stored
+	// under ArchiveS3Prefix
+	ArchiveS3URL       string
+	ArchiveS3Bucket    string
+	ArchiveS3Prefix    string
+	ArchiveS3Region    string
+	ArchiveS3AccessKey string
+	ArchiveS3SecretKey string
+
+	// Scheduling is the order in which pending jobs of the same priority
+	// are claimed: SchedulingFIFO (the default when empty) or SchedulingFair
+	Scheduling string
+
+	// PriorityMode is how claims choose between priorities:
+	// PriorityModeStrict (the default when empty) or PriorityModeWeighted,
+	// which draws them by PriorityWeights, the shares of foreground,
+	// background and best_effort claims. nil weights use
+	// DefaultPriorityWeights.
+	PriorityMode    string
+	PriorityWeights []int
+
+	// EnablePprof serves the net/http/pprof profiling endpoints under
+	// /debug/pprof/. Only enable it where the server is reachable from
+	// trusted networks.
+	EnablePprof bool
+
+	// TracerProvider records spans for requests; nil uses the global
+	// provider, which records nothing unless set with otel.SetTracerProvider
+	TracerProvider trace.TracerProvider
 }
 
+// DefaultMaxOutputBytesLimit is the default ceiling for per-job output limits
+const DefaultMaxOutputBytesLimit = 64 * 1024 * 1024
+
+// DefaultHeartbeatTimeout is how many seconds a running job may go without a
+// heartbeat before it is reset to pending, unless configured otherwise
+const DefaultHeartbeatTimeout = 15
+
+// DefaultRetryBackoffBase is the retry backoff base in seconds for jobs that
+// don't set retry_backoff_base. Retry n waits base * 2^(n-1) after the
+// failure it follows.
+const DefaultRetryBackoffBase = 60
+
+// MaxRetries and MaxRetryBackoffBase bound max_retries and
+// retry_backoff_base of submissions
+const (
+	MaxRetries          = 100
+	MaxRetryBackoffBase = 24 * 60 * 60
+)
+
+// MaxJobTypeLength bounds the job type in bytes. Job events carry the type in
+// a NOTIFY payload, which Postgres limits to 8000 bytes.
+const MaxJobTypeLength = 255
+
+// DefaultShutdownTimeout is how many seconds in-flight requests and
+// background workers get to finish when the server shuts down
+const DefaultShutdownTimeout = 30
+
+// poolStatsInterval is how often the database connection pool usage is
+// sampled for the metrics
+const poolStatsInterval = 5 * time.Second
+
+// queueAgeInterval is how often the age of the oldest pending job per
+// priority is refreshed for the metrics
+const queueAgeInterval = 5 * time.Second
+
 // Server represents the job server
 type Server struct {
-	config  *Config
-	pool    *pgxpool.Pool
-	queries *db.Queries
-	server  *http.Server
-	wg      sync.WaitGroup
-	port    int // actual port (for testing with port 0)
-	ready   chan struct{} // signals when server is ready
+	config   *Config
+	pool     *pgxpool.Pool
+	queries  *db.Queries
+	server   *http.Server
+	wg       sync.WaitGroup
+	port     int           // actual port (for testing with port 0)
+	ready    chan struct{} // signals when server is ready
+	stopping chan struct{} // closed when shutdown starts, ends long-polls and streams
+
+	jobsAvailable jobSignal // wakes up long-polling claims, fed by jobListener
+	events        eventHub  // open job event streams, fed by jobListener
+
+	// nil when the limit is disabled
+	claimLimiter     *rateLimiter
+	heartbeatLimiter *rateLimiter
+	submitLimiter    *rateLimiter
+
+	downloadCredentials *utils.DownloadCredentials // nil when downloads are not authenticated
+
+	archive jobArchive // nil when deleted jobs are not archived
+
+	tracer trace.Tracer
 }
 
 // New creates a new server instance
 func New(cfg *Config) (*Server, error) {
+	if err := validateSensitiveEnvPatterns(cfg.SensitiveEnvPatterns); err != nil {
+		return nil, err
+	}
+	if err := validateScheduling(cfg); err != nil {
+		return nil, err
+	}
+	claimLimiter, err := newRateLimiter(cfg.ClaimRatePerSec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid claim rate: %w", err)
+	}
+	heartbeatLimiter, err := newRateLimiter(cfg.HeartbeatRatePerSec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid heartbeat rate: %w", err)
+	}
+	submitLimiter, err := newRateLimiter(cfg.SubmitRatePerSec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid submit rate: %w", err)
+	}
+	var downloadCredentials *utils.DownloadCredentials
+	if cfg.BinaryAuthConfig != "" {
+		if downloadCredentials, err = utils.LoadDownloadCredentials(cfg.BinaryAuthConfig); err != nil {
+			return nil, err
+		}
+	}
+	archive, err := newJobArchive(cfg)
+	if err != nil {
+		return nil, err
+	}
 	return &Server{
-		config: cfg,
-		ready:  make(chan struct{}),
+		config:              cfg,
+		ready:               make(chan struct{}),
+		stopping:            make(chan struct{}),
+		claimLimiter:        claimLimiter,
+		heartbeatLimiter:    heartbeatLimiter,
+		submitLimiter:       submitLimiter,
+		downloadCredentials: downloadCredentials,
+		archive:             archive,
+		tracer:              tracing.Tracer(cfg.TracerProvider),
 	}, nil
 }
 
 // Run starts the server
 func (s *Server) Run(ctx context.Context) error {
+	// Load API keys first so a bad keys file fails fast
+	var apiKeys APIKeys
+	if s.config.APIKeysFile != "" {
+		keys, err := LoadAPIKeys(s.config.APIKeysFile)
+		if err != nil {
+			return err
+		}
+		apiKeys = keys
+		slog.Info("API key authentication enabled", "keys", len(apiKeys))
+	}
+
 	// Connect to database
 	if err := s.connectDB(ctx); err != nil {
 		return fmt.Errorf("failed to connect to database: %w", err)
@@ -76,20 +253,31 @@ func (s *Server) Run(ctx context.Context) error {
 	}
 	slog.Info("Migrations completed successfully")
 
-	// Start background workers
-	s.startWorkers(ctx)
+	// Start background workers. They also stop when the HTTP server fails.
+	workerCtx, stopWorkers := context.WithCancel(ctx)
+	defer stopWorkers()
+	s.startWorkers(workerCtx)
 
 	// Setup HTTP server
 	mux := http.NewServeMux()
 	s.setupRoutes(mux)
 
-	// Wrap with metrics middleware
-	handler := metrics.HTTPMiddleware(mux)
+	// Wrap with rate limit, auth, metrics, API version negotiation, tracing
+	// and request ID middleware
+	var handler http.Handler = s.rateLimitMiddleware(mux)
+	if apiKeys != nil {
+		handler = s.authMiddleware(apiKeys, handler)
+	}
+	handler = metrics.HTTPMiddleware(handler)
+	handler = s.apiVersionMiddleware(handler)
+	handler = s.tracingMiddleware(handler)
+	handler = requestIDMiddleware(handler)
 
 	s.server = &http.Server{
 		Addr:    fmt.Sprintf(":%d", s.config.Port),
 		Handler: handler,
 	}
+	s.server.RegisterOnShutdown(func() { close(s.stopping) })
 
 	// Start HTTP server
 	serverErr := make(chan error, 1)
@@ -102,7 +290,7 @@ func (s *Server) Run(ctx context.Context) error {
 			return fmt.Errorf("failed to create listener: %w", err)
 		}
 		s.port = listener.Addr().(*net.TCPAddr).Port
-		slog.Info("Starting server", "port", s.port)
+		slog.Info("Starting server", "port", s.port, "version", s.version().Version)
 		
 		// Signal that server is ready now that we have a port
 		close(s.ready)
@@ -114,7 +302,7 @@ func (s *Server) Run(ctx context.Context) error {
 		}()
 	} else {
 		s.port = s.config.Port
-		slog.Info("Starting server", "port", s.port)
+		slog.Info("Starting server", "port", s.port, "version", s.version().Version)
 		
 		// Signal that server is ready
 		close(s.ready)
@@ -129,19 +317,71 @@ func (s *Server) Run(ctx context.Context) error {
 	// Wait for context cancellation or server error
 	select {
 	case <-ctx.Done():
-		slog.Info("Shutting down server...")
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		return s.shutdown()
+	case err := <-serverErr:
+		stopWorkers()
+		waitCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout())
 		defer cancel()
-		if err := s.server.Shutdown(shutdownCtx); err != nil {
-			return fmt.Errorf("server shutdown failed: %w", err)
+		if waitErr := s.waitForWorkers(waitCtx); waitErr != nil {
+			slog.Error("Background workers did not stop", "error", waitErr)
 		}
-	case err := <-serverErr:
 		return fmt.Errorf("server failed: %w", err)
 	}
+}
+
+// shutdownTimeout returns how long shutting down may take
+func (s *Server) shutdownTimeout() time.Duration {
+	timeout := s.config.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	return time.Duration(timeout) * time.Second
+}
 
-	// Wait for background workers to finish
-	s.wg.Wait()
-	return nil
+// shutdown stops the HTTP server and waits for the background workers,
+// which stop with the Run context, within the shutdown timeout. Long-polling
+// claims and event streams end right away; requests still running at the
+// deadline are cut off.
+func (s *Server) shutdown() error {
+	timeout := s.shutdownTimeout()
+	slog.Info("Shutting down server...", "timeout", timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var shutdownErr error
+	if err := s.server.Shutdown(ctx); err != nil {
+		slog.Warn("Requests did not finish within the shutdown timeout, closing their connections", "error", err)
+		s.server.Close()
+		shutdownErr = fmt.Errorf("server shutdown failed: %w", err)
+	}
+
+	if err := s.waitForWorkers(ctx); err != nil {
+		return errors.Join(shutdownErr, err)
+	}
+	return shutdownErr
+}
+
+// waitForWorkers waits for the background workers to finish until ctx is
+// done, so a stuck worker can't block shutdown forever
+func (s *Server) waitForWorkers(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		// Workers may have finished right at the deadline
+		select {
+		case <-done:
+			return nil
+		default:
+			return errors.New("background workers did not stop within the shutdown timeout")
+		}
+	}
 }
 
 func (s *Server) connectDB(ctx context.Context) error {
@@ -200,21 +440,38 @@ func (s *Server) setupRoutes(mux *http.ServeMux) {
 	// Metrics endpoint (no middleware for this)
 	mux.Handle("/api/v1/metrics", promhttp.Handler())
 	
-	// Health check
+	// Health check and build information
 	mux.HandleFunc("/api/v1/health", s.handleHealth)
+	mux.HandleFunc("/api/v1/version", s.handleVersion)
 
 	// Job endpoints
 	mux.HandleFunc("/api/v1/jobs", s.handleJobs)
 	mux.HandleFunc("/api/v1/jobs/", s.handleJobByID)
 	mux.HandleFunc("/api/v1/jobs/claim", s.handleClaimJob)
+	mux.HandleFunc("/api/v1/jobs/events", s.handleJobEvents)
 	
 	// Bulk operations
 	mux.HandleFunc("/api/v1/jobs/bulk", s.handleBulkJobs)
 	mux.HandleFunc("/api/v1/jobs/bulk/cancel", s.handleBulkCancel)
 	
+	// Recurring job schedules
+	mux.HandleFunc("/api/v1/schedules", s.handleSchedules)
+	mux.HandleFunc("/api/v1/schedules/", s.handleScheduleByID)
+	
+	// Executor registration
+	mux.HandleFunc("/api/v1/executors", s.handleExecutors)
+	mux.HandleFunc("/api/v1/executors/", s.handleExecutorByID)
+	
 	// Admin endpoints
 	mux.HandleFunc("/api/v1/admin/stats", s.handleAdminStats)
 	mux.HandleFunc("/api/v1/admin/executors", s.handleAdminExecutors)
+	mux.HandleFunc(jobTypeLimitsPath, s.handleJobTypeLimits)
+	mux.HandleFunc(jobTypeLimitsPath+"/", s.handleJobTypeLimitByType)
+
+	if s.config.EnablePprof {
+		slog.Warn("Serving pprof profiling endpoints", "path", pprofPrefix)
+		registerPprof(mux)
+	}
 }
 
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
@@ -234,9 +491,14 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 		status = "unhealthy"
 	}
 
-	response := map[string]string{
-		"status":   status,
-		"database": dbStatus,
+	response := map[string]interface{}{
+		"status":                 status,
+		"database":               dbStatus,
+		"version":                s.version().Version,
+		"max_output_bytes_limit": s.maxOutputBytesLimit(),
+	}
+	if s.config.HashBinaries {
+		response["server_side_hashing"] = true
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -278,19 +540,30 @@ func (s *Server) handleJobByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setSpanJob(r.Context(), jobID)
+
 	// Handle sub-paths
 	subPath := ""
 	if len(idStr) > 36 {
 		subPath = idStr[36:]
 	}
 
+	if name, ok := strings.CutPrefix(subPath, "/artifacts/"); ok {
+		s.handleArtifact(w, r, jobID, name)
+		return
+	}
+
 	switch subPath {
 	case "", "/":
 		switch r.Method {
 		case http.MethodGet:
 			s.handleGetJob(w, r, jobID)
+		case http.MethodHead:
+			s.handleJobExists(w, r, jobID)
 		case http.MethodDelete:
 			s.handleCancelJob(w, r, jobID)
+		case http.MethodPatch:
+			s.handleUpdateJob(w, r, jobID)
 		default:
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
@@ -312,6 +585,39 @@ func (s *Server) handleJobByID(w http.ResponseWriter, r *http.Request) {
 		} else {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
+	case "/cancelled":
+		if r.Method == http.MethodPut {
+			s.handleCancelledJob(w, r, jobID)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case "/output":
+		switch r.Method {
+		case http.MethodGet:
+			s.handleGetJobOutput(w, r, jobID)
+		case http.MethodPut:
+			s.handleAppendOutput(w, r, jobID)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case "/logs":
+		if r.Method == http.MethodGet {
+			s.handleStreamLogs(w, r, jobID)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case "/requeue":
+		if r.Method == http.MethodPost {
+			s.handleRequeueJob(w, r, jobID)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case "/artifacts":
+		if r.Method == http.MethodGet {
+			s.handleListArtifacts(w, r, jobID)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
 	default:
 		http.NotFound(w, r)
 	}
@@ -319,8 +625,7 @@ func (s *Server) handleJobByID(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleSubmitJob(w http.ResponseWriter, r *http.Request) {
 	var submission models.JobSubmission
-	if err := json.NewDecoder(r.Body).Decode(&submission); err != nil {
-		s.writeError(w, http.StatusBadRequest, "Invalid request body", nil)
+	if !s.decodeUpload(w, r, &submission) {
 		return
 	}
 
@@ -330,40 +635,183 @@ func (s *Server) handleSubmitJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(submission.Type) > MaxJobTypeLength {
+		s.writeError(w, http.StatusBadRequest, "type is too long", map[string]interface{}{"max_length": MaxJobTypeLength})
+		return
+	}
+
+	if submission.Timeout < 0 {
+		s.writeError(w, http.StatusBadRequest, "timeout must not be negative", map[string]interface{}{"timeout": submission.Timeout})
+		return
+	}
+
+	if submission.MaxOutputBytes < 0 {
+		s.writeError(w, http.StatusBadRequest, "max_output_bytes must not be negative", map[string]interface{}{"max_output_bytes": submission.MaxOutputBytes})
+		return
+	}
+
+	if submission.WorkdirQuotaBytes < 0 {
+		s.writeError(w, http.StatusBadRequest, "workdir_quota_bytes must not be negative", map[string]interface{}{"workdir_quota_bytes": submission.WorkdirQuotaBytes})
+		return
+	}
+
+	if submission.CPUMillicores < 0 || submission.MemLimitBytes < 0 {
+		s.writeError(w, http.StatusBadRequest, "cpu_millicores and mem_limit_bytes must not be negative", map[string]interface{}{
+			"cpu_millicores":  submission.CPUMillicores,
+			"mem_limit_bytes": submission.MemLimitBytes,
+		})
+		return
+	}
+
+	if submission.MaxRetries < 0 || submission.MaxRetries > MaxRetries {
+		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("max_retries must be between 0 and %d", MaxRetries), map[string]interface{}{"max_retries": submission.MaxRetries})
+		return
+	}
+
+	if submission.RetryBackoffBase < 0 || submission.RetryBackoffBase > MaxRetryBackoffBase {
+		s.writeError(w, http.StatusBadRequest, fmt.Sprintf("retry_backoff_base must be between 0 and %d", MaxRetryBackoffBase), map[string]interface{}{"retry_backoff_base": submission.RetryBackoffBase})
+		return
+	}
+
+	if submission.SignatureURL != "" && submission.PublicKey == "" {
+		s.writeError(w, http.StatusBadRequest, "public_key is required when signature_url is set", nil)
+		return
+	}
+
+	if _, ok := submission.Labels[""]; ok {
+		s.writeError(w, http.StatusBadRequest, "label keys must not be empty", nil)
+		return
+	}
+
+	if len(submission.Stdin) > s.maxOutputBytesLimit() {
+		s.writeError(w, http.StatusBadRequest, "stdin is too large", map[string]interface{}{"max_bytes": s.maxOutputBytesLimit()})
+		return
+	}
+
+	if err := validateInputFiles(submission.InputFiles, s.maxOutputBytesLimit()); err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if err := validateOutputGlobs(submission.OutputGlobs); err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if err := validateSecretRefs(submission.EnvVariables); err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	// A dry run only checks the binary
+	if r.URL.Query().Get("dry_run") == "true" {
+		s.handleValidateJob(w, r, &submission)
+		return
+	}
+
+	key := r.Header.Get(idempotencyKeyHeader)
+	if len(key) > maxIdempotencyKeyLength {
+		s.writeError(w, http.StatusBadRequest, "Idempotency-Key is too long", map[string]interface{}{"max_length": maxIdempotencyKeyLength})
+		return
+	}
+
+	// Recurring jobs are stored as a schedule instead
+	if submission.CronSpec != "" {
+		if key != "" {
+			s.writeError(w, http.StatusBadRequest, "Idempotency-Key can't be combined with cron_spec", nil)
+			return
+		}
+		if !s.hashBinary(w, r, &submission) {
+			return
+		}
+		s.createSchedule(w, r, &submission)
+		return
+	}
+
+	// A retried submission gets the job the first attempt created
+	scope := idempotencyScope(r)
+	hash := submissionHash(&submission)
+	if key != "" {
+		existing, err := s.jobByIdempotencyKey(r.Context(), scope, key)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to look up idempotency key", "error", err)
+			s.writeError(w, http.StatusInternalServerError, "Failed to create job", nil)
+			return
+		}
+		if existing != nil {
+			s.writeExistingJob(w, r, *existing, hash)
+			return
+		}
+	}
+
+	if !s.hashBinary(w, r, &submission) {
+		return
+	}
+
 	// Create job in database
-	envJSON, _ := json.Marshal(submission.EnvVariables)
-	
-	job, err := s.queries.CreateJob(r.Context(), db.CreateJobParams{
-		Type:         submission.Type,
-		BinaryUrl:    submission.BinaryURL,
-		BinarySha256: submission.BinarySHA256,
-		Arguments:    submission.Arguments,
-		EnvVariables: envJSON,
-		Priority:     string(submission.Priority),
-	})
+	dependsOn := dedupeDependencies(submission.DependsOn)
+	params := s.createJobParams(r.Context(), &submission)
+	if key != "" {
+		params.IdempotencyKey = idempotencyKey(key)
+		params.IdempotencyScope = scope
+		params.IdempotencyHash = hash
+	}
+	job, err := s.createJob(r.Context(), params, dependsOn)
+	if key != "" && isUniqueViolation(err) {
+		// A concurrent retry with the same key won the race
+		existing, err := s.jobByIdempotencyKey(r.Context(), scope, key)
+		if err == nil && existing != nil {
+			s.writeExistingJob(w, r, *existing, hash)
+			return
+		}
+	}
+	if errors.Is(err, errUnknownDependency) {
+		s.writeError(w, http.StatusBadRequest, err.Error(), map[string]interface{}{"depends_on": dependsOn})
+		return
+	}
 	if err != nil {
-		slog.Error("Failed to create job", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to create job", "error", err)
 		s.writeError(w, http.StatusInternalServerError, "Failed to create job", nil)
 		return
 	}
 
 	// Track metrics
 	metrics.JobsSubmitted.WithLabelValues(submission.Type, string(submission.Priority)).Inc()
+	setSpanJob(r.Context(), job.ID)
 	
 	// Convert to response model
 	response := s.dbJobToModel(job)
+	response.DependsOn = dependsOn
 	
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(response)
 }
 
+// listSortKeys are the columns jobs can be sorted by when listing
+var listSortKeys = map[string]bool{
+	"created_at":   true,
+	"completed_at": true,
+	"priority":     true,
+	"type":         true,
+	"status":       true,
+}
+
 func (s *Server) handleListJobs(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query()
 	
 	status := q.Get("status")
 	jobType := q.Get("type")
 	priority := q.Get("priority")
+	executorID := q.Get("executor_id")
+	
+	if status != "" && !models.Status(status).IsValid() {
+		s.writeError(w, http.StatusBadRequest, "Invalid status", map[string]interface{}{
+			"status":  status,
+			"allowed": models.Statuses,
+		})
+		return
+	}
 	
 	limit := int32(100)
 	if l := q.Get("limit"); l != "" {
@@ -379,36 +827,119 @@ func (s *Server) handleListJobs(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	sortBy := q.Get("sort")
+	if sortBy == "" {
+		sortBy = "created_at"
+	}
+	if !listSortKeys[sortBy] {
+		s.writeError(w, http.StatusBadRequest, "Invalid sort key", map[string]interface{}{
+			"sort":    sortBy,
+			"allowed": []string{"created_at", "completed_at", "priority", "type", "status"},
+		})
+		return
+	}
+
+	sortOrder := q.Get("order")
+	if sortOrder == "" {
+		sortOrder = "desc"
+	}
+	if sortOrder != "asc" && sortOrder != "desc" {
+		s.writeError(w, http.StatusBadRequest, "order must be asc or desc", map[string]interface{}{"order": sortOrder})
+		return
+	}
+
+	labels, err := parseLabelFilters(q["label"])
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error(), map[string]interface{}{"label": q["label"]})
+		return
+	}
+	var labelsFilter []byte
+	if labels != nil {
+		labelsFilter = labelsJSON(labels)
+	}
+
+	statusFilter := pgtype.Text{String: status, Valid: status != ""}
+	typeFilter := pgtype.Text{String: jobType, Valid: jobType != ""}
+	priorityFilter := pgtype.Text{String: priority, Valid: priority != ""}
+	executorFilter := pgtype.Text{String: executorID, Valid: executorID != ""}
+
 	jobs, err := s.queries.ListJobs(r.Context(), db.ListJobsParams{
-		Column1: status,
-		Column2: jobType,
-		Column3: priority,
-		Limit:   limit,
-		Offset:  offset,
+		Status:     statusFilter,
+		Type:       typeFilter,
+		Priority:   priorityFilter,
+		Labels:     labelsFilter,
+		ExecutorID: executorFilter,
+		SortBy:     sortBy,
+		SortOrder:  sortOrder,
+		Limit:      limit,
+		Offset:     offset,
 	})
 	if err != nil {
-		slog.Error("Failed to list jobs", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to list jobs", "error", err)
+		s.writeError(w, http.StatusInternalServerError, "Failed to list jobs", nil)
+		return
+	}
+
+	total, err := s.queries.CountJobs(r.Context(), db.CountJobsParams{
+		Status:     statusFilter,
+		Type:       typeFilter,
+		Priority:   priorityFilter,
+		Labels:     labelsFilter,
+		ExecutorID: executorFilter,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to count jobs", "error", err)
 		s.writeError(w, http.StatusInternalServerError, "Failed to list jobs", nil)
 		return
 	}
 
 	// Convert to response models
-	response := make([]models.Job, len(jobs))
+	response := models.JobList{
+		Jobs:   make([]models.Job, len(jobs)),
+		Total:  total,
+		Limit:  int(limit),
+		Offset: int(offset),
+	}
+	noOutput := q.Get("no_output") == "true"
 	for i, job := range jobs {
-		response[i] = s.dbJobToModel(job)
+		response.Jobs[i] = s.dbJobToModel(job)
+		if noOutput {
+			withoutOutput(&response.Jobs[i])
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
+// jobStatusHeader carries the status of a job in answers to HEAD requests
+const jobStatusHeader = "X-Job-Status"
+
+// handleJobExists answers HEAD requests for a job with only its status, for
+// monitoring loops that poll frequently
+func (s *Server) handleJobExists(w http.ResponseWriter, r *http.Request, jobID uuid.UUID) {
+	status, err := s.queries.GetJobStatus(r.Context(), jobID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			w.WriteHeader(http.StatusNotFound)
+		} else {
+			slog.ErrorContext(r.Context(), "Failed to get job status", "error", err, "job_id", jobID)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set(jobStatusHeader, status)
+	w.WriteHeader(http.StatusOK)
+}
+
 func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request, jobID uuid.UUID) {
 	job, err := s.queries.GetJob(r.Context(), jobID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			s.writeError(w, http.StatusNotFound, "Job not found", map[string]interface{}{"job_id": jobID})
 		} else {
-			slog.Error("Failed to get job", "error", err, "job_id", jobID)
+			slog.ErrorContext(r.Context(), "Failed to get job", "error", err, "job_id", jobID)
 			s.writeError(w, http.StatusInternalServerError, "Failed to get job", nil)
 		}
 		return
@@ -417,47 +948,231 @@ func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request, jobID uuid
 	// Get job attempts
 	attempts, err := s.queries.GetJobAttempts(r.Context(), jobID)
 	if err != nil {
-		slog.Error("Failed to get job attempts", "error", err, "job_id", jobID)
+		slog.ErrorContext(r.Context(), "Failed to get job attempts", "error", err, "job_id", jobID)
 	}
 
 	response := s.dbJobToModel(job)
+	if r.URL.Query().Get("no_output") == "true" {
+		withoutOutput(&response)
+	}
+
+	dependsOn, err := s.queries.GetJobDependencies(r.Context(), jobID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get job dependencies", "error", err, "job_id", jobID)
+	} else if len(dependsOn) > 0 {
+		response.DependsOn = dependsOn
+	}
 	
 	// Add attempts to response
 	if len(attempts) > 0 {
-		attemptModels := make([]models.JobAttempt, len(attempts))
-		for i, attempt := range attempts {
-			attemptModels[i] = models.JobAttempt{
-				ID:           attempt.ID,
-				JobID:        attempt.JobID,
-				ExecutorID:   attempt.ExecutorID,
-				ExecutorIP:   attempt.ExecutorIp,
-				StartedAt:    attempt.StartedAt.Time,
-				Status:       attempt.Status,
-			}
-			if attempt.EndedAt.Valid {
-				attemptModels[i].EndedAt = &attempt.EndedAt.Time
+		response.Attempts = dbAttemptsToModels(attempts)
+	}
+
+	// The estimate moves with time, so it's left out of the ETag; otherwise
+	// polling clients would never see an unchanged job
+	etagSource := response
+	eta, err := s.estimatedCompletion(r.Context(), job)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to estimate job completion", "error", err, "job_id", jobID)
+	}
+	response.EstimatedCompletion = eta
+
+	s.writeJSONWithETagOf(w, r, response, etagSource)
+}
+
+// maxCancellationReasonLength bounds the size of stored cancellation reasons
+const maxCancellationReasonLength = 1024
+
+// cancellationReason reads the optional reason of a cancellation from the
+// reason query parameter or a JSON body. Invalid requests are answered and
+// false is returned.
+func (s *Server) cancellationReason(w http.ResponseWriter, r *http.Request) (pgtype.Text, bool) {
+	reason := r.URL.Query().Get("reason")
+	if reason == "" && hasBody(r) {
+		var request models.CancelJobRequest
+		if !s.decodeBody(w, r, &request) {
+			return pgtype.Text{}, false
+		}
+		reason = request.Reason
+	}
+	return s.validCancellationReason(w, reason)
+}
+
+// validCancellationReason checks the length of a cancellation reason and
+// turns it into the column value, NULL when no reason was given
+func (s *Server) validCancellationReason(w http.ResponseWriter, reason string) (pgtype.Text, bool) {
+	reason = strings.TrimSpace(reason)
+	if len(reason) > maxCancellationReasonLength {
+		s.writeError(w, http.StatusBadRequest, "Cancellation reason is too long", map[string]interface{}{"max_length": maxCancellationReasonLength})
+		return pgtype.Text{}, false
+	}
+	return pgtype.Text{String: reason, Valid: reason != ""}, true
+}
+
+func (s *Server) handleCancelJob(w http.ResponseWriter, r *http.Request, jobID uuid.UUID) {
+	reason, ok := s.cancellationReason(w, r)
+	if !ok {
+		return
+	}
+
+	job, err := s.queries.CancelJob(r.Context(), db.CancelJobParams{ID: jobID, Reason: reason})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.ErrorContext(r.Context(), "Failed to cancel job", "error", err, "job_id", jobID)
+			s.writeError(w, http.StatusInternalServerError, "Failed to cancel job", nil)
+			return
+		}
+
+		// Distinguish a missing job from one that already finished
+		current, err := s.queries.GetJob(r.Context(), jobID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				s.writeError(w, http.StatusNotFound, "Job not found", map[string]interface{}{"job_id": jobID})
+			} else {
+				slog.ErrorContext(r.Context(), "Failed to get job", "error", err, "job_id", jobID)
+				s.writeError(w, http.StatusInternalServerError, "Failed to cancel job", nil)
 			}
-			if attempt.ErrorMessage.Valid {
-				attemptModels[i].ErrorMessage = attempt.ErrorMessage.String
+			return
+		}
+		s.writeError(w, http.StatusConflict, "Only pending or running jobs can be cancelled", map[string]interface{}{
+			"job_id": jobID,
+			"status": current.Status,
+		})
+		return
+	}
+
+	s.endCancelledAttempt(r.Context(), job)
+	metrics.JobsCancelled.Inc()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// endCancelledAttempt closes the attempt of a job that was cancelled while
+// running. The executor stops the job once a heartbeat tells it about the
+// cancellation.
+func (s *Server) endCancelledAttempt(ctx context.Context, job db.Job) {
+	if !job.ExecutorID.Valid {
+		return
+	}
+
+	message := "job cancelled while running"
+	if job.CancellationReason.Valid {
+		message += ": " + job.CancellationReason.String
+	}
+
+	err := s.queries.UpdateJobAttempt(ctx, db.UpdateJobAttemptParams{
+		JobID:        job.ID,
+		Status:       "cancelled",
+		ErrorMessage: pgtype.Text{String: message, Valid: true},
+		ExecutorID:   job.ExecutorID.String,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to close job attempt", "error", err, "job_id", job.ID)
+	}
+}
+
+// writeNotRunning answers a heartbeat or result of an executor for a job
+// that isn't running on it, telling a cancelled job apart so the executor
+// knows why
+func (s *Server) writeNotRunning(w http.ResponseWriter, r *http.Request, jobID uuid.UUID) {
+	job, err := s.queries.GetJob(r.Context(), jobID)
+	if err == nil && job.Status == string(models.StatusCancelled) {
+		s.writeError(w, http.StatusConflict, "Job was cancelled", map[string]interface{}{
+			"job_id": jobID,
+			"status": job.Status,
+		})
+		return
+	}
+	s.writeError(w, http.StatusConflict, "Job is not running on this executor", map[string]interface{}{"job_id": jobID})
+}
+
+// handleUpdateJob changes the priority of a pending job
+func (s *Server) handleUpdateJob(w http.ResponseWriter, r *http.Request, jobID uuid.UUID) {
+	var req models.UpdateJobRequest
+	if !s.decodeBody(w, r, &req) {
+		return
+	}
+
+	if !req.Priority.IsValid() {
+		s.writeError(w, http.StatusBadRequest, "Invalid priority", map[string]interface{}{
+			"priority": req.Priority,
+			"allowed":  models.Priorities,
+		})
+		return
+	}
+
+	job, err := s.queries.UpdateJobPriority(r.Context(), db.UpdateJobPriorityParams{
+		ID:       jobID,
+		Priority: string(req.Priority),
+	})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.ErrorContext(r.Context(), "Failed to update job priority", "error", err, "job_id", jobID)
+			s.writeError(w, http.StatusInternalServerError, "Failed to update job", nil)
+			return
+		}
+
+		// Distinguish a missing job from one that already left pending
+		current, err := s.queries.GetJob(r.Context(), jobID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				s.writeError(w, http.StatusNotFound, "Job not found", map[string]interface{}{"job_id": jobID})
+			} else {
+				slog.ErrorContext(r.Context(), "Failed to get job", "error", err, "job_id", jobID)
+				s.writeError(w, http.StatusInternalServerError, "Failed to update job", nil)
 			}
+			return
 		}
-		// We'll need to extend the response to include attempts
-		// For now, return the job without attempts
+		s.writeError(w, http.StatusConflict, "Only pending jobs can be updated", map[string]interface{}{
+			"job_id": jobID,
+			"status": current.Status,
+		})
+		return
 	}
 
+	slog.InfoContext(r.Context(), "Job priority updated", "job_id", jobID, "priority", job.Priority)
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(s.dbJobToModel(job))
 }
 
-func (s *Server) handleCancelJob(w http.ResponseWriter, r *http.Request, jobID uuid.UUID) {
-	_, err := s.queries.CancelJob(r.Context(), jobID)
+// handleRequeueJob clones a terminal job into a new pending job
+func (s *Server) handleRequeueJob(w http.ResponseWriter, r *http.Request, jobID uuid.UUID) {
+	job, err := s.queries.RequeueJob(r.Context(), jobID)
 	if err != nil {
-		slog.Error("Failed to cancel job", "error", err, "job_id", jobID)
-		s.writeError(w, http.StatusInternalServerError, "Failed to cancel job", nil)
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.ErrorContext(r.Context(), "Failed to requeue job", "error", err, "job_id", jobID)
+			s.writeError(w, http.StatusInternalServerError, "Failed to requeue job", nil)
+			return
+		}
+
+		// Distinguish a missing job from one that is not yet terminal
+		original, err := s.queries.GetJob(r.Context(), jobID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				s.writeError(w, http.StatusNotFound, "Job not found", map[string]interface{}{"job_id": jobID})
+			} else {
+				slog.ErrorContext(r.Context(), "Failed to get job", "error", err, "job_id", jobID)
+				s.writeError(w, http.StatusInternalServerError, "Failed to requeue job", nil)
+			}
+			return
+		}
+		s.writeError(w, http.StatusConflict, "Only completed, failed, cancelled or dead-lettered jobs can be requeued", map[string]interface{}{
+			"job_id": jobID,
+			"status": original.Status,
+		})
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	metrics.JobsSubmitted.WithLabelValues(job.Type, job.Priority).Inc()
+
+	slog.InfoContext(r.Context(), "Job requeued", "job_id", jobID, "new_job_id", job.ID)
+
+	response := s.dbJobToModel(job)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
 }
 
 func (s *Server) handleClaimJob(w http.ResponseWriter, r *http.Request) {
@@ -467,8 +1182,7 @@ func (s *Server) handleClaimJob(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var claim models.ClaimRequest
-	if err := json.NewDecoder(r.Body).Decode(&claim); err != nil {
-		s.writeError(w, http.StatusBadRequest, "Invalid request body", nil)
+	if !s.decodeBody(w, r, &claim) {
 		return
 	}
 
@@ -477,18 +1191,55 @@ func (s *Server) handleClaimJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	executorID := pgtype.Text{String: claim.ExecutorID, Valid: true}
-	job, err := s.queries.ClaimNextJob(r.Context(), executorID)
+	wait, err := claimWait(r)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		s.writeError(w, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	deadline := time.Now().Add(wait)
+
+	// Without a job to claim, wait for one until the deadline
+	var job db.Job
+	for {
+		woken := s.jobsAvailable.wait()
+
+		job, err = s.claimNextJob(r.Context(), db.ClaimNextJobParams{
+			ExecutorID:   pgtype.Text{String: claim.ExecutorID, Valid: true},
+			Capabilities: normalizeCapabilities(claim.Capabilities),
+		})
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.ErrorContext(r.Context(), "Failed to claim job", "error", err)
+			s.writeError(w, http.StatusInternalServerError, "Failed to claim job", nil)
+			return
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
 			w.WriteHeader(http.StatusNoContent)
 			return
 		}
-		slog.Error("Failed to claim job", "error", err)
-		s.writeError(w, http.StatusInternalServerError, "Failed to claim job", nil)
-		return
+
+		recheck := time.NewTimer(min(remaining, claimRecheckInterval))
+		select {
+		case <-r.Context().Done():
+			recheck.Stop()
+			return
+		case <-s.stopping:
+			// Executors retry against the next server
+			recheck.Stop()
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case <-woken:
+			recheck.Stop()
+		case <-recheck.C:
+		}
 	}
 
+	setSpanJob(r.Context(), job.ID)
+
 	// Record job attempt
 	_, err = s.queries.RecordJobAttempt(r.Context(), db.RecordJobAttemptParams{
 		JobID:      job.ID,
@@ -496,19 +1247,24 @@ func (s *Server) handleClaimJob(w http.ResponseWriter, r *http.Request) {
 		ExecutorIp: claim.ExecutorIP,
 	})
 	if err != nil {
-		slog.Error("Failed to record job attempt", "error", err, "job_id", job.ID)
+		slog.ErrorContext(r.Context(), "Failed to record job attempt", "error", err, "job_id", job.ID)
 		// Don't fail the claim, just log the error
 	}
 
-	response := s.dbJobToModel(job)
+	metrics.ExecutorJobsClaimed.WithLabelValues(claim.ExecutorID).Inc()
+	if job.StartedAt.Valid {
+		metrics.JobWaitTime.WithLabelValues(job.Type, job.Priority).Observe(job.StartedAt.Time.Sub(job.CreatedAt.Time).Seconds())
+	}
+
+	// The executor needs the raw env variables to run the job
+	response := s.dbJobToUnredactedModel(job)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
 func (s *Server) handleHeartbeat(w http.ResponseWriter, r *http.Request, jobID uuid.UUID) {
 	var req models.HeartbeatRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.writeError(w, http.StatusBadRequest, "Invalid request body", nil)
+	if !s.decodeBody(w, r, &req) {
 		return
 	}
 
@@ -518,23 +1274,65 @@ func (s *Server) handleHeartbeat(w http.ResponseWriter, r *http.Request, jobID u
 	}
 
 	executorID := pgtype.Text{String: req.ExecutorID, Valid: true}
-	err := s.queries.UpdateHeartbeat(r.Context(), db.UpdateHeartbeatParams{
+	rows, err := s.queries.UpdateHeartbeat(r.Context(), db.UpdateHeartbeatParams{
 		ID:         jobID,
 		ExecutorID: executorID,
 	})
 	if err != nil {
-		slog.Error("Failed to update heartbeat", "error", err, "job_id", jobID)
+		slog.ErrorContext(r.Context(), "Failed to update heartbeat", "error", err, "job_id", jobID)
 		s.writeError(w, http.StatusInternalServerError, "Failed to update heartbeat", nil)
 		return
 	}
 
+	if req.CPUPercent != nil || req.MemBytes != nil {
+		s.recordExecutorUsage(r.Context(), &req)
+	}
+
+	if rows == 0 {
+		// Tell the executor to stop a job that was cancelled while running
+		job, err := s.queries.GetJob(r.Context(), jobID)
+		if err == nil && job.Status == string(models.StatusCancelled) && job.ExecutorID.String == req.ExecutorID {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(models.HeartbeatResponse{CancelRequested: true})
+			return
+		}
+		s.writeError(w, http.StatusConflict, "Job is not running on this executor", map[string]interface{}{"job_id": jobID})
+		return
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// recordExecutorUsage stores the resource usage sent with a heartbeat. Failures
+// are logged but don't fail the heartbeat.
+func (s *Server) recordExecutorUsage(ctx context.Context, req *models.HeartbeatRequest) {
+	params := db.RecordExecutorUsageParams{ID: req.ExecutorID}
+	if req.CPUPercent != nil {
+		params.CpuPercent = pgtype.Float8{Float64: *req.CPUPercent, Valid: true}
+		metrics.ExecutorCPUPercent.WithLabelValues(req.ExecutorID).Set(*req.CPUPercent)
+	}
+	if req.MemBytes != nil {
+		params.MemBytes = pgtype.Int8{Int64: *req.MemBytes, Valid: true}
+		metrics.ExecutorMemBytes.WithLabelValues(req.ExecutorID).Set(float64(*req.MemBytes))
+	}
+
+	if err := s.queries.RecordExecutorUsage(ctx, params); err != nil {
+		slog.ErrorContext(ctx, "Failed to record executor usage", "error", err, "executor_id", req.ExecutorID)
+	}
+}
+
+// resultJSON turns the structured result of a job into the column value,
+// NULL when the job reported none
+func resultJSON(result json.RawMessage) []byte {
+	if len(result) == 0 || string(result) == "null" {
+		return nil
+	}
+	return result
+}
+
 func (s *Server) handleCompleteJob(w http.ResponseWriter, r *http.Request, jobID uuid.UUID) {
 	var req models.CompleteRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.writeError(w, http.StatusBadRequest, "Invalid request body", nil)
+	if !s.decodeUpload(w, r, &req) {
 		return
 	}
 
@@ -543,25 +1341,46 @@ func (s *Server) handleCompleteJob(w http.ResponseWriter, r *http.Request, jobID
 		return
 	}
 
-	_, err := s.queries.CompleteJob(r.Context(), db.CompleteJobParams{
+	job, err := s.queries.CompleteJob(r.Context(), db.CompleteJobParams{
 		ID:         jobID,
 		Stdout:     pgtype.Text{String: req.Stdout, Valid: true},
 		Stderr:     pgtype.Text{String: req.Stderr, Valid: true},
 		ExitCode:   pgtype.Int4{Int32: int32(req.ExitCode), Valid: true},
+		StdoutUrl:  pgtype.Text{String: req.StdoutURL, Valid: req.StdoutURL != ""},
+		StderrUrl:  pgtype.Text{String: req.StderrURL, Valid: req.StderrURL != ""},
+		ExecutorID: pgtype.Text{String: req.ExecutorID, Valid: true},
+		ResultJson: resultJSON(req.ResultJSON),
 	})
 	if err != nil {
-		slog.Error("Failed to complete job", "error", err, "job_id", jobID)
+		if errors.Is(err, sql.ErrNoRows) {
+			s.writeNotRunning(w, r, jobID)
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to complete job", "error", err, "job_id", jobID)
 		s.writeError(w, http.StatusInternalServerError, "Failed to complete job", nil)
 		return
 	}
 
+	err = s.queries.CompleteJobAttempt(r.Context(), db.CompleteJobAttemptParams{
+		JobID:      jobID,
+		ExecutorID: req.ExecutorID,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to close job attempt", "error", err, "job_id", jobID)
+	}
+
+	s.recordArtifacts(r, jobID, req.Artifacts)
+
+	metrics.JobsCompleted.WithLabelValues(job.Type, job.Priority, req.ExecutorID).Inc()
+	observeJobDuration(job)
+	s.recordJobTypeDuration(r.Context(), job)
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
 func (s *Server) handleFailJob(w http.ResponseWriter, r *http.Request, jobID uuid.UUID) {
 	var req models.FailRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.writeError(w, http.StatusBadRequest, "Invalid request body", nil)
+	if !s.decodeUpload(w, r, &req) {
 		return
 	}
 
@@ -581,22 +1400,89 @@ func (s *Server) handleFailJob(w http.ResponseWriter, r *http.Request, jobID uui
 	if req.ExitCode != 0 {
 		exitCode = pgtype.Int4{Int32: int32(req.ExitCode), Valid: true}
 	}
-	_, err := s.queries.FailJob(r.Context(), db.FailJobParams{
+	job, err := s.queries.FailJob(r.Context(), db.FailJobParams{
 		ID:           jobID,
 		ErrorMessage: pgtype.Text{String: req.ErrorMessage, Valid: true},
 		Stdout:       stdout,
 		Stderr:       stderr,
 		ExitCode:     exitCode,
+		StdoutUrl:    pgtype.Text{String: req.StdoutURL, Valid: req.StdoutURL != ""},
+		StderrUrl:    pgtype.Text{String: req.StderrURL, Valid: req.StderrURL != ""},
+		ExecutorID:   pgtype.Text{String: req.ExecutorID, Valid: true},
+		ResultJson:   resultJSON(req.ResultJSON),
 	})
 	if err != nil {
-		slog.Error("Failed to fail job", "error", err, "job_id", jobID)
+		if errors.Is(err, sql.ErrNoRows) {
+			s.writeNotRunning(w, r, jobID)
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to fail job", "error", err, "job_id", jobID)
 		s.writeError(w, http.StatusInternalServerError, "Failed to mark job as failed", nil)
 		return
 	}
 
+	err = s.queries.FailJobAttempt(r.Context(), db.FailJobAttemptParams{
+		JobID:        jobID,
+		ExecutorID:   req.ExecutorID,
+		ErrorMessage: pgtype.Text{String: req.ErrorMessage, Valid: true},
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to close job attempt", "error", err, "job_id", jobID)
+	}
+
+	s.recordArtifacts(r, jobID, req.Artifacts)
+
+	metrics.JobsFailed.WithLabelValues(job.Type, job.Priority, req.ExecutorID).Inc()
+	observeJobDuration(job)
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// handleCancelledJob stores the output of a job that its executor stopped
+// after it was cancelled while running. The job stays cancelled.
+func (s *Server) handleCancelledJob(w http.ResponseWriter, r *http.Request, jobID uuid.UUID) {
+	var req models.CancelledRequest
+	if !s.decodeUpload(w, r, &req) {
+		return
+	}
+
+	if req.ExecutorID == "" {
+		s.writeError(w, http.StatusBadRequest, "executor_id is required", nil)
+		return
+	}
+
+	rows, err := s.queries.RecordCancelledJobResult(r.Context(), db.RecordCancelledJobResultParams{
+		ID:         jobID,
+		Stdout:     pgtype.Text{String: req.Stdout, Valid: true},
+		Stderr:     pgtype.Text{String: req.Stderr, Valid: true},
+		ExitCode:   pgtype.Int4{Int32: int32(req.ExitCode), Valid: true},
+		StdoutUrl:  pgtype.Text{String: req.StdoutURL, Valid: req.StdoutURL != ""},
+		StderrUrl:  pgtype.Text{String: req.StderrURL, Valid: req.StderrURL != ""},
+		ExecutorID: pgtype.Text{String: req.ExecutorID, Valid: true},
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to record cancelled job result", "error", err, "job_id", jobID)
+		s.writeError(w, http.StatusInternalServerError, "Failed to record cancelled job result", nil)
+		return
+	}
+
+	if rows == 0 {
+		s.writeError(w, http.StatusConflict, "Job was not cancelled on this executor", map[string]interface{}{"job_id": jobID})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// observeJobDuration records how long a finished job ran
+func observeJobDuration(job db.Job) {
+	if !job.StartedAt.Valid || !job.CompletedAt.Valid {
+		return
+	}
+	duration := job.CompletedAt.Time.Sub(job.StartedAt.Time)
+	metrics.JobDuration.WithLabelValues(job.Type, job.Priority, job.Status).Observe(duration.Seconds())
+}
+
 func (s *Server) startWorkers(ctx context.Context) {
 	// Heartbeat monitor
 	s.wg.Add(1)
@@ -605,23 +1491,122 @@ func (s *Server) startWorkers(ctx context.Context) {
 		s.heartbeatMonitor(ctx)
 	}()
 
-	// Job cleaner
-	s.wg.Add(1)
-	go func() {
-		defer s.wg.Done()
-		s.jobCleaner(ctx)
-	}()
-	
-	// Job retry worker
-	s.wg.Add(1)
-	go func() {
-		defer s.wg.Done()
-		s.jobRetryWorker(ctx)
-	}()
+	// Job cleaner
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.jobCleaner(ctx)
+	}()
+	
+	// Recurring job scheduler
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.scheduleWorker(ctx)
+	}()
+	
+	// Job retry worker
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.jobRetryWorker(ctx)
+	}()
+	
+	// Job notifications for long-polling claims and event streams
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.jobListener(ctx)
+	}()
+	
+	// Database connection pool metrics
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.poolStatsWorker(ctx)
+	}()
+
+	// Queue age metrics
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.queueAgeWorker(ctx)
+	}()
+}
+
+// poolStatsWorker reports how many database connections are in use, which
+// shows when the pool is saturated
+func (s *Server) poolStatsWorker(ctx context.Context) {
+	ticker := time.NewTicker(poolStatsInterval)
+	defer ticker.Stop()
+
+	for {
+		metrics.DatabaseConnections.Set(float64(s.pool.Stat().AcquiredConns()))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// queueAgeWorker reports how long the oldest pending job of each priority
+// has been waiting, so a backed-up queue shows without anyone requesting the
+// statistics
+func (s *Server) queueAgeWorker(ctx context.Context) {
+	ticker := time.NewTicker(queueAgeInterval)
+	defer ticker.Stop()
+
+	for {
+		if ages, err := s.oldestPendingAges(ctx); err == nil {
+			metrics.UpdateOldestPendingAge(ages)
+		} else if ctx.Err() == nil {
+			slog.Error("Failed to get oldest pending job ages", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// oldestPendingAges returns the age in seconds of the oldest pending job of
+// every priority, 0 for priorities without one
+func (s *Server) oldestPendingAges(ctx context.Context) (map[string]float64, error) {
+	pendingAges, err := s.queries.GetOldestPendingAgeByPriority(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ages := make(map[string]float64, len(models.Priorities))
+	for _, priority := range models.Priorities {
+		ages[string(priority)] = 0
+	}
+	for _, a := range pendingAges {
+		ages[a.Priority] = a.AgeSeconds
+	}
+	return ages, nil
+}
+
+// heartbeatTimeout returns how long a running job may go without a heartbeat
+func (s *Server) heartbeatTimeout() time.Duration {
+	timeout := s.config.HeartbeatTimeout
+	if timeout <= 0 {
+		timeout = DefaultHeartbeatTimeout
+	}
+	return time.Duration(timeout) * time.Second
+}
+
+// staleCheckInterval returns how often the heartbeat monitor looks for stale
+// jobs: a third of the heartbeat timeout, between one and five seconds
+func (s *Server) staleCheckInterval() time.Duration {
+	return min(max(s.heartbeatTimeout()/3, time.Second), 5*time.Second)
 }
 
 func (s *Server) heartbeatMonitor(ctx context.Context) {
-	ticker := time.NewTicker(5 * time.Second)
+	ticker := time.NewTicker(s.staleCheckInterval())
 	defer ticker.Stop()
 
 	for {
@@ -634,19 +1619,76 @@ func (s *Server) heartbeatMonitor(ctx context.Context) {
 	}
 }
 
+// checkStaleJobs resets running jobs whose last heartbeat is older than the
+// heartbeat timeout to pending so another executor can claim them
 func (s *Server) checkStaleJobs(ctx context.Context) {
-	jobs, err := s.queries.FindStaleJobs(ctx)
+	timeout := pgtype.Interval{
+		Microseconds: s.heartbeatTimeout().Microseconds(),
+		Valid:        true,
+	}
+	jobs, err := s.queries.FindStaleJobs(ctx, timeout)
 	if err != nil {
 		slog.Error("Failed to find stale jobs", "error", err)
 		return
 	}
 
+	recovered := 0
 	for _, job := range jobs {
-		slog.Info("Resetting stale job", "job_id", job.ID)
-		if err := s.queries.ResetStaleJob(ctx, job.ID); err != nil {
+		slog.Info("Resetting stale job", "job_id", job.ID, "executor_id", job.ExecutorID.String)
+		reset, err := s.resetStaleJob(ctx, job)
+		if err != nil {
 			slog.Error("Failed to reset stale job", "error", err, "job_id", job.ID)
+			continue
+		}
+		// The job may have completed since it was found
+		if !reset {
+			continue
 		}
+		metrics.StaleJobsRecovered.Inc()
+		recovered++
+	}
+
+	if recovered > 0 {
+		slog.Warn("Reset stale jobs", "count", recovered, "found", len(jobs))
+	}
+}
+
+// resetStaleJob resets a stale job to pending and closes its attempt as
+// timed out. Both happen in one transaction, so a job is never reset while
+// its attempt stays running; a failed reset is retried on the next check.
+// It reports false when the job stopped running since it was found.
+func (s *Server) resetStaleJob(ctx context.Context, job db.Job) (bool, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	q := s.queries.WithTx(tx)
+
+	reset, err := q.ResetStaleJob(ctx, job.ID)
+	if err != nil {
+		return false, err
+	}
+	if reset == 0 {
+		return false, nil
 	}
+
+	err = q.UpdateJobAttempt(ctx, db.UpdateJobAttemptParams{
+		JobID:        job.ID,
+		Status:       "timeout",
+		ErrorMessage: pgtype.Text{String: "executor stopped sending heartbeats", Valid: true},
+		ExecutorID:   job.ExecutorID.String,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to close job attempt: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
 }
 
 func (s *Server) jobCleaner(ctx context.Context) {
@@ -659,16 +1701,28 @@ func (s *Server) jobCleaner(ctx context.Context) {
 			return
 		case <-ticker.C:
 			s.cleanupOldJobs(ctx)
+			s.cleanupStaleExecutors(ctx)
+			s.cleanupJobTypeClaims(ctx)
 		}
 	}
 }
 
+// jobRetention returns how long finished jobs are kept, and executors
+// without heartbeats remembered, as an interval. JobRetention is in seconds.
+func (s *Server) jobRetention() pgtype.Interval {
+	return pgtype.Interval{
+		Microseconds: (time.Duration(s.config.JobRetention) * time.Second).Microseconds(),
+		Valid:        true,
+	}
+}
+
 func (s *Server) cleanupOldJobs(ctx context.Context) {
-	interval := pgtype.Interval{}
-	// Convert hours to microseconds (1 hour = 3600 seconds = 3600000000 microseconds)
-	interval.Microseconds = int64(s.config.JobRetention) * 3600000000
-	interval.Valid = true
-	err := s.queries.CleanupOldJobs(ctx, interval)
+	if s.archive != nil {
+		s.archiveOldJobs(ctx)
+		return
+	}
+
+	err := s.queries.CleanupOldJobs(ctx, s.jobRetention())
 	if err != nil {
 		slog.Error("Failed to cleanup old jobs", "error", err)
 	} else {
@@ -677,8 +1731,40 @@ func (s *Server) cleanupOldJobs(ctx context.Context) {
 	}
 }
 
+// cleanupStaleExecutors forgets executors that haven't sent a heartbeat within
+// the job retention period
+func (s *Server) cleanupStaleExecutors(ctx context.Context) {
+	removed, err := s.queries.CleanupStaleExecutors(ctx, s.jobRetention())
+	if err != nil {
+		slog.Error("Failed to cleanup stale executors", "error", err)
+		return
+	}
+
+	for _, id := range removed {
+		metrics.ExecutorHeartbeats.DeleteLabelValues(id)
+		metrics.ExecutorJobsClaimed.DeleteLabelValues(id)
+		metrics.ExecutorCPUPercent.DeleteLabelValues(id)
+		metrics.ExecutorMemBytes.DeleteLabelValues(id)
+	}
+	if len(removed) > 0 {
+		slog.Debug("Cleaned up stale executors", "count", len(removed))
+	}
+}
+
+// cleanupJobTypeClaims forgets when job types were last claimed by fair
+// scheduling if that was longer ago than the job retention period
+func (s *Server) cleanupJobTypeClaims(ctx context.Context) {
+	if err := s.queries.CleanupJobTypeClaims(ctx, s.jobRetention()); err != nil {
+		slog.Error("Failed to cleanup job type claims", "error", err)
+	}
+}
+
 func (s *Server) jobRetryWorker(ctx context.Context) {
-	ticker := time.NewTicker(30 * time.Second) // Check every 30 seconds
+	interval := time.Duration(s.config.RetryInterval) * time.Second
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
@@ -687,6 +1773,7 @@ func (s *Server) jobRetryWorker(ctx context.Context) {
 			return
 		case <-ticker.C:
 			s.retryFailedJobs(ctx)
+			s.skipJobsWithUnmetDependencies(ctx)
 		}
 	}
 }
@@ -704,32 +1791,115 @@ func (s *Server) retryFailedJobs(ctx context.Context) {
 			continue
 		}
 		
+		// Record why the job is being retried
+		reason := "job failed"
+		if job.ErrorMessage.Valid && job.ErrorMessage.String != "" {
+			reason = job.ErrorMessage.String
+		}
+		err := s.queries.RecordJobRetry(ctx, db.RecordJobRetryParams{
+			JobID:        job.ID,
+			ExecutorID:   job.ExecutorID.String,
+			ErrorMessage: pgtype.Text{String: fmt.Sprintf("retry %d/%d: %s", job.RetryCount+1, job.MaxRetries, reason), Valid: true},
+		})
+		if err != nil {
+			slog.Error("Failed to record job retry", "job_id", job.ID, "error", err)
+		}
+		
 		slog.Info("Retrying failed job", 
 			"job_id", job.ID, 
 			"type", job.Type,
 			"retry_count", job.RetryCount+1,
 			"max_retries", job.MaxRetries)
 	}
+
+	// Jobs that used up their retries move to the dead letter status
+	exhausted, err := s.queries.MoveExhaustedJobsToDeadLetter(ctx)
+	if err != nil {
+		slog.Error("Failed to move jobs with exhausted retries to dead letter", "error", err)
+		return
+	}
+
+	for _, job := range exhausted {
+		metrics.JobsDeadLettered.WithLabelValues(job.Type).Inc()
+		slog.Warn("Job retries exhausted, moved to dead letter",
+			"job_id", job.ID,
+			"type", job.Type,
+			"retry_count", job.RetryCount,
+			"max_retries", job.MaxRetries)
+	}
+}
+
+// withoutOutput drops a job's stdout and stderr from a response, for
+// clients that only need its metadata. Output URLs and the exit code stay.
+func withoutOutput(job *models.Job) {
+	job.Stdout = ""
+	job.Stderr = ""
+}
+
+// dbAttemptsToModels converts job attempts for responses and the archive
+func dbAttemptsToModels(attempts []db.JobAttempt) []models.JobAttempt {
+	attemptModels := make([]models.JobAttempt, len(attempts))
+	for i, attempt := range attempts {
+		attemptModels[i] = models.JobAttempt{
+			ID:         attempt.ID,
+			JobID:      attempt.JobID,
+			ExecutorID: attempt.ExecutorID,
+			ExecutorIP: attempt.ExecutorIp,
+			StartedAt:  attempt.StartedAt.Time,
+			Status:     attempt.Status,
+		}
+		if attempt.EndedAt.Valid {
+			attemptModels[i].EndedAt = &attempt.EndedAt.Time
+		}
+		if attempt.ErrorMessage.Valid {
+			attemptModels[i].ErrorMessage = attempt.ErrorMessage.String
+		}
+	}
+	return attemptModels
 }
 
+// dbJobToModel converts a job for API responses, with sensitive env
+// variables redacted
 func (s *Server) dbJobToModel(job db.Job) models.Job {
+	model := s.dbJobToUnredactedModel(job)
+	model.EnvVariables = s.redactEnv(model.EnvVariables)
+	return model
+}
+
+// dbJobToUnredactedModel converts a job including the values of sensitive
+// env variables, for executors
+func (s *Server) dbJobToUnredactedModel(job db.Job) models.Job {
 	var envVars map[string]string
 	if job.EnvVariables != nil {
 		json.Unmarshal(job.EnvVariables, &envVars)
 	}
 
-	model := models.Job{
-		ID:            job.ID,
-		Type:          job.Type,
-		BinaryURL:     job.BinaryUrl,
-		BinarySHA256:  job.BinarySha256,
-		Arguments:     job.Arguments,
-		EnvVariables:  envVars,
-		Priority:      models.Priority(job.Priority),
-		Status:        models.Status(job.Status),
-		CreatedAt:     job.CreatedAt.Time,
+	var labels map[string]string
+	if job.Labels != nil {
+		json.Unmarshal(job.Labels, &labels)
 	}
 
+	model := models.Job{
+		ID:                   job.ID,
+		Type:                 job.Type,
+		BinaryURL:            job.BinaryUrl,
+		BinarySHA256:         job.BinarySha256,
+		Arguments:            job.Arguments,
+		EnvVariables:         envVars,
+		Priority:             models.Priority(job.Priority),
+		Status:               models.Status(job.Status),
+		CreatedAt:            job.CreatedAt.Time,
+		MaxRetries:           int(job.MaxRetries),
+		RetryCount:           int(job.RetryCount),
+		RetryBackoffBase:     int(job.RetryBackoffBase),
+		Timeout:              int(job.TimeoutSeconds),
+		MaxOutputBytes:       int(job.MaxOutputBytes),
+		RequiredCapabilities: job.RequiredCapabilities,
+	}
+
+	if len(labels) > 0 {
+		model.Labels = labels
+	}
 	if job.ExecutorID.Valid {
 		model.ExecutorID = job.ExecutorID.String
 	}
@@ -739,6 +1909,18 @@ func (s *Server) dbJobToModel(job db.Job) models.Job {
 	if job.Stderr.Valid {
 		model.Stderr = job.Stderr.String
 	}
+	if job.StdoutUrl.Valid {
+		model.StdoutURL = job.StdoutUrl.String
+	}
+	if job.StderrUrl.Valid {
+		model.StderrURL = job.StderrUrl.String
+	}
+	if job.SignatureUrl.Valid {
+		model.SignatureURL = job.SignatureUrl.String
+	}
+	if job.PublicKey.Valid {
+		model.PublicKey = job.PublicKey.String
+	}
 	if job.ExitCode.Valid {
 		exitCode := int(job.ExitCode.Int32)
 		model.ExitCode = &exitCode
@@ -755,10 +1937,148 @@ func (s *Server) dbJobToModel(job db.Job) models.Job {
 	if job.LastHeartbeat.Valid {
 		model.LastHeartbeat = &job.LastHeartbeat.Time
 	}
+	if job.NextRetryAt.Valid {
+		model.NextRetryAt = &job.NextRetryAt.Time
+	}
+	if job.ScheduledAt.Valid {
+		model.ScheduledAt = &job.ScheduledAt.Time
+	}
+	if job.IdempotencyKey.Valid {
+		model.IdempotencyKey = job.IdempotencyKey.String
+	}
+	if len(job.Stdin) > 0 {
+		model.Stdin = job.Stdin
+	}
+	if len(job.InputFiles) > 0 {
+		json.Unmarshal(job.InputFiles, &model.InputFiles)
+	}
+	if len(job.OutputGlobs) > 0 {
+		model.OutputGlobs = job.OutputGlobs
+	}
+	model.WorkdirQuotaBytes = job.WorkdirQuotaBytes
+	model.CPUMillicores = int(job.CpuMillicores)
+	model.MemLimitBytes = job.MemLimitBytes
+	model.TraceParent = job.TraceParent.String
+	model.CancellationReason = job.CancellationReason.String
+	if len(job.ResultJson) > 0 {
+		model.ResultJSON = job.ResultJson
+	}
 
 	return model
 }
 
+// createJobParams builds the insert parameters for a validated submission.
+// The job records the trace context of ctx so its execution joins the trace.
+func (s *Server) createJobParams(ctx context.Context, submission *models.JobSubmission) db.CreateJobParams {
+	envJSON, _ := json.Marshal(submission.EnvVariables)
+	traceParent := tracing.TraceParent(ctx)
+
+	return db.CreateJobParams{
+		Type:                 submission.Type,
+		BinaryUrl:            submission.BinaryURL,
+		BinarySha256:         submission.BinarySHA256,
+		Arguments:            submission.Arguments,
+		EnvVariables:         envJSON,
+		Priority:             string(submission.Priority),
+		MaxRetries:           int32(submission.MaxRetries),
+		RetryBackoffBase:     retryBackoffBase(submission.RetryBackoffBase),
+		TimeoutSeconds:       int32(submission.Timeout),
+		MaxOutputBytes:       s.clampMaxOutputBytes(submission.MaxOutputBytes),
+		SignatureUrl:         pgtype.Text{String: submission.SignatureURL, Valid: submission.SignatureURL != ""},
+		PublicKey:            pgtype.Text{String: submission.PublicKey, Valid: submission.PublicKey != ""},
+		RequiredCapabilities: normalizeCapabilities(submission.RequiredCapabilities),
+		ScheduledAt:          scheduledAt(submission.ScheduledAt),
+		Labels:               labelsJSON(submission.Labels),
+		Stdin:                submission.Stdin,
+		InputFiles:           inputFilesJSON(submission.InputFiles),
+		OutputGlobs:          outputGlobs(submission.OutputGlobs),
+		WorkdirQuotaBytes:    submission.WorkdirQuotaBytes,
+		CpuMillicores:        int32(submission.CPUMillicores),
+		MemLimitBytes:        submission.MemLimitBytes,
+		TraceParent:          pgtype.Text{String: traceParent, Valid: traceParent != ""},
+	}
+}
+
+// maxOutputBytesLimit returns the configured ceiling for per-job output,
+// which also bounds the size of a job's stdin
+func (s *Server) maxOutputBytesLimit() int {
+	if s.config.MaxOutputBytesLimit <= 0 {
+		return DefaultMaxOutputBytesLimit
+	}
+	return s.config.MaxOutputBytesLimit
+}
+
+// clampMaxOutputBytes limits a requested per-job output size to the
+// configured ceiling
+func (s *Server) clampMaxOutputBytes(requested int) int32 {
+	limit := s.maxOutputBytesLimit()
+	if requested > limit {
+		return int32(limit)
+	}
+	return int32(requested)
+}
+
+// retryBackoffBase returns the backoff base to store for a submission,
+// applying DefaultRetryBackoffBase when none was requested
+func retryBackoffBase(requested int) int32 {
+	if requested == 0 {
+		return DefaultRetryBackoffBase
+	}
+	return int32(requested)
+}
+
+// labelsJSON encodes job labels for storage; jobs without labels get an
+// empty object so that containment filters work
+func labelsJSON(labels map[string]string) []byte {
+	if len(labels) == 0 {
+		return []byte("{}")
+	}
+	data, _ := json.Marshal(labels)
+	return data
+}
+
+// parseLabelFilters parses repeated key=value label filters
+func parseLabelFilters(filters []string) (map[string]string, error) {
+	if len(filters) == 0 {
+		return nil, nil
+	}
+
+	labels := make(map[string]string, len(filters))
+	for _, filter := range filters {
+		key, value, ok := strings.Cut(filter, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid label filter %q, expected key=value", filter)
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
+
+// scheduledAt converts an optional submission run-at time for storage
+func scheduledAt(t *time.Time) pgtype.Timestamptz {
+	if t == nil {
+		return pgtype.Timestamptz{}
+	}
+	return pgtype.Timestamptz{Time: *t, Valid: true}
+}
+
+// normalizeCapabilities trims, deduplicates and sorts a capability list.
+// The result is never nil so it can be stored in a NOT NULL array column.
+func normalizeCapabilities(capabilities []string) []string {
+	seen := make(map[string]bool, len(capabilities))
+	result := make([]string, 0, len(capabilities))
+	for _, capability := range capabilities {
+		capability = strings.TrimSpace(capability)
+		if capability == "" || seen[capability] {
+			continue
+		}
+		seen[capability] = true
+		result = append(result, capability)
+	}
+	sort.Strings(result)
+	return result
+}
+
 func (s *Server) writeError(w http.ResponseWriter, code int, message string, context map[string]interface{}) {
 	response := map[string]interface{}{
 		"error": message,
@@ -798,7 +2118,7 @@ func (s *Server) handleAdminStats(w http.ResponseWriter, r *http.Request) {
 	// Count jobs by status
 	statusCounts, err := s.queries.CountJobsByStatus(ctx)
 	if err != nil {
-		slog.Error("Failed to get job status counts", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to get job status counts", "error", err)
 		s.writeError(w, http.StatusInternalServerError, "Failed to get statistics", nil)
 		return
 	}
@@ -806,22 +2126,52 @@ func (s *Server) handleAdminStats(w http.ResponseWriter, r *http.Request) {
 	// Count pending jobs by priority  
 	priorityCounts, err := s.queries.CountPendingJobsByPriority(ctx)
 	if err != nil {
-		slog.Error("Failed to get priority counts", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to get priority counts", "error", err)
+		s.writeError(w, http.StatusInternalServerError, "Failed to get statistics", nil)
+		return
+	}
+	
+	// Age of the oldest pending job by priority, 0 when none is waiting
+	oldestPendingAge, err := s.oldestPendingAges(ctx)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get oldest pending job ages", "error", err)
 		s.writeError(w, http.StatusInternalServerError, "Failed to get statistics", nil)
 		return
 	}
 	
+	// Optionally count jobs per value of a label, by status
+	if labelKey := r.URL.Query().Get("group_by_label"); labelKey != "" {
+		labelCounts, err := s.queries.CountJobsByLabel(ctx, labelKey)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to get label counts", "error", err, "label", labelKey)
+			s.writeError(w, http.StatusInternalServerError, "Failed to get statistics", nil)
+			return
+		}
+		
+		byLabel := make(map[string]map[string]int64)
+		for _, c := range labelCounts {
+			if byLabel[c.Value] == nil {
+				byLabel[c.Value] = make(map[string]int64)
+			}
+			byLabel[c.Value][c.Status] = c.Count
+		}
+		stats["group_by_label"] = labelKey
+		stats["jobs_by_label"] = byLabel
+	}
+	
 	// Get active executors count
 	executors, err := s.queries.GetActiveExecutors(ctx)
 	if err != nil {
-		slog.Error("Failed to get active executors", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to get active executors", "error", err)
 		executors = []db.GetActiveExecutorsRow{}
 	}
 	
 	// Build response
 	stats["jobs_by_status"] = statusCounts
 	stats["pending_by_priority"] = priorityCounts
+	stats["oldest_pending_age_seconds"] = oldestPendingAge
 	stats["active_executors"] = len(executors)
+	metrics.ExecutorsActive.Set(float64(len(executors)))
 	stats["timestamp"] = time.Now().UTC()
 	
 	// Update Prometheus metrics
@@ -836,6 +2186,8 @@ func (s *Server) handleAdminStats(w http.ResponseWriter, r *http.Request) {
 		"completed": make(map[string]int),
 		"failed": make(map[string]int),
 		"cancelled": make(map[string]int),
+		"dead_letter": make(map[string]int),
+		"skipped": make(map[string]int),
 	}
 	
 	for _, s := range statusCounts {
@@ -847,6 +2199,10 @@ func (s *Server) handleAdminStats(w http.ResponseWriter, r *http.Request) {
 			statusMaps["failed"]["all"] = int(s.Count)
 		} else if s.Status == "cancelled" {
 			statusMaps["cancelled"]["all"] = int(s.Count)
+		} else if s.Status == "dead_letter" {
+			statusMaps["dead_letter"]["all"] = int(s.Count)
+		} else if s.Status == "skipped" {
+			statusMaps["skipped"]["all"] = int(s.Count)
 		}
 	}
 	
@@ -856,6 +2212,8 @@ func (s *Server) handleAdminStats(w http.ResponseWriter, r *http.Request) {
 		statusMaps["completed"],
 		statusMaps["failed"],
 		statusMaps["cancelled"],
+		statusMaps["dead_letter"],
+		statusMaps["skipped"],
 	)
 	
 	w.Header().Set("Content-Type", "application/json")
@@ -873,35 +2231,36 @@ func (s *Server) handleAdminExecutors(w http.ResponseWriter, r *http.Request) {
 	// Get active executors (those with recent heartbeats)
 	executors, err := s.queries.GetActiveExecutors(ctx)
 	if err != nil {
-		slog.Error("Failed to get active executors", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to get active executors", "error", err)
 		s.writeError(w, http.StatusInternalServerError, "Failed to get executors", nil)
 		return
 	}
 	
 	// Format response
 	type executorInfo struct {
-		ExecutorID    string    `json:"executor_id"`
-		CurrentJobID  *string   `json:"current_job_id,omitempty"`
-		JobType       *string   `json:"job_type,omitempty"`
-		LastHeartbeat time.Time `json:"last_heartbeat"`
-		JobsCompleted int64     `json:"jobs_completed"`
+		models.Executor
+		RunningJobIDs []string `json:"running_job_ids"`
+		JobsCompleted int64    `json:"jobs_completed"`
 	}
 	
-	var response []executorInfo
+	response := make([]executorInfo, 0, len(executors))
 	for _, e := range executors {
 		info := executorInfo{
-			ExecutorID:    e.ExecutorID.String,
-			LastHeartbeat: e.LastHeartbeat.Time,
+			Executor: *dbExecutorToModel(db.Executor{
+				ID:            e.ExecutorID,
+				Name:          e.Name,
+				Capabilities:  e.Capabilities,
+				MaxJobs:       e.MaxJobs,
+				CpuPercent:    e.CpuPercent,
+				MemBytes:      e.MemBytes,
+				RegisteredAt:  e.RegisteredAt,
+				LastHeartbeat: e.LastHeartbeat,
+				AdvertiseAddr: e.AdvertiseAddr,
+			}),
+			RunningJobIDs: e.RunningJobIds,
 			JobsCompleted: e.JobsCompleted,
 		}
 		
-		// JobID is a UUID, not a nullable field
-		jobID := e.JobID.String()
-		info.CurrentJobID = &jobID
-		
-		// JobType is a string, not a nullable field  
-		info.JobType = &e.JobType
-		
 		response = append(response, info)
 	}
 	
@@ -919,8 +2278,7 @@ func (s *Server) handleBulkJobs(w http.ResponseWriter, r *http.Request) {
 
 	// Parse bulk submission request
 	var submissions []models.JobSubmission
-	if err := json.NewDecoder(r.Body).Decode(&submissions); err != nil {
-		s.writeError(w, http.StatusBadRequest, "Invalid request body", nil)
+	if !s.decodeUpload(w, r, &submissions) {
 		return
 	}
 
@@ -957,19 +2315,134 @@ func (s *Server) handleBulkJobs(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
+		if len(submission.Type) > MaxJobTypeLength {
+			results[i] = jobResult{
+				Index:   i,
+				Success: false,
+				Error:   fmt.Sprintf("type must be at most %d bytes", MaxJobTypeLength),
+			}
+			continue
+		}
+
+		if submission.Timeout < 0 {
+			results[i] = jobResult{
+				Index:   i,
+				Success: false,
+				Error:   "timeout must not be negative",
+			}
+			continue
+		}
+
+		if submission.MaxOutputBytes < 0 {
+			results[i] = jobResult{
+				Index:   i,
+				Success: false,
+				Error:   "max_output_bytes must not be negative",
+			}
+			continue
+		}
+
+		if submission.WorkdirQuotaBytes < 0 {
+			results[i] = jobResult{
+				Index:   i,
+				Success: false,
+				Error:   "workdir_quota_bytes must not be negative",
+			}
+			continue
+		}
+
+		if submission.CPUMillicores < 0 || submission.MemLimitBytes < 0 {
+			results[i] = jobResult{
+				Index:   i,
+				Success: false,
+				Error:   "cpu_millicores and mem_limit_bytes must not be negative",
+			}
+			continue
+		}
+
+		if submission.MaxRetries < 0 || submission.MaxRetries > MaxRetries {
+			results[i] = jobResult{
+				Index:   i,
+				Success: false,
+				Error:   fmt.Sprintf("max_retries must be between 0 and %d", MaxRetries),
+			}
+			continue
+		}
+
+		if submission.RetryBackoffBase < 0 || submission.RetryBackoffBase > MaxRetryBackoffBase {
+			results[i] = jobResult{
+				Index:   i,
+				Success: false,
+				Error:   fmt.Sprintf("retry_backoff_base must be between 0 and %d", MaxRetryBackoffBase),
+			}
+			continue
+		}
+
+		if submission.SignatureURL != "" && submission.PublicKey == "" {
+			results[i] = jobResult{
+				Index:   i,
+				Success: false,
+				Error:   "public_key is required when signature_url is set",
+			}
+			continue
+		}
+
+		if _, ok := submission.Labels[""]; ok {
+			results[i] = jobResult{
+				Index:   i,
+				Success: false,
+				Error:   "label keys must not be empty",
+			}
+			continue
+		}
+
+		if len(submission.Stdin) > s.maxOutputBytesLimit() {
+			results[i] = jobResult{
+				Index:   i,
+				Success: false,
+				Error:   fmt.Sprintf("stdin is larger than %d bytes", s.maxOutputBytesLimit()),
+			}
+			continue
+		}
+
+		if err := validateInputFiles(submission.InputFiles, s.maxOutputBytesLimit()); err != nil {
+			results[i] = jobResult{
+				Index:   i,
+				Success: false,
+				Error:   err.Error(),
+			}
+			continue
+		}
+
+		if err := validateOutputGlobs(submission.OutputGlobs); err != nil {
+			results[i] = jobResult{
+				Index:   i,
+				Success: false,
+				Error:   err.Error(),
+			}
+			continue
+		}
+
+		if err := validateSecretRefs(submission.EnvVariables); err != nil {
+			results[i] = jobResult{
+				Index:   i,
+				Success: false,
+				Error:   err.Error(),
+			}
+			continue
+		}
+
+		if submission.CronSpec != "" {
+			results[i] = jobResult{
+				Index:   i,
+				Success: false,
+				Error:   "cron_spec is not supported in bulk submissions",
+			}
+			continue
+		}
+
 		// Create job
-		envJSON, _ := json.Marshal(submission.EnvVariables)
-		
-		job, err := s.queries.CreateJobWithRetries(r.Context(), db.CreateJobWithRetriesParams{
-			Type:         submission.Type,
-			BinaryUrl:    submission.BinaryURL,
-			BinarySha256: submission.BinarySHA256,
-			Arguments:    submission.Arguments,
-			EnvVariables: envJSON,
-			Priority:     string(submission.Priority),
-			Status:       "pending",
-			MaxRetries:   int32(submission.MaxRetries),
-		})
+		job, err := s.createJob(r.Context(), s.createJobParams(r.Context(), &submission), dedupeDependencies(submission.DependsOn))
 
 		if err != nil {
 			results[i] = jobResult{
@@ -1020,10 +2493,14 @@ func (s *Server) handleBulkCancel(w http.ResponseWriter, r *http.Request) {
 		JobIDs []string `json:"job_ids"`
 		Type   string   `json:"type,omitempty"`
 		Status string   `json:"status,omitempty"`
+		Reason string   `json:"reason,omitempty"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		s.writeError(w, http.StatusBadRequest, "Invalid request body", nil)
+	if !s.decodeBody(w, r, &request) {
+		return
+	}
+	reason, ok := s.validCancellationReason(w, request.Reason)
+	if !ok {
 		return
 	}
 
@@ -1040,19 +2517,39 @@ func (s *Server) handleBulkCancel(w http.ResponseWriter, r *http.Request) {
 				continue
 			}
 
-			_, err = s.queries.CancelJob(r.Context(), jobID)
+			job, err := s.queries.CancelJob(r.Context(), db.CancelJobParams{ID: jobID, Reason: reason})
 			if err != nil {
 				failedCount++
 			} else {
+				s.endCancelledAttempt(r.Context(), job)
 				cancelledCount++
 				metrics.JobsCancelled.Inc()
 			}
 		}
 	} else if request.Type != "" || request.Status != "" {
-		// Cancel by criteria
-		// This would need a new query to cancel jobs by type/status
-		s.writeError(w, http.StatusNotImplemented, "Cancellation by criteria not yet implemented", nil)
-		return
+		// Cancel by criteria - only pending and running jobs can be cancelled
+		if request.Status != "" && request.Status != string(models.StatusPending) && request.Status != string(models.StatusRunning) {
+			s.writeError(w, http.StatusBadRequest, "status must be pending or running", map[string]interface{}{"status": request.Status})
+			return
+		}
+
+		params := db.CancelJobsByCriteriaParams{Reason: reason}
+		if request.Type != "" {
+			params.Type = pgtype.Text{String: request.Type, Valid: true}
+		}
+		if request.Status != "" {
+			params.Status = pgtype.Text{String: request.Status, Valid: true}
+		}
+
+		count, err := s.queries.CancelJobsByCriteria(r.Context(), params)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to cancel jobs by criteria", "error", err, "type", request.Type, "status", request.Status)
+			s.writeError(w, http.StatusInternalServerError, "Failed to cancel jobs", nil)
+			return
+		}
+
+		cancelledCount = int(count)
+		metrics.JobsCancelled.Add(float64(count))
 	} else {
 		s.writeError(w, http.StatusBadRequest, "Must provide job_ids or criteria", nil)
 		return
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// startTestServer serves handler like Run does, without a database
+func startTestServer(t *testing.T, s *Server, handler http.HandlerFunc) string {
+	t.Helper()
+	s.stopping = make(chan struct{})
+	s.server = &http.Server{Handler: handler}
+	s.server.RegisterOnShutdown(func() { close(s.stopping) })
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.server.Serve(listener)
+	return "http://" + listener.Addr().String()
+}
+
+func TestShutdownEndsLongPolls(t *testing.T) {
+	s := &Server{config: &Config{ShutdownTimeout: 5}}
+	polling := make(chan struct{})
+	url := startTestServer(t, s, func(w http.ResponseWriter, r *http.Request) {
+		close(polling)
+		select {
+		case <-r.Context().Done():
+		case <-s.stopping:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	go http.Get(url)
+	<-polling
+
+	start := time.Now()
+	if err := s.shutdown(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("shutdown waited %s for a long-poll", elapsed)
+	}
+}
+
+func TestShutdownFinishesWithinTimeout(t *testing.T) {
+	s := &Server{config: &Config{ShutdownTimeout: 1}}
+	release := make(chan struct{})
+	defer close(release)
+
+	// A request and a worker that ignore the shutdown
+	started := make(chan struct{})
+	url := startTestServer(t, s, func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+	go http.Get(url)
+	<-started
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		<-release
+	}()
+
+	start := time.Now()
+	err := s.shutdown()
+	elapsed := time.Since(start)
+	if err == nil {
+		t.Fatal("expected error for the stuck request and worker")
+	}
+	if elapsed < time.Second || elapsed > 2*time.Second {
+		t.Fatalf("expected shutdown to take the 1s timeout, took %s", elapsed)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/draganm/executr/internal/metrics"
+	"github.com/draganm/executr/internal/tracing"
+	"github.com/draganm/executr/internal/utils"
+)
+
+// tracingMiddleware runs each request in a server span that continues the
+// trace of the caller's traceparent header, if any. Spans are named after
+// the method and the path with IDs replaced, e.g. "POST /api/v1/jobs/claim".
+func (s *Server) tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := tracing.Extract(r.Context(), r.Header)
+		ctx, span := s.tracer.Start(ctx, r.Method+" "+metrics.NormalizeEndpoint(r.URL.Path),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+				attribute.String("executr.request_id", utils.RequestIDFromContext(r.Context())),
+			),
+		)
+		defer span.End()
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.response.status_code", recorder.status))
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.status))
+		}
+	})
+}
+
+// setSpanJob records the job a request acted on in its span
+func setSpanJob(ctx context.Context, jobID uuid.UUID) {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("executr.job_id", jobID.String()))
+}
+
+// statusRecorder remembers the status code written through it
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher so streaming responses work through the middleware
+func (w *statusRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
\ No newline at end of file
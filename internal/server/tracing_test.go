@@ -0,0 +1,32 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/draganm/executr/internal/tracing"
+)
+
+func TestTracingMiddlewareContinuesCallerTrace(t *testing.T) {
+	s := &Server{tracer: tracing.Tracer(nil)}
+	var traceParent string
+	h := s.tracingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceParent = tracing.TraceParent(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/jobs", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	// The submitted job would record this trace for its executor
+	if traceParent != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Fatalf("expected the caller's trace context, got %q", traceParent)
+	}
+
+	traceParent = ""
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/jobs", nil))
+	if traceParent != "" {
+		t.Fatalf("expected no trace context without a traceparent header, got %q", traceParent)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/draganm/executr/internal/utils"
+	"github.com/draganm/executr/pkg/models"
+)
+
+// binaryFetchTimeout bounds how long a submission may spend downloading the
+// binary to hash or validate it
+const binaryFetchTimeout = 5 * time.Minute
+
+// handleValidateJob answers a dry-run submission. The binary is downloaded
+// and hashed the way an executor would, but no job is created.
+func (s *Server) handleValidateJob(w http.ResponseWriter, r *http.Request, submission *models.JobSubmission) {
+	ctx, cancel := context.WithTimeout(r.Context(), binaryFetchTimeout)
+	defer cancel()
+
+	result := validateBinary(ctx, s.binaryDownloader(), submission, s.config.HashBinaries)
+
+	slog.InfoContext(r.Context(), "Validated job submission", "binary_url", submission.BinaryURL, "valid", result.Valid)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// binaryDownloader returns a downloader for job binaries that authenticates
+// with the configured download credentials
+func (s *Server) binaryDownloader() *utils.BinaryDownloader {
+	d := utils.NewBinaryDownloader()
+	d.SetCredentials(s.downloadCredentials)
+	return d
+}
+
+// validateBinary checks that the submission's binary can be downloaded and
+// matches binary_sha256. A missing hash is only an error when the server
+// won't compute it on submission.
+func validateBinary(ctx context.Context, downloader *utils.BinaryDownloader, submission *models.JobSubmission, hashBinaries bool) *models.ValidationResult {
+	result := &models.ValidationResult{}
+
+	var sha string
+	var err error
+	switch {
+	case utils.IsOCIReference(submission.BinaryURL):
+		sha, err = imageBinarySHA256(ctx, submission.BinaryURL)
+	case utils.IsHTTPURL(submission.BinaryURL):
+		sha, err = downloader.CalculateSHA256FromURL(ctx, submission.BinaryURL, nil)
+	default:
+		// e.g. file:// binaries, which only exist on the executors
+		result.Errors = append(result.Errors, "binary_url can't be checked by the server, only http(s):// and oci:// binaries can")
+		return result
+	}
+	if err != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("binary is not reachable: %v", err))
+		return result
+	}
+	result.BinaryReachable = true
+	result.BinarySHA256 = sha
+
+	switch {
+	case submission.BinarySHA256 == "":
+		// Executors identify image binaries by the image digest
+		if !hashBinaries && utils.IsHTTPURL(submission.BinaryURL) {
+			result.Errors = append(result.Errors, "binary_sha256 is not set")
+		}
+	case submission.BinarySHA256 != sha:
+		result.Errors = append(result.Errors, fmt.Sprintf("SHA256 mismatch: expected %s, got %s", submission.BinarySHA256, sha))
+	default:
+		result.SHA256Matches = true
+	}
+
+	result.Valid = len(result.Errors) == 0
+	return result
+}
+
+// imageBinarySHA256 pulls the binary of an oci:// URL from its image and
+// returns its SHA256
+func imageBinarySHA256(ctx context.Context, binaryURL string) (string, error) {
+	ref, err := utils.ParseOCIReference(binaryURL)
+	if err != nil {
+		return "", err
+	}
+	hasher := sha256.New()
+	if err := utils.NewOCIPuller().Extract(ctx, ref, hasher); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// hashBinary fills in the binary_sha256 of a submission that has none when
+// server-side hashing is enabled. It writes an error response and returns
+// false when the binary can't be downloaded.
+func (s *Server) hashBinary(w http.ResponseWriter, r *http.Request, submission *models.JobSubmission) bool {
+	// Only downloads need one; executors cache image binaries by digest and
+	// read file:// binaries from their own disk
+	if submission.BinarySHA256 != "" || !s.config.HashBinaries || !utils.IsHTTPURL(submission.BinaryURL) {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), binaryFetchTimeout)
+	defer cancel()
+
+	sha, err := s.binaryDownloader().CalculateSHA256FromURL(ctx, submission.BinaryURL, nil)
+	if err != nil {
+		slog.WarnContext(r.Context(), "Failed to hash binary", "error", err, "binary_url", submission.BinaryURL)
+		s.writeError(w, http.StatusBadRequest, "Failed to download binary to calculate its SHA256", map[string]interface{}{
+			"binary_url": submission.BinaryURL,
+			"error":      err.Error(),
+		})
+		return false
+	}
+
+	slog.InfoContext(r.Context(), "Calculated binary SHA256", "binary_url", submission.BinaryURL, "sha256", sha)
+	submission.BinarySHA256 = sha
+	return true
+}
\ No newline at end of file
@@ -0,0 +1,28 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/draganm/executr/pkg/models"
+)
+
+// version returns the server's build information, "dev" when it was built
+// without version information
+func (s *Server) version() models.VersionInfo {
+	info := s.config.Version
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	return info
+}
+
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.version())
+}
\ No newline at end of file
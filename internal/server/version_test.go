@@ -0,0 +1,38 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/draganm/executr/pkg/models"
+)
+
+func TestHandleVersion(t *testing.T) {
+	tests := []struct {
+		configured models.VersionInfo
+		want       string
+	}{
+		{models.VersionInfo{}, "dev"},
+		{models.VersionInfo{Version: "v1.2.0", Commit: "3f2c9a1"}, "v1.2.0"},
+	}
+
+	for _, tt := range tests {
+		s := &Server{config: &Config{Version: tt.configured}}
+
+		rec := httptest.NewRecorder()
+		s.handleVersion(rec, httptest.NewRequest(http.MethodGet, "/api/v1/version", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got status %d", rec.Code)
+		}
+
+		var got models.VersionInfo
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if got.Version != tt.want || got.Commit != tt.configured.Commit {
+			t.Errorf("got %+v, want version %q and commit %q", got, tt.want, tt.configured.Commit)
+		}
+	}
+}
\ No newline at end of file
@@ -0,0 +1,59 @@
+// Package tracing holds the OpenTelemetry instrumentation shared by the
+// server, the executor and the client. Trace context travels in W3C
+// traceparent headers and, from submission to execution, on the job record.
+// Nothing is recorded unless a tracer provider with an exporter is
+// configured, either explicitly or through otel.SetTracerProvider.
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies executr's spans
+const instrumentationName = "github.com/draganm/executr"
+
+// traceParentHeader is the W3C header carrying the trace context
+const traceParentHeader = "traceparent"
+
+var propagator = propagation.TraceContext{}
+
+// Tracer returns executr's tracer from tp, or from the global tracer
+// provider when tp is nil
+func Tracer(tp trace.TracerProvider) trace.Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(instrumentationName)
+}
+
+// Inject adds the trace context of the span in ctx to h
+func Inject(ctx context.Context, h http.Header) {
+	propagator.Inject(ctx, propagation.HeaderCarrier(h))
+}
+
+// Extract returns ctx with the remote trace context found in h, if any
+func Extract(ctx context.Context, h http.Header) context.Context {
+	return propagator.Extract(ctx, propagation.HeaderCarrier(h))
+}
+
+// TraceParent returns the traceparent value for the span in ctx, or an empty
+// string when ctx isn't part of a sampled trace
+func TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	return carrier.Get(traceParentHeader)
+}
+
+// WithTraceParent returns ctx with the remote trace context of a
+// traceparent value. Empty or invalid values leave ctx unchanged.
+func WithTraceParent(ctx context.Context, traceParent string) context.Context {
+	if traceParent == "" {
+		return ctx
+	}
+	return propagator.Extract(ctx, propagation.MapCarrier{traceParentHeader: traceParent})
+}
\ No newline at end of file
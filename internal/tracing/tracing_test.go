@@ -0,0 +1,41 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestTraceParentRoundTrip(t *testing.T) {
+	ctx := WithTraceParent(context.Background(), testTraceParent)
+	if got := TraceParent(ctx); got != testTraceParent {
+		t.Fatalf("got traceparent %q, want %q", got, testTraceParent)
+	}
+
+	// Spans started without a recording provider keep the trace going
+	ctx, span := Tracer(nil).Start(ctx, "child")
+	defer span.End()
+
+	h := http.Header{}
+	Inject(ctx, h)
+	got := trace.SpanContextFromContext(Extract(context.Background(), h))
+	if got.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("trace was not propagated, got %s", got.TraceID())
+	}
+}
+
+func TestWithoutTraceParent(t *testing.T) {
+	for _, value := range []string{"", "not-a-traceparent"} {
+		ctx := WithTraceParent(context.Background(), value)
+		if trace.SpanContextFromContext(ctx).IsValid() {
+			t.Errorf("%q: expected no trace context", value)
+		}
+		if got := TraceParent(ctx); got != "" {
+			t.Errorf("%q: expected no traceparent, got %q", value, got)
+		}
+	}
+}
\ No newline at end of file
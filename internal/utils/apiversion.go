@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// APIVersionHeader carries the API version a client speaks and, in
+// responses, the newest API version the server supports
+const APIVersionHeader = "X-Executr-API-Version"
+
+// MinAPIVersionHeader carries the oldest API version the server supports
+const MinAPIVersionHeader = "X-Executr-Min-API-Version"
+
+// APIVersion is a MAJOR.MINOR version of the HTTP API. The major version is
+// bumped for changes that break existing clients, such as changing the
+// shape of a response, the minor version for additions such as new
+// endpoints or fields.
+type APIVersion struct {
+	Major, Minor int
+}
+
+var (
+	// CurrentAPIVersion is the API version implemented by this build
+	CurrentAPIVersion = APIVersion{Major: 1, Minor: 0}
+
+	// MinAPIVersion is the oldest API version this build's server still
+	// serves
+	MinAPIVersion = APIVersion{Major: 1, Minor: 0}
+)
+
+// ParseAPIVersion parses a MAJOR.MINOR version
+func ParseAPIVersion(s string) (APIVersion, error) {
+	majorStr, minorStr, ok := strings.Cut(strings.TrimSpace(s), ".")
+	if !ok {
+		return APIVersion{}, fmt.Errorf("invalid API version %q, expected MAJOR.MINOR", s)
+	}
+	major, err := strconv.Atoi(majorStr)
+	if err != nil || major < 0 {
+		return APIVersion{}, fmt.Errorf("invalid major version in API version %q", s)
+	}
+	minor, err := strconv.Atoi(minorStr)
+	if err != nil || minor < 0 {
+		return APIVersion{}, fmt.Errorf("invalid minor version in API version %q", s)
+	}
+	return APIVersion{Major: major, Minor: minor}, nil
+}
+
+// String formats the version as MAJOR.MINOR
+func (v APIVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// Less reports whether v is older than o
+func (v APIVersion) Less(o APIVersion) bool {
+	if v.Major != o.Major {
+		return v.Major < o.Major
+	}
+	return v.Minor < o.Minor
+}
+
+// CompatibleWith reports whether a client speaking v can talk to a server
+// supporting the versions from min to max. The client must not be older
+// than min, and must not be newer than max since it may rely on additions
+// the server doesn't have. Crossing a major version is never compatible.
+func (v APIVersion) CompatibleWith(min, max APIVersion) bool {
+	if v.Major != max.Major {
+		return false
+	}
+	return !v.Less(min) && !max.Less(v)
+}
\ No newline at end of file
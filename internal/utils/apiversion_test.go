@@ -0,0 +1,42 @@
+package utils
+
+import "testing"
+
+func TestParseAPIVersion(t *testing.T) {
+	v, err := ParseAPIVersion("1.12")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v != (APIVersion{Major: 1, Minor: 12}) || v.String() != "1.12" {
+		t.Fatalf("got %v", v)
+	}
+
+	for _, s := range []string{"", "1", "1.", "a.1", "1.b", "-1.0", "1.2.3"} {
+		if _, err := ParseAPIVersion(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
+
+func TestAPIVersionCompatibleWith(t *testing.T) {
+	min := APIVersion{Major: 1, Minor: 2}
+	max := APIVersion{Major: 1, Minor: 4}
+
+	tests := []struct {
+		client APIVersion
+		want   bool
+	}{
+		{APIVersion{Major: 1, Minor: 1}, false},
+		{APIVersion{Major: 1, Minor: 2}, true},
+		{APIVersion{Major: 1, Minor: 4}, true},
+		{APIVersion{Major: 1, Minor: 5}, false},
+		{APIVersion{Major: 2, Minor: 0}, false},
+		{APIVersion{Major: 0, Minor: 9}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.client.CompatibleWith(min, max); got != tt.want {
+			t.Errorf("%s with %s-%s: got %v, want %v", tt.client, min, max, got, tt.want)
+		}
+	}
+}
\ No newline at end of file
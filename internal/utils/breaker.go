@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// BreakerState is the state of a CircuitBreaker
+type BreakerState int
+
+const (
+	// BreakerClosed lets all calls through
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects calls until the cooldown has passed
+	BreakerOpen
+	// BreakerHalfOpen lets a single probe call through
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreaker stops calls to a failing server. After threshold
+// consecutive failures it opens and rejects calls for the cooldown, then
+// lets one probe call through: success closes it, failure opens it again.
+// It complements RetryableHTTPClient, which retries a single call; the
+// breaker backs off across calls.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu            sync.Mutex
+	state         BreakerState
+	failures      int
+	openedAt      time.Time
+	probing       bool
+	onStateChange func(from, to BreakerState)
+}
+
+// NewCircuitBreaker creates a circuit breaker. A threshold of 0 or less
+// disables it.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// OnStateChange sets a function that is called after every state change
+func (b *CircuitBreaker) OnStateChange(fn func(from, to BreakerState)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onStateChange = fn
+}
+
+// State returns the current state
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Allow reports whether a call may be made. Every allowed call must be
+// followed by Success or Failure.
+func (b *CircuitBreaker) Allow() bool {
+	if b.threshold <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	from := b.state
+	allowed := false
+	switch b.state {
+	case BreakerClosed:
+		allowed = true
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) >= b.cooldown {
+			b.state = BreakerHalfOpen
+			b.probing = true
+			allowed = true
+		}
+	case BreakerHalfOpen:
+		if !b.probing {
+			b.probing = true
+			allowed = true
+		}
+	}
+	to := b.state
+	b.mu.Unlock()
+
+	b.changed(from, to)
+	return allowed
+}
+
+// Success records a successful call and closes the breaker
+func (b *CircuitBreaker) Success() {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	from := b.state
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = false
+	b.mu.Unlock()
+
+	b.changed(from, BreakerClosed)
+}
+
+// Failure records a failed call. The breaker opens once threshold calls in
+// a row have failed, or when the probe call fails.
+func (b *CircuitBreaker) Failure() {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	from := b.state
+	b.failures++
+	b.probing = false
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+	}
+	to := b.state
+	b.mu.Unlock()
+
+	b.changed(from, to)
+}
+
+// changed calls the state change function, outside the lock so it may
+// inspect the breaker
+func (b *CircuitBreaker) changed(from, to BreakerState) {
+	if from == to {
+		return
+	}
+
+	b.mu.Lock()
+	fn := b.onStateChange
+	b.mu.Unlock()
+
+	if fn != nil {
+		fn(from, to)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	b := NewCircuitBreaker(3, time.Minute)
+	b.now = func() time.Time { return now }
+
+	var changes []BreakerState
+	b.OnStateChange(func(from, to BreakerState) {
+		changes = append(changes, to)
+	})
+
+	// Failures below the threshold, and a success resetting the count
+	for i := 0; i < 2; i++ {
+		if !b.Allow() {
+			t.Fatal("closed breaker rejected a call")
+		}
+		b.Failure()
+	}
+	b.Allow()
+	b.Success()
+	for i := 0; i < 2; i++ {
+		b.Allow()
+		b.Failure()
+	}
+	if got := b.State(); got != BreakerClosed {
+		t.Fatalf("got %s after 2 failures in a row, want closed", got)
+	}
+
+	b.Allow()
+	b.Failure()
+	if got := b.State(); got != BreakerOpen {
+		t.Fatalf("got %s after 3 failures in a row, want open", got)
+	}
+	if b.Allow() {
+		t.Fatal("open breaker allowed a call")
+	}
+
+	// After the cooldown a single probe goes through; failing it reopens
+	now = now.Add(time.Minute)
+	if !b.Allow() {
+		t.Fatal("expected a probe after the cooldown")
+	}
+	if b.Allow() {
+		t.Fatal("expected a single probe")
+	}
+	b.Failure()
+	if b.Allow() {
+		t.Fatal("expected a failed probe to reopen the breaker")
+	}
+
+	now = now.Add(time.Minute)
+	b.Allow()
+	b.Success()
+	if got := b.State(); got != BreakerClosed {
+		t.Fatalf("got %s after a successful probe, want closed", got)
+	}
+
+	want := []BreakerState{BreakerOpen, BreakerHalfOpen, BreakerOpen, BreakerHalfOpen, BreakerClosed}
+	if len(changes) != len(want) {
+		t.Fatalf("got state changes %v, want %v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Fatalf("got state changes %v, want %v", changes, want)
+		}
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	b := NewCircuitBreaker(0, time.Minute)
+	for i := 0; i < 10; i++ {
+		if !b.Allow() {
+			t.Fatal("disabled breaker rejected a call")
+		}
+		b.Failure()
+	}
+	if got := b.State(); got != BreakerClosed {
+		t.Fatalf("got %s, want closed", got)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// DownloadCredentials holds the headers sent with downloads from
+// authenticated servers, such as artifact registries, by URL prefix
+type DownloadCredentials struct {
+	rules []credentialRule
+}
+
+type credentialRule struct {
+	scheme string
+	host   string // host and port, lower case
+	path   string
+	header string
+	value  string
+}
+
+// matches reports whether u has the rule's scheme and host and a path under
+// the rule's path. The path must match whole segments, so a rule for /team
+// doesn't match /team-b.
+func (r *credentialRule) matches(u *url.URL) bool {
+	if u.Scheme != r.scheme || strings.ToLower(u.Host) != r.host {
+		return false
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if !strings.HasPrefix(path, r.path) {
+		return false
+	}
+	return len(path) == len(r.path) || strings.HasSuffix(r.path, "/") || path[len(r.path)] == '/'
+}
+
+// LoadDownloadCredentials reads a download credentials file. Each non-empty
+// line holds a URL prefix followed by the header sent with downloads from
+// URLs under it, e.g.
+//
+//	# url prefix                          header
+//	https://artifacts.example.com/        Authorization: Bearer eyJhbGciOi...
+//	https://registry.internal/team-a/     X-Api-Key: 0b41d6aa
+//
+// A prefix matches URLs with exactly its scheme and host whose path starts
+// with the prefix's path. When several prefixes match a URL, the longest one
+// is used. Lines starting with # are ignored.
+func LoadDownloadCredentials(path string) (*DownloadCredentials, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open download credentials file: %w", err)
+	}
+	defer f.Close()
+
+	creds := &DownloadCredentials{}
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// The header value is never part of an error, it is a secret
+		prefix, header := line, ""
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			prefix, header = line[:i], strings.TrimSpace(line[i:])
+		}
+		name, value, ok := strings.Cut(header, ":")
+		name = strings.TrimSpace(name)
+		value = strings.TrimSpace(value)
+		if !ok || name == "" || value == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("line %d: expected <url-prefix> <header>: <value>", lineNo)
+		}
+		u, err := url.Parse(prefix)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("line %d: URL prefix %q must be an http:// or https:// URL", lineNo, prefix)
+		}
+		if u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+			return nil, fmt.Errorf("line %d: URL prefix must not have user info, a query or a fragment", lineNo)
+		}
+		path := u.EscapedPath()
+		if path == "" {
+			path = "/"
+		}
+
+		creds.rules = append(creds.rules, credentialRule{
+			scheme: u.Scheme,
+			host:   strings.ToLower(u.Host),
+			path:   path,
+			header: textproto.CanonicalMIMEHeaderKey(name),
+			value:  value,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read download credentials file: %w", err)
+	}
+
+	if len(creds.rules) == 0 {
+		return nil, fmt.Errorf("download credentials file %s contains no credentials", path)
+	}
+
+	return creds, nil
+}
+
+// Len returns the number of URL prefixes with credentials
+func (c *DownloadCredentials) Len() int {
+	if c == nil {
+		return 0
+	}
+	return len(c.rules)
+}
+
+// Apply sets the header of the longest URL prefix matching req's URL. It
+// does nothing when no prefix matches or c is nil.
+func (c *DownloadCredentials) Apply(req *http.Request) {
+	if c == nil {
+		return
+	}
+
+	var match *credentialRule
+	for i := range c.rules {
+		rule := &c.rules[i]
+		if rule.matches(req.URL) && (match == nil || len(rule.path) > len(match.path)) {
+			match = rule
+		}
+	}
+	if match != nil {
+		req.Header.Set(match.header, match.value)
+	}
+}
+
+// CheckRedirect is an http.Client CheckRedirect for clients sending
+// credentials. The client copies the headers of the original request to
+// redirects, so it removes all credential headers and applies the ones
+// matching the redirect's URL, if any. Like the default policy, it stops
+// after 10 redirects.
+func (c *DownloadCredentials) CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	if c == nil {
+		return nil
+	}
+
+	for _, rule := range c.rules {
+		req.Header.Del(rule.header)
+	}
+	c.Apply(req)
+	return nil
+}
\ No newline at end of file
@@ -4,7 +4,9 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"net/http"
 	"os"
@@ -19,11 +21,19 @@ type ProgressFunc func(bytesDownloaded, totalBytes int64)
 type DownloadOptions struct {
 	SHA256       string       // Expected SHA256 hash (optional)
 	ProgressFunc ProgressFunc // Progress callback function (optional)
+	MaxSize      int64        // Downloads larger than this fail with ErrDownloadTooLarge (optional)
+	Mode         os.FileMode  // Permissions of the downloaded file, 0755 when 0
 }
 
+// ErrDownloadTooLarge is returned when a download exceeds DownloadOptions.MaxSize
+var ErrDownloadTooLarge = errors.New("download exceeds the size limit")
+
 // BinaryDownloader handles binary downloads with progress tracking
 type BinaryDownloader struct {
-	client *RetryableHTTPClient
+	client      *RetryableHTTPClient
+	maxAttempts int           // attempts per download, including resumes
+	resumeDelay time.Duration // wait before resuming an interrupted download
+	credentials *DownloadCredentials
 }
 
 // NewBinaryDownloader creates a new binary downloader
@@ -32,68 +42,84 @@ func NewBinaryDownloader() *BinaryDownloader {
 	// No timeout for binary downloads (as per requirements)
 	client.SetTimeout(0)
 	return &BinaryDownloader{
-		client: client,
+		client:      client,
+		maxAttempts: 5,
+		resumeDelay: time.Second,
 	}
 }
 
-// Download downloads a binary from the given URL to the destination path
+// SetCredentials makes the downloader authenticate requests to URLs that
+// have credentials in creds. nil sends no credentials. Credentials are
+// dropped from redirects to URLs they don't match.
+func (d *BinaryDownloader) SetCredentials(creds *DownloadCredentials) {
+	d.credentials = creds
+	d.client.SetCheckRedirect(creds.CheckRedirect)
+}
+
+// errTransfer marks errors that interrupted a transfer and can be resumed
+type errTransfer struct {
+	err error
+}
+
+func (e *errTransfer) Error() string { return e.err.Error() }
+func (e *errTransfer) Unwrap() error { return e.err }
+
+// Download downloads a binary from the given URL to the destination path.
+// Data is written to a ".download-<name>" file next to the destination. If a
+// transfer is interrupted, the download resumes from the current offset
+// using a Range request when the server supports it; a partial file left by
+// an earlier call is resumed the same way. The partial file is removed when
+// ctx is cancelled. The SHA256 is verified over the complete file before it
+// is atomically moved into place.
 func (d *BinaryDownloader) Download(ctx context.Context, url, destPath string, opts *DownloadOptions) error {
 	if opts == nil {
 		opts = &DownloadOptions{}
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	tmpPath := filepath.Join(filepath.Dir(destPath), ".download-"+filepath.Base(destPath))
+
+	tmpFile, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE, 0644)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return fmt.Errorf("failed to create temp file: %w", err)
 	}
 
-	resp, err := d.client.DoWithContext(ctx, req)
+	// Hash any data left by an earlier, interrupted download
+	hasher := sha256.New()
+	offset, err := io.Copy(hasher, tmpFile)
 	if err != nil {
-		return fmt.Errorf("failed to download: %w", err)
+		tmpFile.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to read partial download: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("download failed with status: %s", resp.Status)
-	}
+	for attempt := 1; ; attempt++ {
+		err = d.fetch(ctx, url, tmpFile, hasher, &offset, opts)
+		if err == nil {
+			break
+		}
 
-	// Create temporary file in the same directory as destination
-	tmpFile, err := os.CreateTemp(filepath.Dir(destPath), ".download-*")
-	if err != nil {
-		return fmt.Errorf("failed to create temp file: %w", err)
-	}
-	tmpPath := tmpFile.Name()
-	
-	// Clean up temp file on error
-	defer func() {
-		if err != nil {
-			os.Remove(tmpPath)
+		var transferErr *errTransfer
+		if !errors.As(err, &transferErr) || attempt >= d.maxAttempts || ctx.Err() != nil {
+			tmpFile.Close()
+			// Keep interrupted transfers so a later call can resume them,
+			// unless the caller gave up on the download
+			if !errors.As(err, &transferErr) || ctx.Err() != nil {
+				os.Remove(tmpPath)
+			}
+			return err
 		}
-	}()
 
-	// Create a reader that tracks progress and calculates SHA256
-	var reader io.Reader = resp.Body
-	hasher := sha256.New()
-	
-	// Wrap with TeeReader to calculate hash while downloading
-	reader = io.TeeReader(reader, hasher)
-	
-	// Wrap with progress reader if callback provided
-	if opts.ProgressFunc != nil {
-		reader = &progressReader{
-			reader:       reader,
-			totalBytes:   resp.ContentLength,
-			progressFunc: opts.ProgressFunc,
+		select {
+		case <-ctx.Done():
+			tmpFile.Close()
+			os.Remove(tmpPath)
+			return ctx.Err()
+		case <-time.After(d.resumeDelay):
 		}
 	}
 
-	// Copy to temporary file
-	if _, err = io.Copy(tmpFile, reader); err != nil {
-		tmpFile.Close()
-		return fmt.Errorf("failed to save file: %w", err)
-	}
-	
-	if err = tmpFile.Close(); err != nil {
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpPath)
 		return fmt.Errorf("failed to close temp file: %w", err)
 	}
 
@@ -101,29 +127,157 @@ func (d *BinaryDownloader) Download(ctx context.Context, url, destPath string, o
 	if opts.SHA256 != "" {
 		calculatedHash := hex.EncodeToString(hasher.Sum(nil))
 		if calculatedHash != opts.SHA256 {
+			os.Remove(tmpPath)
 			return fmt.Errorf("SHA256 mismatch: expected %s, got %s", opts.SHA256, calculatedHash)
 		}
 	}
 
-	// Set executable permissions
-	if err = os.Chmod(tmpPath, 0755); err != nil {
-		return fmt.Errorf("failed to set executable permissions: %w", err)
+	// Set executable permissions, unless asked for others
+	mode := opts.Mode
+	if mode == 0 {
+		mode = 0755
+	}
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to set permissions: %w", err)
 	}
 
 	// Atomically move to final destination
-	if err = os.Rename(tmpPath, destPath); err != nil {
+	if err := os.Rename(tmpPath, destPath); err != nil {
+		os.Remove(tmpPath)
 		return fmt.Errorf("failed to move file to destination: %w", err)
 	}
 
 	return nil
 }
 
+// fetch downloads the data after *offset into file, updating hasher and
+// *offset as data is written. It restarts from zero when the server does not
+// honor the Range request.
+func (d *BinaryDownloader) fetch(ctx context.Context, url string, file *os.File, hasher hash.Hash, offset *int64, opts *DownloadOptions) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	d.credentials.Apply(req)
+	if *offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", *offset))
+	}
+
+	resp, err := d.client.DoWithContext(ctx, req)
+	if err != nil {
+		return &errTransfer{fmt.Errorf("failed to download: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && *offset > 0 && contentRangeStart(resp) == *offset:
+		// Resuming
+	case resp.StatusCode == http.StatusOK, resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && *offset > 0:
+		// Full response, or the partial data no longer matches: start over
+		if *offset > 0 {
+			if err := file.Truncate(0); err != nil {
+				return fmt.Errorf("failed to reset partial download: %w", err)
+			}
+			*offset = 0
+			hasher.Reset()
+		}
+		if resp.StatusCode != http.StatusOK {
+			return &errTransfer{fmt.Errorf("download failed with status: %s", resp.Status)}
+		}
+	default:
+		return fmt.Errorf("download failed with status: %s", resp.Status)
+	}
+
+	if opts.MaxSize > 0 && resp.ContentLength >= 0 && *offset+resp.ContentLength > opts.MaxSize {
+		return fmt.Errorf("%w: %d bytes, limit is %d", ErrDownloadTooLarge, *offset+resp.ContentLength, opts.MaxSize)
+	}
+
+	if _, err := file.Seek(*offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek temp file: %w", err)
+	}
+
+	// Wrap with progress reader if callback provided
+	var reader io.Reader = resp.Body
+	if opts.MaxSize > 0 {
+		// Read one byte past the limit to notice bodies without a length
+		// that exceed it
+		reader = io.LimitReader(reader, opts.MaxSize-*offset+1)
+	}
+	if opts.ProgressFunc != nil {
+		totalBytes := int64(-1)
+		if resp.ContentLength >= 0 {
+			totalBytes = *offset + resp.ContentLength
+		}
+		reader = &progressReader{
+			reader:          reader,
+			bytesDownloaded: *offset,
+			totalBytes:      totalBytes,
+			progressFunc:    opts.ProgressFunc,
+		}
+	}
+
+	// Copy to the temp file, hashing only data that was fully written
+	w := &hashingWriter{file: file, hasher: hasher, offset: offset}
+	if _, err := io.Copy(w, reader); err != nil {
+		if w.writeErr != nil {
+			return fmt.Errorf("failed to save file: %w", err)
+		}
+		return &errTransfer{fmt.Errorf("download interrupted: %w", err)}
+	}
+
+	if opts.MaxSize > 0 && *offset > opts.MaxSize {
+		return fmt.Errorf("%w: limit is %d bytes", ErrDownloadTooLarge, opts.MaxSize)
+	}
+
+	if resp.ContentLength >= 0 && *offset != contentRangeStart(resp)+resp.ContentLength {
+		return &errTransfer{fmt.Errorf("download incomplete: got %d bytes", *offset)}
+	}
+
+	return nil
+}
+
+// hashingWriter writes to a file and feeds the hasher with what was written
+type hashingWriter struct {
+	file     *os.File
+	hasher   hash.Hash
+	offset   *int64
+	writeErr error
+}
+
+func (w *hashingWriter) Write(p []byte) (int, error) {
+	n, err := w.file.Write(p)
+	w.hasher.Write(p[:n])
+	*w.offset += int64(n)
+	if err != nil {
+		w.writeErr = err
+	}
+	return n, err
+}
+
+// contentRangeStart returns the first byte position of a 206 response, or 0
+func contentRangeStart(resp *http.Response) int64 {
+	if resp.StatusCode != http.StatusPartialContent {
+		return 0
+	}
+	var start, end, total int64
+	cr := resp.Header.Get("Content-Range")
+	if _, err := fmt.Sscanf(cr, "bytes %d-%d/%d", &start, &end, &total); err != nil {
+		// Total may be unknown ("*")
+		if _, err := fmt.Sscanf(cr, "bytes %d-%d/*", &start, &end); err != nil {
+			return -1
+		}
+	}
+	return start
+}
+
 // CalculateSHA256FromURL downloads and calculates SHA256 without saving the file
 func (d *BinaryDownloader) CalculateSHA256FromURL(ctx context.Context, url string, progressFunc ProgressFunc) (string, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
+	d.credentials.Apply(req)
 
 	resp, err := d.client.DoWithContext(ctx, req)
 	if err != nil {
@@ -0,0 +1,199 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyServer serves content, aborting the first full response half way
+// through. Range requests are honored when supportRanges is set.
+type flakyServer struct {
+	content       []byte
+	supportRanges bool
+
+	mu       sync.Mutex
+	requests []string // Range header of each request
+}
+
+func (s *flakyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Header.Get("Range"))
+	first := len(s.requests) == 1
+	s.mu.Unlock()
+
+	start := 0
+	if rng := r.Header.Get("Range"); rng != "" && s.supportRanges {
+		if _, err := fmt.Sscanf(rng, "bytes=%d-", &start); err != nil {
+			http.Error(w, "bad range", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(s.content)-1, len(s.content)))
+		w.Header().Set("Content-Length", strconv.Itoa(len(s.content)-start))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(s.content[start:])
+		return
+	}
+
+	if s.supportRanges {
+		w.Header().Set("Accept-Ranges", "bytes")
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(s.content)))
+	w.WriteHeader(http.StatusOK)
+
+	if first {
+		// Send half of the body, then drop the connection
+		w.Write(s.content[:len(s.content)/2])
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+	w.Write(s.content)
+}
+
+func testContent() ([]byte, string) {
+	content := bytes.Repeat([]byte("executr-binary-"), 64*1024)
+	sum := sha256.Sum256(content)
+	return content, hex.EncodeToString(sum[:])
+}
+
+func newTestDownloader() *BinaryDownloader {
+	d := NewBinaryDownloader()
+	d.resumeDelay = 10 * time.Millisecond
+	return d
+}
+
+func TestDownloadResumesInterruptedTransfer(t *testing.T) {
+	content, sum := testContent()
+	srv := &flakyServer{content: content, supportRanges: true}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	dest := filepath.Join(t.TempDir(), "binary")
+	err := newTestDownloader().Download(context.Background(), ts.URL, dest, &DownloadOptions{SHA256: sum})
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("failed to read downloaded file: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatalf("downloaded content mismatch: got %d bytes, want %d", len(got), len(content))
+	}
+
+	if len(srv.requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d: %q", len(srv.requests), srv.requests)
+	}
+	if srv.requests[0] != "" {
+		t.Errorf("first request should not use a range, got %q", srv.requests[0])
+	}
+	var resumeFrom int
+	if _, err := fmt.Sscanf(srv.requests[1], "bytes=%d-", &resumeFrom); err != nil || resumeFrom == 0 {
+		t.Errorf("second request should resume from a non-zero offset, got Range %q", srv.requests[1])
+	}
+}
+
+func TestDownloadRestartsWithoutRangeSupport(t *testing.T) {
+	content, sum := testContent()
+	srv := &flakyServer{content: content}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	dest := filepath.Join(t.TempDir(), "binary")
+	err := newTestDownloader().Download(context.Background(), ts.URL, dest, &DownloadOptions{SHA256: sum})
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("failed to read downloaded file: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatalf("downloaded content mismatch: got %d bytes, want %d", len(got), len(content))
+	}
+}
+
+func TestDownloadResumesPartialFileFromEarlierCall(t *testing.T) {
+	content, sum := testContent()
+	srv := &flakyServer{content: content, supportRanges: true}
+	srv.requests = []string{"earlier"} // don't abort the first request
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "binary")
+	partial := content[:1000]
+	if err := os.WriteFile(filepath.Join(dir, ".download-binary"), partial, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := newTestDownloader().Download(context.Background(), ts.URL, dest, &DownloadOptions{SHA256: sum})
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+
+	if got := srv.requests[1]; got != "bytes=1000-" {
+		t.Errorf("expected resume from byte 1000, got Range %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".download-binary")); !os.IsNotExist(err) {
+		t.Errorf("temp file should be gone after a successful download")
+	}
+}
+
+func TestDownloadSHA256MismatchRemovesTempFile(t *testing.T) {
+	content, _ := testContent()
+	srv := &flakyServer{content: content, supportRanges: true}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "binary")
+	err := newTestDownloader().Download(context.Background(), ts.URL, dest, &DownloadOptions{SHA256: "deadbeef"})
+	if err == nil {
+		t.Fatal("expected SHA256 mismatch error")
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("expected no files left behind, found %d", len(entries))
+	}
+}
+
+func TestDownloadRejectsFilesOverMaxSize(t *testing.T) {
+	content, _ := testContent()
+
+	for _, withLength := range []bool{true, false} {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if withLength {
+				w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			}
+			w.Write(content)
+		}))
+
+		dir := t.TempDir()
+		dest := filepath.Join(dir, "input")
+		err := newTestDownloader().Download(context.Background(), ts.URL, dest, &DownloadOptions{MaxSize: int64(len(content) - 1)})
+		ts.Close()
+		if !errors.Is(err, ErrDownloadTooLarge) {
+			t.Fatalf("content length %v: expected ErrDownloadTooLarge, got %v", withLength, err)
+		}
+
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 0 {
+			t.Errorf("content length %v: expected no files left behind, found %d", withLength, len(entries))
+		}
+	}
+}
\ No newline at end of file
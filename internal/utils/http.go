@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,6 +15,8 @@ type RetryableHTTPClient struct {
 	maxRetries  int
 	retryDelay  time.Duration
 	maxDelay    time.Duration
+	retryBudget time.Duration // total time for all attempts and backoffs, 0 means unlimited
+	userAgent   string        // sent with requests that don't set one
 	shouldRetry func(resp *http.Response, err error) bool
 }
 
@@ -23,6 +27,7 @@ func NewRetryableHTTPClient() *RetryableHTTPClient {
 		maxRetries: 3,
 		retryDelay: 1 * time.Second,
 		maxDelay:   10 * time.Second,
+		userAgent:  DefaultUserAgent,
 		shouldRetry: func(resp *http.Response, err error) bool {
 			if err != nil {
 				return true
@@ -44,10 +49,14 @@ func (c *RetryableHTTPClient) DoWithContext(ctx context.Context, req *http.Reque
 	var err error
 	
 	delay := c.retryDelay
+	start := time.Now()
 	
 	for i := 0; i <= c.maxRetries; i++ {
 		// Clone the request for each attempt
 		reqCopy := req.Clone(ctx)
+		if c.userAgent != "" && reqCopy.Header.Get("User-Agent") == "" {
+			reqCopy.Header.Set("User-Agent", c.userAgent)
+		}
 		
 		resp, err = c.client.Do(reqCopy)
 		
@@ -61,6 +70,22 @@ func (c *RetryableHTTPClient) DoWithContext(ctx context.Context, req *http.Reque
 			break
 		}
 		
+		// Wait as long as the server asks for, within maxDelay
+		wait := delay
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				wait = min(retryAfter, c.maxDelay)
+			}
+		}
+		
+		// Don't retry if the next attempt would start after the budget ran out
+		if c.retryBudget > 0 && time.Since(start)+wait > c.retryBudget {
+			if err != nil {
+				return nil, fmt.Errorf("request failed after %d attempts, retry budget of %s exhausted: %w", i+1, c.retryBudget, err)
+			}
+			return resp, nil
+		}
+		
 		// Close the response body if it exists
 		if resp != nil && resp.Body != nil {
 			resp.Body.Close()
@@ -70,7 +95,7 @@ func (c *RetryableHTTPClient) DoWithContext(ctx context.Context, req *http.Reque
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
-		case <-time.After(delay):
+		case <-time.After(wait):
 			// Exponential backoff with max delay
 			delay = delay * 2
 			if delay > c.maxDelay {
@@ -86,12 +111,66 @@ func (c *RetryableHTTPClient) DoWithContext(ctx context.Context, req *http.Reque
 	return resp, nil
 }
 
+// IsHTTPURL reports whether a binary URL is downloaded over HTTP(S), as
+// opposed to e.g. oci:// images or file:// paths on the executors
+func IsHTTPURL(binaryURL string) bool {
+	scheme, _, _ := strings.Cut(binaryURL, "://")
+	scheme = strings.ToLower(scheme)
+	return scheme == "http" || scheme == "https"
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as
+// an HTTP date, relative to now. Dates in the past mean no wait.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	return max(at.Sub(now), 0), true
+}
+
 // SetMaxRetries sets the maximum number of retries
 func (c *RetryableHTTPClient) SetMaxRetries(n int) {
 	c.maxRetries = n
 }
 
+// SetRetryBudget caps the total time spent on a request across all
+// attempts and backoffs. Once a retry would start after the budget ran
+// out, the last response or error is returned even if retries remain. 0
+// means no cap besides the request's context.
+func (c *RetryableHTTPClient) SetRetryBudget(budget time.Duration) {
+	c.retryBudget = budget
+}
+
+// SetHTTPClient makes the client send its requests with hc, e.g. to use a
+// custom transport
+func (c *RetryableHTTPClient) SetHTTPClient(hc *http.Client) {
+	c.client = hc
+}
+
+// SetUserAgent sets the User-Agent sent with requests that don't set one,
+// DefaultUserAgent by default
+func (c *RetryableHTTPClient) SetUserAgent(userAgent string) {
+	c.userAgent = userAgent
+}
+
 // SetTimeout sets the HTTP client timeout
 func (c *RetryableHTTPClient) SetTimeout(timeout time.Duration) {
 	c.client.Timeout = timeout
+}
+
+// SetCheckRedirect sets the redirect policy of the HTTP client, see
+// http.Client.CheckRedirect
+func (c *RetryableHTTPClient) SetCheckRedirect(checkRedirect func(req *http.Request, via []*http.Request) error) {
+	c.client.CheckRedirect = checkRedirect
 }
\ No newline at end of file
@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryBudgetStopsRetrying(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := NewRetryableHTTPClient()
+	c.retryDelay = 50 * time.Millisecond
+	c.SetMaxRetries(10)
+	c.SetRetryBudget(300 * time.Millisecond)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	resp, err := c.DoWithContext(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected the last response, got status %d", resp.StatusCode)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("retries took %s, beyond the budget", elapsed)
+	}
+	// Backoffs of 50, 100 and 200ms: the third retry would end past 300ms
+	if n := requests.Load(); n != 3 {
+		t.Fatalf("expected 3 attempts, got %d", n)
+	}
+}
+
+func TestRetryBudgetReturnsLastError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close() // connections are refused from now on
+
+	c := NewRetryableHTTPClient()
+	c.SetMaxRetries(10)
+	c.SetRetryBudget(500 * time.Millisecond) // shorter than the first backoff
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.DoWithContext(context.Background(), req)
+	if err == nil || !strings.Contains(err.Error(), "retry budget of 500ms exhausted") {
+		t.Fatalf("expected a retry budget error, got %v", err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"120", 2 * time.Minute, true},
+		{" 0 ", 0, true},
+		{"Wed, 10 Jan 2024 10:00:30 GMT", 30 * time.Second, true},
+		{"Wed, 10 Jan 2024 09:59:00 GMT", 0, true},
+		{"", 0, false},
+		{"-5", 0, false},
+		{"soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%q: got %s, %v, want %s, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRetryAfterDelaysNextAttempt(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		minWait    time.Duration
+		maxWait    time.Duration
+	}{
+		// Capped by maxDelay
+		{"seconds", "1", 200 * time.Millisecond, 900 * time.Millisecond},
+		{"http date", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), 200 * time.Millisecond, 900 * time.Millisecond},
+		// Exponential backoff starting at retryDelay
+		{"absent", "", 0, 150 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		var requests atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if requests.Add(1) == 1 {
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		c := NewRetryableHTTPClient()
+		c.retryDelay = 10 * time.Millisecond
+		c.maxDelay = 200 * time.Millisecond
+
+		req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		start := time.Now()
+		resp, err := c.DoWithContext(context.Background(), req)
+		elapsed := time.Since(start)
+		srv.Close()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK || requests.Load() != 2 {
+			t.Fatalf("%s: expected success on the second attempt, got status %d after %d attempts", tt.name, resp.StatusCode, requests.Load())
+		}
+		if elapsed < tt.minWait || elapsed > tt.maxWait {
+			t.Errorf("%s: retried after %s, want between %s and %s", tt.name, elapsed, tt.minWait, tt.maxWait)
+		}
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	var userAgents []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents = append(userAgents, r.Header.Get("User-Agent"))
+	}))
+	defer srv.Close()
+
+	c := NewRetryableHTTPClient()
+	for _, userAgent := range []string{"", "custom/1.0"} {
+		req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if userAgent != "" {
+			req.Header.Set("User-Agent", userAgent)
+		}
+		resp, err := c.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if len(userAgents) != 2 || userAgents[0] != DefaultUserAgent || userAgents[1] != "custom/1.0" {
+		t.Fatalf("got user agents %q", userAgents)
+	}
+	if !strings.HasPrefix(DefaultUserAgent, "executr-client/") {
+		t.Fatalf("unexpected default user agent %q", DefaultUserAgent)
+	}
+}
\ No newline at end of file
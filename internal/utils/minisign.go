@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// ErrSignatureMismatch is returned when a minisign signature does not match the data
+var ErrSignatureMismatch = errors.New("signature does not match")
+
+const (
+	minisignTrustedPrefix = "trusted comment: "
+	minisignKeyIDLen      = 8
+)
+
+// minisignPublicKey is a parsed minisign public key
+type minisignPublicKey struct {
+	keyID [minisignKeyIDLen]byte
+	key   ed25519.PublicKey
+}
+
+// minisignSignature is a parsed minisign detached signature
+type minisignSignature struct {
+	algorithm      string
+	keyID          [minisignKeyIDLen]byte
+	signature      []byte
+	trustedComment string
+	globalSig      []byte
+}
+
+// VerifyMinisign verifies a minisign detached signature over the data read
+// from r. The public key may be given either as the bare base64 key or as the
+// full contents of a minisign .pub file. Both legacy ("Ed") and prehashed
+// ("ED") signatures are supported; the trusted comment is verified as well.
+func VerifyMinisign(r io.Reader, signature []byte, publicKey string) error {
+	pub, err := parseMinisignPublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+
+	sig, err := parseMinisignSignature(signature)
+	if err != nil {
+		return err
+	}
+
+	if sig.keyID != pub.keyID {
+		return fmt.Errorf("signature key ID %X does not match public key ID %X", sig.keyID, pub.keyID)
+	}
+
+	var message []byte
+	switch sig.algorithm {
+	case "Ed":
+		message, err = io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("failed to read data: %w", err)
+		}
+	case "ED":
+		h, _ := blake2b.New512(nil)
+		if _, err := io.Copy(h, r); err != nil {
+			return fmt.Errorf("failed to read data: %w", err)
+		}
+		message = h.Sum(nil)
+	default:
+		return fmt.Errorf("unsupported signature algorithm %q", sig.algorithm)
+	}
+
+	if !ed25519.Verify(pub.key, message, sig.signature) {
+		return ErrSignatureMismatch
+	}
+
+	global := append(append([]byte{}, sig.signature...), sig.trustedComment...)
+	if !ed25519.Verify(pub.key, global, sig.globalSig) {
+		return fmt.Errorf("trusted comment: %w", ErrSignatureMismatch)
+	}
+
+	return nil
+}
+
+func parseMinisignPublicKey(publicKey string) (*minisignPublicKey, error) {
+	var encoded string
+	for _, line := range strings.Split(strings.TrimSpace(publicKey), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "untrusted comment:") {
+			continue
+		}
+		encoded = line
+		break
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key encoding: %w", err)
+	}
+	if len(raw) != 2+minisignKeyIDLen+ed25519.PublicKeySize || string(raw[:2]) != "Ed" {
+		return nil, errors.New("invalid minisign public key")
+	}
+
+	pub := &minisignPublicKey{
+		key: ed25519.PublicKey(raw[2+minisignKeyIDLen:]),
+	}
+	copy(pub.keyID[:], raw[2:2+minisignKeyIDLen])
+	return pub, nil
+}
+
+func parseMinisignSignature(signature []byte) (*minisignSignature, error) {
+	lines := strings.Split(strings.ReplaceAll(string(bytes.TrimSpace(signature)), "\r\n", "\n"), "\n")
+	if len(lines) < 4 {
+		return nil, errors.New("invalid minisign signature: expected 4 lines")
+	}
+	if !strings.HasPrefix(lines[2], minisignTrustedPrefix) {
+		return nil, errors.New("invalid minisign signature: missing trusted comment")
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	if len(raw) != 2+minisignKeyIDLen+ed25519.SignatureSize {
+		return nil, errors.New("invalid minisign signature length")
+	}
+
+	globalSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid global signature encoding: %w", err)
+	}
+	if len(globalSig) != ed25519.SignatureSize {
+		return nil, errors.New("invalid minisign global signature length")
+	}
+
+	sig := &minisignSignature{
+		algorithm:      string(raw[:2]),
+		signature:      raw[2+minisignKeyIDLen:],
+		trustedComment: strings.TrimPrefix(lines[2], minisignTrustedPrefix),
+		globalSig:      globalSig,
+	}
+	copy(sig.keyID[:], raw[2:2+minisignKeyIDLen])
+	return sig, nil
+}
\ No newline at end of file
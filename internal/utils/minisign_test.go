@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// minisignFixture signs data the way minisign does and returns the public
+// key file and signature file contents
+func minisignFixture(t *testing.T, data []byte, prehash bool) (string, []byte) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+	pubFile := fmt.Sprintf("untrusted comment: minisign public key\n%s\n",
+		base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), pub...)))
+
+	alg, message := "Ed", data
+	if prehash {
+		sum := blake2b.Sum512(data)
+		alg, message = "ED", sum[:]
+	}
+	sig := ed25519.Sign(priv, message)
+	trusted := "timestamp:1700000000\tfile:job-binary"
+	global := ed25519.Sign(priv, append(append([]byte{}, sig...), trusted...))
+
+	sigFile := fmt.Sprintf("untrusted comment: signature from minisign secret key\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(append(append([]byte(alg), keyID...), sig...)),
+		trusted,
+		base64.StdEncoding.EncodeToString(global))
+
+	return pubFile, []byte(sigFile)
+}
+
+func TestVerifyMinisign(t *testing.T) {
+	data := []byte("#!/bin/sh\necho hello\n")
+
+	for _, prehash := range []bool{false, true} {
+		pubFile, sig := minisignFixture(t, data, prehash)
+
+		if err := VerifyMinisign(bytes.NewReader(data), sig, pubFile); err != nil {
+			t.Fatalf("prehash=%v: unexpected error: %v", prehash, err)
+		}
+
+		tampered := append([]byte{}, data...)
+		tampered[0] ^= 0xff
+		err := VerifyMinisign(bytes.NewReader(tampered), sig, pubFile)
+		if !errors.Is(err, ErrSignatureMismatch) {
+			t.Fatalf("prehash=%v: expected signature mismatch, got %v", prehash, err)
+		}
+	}
+}
+
+func TestVerifyMinisignBareKey(t *testing.T) {
+	data := []byte("payload")
+	pubFile, sig := minisignFixture(t, data, true)
+
+	// Only the base64 line of the .pub file
+	bare := string(bytes.Split([]byte(pubFile), []byte("\n"))[1])
+	if err := VerifyMinisign(bytes.NewReader(data), sig, bare); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestVerifyMinisignRejectsWrongKey(t *testing.T) {
+	data := []byte("payload")
+	_, sig := minisignFixture(t, data, true)
+	otherPub, _ := minisignFixture(t, data, true)
+
+	if err := VerifyMinisign(bytes.NewReader(data), sig, otherPub); err == nil {
+		t.Fatal("expected verification with a different key to fail")
+	}
+}
+
+func TestVerifyMinisignRejectsTamperedTrustedComment(t *testing.T) {
+	data := []byte("payload")
+	pubFile, sig := minisignFixture(t, data, true)
+
+	sig = bytes.Replace(sig, []byte("file:job-binary"), []byte("file:other"), 1)
+	err := VerifyMinisign(bytes.NewReader(data), sig, pubFile)
+	if !errors.Is(err, ErrSignatureMismatch) {
+		t.Fatalf("expected signature mismatch, got %v", err)
+	}
+}
\ No newline at end of file
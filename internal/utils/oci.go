@@ -0,0 +1,575 @@
+package utils
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"runtime"
+	"strings"
+)
+
+// OCIScheme is the binary URL scheme of binaries inside container images
+const OCIScheme = "oci://"
+
+// ErrNotInImage is returned when an image doesn't contain the requested
+// binary
+var ErrNotInImage = errors.New("binary not found in image")
+
+// Media types of image manifests and indexes, in the OCI and the older
+// Docker flavor
+const (
+	ociIndexMediaType           = "application/vnd.oci.image.index.v1+json"
+	ociManifestMediaType        = "application/vnd.oci.image.manifest.v1+json"
+	dockerManifestListMediaType = "application/vnd.docker.distribution.manifest.list.v2+json"
+	dockerManifestMediaType     = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+var manifestAccept = strings.Join([]string{
+	ociIndexMediaType,
+	ociManifestMediaType,
+	dockerManifestListMediaType,
+	dockerManifestMediaType,
+}, ", ")
+
+// maxSymlinks bounds the symbolic links followed to find a binary
+const maxSymlinks = 16
+
+// IsOCIReference reports whether binaryURL names a binary inside a container
+// image rather than a file served over HTTP
+func IsOCIReference(binaryURL string) bool {
+	return strings.HasPrefix(binaryURL, OCIScheme)
+}
+
+// OCIReference is a binary inside a container image, written as
+// oci://registry/repository[:tag|@digest][#/path/to/binary], e.g.
+// oci://ghcr.io/acme/tools:v1.2#/usr/local/bin/report
+type OCIReference struct {
+	Registry   string // host and optional port, e.g. ghcr.io or localhost:5000
+	Repository string // e.g. acme/tools
+	Reference  string // tag or digest, latest when not given
+	Path       string // absolute path of the binary, the image's entrypoint when empty
+}
+
+// ParseOCIReference parses an oci:// binary URL. Images on Docker Hub may be
+// given as oci://docker.io/alpine, like docker pull alpine.
+func ParseOCIReference(binaryURL string) (*OCIReference, error) {
+	if !IsOCIReference(binaryURL) {
+		return nil, fmt.Errorf("invalid OCI reference %q: must start with %s", binaryURL, OCIScheme)
+	}
+	rest, binPath, _ := strings.Cut(strings.TrimPrefix(binaryURL, OCIScheme), "#")
+
+	registry, name, ok := strings.Cut(rest, "/")
+	if !ok || registry == "" || name == "" {
+		return nil, fmt.Errorf("invalid OCI reference %q: expected %sregistry/repository[:tag]", binaryURL, OCIScheme)
+	}
+
+	ref := &OCIReference{Registry: registry, Reference: "latest"}
+	if name, digest, ok := strings.Cut(name, "@"); ok {
+		if !isDigest(digest) {
+			return nil, fmt.Errorf("invalid OCI reference %q: malformed digest %q", binaryURL, digest)
+		}
+		ref.Repository, ref.Reference = name, digest
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		ref.Repository, ref.Reference = name[:i], name[i+1:]
+	} else {
+		ref.Repository = name
+	}
+	if ref.Repository == "" || ref.Reference == "" || ref.Repository != strings.ToLower(ref.Repository) {
+		return nil, fmt.Errorf("invalid OCI reference %q: repository names are lower case and tags can't be empty", binaryURL)
+	}
+
+	if registry == "docker.io" && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+
+	if binPath != "" {
+		if !path.IsAbs(binPath) {
+			return nil, fmt.Errorf("invalid OCI reference %q: binary path %q must be absolute", binaryURL, binPath)
+		}
+		ref.Path = path.Clean(binPath)
+	}
+
+	return ref, nil
+}
+
+// isDigest reports whether s is a sha256 content digest
+func isDigest(s string) bool {
+	hexDigest, ok := strings.CutPrefix(s, "sha256:")
+	if !ok || len(hexDigest) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(hexDigest)
+	return err == nil
+}
+
+// baseURL returns the registry API endpoint. Registries on the local host
+// are spoken to over plain HTTP, like docker does.
+func (r *OCIReference) baseURL() string {
+	host := r.Registry
+	if host == "docker.io" {
+		host = "registry-1.docker.io"
+	}
+	scheme := "https"
+	hostname := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+	}
+	if ip := net.ParseIP(hostname); hostname == "localhost" || (ip != nil && ip.IsLoopback()) {
+		scheme = "http"
+	}
+	return scheme + "://" + host + "/v2/" + r.Repository
+}
+
+// OCIPuller reads binaries from container images in a registry. Only
+// anonymous pulls are supported; registries that hand out anonymous tokens,
+// like Docker Hub and GitHub's, work as well.
+type OCIPuller struct {
+	client *RetryableHTTPClient
+	os     string
+	arch   string
+}
+
+// NewOCIPuller creates a puller selecting images for the host's platform
+// from multi-platform images
+func NewOCIPuller() *OCIPuller {
+	client := NewRetryableHTTPClient()
+	// Layers can be large, like binary downloads
+	client.SetTimeout(0)
+	return &OCIPuller{
+		client: client,
+		os:     runtime.GOOS,
+		arch:   runtime.GOARCH,
+	}
+}
+
+type ociPlatform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+}
+
+type ociDescriptor struct {
+	MediaType string       `json:"mediaType"`
+	Digest    string       `json:"digest"`
+	Size      int64        `json:"size"`
+	Platform  *ociPlatform `json:"platform,omitempty"`
+}
+
+// ociManifest holds the fields of both image manifests and indexes
+type ociManifest struct {
+	MediaType string          `json:"mediaType"`
+	Config    ociDescriptor   `json:"config"`
+	Layers    []ociDescriptor `json:"layers"`
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+type ociImageConfig struct {
+	Config struct {
+		Entrypoint []string `json:"Entrypoint"`
+		Cmd        []string `json:"Cmd"`
+	} `json:"config"`
+}
+
+// Resolve returns the digest of the manifest ref points to. References by
+// digest are returned without asking the registry.
+func (p *OCIPuller) Resolve(ctx context.Context, ref *OCIReference) (string, error) {
+	if isDigest(ref.Reference) {
+		return ref.Reference, nil
+	}
+
+	s := &registrySession{puller: p, ref: ref}
+	resp, err := s.get(ctx, "HEAD", "/manifests/"+ref.Reference, manifestAccept)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if digest := resp.Header.Get("Docker-Content-Digest"); isDigest(digest) {
+		return digest, nil
+	}
+
+	// Not every registry sends the digest, hash the manifest instead
+	_, digest, err := s.manifest(ctx, ref.Reference)
+	return digest, err
+}
+
+// Extract writes the binary ref points to into w. Images with several
+// platforms are resolved to the puller's platform. Layers are searched from
+// the top, honoring deleted files and following symbolic links, and only
+// read until the binary is found.
+func (p *OCIPuller) Extract(ctx context.Context, ref *OCIReference, w io.Writer) error {
+	s := &registrySession{puller: p, ref: ref}
+
+	manifest, _, err := s.manifest(ctx, ref.Reference)
+	if err != nil {
+		return err
+	}
+	if len(manifest.Manifests) > 0 {
+		digest, err := p.selectPlatform(manifest.Manifests)
+		if err != nil {
+			return err
+		}
+		if manifest, _, err = s.manifest(ctx, digest); err != nil {
+			return err
+		}
+	}
+	if len(manifest.Layers) == 0 {
+		return fmt.Errorf("image manifest of %s has no layers", ref.Repository)
+	}
+
+	binPath := ref.Path
+	if binPath == "" {
+		if binPath, err = s.entrypoint(ctx, manifest.Config); err != nil {
+			return err
+		}
+	}
+
+	target := strings.TrimPrefix(binPath, "/")
+	for hops := 0; hops <= maxSymlinks; hops++ {
+		next, err := s.extractFromLayers(ctx, manifest.Layers, target, w)
+		if err != nil {
+			return err
+		}
+		if next == "" {
+			return nil
+		}
+		target = next
+	}
+	return fmt.Errorf("too many symbolic links resolving %s", binPath)
+}
+
+// selectPlatform picks the manifest for the puller's platform from an index
+func (p *OCIPuller) selectPlatform(manifests []ociDescriptor) (string, error) {
+	var available []string
+	for _, m := range manifests {
+		if m.Platform == nil {
+			continue
+		}
+		if m.Platform.OS == p.os && m.Platform.Architecture == p.arch {
+			return m.Digest, nil
+		}
+		available = append(available, m.Platform.OS+"/"+m.Platform.Architecture)
+	}
+	return "", fmt.Errorf("image has no variant for %s/%s, available: %s", p.os, p.arch, strings.Join(available, ", "))
+}
+
+// registrySession talks to one repository, obtaining an anonymous token
+// when the registry asks for one
+type registrySession struct {
+	puller *OCIPuller
+	ref    *OCIReference
+	token  string
+}
+
+func (s *registrySession) get(ctx context.Context, method, apiPath, accept string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, s.ref.baseURL()+apiPath, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create registry request: %w", err)
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if s.token != "" {
+			req.Header.Set("Authorization", "Bearer "+s.token)
+		}
+
+		resp, err := s.puller.client.DoWithContext(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("registry request failed: %w", err)
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			challenge := resp.Header.Get("WWW-Authenticate")
+			resp.Body.Close()
+			if err := s.authenticate(ctx, challenge); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("registry returned %s for %s%s", resp.Status, s.ref.Repository, apiPath)
+		}
+		return resp, nil
+	}
+}
+
+// authenticate fetches an anonymous pull token as described by a Bearer
+// challenge
+func (s *registrySession) authenticate(ctx context.Context, challenge string) error {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return fmt.Errorf("registry requires %s authentication, only anonymous pulls are supported", scheme)
+	}
+
+	fields := parseChallengeParams(params)
+	realm, err := url.Parse(fields["realm"])
+	if err != nil || realm.Host == "" {
+		return fmt.Errorf("registry sent an invalid token realm %q", fields["realm"])
+	}
+	query := realm.Query()
+	if fields["service"] != "" {
+		query.Set("service", fields["service"])
+	}
+	scope := fields["scope"]
+	if scope == "" {
+		scope = "repository:" + s.ref.Repository + ":pull"
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", realm.String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create token request: %w", err)
+	}
+	resp, err := s.puller.client.DoWithContext(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to get registry token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get registry token: %s", resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("failed to decode registry token: %w", err)
+	}
+	s.token = token.Token
+	if s.token == "" {
+		s.token = token.AccessToken
+	}
+	if s.token == "" {
+		return errors.New("registry returned an empty token")
+	}
+	return nil
+}
+
+// parseChallengeParams parses the key="value" pairs of a WWW-Authenticate
+// header
+func parseChallengeParams(params string) map[string]string {
+	fields := make(map[string]string)
+	for params != "" {
+		var key, value string
+		key, params, _ = strings.Cut(strings.TrimLeft(params, " ,"), "=")
+		if strings.HasPrefix(params, `"`) {
+			value, params, _ = strings.Cut(params[1:], `"`)
+		} else {
+			value, params, _ = strings.Cut(params, ",")
+		}
+		fields[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+	return fields
+}
+
+// manifest fetches a manifest or index and returns it with its digest.
+// Manifests fetched by digest are verified against it.
+func (s *registrySession) manifest(ctx context.Context, reference string) (*ociManifest, string, error) {
+	resp, err := s.get(ctx, "GET", "/manifests/"+reference, manifestAccept)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	// Manifests are small, anything above this is not one
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read manifest: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	if isDigest(reference) && digest != reference {
+		return nil, "", fmt.Errorf("manifest digest mismatch: expected %s, got %s", reference, digest)
+	}
+
+	var manifest ociManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, "", fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	return &manifest, digest, nil
+}
+
+// blobReader reads a blob while hashing it, so its content can be checked
+// against the digest of its descriptor
+type blobReader struct {
+	io.Reader
+	body   io.ReadCloser
+	hash   hash.Hash
+	digest string
+}
+
+// openBlob starts fetching the blob desc describes
+func (s *registrySession) openBlob(ctx context.Context, desc ociDescriptor) (*blobReader, error) {
+	if !isDigest(desc.Digest) {
+		return nil, fmt.Errorf("unsupported blob digest %q", desc.Digest)
+	}
+	resp, err := s.get(ctx, "GET", "/blobs/"+desc.Digest, "")
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	return &blobReader{Reader: io.TeeReader(resp.Body, h), body: resp.Body, hash: h, digest: desc.Digest}, nil
+}
+
+// verify reads the rest of the blob and checks its content against the
+// digest
+func (b *blobReader) verify() error {
+	if _, err := io.Copy(io.Discard, b.Reader); err != nil {
+		return fmt.Errorf("failed to read blob %s: %w", b.digest, err)
+	}
+	if digest := "sha256:" + hex.EncodeToString(b.hash.Sum(nil)); digest != b.digest {
+		return fmt.Errorf("blob digest mismatch: expected %s, got %s", b.digest, digest)
+	}
+	return nil
+}
+
+func (b *blobReader) Close() error {
+	return b.body.Close()
+}
+
+// entrypoint returns the first element of the image's entrypoint, or of
+// its command when it has none
+func (s *registrySession) entrypoint(ctx context.Context, config ociDescriptor) (string, error) {
+	blob, err := s.openBlob(ctx, config)
+	if err != nil {
+		return "", err
+	}
+	defer blob.Close()
+
+	var cfg ociImageConfig
+	if err := json.NewDecoder(io.LimitReader(blob, 4<<20)).Decode(&cfg); err != nil {
+		return "", fmt.Errorf("failed to decode image config: %w", err)
+	}
+	if err := blob.verify(); err != nil {
+		return "", err
+	}
+
+	args := cfg.Config.Entrypoint
+	if len(args) == 0 {
+		args = cfg.Config.Cmd
+	}
+	if len(args) == 0 || !path.IsAbs(args[0]) {
+		return "", fmt.Errorf("image has no entrypoint with an absolute path, name the binary after # in the URL")
+	}
+	return path.Clean(args[0]), nil
+}
+
+// extractFromLayers copies the file at target (without leading slash) from
+// the topmost layer containing it into w. When target or one of its parent
+// directories is a symbolic link, nothing is written and the path the link
+// points to is returned instead.
+func (s *registrySession) extractFromLayers(ctx context.Context, layers []ociDescriptor, target string, w io.Writer) (string, error) {
+	for i := len(layers) - 1; i >= 0; i-- {
+		found, next, hidden, err := s.extractFromLayer(ctx, layers[i], target, w)
+		if err != nil {
+			return "", err
+		}
+		if found {
+			return next, nil
+		}
+		if hidden {
+			break
+		}
+	}
+	return "", fmt.Errorf("%w: /%s", ErrNotInImage, target)
+}
+
+// extractFromLayer looks for target in one layer. hidden reports a
+// whiteout that hides target in the layers below. The whole layer is read
+// and checked against its digest before the result is accepted; a binary
+// already written to w is then rejected by the error.
+func (s *registrySession) extractFromLayer(ctx context.Context, layer ociDescriptor, target string, w io.Writer) (found bool, next string, hidden bool, err error) {
+	blob, err := s.openBlob(ctx, layer)
+	if err != nil {
+		return false, "", false, err
+	}
+	defer blob.Close()
+
+	var r io.Reader = blob
+	switch {
+	case strings.HasSuffix(layer.MediaType, "gzip"):
+		gz, err := gzip.NewReader(blob)
+		if err != nil {
+			return false, "", false, fmt.Errorf("failed to decompress layer %s: %w", layer.Digest, err)
+		}
+		defer gz.Close()
+		r = gz
+	case strings.HasSuffix(layer.MediaType, "zstd"):
+		return false, "", false, fmt.Errorf("layer %s is zstd compressed, which is not supported", layer.Digest)
+	}
+
+	found, next, hidden, err = scanLayer(r, layer.Digest, target, w)
+	if err != nil {
+		return false, "", false, err
+	}
+	if err := blob.verify(); err != nil {
+		return false, "", false, err
+	}
+	return found, next, hidden, nil
+}
+
+// scanLayer reads the tar stream of a layer up to target, see
+// extractFromLayer
+func scanLayer(r io.Reader, digest, target string, w io.Writer) (found bool, next string, hidden bool, err error) {
+	dir, base := path.Split(target)
+	whiteout := dir + ".wh." + base
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return false, "", hidden, nil
+		}
+		if err != nil {
+			return false, "", false, fmt.Errorf("failed to read layer %s: %w", digest, err)
+		}
+
+		name := strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+		switch {
+		case name == target:
+			switch hdr.Typeflag {
+			case tar.TypeReg:
+				if _, err := io.Copy(w, tr); err != nil {
+					return false, "", false, fmt.Errorf("failed to extract /%s: %w", target, err)
+				}
+				return true, "", false, nil
+			case tar.TypeSymlink:
+				return true, linkTarget(name, hdr.Linkname), false, nil
+			case tar.TypeLink:
+				return true, strings.TrimPrefix(path.Clean("/"+hdr.Linkname), "/"), false, nil
+			default:
+				return false, "", false, fmt.Errorf("/%s in the image is not a regular file", target)
+			}
+		case name == whiteout:
+			hidden = true
+		case strings.HasSuffix(name, "/.wh..wh..opq") && strings.HasPrefix(target, strings.TrimSuffix(name, ".wh..wh..opq")):
+			// Opaque directory: lower layers don't contribute to it
+			hidden = true
+		case hdr.Typeflag == tar.TypeSymlink && strings.HasPrefix(target, name+"/"):
+			// A parent directory is a link, e.g. /bin to /usr/bin
+			return true, path.Join(linkTarget(name, hdr.Linkname), strings.TrimPrefix(target, name+"/")), false, nil
+		}
+	}
+}
+
+// linkTarget resolves the target of the symbolic link at name to a path
+// without leading slash
+func linkTarget(name, linkname string) string {
+	if !path.IsAbs(linkname) {
+		linkname = path.Join("/", path.Dir(name), linkname)
+	}
+	return strings.TrimPrefix(path.Clean(linkname), "/")
+}
\ No newline at end of file
@@ -0,0 +1,277 @@
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeRegistry serves the blobs and manifests of one repository, requiring
+// an anonymous bearer token like Docker Hub does
+type fakeRegistry struct {
+	t        *testing.T
+	srv      *httptest.Server
+	blobs    map[string][]byte
+	tags     map[string]string // tag to manifest digest
+	requests []string
+}
+
+func newFakeRegistry(t *testing.T) *fakeRegistry {
+	r := &fakeRegistry{t: t, blobs: make(map[string][]byte), tags: make(map[string]string)}
+	r.srv = httptest.NewServer(r)
+	t.Cleanup(r.srv.Close)
+	return r
+}
+
+func (r *fakeRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/token" {
+		if req.URL.Query().Get("scope") != "repository:acme/tools:pull" {
+			http.Error(w, "bad scope", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"token": "anonymous"})
+		return
+	}
+	if req.Header.Get("Authorization") != "Bearer anonymous" {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="`+r.srv.URL+`/token",service="fake"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	r.requests = append(r.requests, req.Method+" "+req.URL.Path)
+	name, ok := strings.CutPrefix(req.URL.Path, "/v2/acme/tools/")
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	kind, ref, _ := strings.Cut(name, "/")
+	if digest, ok := r.tags[ref]; ok && kind == "manifests" {
+		ref = digest
+	}
+	data, ok := r.blobs[ref]
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	w.Header().Set("Docker-Content-Digest", ref)
+	if req.Method == "GET" {
+		w.Write(data)
+	}
+}
+
+// add stores a blob and returns its descriptor
+func (r *fakeRegistry) add(mediaType string, data []byte) ociDescriptor {
+	sum := sha256.Sum256(data)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	r.blobs[digest] = data
+	return ociDescriptor{MediaType: mediaType, Digest: digest, Size: int64(len(data))}
+}
+
+func (r *fakeRegistry) addJSON(mediaType string, v interface{}) ociDescriptor {
+	data, err := json.Marshal(v)
+	if err != nil {
+		r.t.Fatal(err)
+	}
+	return r.add(mediaType, data)
+}
+
+// layer builds a gzipped tar layer; entries with a value starting with ->
+// are symbolic links
+func (r *fakeRegistry) layer(entries map[string]string) ociDescriptor {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range entries {
+		hdr := &tar.Header{Name: name, Mode: 0755, Typeflag: tar.TypeReg, Size: int64(len(content))}
+		if target, ok := strings.CutPrefix(content, "->"); ok {
+			hdr = &tar.Header{Name: name, Typeflag: tar.TypeSymlink, Linkname: target}
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			r.t.Fatal(err)
+		}
+		if hdr.Typeflag == tar.TypeReg {
+			tw.Write([]byte(content))
+		}
+	}
+	tw.Close()
+	gz.Close()
+	return r.add("application/vnd.oci.image.layer.v1.tar+gzip", buf.Bytes())
+}
+
+// image stores a multi-platform image with the given layers for linux/amd64
+// under tag
+func (r *fakeRegistry) image(tag string, layers []ociDescriptor, entrypoint []string) string {
+	config := map[string]interface{}{"config": map[string]interface{}{"Entrypoint": entrypoint}}
+	manifest := r.addJSON(ociManifestMediaType, ociManifest{
+		MediaType: ociManifestMediaType,
+		Config:    r.addJSON("application/vnd.oci.image.config.v1+json", config),
+		Layers:    layers,
+	})
+	manifest.Platform = &ociPlatform{OS: "linux", Architecture: "amd64"}
+	other := r.addJSON(ociManifestMediaType, ociManifest{MediaType: ociManifestMediaType})
+	other.Platform = &ociPlatform{OS: "linux", Architecture: "arm64"}
+
+	index := r.addJSON(ociIndexMediaType, ociManifest{
+		MediaType: ociIndexMediaType,
+		Manifests: []ociDescriptor{other, manifest},
+	})
+	r.tags[tag] = index.Digest
+	return index.Digest
+}
+
+func (r *fakeRegistry) reference(t *testing.T, rest string) *OCIReference {
+	t.Helper()
+	ref, err := ParseOCIReference(OCIScheme + strings.TrimPrefix(r.srv.URL, "http://") + "/acme/tools" + rest)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return ref
+}
+
+func newTestPuller() *OCIPuller {
+	p := NewOCIPuller()
+	p.os, p.arch = "linux", "amd64"
+	return p
+}
+
+func TestParseOCIReference(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("ab", 32)
+	tests := []struct {
+		url  string
+		want OCIReference
+	}{
+		{"oci://ghcr.io/acme/tools:v1.2#/usr/local/bin/report", OCIReference{"ghcr.io", "acme/tools", "v1.2", "/usr/local/bin/report"}},
+		{"oci://localhost:5000/tools", OCIReference{"localhost:5000", "tools", "latest", ""}},
+		{"oci://ghcr.io/acme/tools@" + digest, OCIReference{"ghcr.io", "acme/tools", digest, ""}},
+		{"oci://docker.io/alpine:3.20#/bin/busybox", OCIReference{"docker.io", "library/alpine", "3.20", "/bin/busybox"}},
+	}
+	for _, tt := range tests {
+		ref, err := ParseOCIReference(tt.url)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.url, err)
+			continue
+		}
+		if *ref != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.url, *ref, tt.want)
+		}
+	}
+
+	for _, url := range []string{
+		"https://ghcr.io/acme/tools",
+		"oci://ghcr.io",
+		"oci://ghcr.io/",
+		"oci://ghcr.io/acme/tools:",
+		"oci://ghcr.io/acme/Tools",
+		"oci://ghcr.io/acme/tools@sha256:1234",
+		"oci://ghcr.io/acme/tools#bin/report",
+	} {
+		if _, err := ParseOCIReference(url); err == nil {
+			t.Errorf("expected error for %q", url)
+		}
+	}
+}
+
+func TestOCIPullerExtractsBinary(t *testing.T) {
+	r := newFakeRegistry(t)
+	base := r.layer(map[string]string{
+		"usr/bin/tool":       "tool v1",
+		"./usr/bin/removed":  "removed",
+		"usr/share/doc/tool": "docs",
+	})
+	top := r.layer(map[string]string{
+		"usr/bin/tool":           "tool v2",
+		"usr/bin/.wh.removed":    "",
+		"bin":                    "->usr/bin",
+		"usr/local/bin/tool-alt": "->../../bin/tool",
+	})
+	digest := r.image("v2", []ociDescriptor{base, top}, []string{"/usr/local/bin/tool-alt", "--verbose"})
+
+	p := newTestPuller()
+	for _, rest := range []string{":v2#/usr/bin/tool", ":v2#/bin/tool", ":v2", "@" + digest} {
+		var buf bytes.Buffer
+		if err := p.Extract(context.Background(), r.reference(t, rest), &buf); err != nil {
+			t.Fatalf("%s: unexpected error: %v", rest, err)
+		}
+		if buf.String() != "tool v2" {
+			t.Errorf("%s: got %q, want the binary of the top layer", rest, buf.String())
+		}
+	}
+
+	for _, rest := range []string{":v2#/usr/bin/removed", ":v2#/usr/bin/missing"} {
+		err := p.Extract(context.Background(), r.reference(t, rest), &bytes.Buffer{})
+		if !errors.Is(err, ErrNotInImage) {
+			t.Errorf("%s: expected ErrNotInImage, got %v", rest, err)
+		}
+	}
+
+	p.arch = "s390x"
+	if err := p.Extract(context.Background(), r.reference(t, ":v2#/usr/bin/tool"), &bytes.Buffer{}); err == nil {
+		t.Error("expected error for a platform the image doesn't have")
+	}
+}
+
+func TestOCIPullerResolvesTagsToDigests(t *testing.T) {
+	r := newFakeRegistry(t)
+	digest := r.image("v1", []ociDescriptor{r.layer(map[string]string{"tool": "v1"})}, nil)
+
+	p := newTestPuller()
+	got, err := p.Resolve(context.Background(), r.reference(t, ":v1#/tool"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != digest {
+		t.Fatalf("got %s, want %s", got, digest)
+	}
+	if len(r.requests) != 1 || r.requests[0] != "HEAD /v2/acme/tools/manifests/v1" {
+		t.Fatalf("expected a single HEAD request, got %v", r.requests)
+	}
+
+	// Digests are not looked up
+	r.requests = nil
+	if got, err := p.Resolve(context.Background(), r.reference(t, "@"+digest)); err != nil || got != digest {
+		t.Fatalf("got %s, %v", got, err)
+	}
+	if len(r.requests) != 0 {
+		t.Fatalf("expected no requests, got %v", r.requests)
+	}
+}
+
+func TestOCIPullerRejectsBlobsNotMatchingTheirDigest(t *testing.T) {
+	r := newFakeRegistry(t)
+	layer := r.layer(map[string]string{"usr/bin/tool": "tool"})
+	r.image("v1", []ociDescriptor{layer}, []string{"/usr/bin/tool"})
+
+	// The registry serves other content under the layer's digest
+	tampered := r.layer(map[string]string{"usr/bin/tool": "evil"})
+	r.blobs[layer.Digest] = r.blobs[tampered.Digest]
+
+	var buf bytes.Buffer
+	err := newTestPuller().Extract(context.Background(), r.reference(t, ":v1#/usr/bin/tool"), &buf)
+	if err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Fatalf("expected a digest mismatch, got %v", err)
+	}
+}
+
+func TestOCIPullerRejectsConfigNotMatchingItsDigest(t *testing.T) {
+	r := newFakeRegistry(t)
+	index := r.image("v1", []ociDescriptor{r.layer(map[string]string{"usr/bin/tool": "tool"})}, []string{"/usr/bin/tool"})
+
+	var manifest ociManifest
+	json.Unmarshal(r.blobs[index], &manifest)
+	json.Unmarshal(r.blobs[manifest.Manifests[1].Digest], &manifest)
+	r.blobs[manifest.Config.Digest] = []byte(`{"config":{"Entrypoint":["/usr/bin/other"]}}`)
+
+	err := newTestPuller().Extract(context.Background(), r.reference(t, ":v1"), &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Fatalf("expected a digest mismatch, got %v", err)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"context"
+	"log/slog"
+)
+
+// RequestIDHeader carries the ID that correlates the log lines of a request
+// across the client and the server
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the request ID id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, or an empty
+// string if there is none
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestIDHandler adds the request ID of a record's context to the record
+type requestIDHandler struct {
+	slog.Handler
+}
+
+// NewRequestIDHandler wraps h so that records logged with a context that
+// carries a request ID, e.g. through slog.InfoContext, get a request_id
+// attribute
+func NewRequestIDHandler(h slog.Handler) slog.Handler {
+	return requestIDHandler{Handler: h}
+}
+
+// Handle implements slog.Handler
+func (h requestIDHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestIDFromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs implements slog.Handler
+func (h requestIDHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return requestIDHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup implements slog.Handler
+func (h requestIDHandler) WithGroup(name string) slog.Handler {
+	return requestIDHandler{Handler: h.Handler.WithGroup(name)}
+}
\ No newline at end of file
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestRequestIDHandlerAddsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewRequestIDHandler(slog.NewJSONHandler(&buf, nil))).With("component", "test")
+
+	logger.InfoContext(WithRequestID(context.Background(), "req-1"), "with id")
+	logger.InfoContext(context.Background(), "without id")
+
+	dec := json.NewDecoder(&buf)
+	for _, want := range []string{"req-1", ""} {
+		var record map[string]interface{}
+		if err := dec.Decode(&record); err != nil {
+			t.Fatal(err)
+		}
+		id, _ := record["request_id"].(string)
+		if id != want {
+			t.Errorf("%v: got request_id %q, want %q", record["msg"], id, want)
+		}
+		if record["component"] != "test" {
+			t.Errorf("%v: lost attributes of the logger", record["msg"])
+		}
+	}
+}
\ No newline at end of file
@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	s3Service         = "s3"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	s3TimeFormat      = "20060102T150405Z"
+	s3DateFormat      = "20060102"
+)
+
+// S3Store uploads objects, such as job output, to an S3-compatible bucket
+// using path-style requests signed with AWS Signature Version 4
+type S3Store struct {
+	endpoint   *url.URL
+	bucket     string
+	region     string
+	accessKey  string
+	secretKey  string
+	httpClient *http.Client
+}
+
+// NewS3Store creates a store for the given endpoint
+// (e.g. https://s3.eu-west-1.amazonaws.com or http://minio:9000) and bucket
+func NewS3Store(endpoint, bucket, region, accessKey, secretKey string) (*S3Store, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("S3 endpoint URL must use http or https, got %q", u.Scheme)
+	}
+	if bucket == "" {
+		return nil, fmt.Errorf("S3 bucket is required")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &S3Store{
+		endpoint:   u,
+		bucket:     bucket,
+		region:     region,
+		accessKey:  accessKey,
+		secretKey:  secretKey,
+		httpClient: &http.Client{Timeout: 10 * time.Minute},
+	}, nil
+}
+
+// Put uploads an object and returns its URL
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64) (string, error) {
+	objectURL := *s.endpoint
+	objectURL.Path = strings.TrimSuffix(s.endpoint.Path, "/") + "/" + s.bucket + "/" + key
+	objectURL.RawPath = s3EscapePath(objectURL.Path)
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", objectURL.String(), r)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", objectContentType(key))
+
+	if s.accessKey != "" {
+		s.sign(req, time.Now().UTC())
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("upload failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("upload failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return objectURL.String(), nil
+}
+
+// objectContentType guesses the content type of an object from its key.
+// Job output is text, artifacts without a known extension are binary.
+func objectContentType(key string) string {
+	if contentType := mime.TypeByExtension(path.Ext(key)); contentType != "" {
+		return contentType
+	}
+	if strings.Contains(key, "/artifacts/") {
+		return "application/octet-stream"
+	}
+	return "text/plain; charset=utf-8"
+}
+
+// sign adds AWS Signature Version 4 headers to req. The payload isn't
+// signed, so it can be streamed.
+func (s *S3Store) sign(req *http.Request, now time.Time) {
+	req.Header.Set("X-Amz-Date", now.Format(s3TimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", s3UnsignedPayload)
+	s.authorize(req, now, []string{"host", "x-amz-content-sha256", "x-amz-date"}, s3UnsignedPayload)
+}
+
+// authorize sets the Authorization header of req, signing the headers named
+// in signedHeaders, which must be lower case and sorted, and the payload hash
+func (s *S3Store) authorize(req *http.Request, now time.Time, signedHeaders []string, payloadHash string) {
+	amzDate := now.Format(s3TimeFormat)
+	scope := strings.Join([]string{now.Format(s3DateFormat), s.region, s3Service, "aws4_request"}, "/")
+
+	lines := []string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+	}
+	for _, name := range signedHeaders {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.Host
+		}
+		lines = append(lines, name+":"+strings.TrimSpace(value))
+	}
+	lines = append(lines, "", strings.Join(signedHeaders, ";"), payloadHash)
+	canonicalRequest := strings.Join(lines, "\n")
+
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.secretKey), now.Format(s3DateFormat))
+	signingKey = hmacSHA256(signingKey, s.region)
+	signingKey = hmacSHA256(signingKey, s3Service)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, strings.Join(signedHeaders, ";"), signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3EscapePath URI-encodes every byte of a path except unreserved
+// characters and '/', as required by the S3 canonical request
+func s3EscapePath(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
\ No newline at end of file
@@ -0,0 +1,42 @@
+package utils
+
+import "runtime/debug"
+
+// modulePath is the module whose version identifies an executr build
+const modulePath = "github.com/draganm/executr"
+
+// DefaultUserAgent identifies requests made by executr, e.g. to the server
+// or to hosts serving job binaries
+var DefaultUserAgent = "executr-client/" + moduleVersion()
+
+// SetVersion makes DefaultUserAgent report version, e.g. the version a
+// binary was built with through -ldflags. It must be called before creating
+// clients, which copy DefaultUserAgent.
+func SetVersion(version string) {
+	DefaultUserAgent = "executr-client/" + version
+}
+
+// moduleVersion returns the version of the executr module compiled into
+// this binary, either as the main module or as a dependency of a program
+// using the client. It is "dev" for builds without version information.
+func moduleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "dev"
+	}
+
+	version := ""
+	if info.Main.Path == modulePath {
+		version = info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			version = dep.Version
+		}
+	}
+
+	if version == "" || version == "(devel)" {
+		return "dev"
+	}
+	return version
+}
\ No newline at end of file
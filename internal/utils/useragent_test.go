@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSetVersionChangesUserAgentOfNewClients(t *testing.T) {
+	defer func(userAgent string) { DefaultUserAgent = userAgent }(DefaultUserAgent)
+
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.UserAgent()
+	}))
+	defer srv.Close()
+
+	SetVersion("v1.2.3")
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	resp, err := NewRetryableHTTPClient().Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if got != "executr-client/v1.2.3" {
+		t.Errorf("User-Agent = %q, want %q", got, "executr-client/v1.2.3")
+	}
+}
\ No newline at end of file
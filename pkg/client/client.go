@@ -1,6 +1,7 @@
 package client
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -13,9 +14,13 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
-	"github.com/draganm/executr/internal/models"
+	"github.com/draganm/executr/internal/tracing"
 	"github.com/draganm/executr/internal/utils"
+	"github.com/draganm/executr/pkg/models"
 )
 
 // Client is the interface for interacting with the Executr server
@@ -23,20 +28,82 @@ type Client interface {
 	// SubmitJob submits a new job to the server
 	SubmitJob(ctx context.Context, job *models.JobSubmission) (*models.Job, error)
 	
+	// SubmitJobWithKey submits a job with an idempotency key. Submitting again
+	// with the same key returns the job created the first time instead of a
+	// new one, so the call is safe to retry.
+	SubmitJobWithKey(ctx context.Context, job *models.JobSubmission, idempotencyKey string) (*models.Job, error)
+	
+	// ValidateJob checks that a job's binary can be downloaded and matches
+	// its SHA256 without submitting the job
+	ValidateJob(ctx context.Context, job *models.JobSubmission) (*models.ValidationResult, error)
+	
 	// GetJob retrieves a job by ID
 	GetJob(ctx context.Context, jobID uuid.UUID) (*models.Job, error)
 	
+	// GetJobIfChanged retrieves a job unless it is unchanged since the
+	// response that carried etag, and returns the job's current ETag. An
+	// unchanged job gives ErrNotModified, so callers polling a job can skip
+	// processing it again. An empty etag always retrieves the job.
+	GetJobIfChanged(ctx context.Context, jobID uuid.UUID, etag string) (*models.Job, string, error)
+	
+	// GetJobMetadata retrieves a job by ID without its stdout and stderr,
+	// for callers that poll jobs and don't need their output
+	GetJobMetadata(ctx context.Context, jobID uuid.UUID) (*models.Job, error)
+	
+	// GetJobOutput retrieves only the output and exit code of a job
+	GetJobOutput(ctx context.Context, jobID uuid.UUID) (*models.JobOutput, error)
+	
+	// JobExists reports whether a job exists and, if it does, its status,
+	// without transferring the job itself
+	JobExists(ctx context.Context, jobID uuid.UUID) (bool, models.Status, error)
+	
+	// WaitForJob polls a job until it reaches one of terminalStates, or any
+	// terminal state if none are given, and returns the job in that state
+	WaitForJob(ctx context.Context, jobID uuid.UUID, terminalStates ...models.Status) (*models.Job, error)
+	
+	// SubmitAndWait submits a job and waits until it is completed, failed,
+	// cancelled or otherwise terminal. A failed job is returned without an
+	// error so that its stderr and exit code can be inspected.
+	SubmitAndWait(ctx context.Context, job *models.JobSubmission) (*models.Job, error)
+	
 	// ListJobs lists jobs with optional filtering
 	ListJobs(ctx context.Context, filter *ListJobsFilter) ([]*models.Job, error)
 	
-	// CancelJob cancels a pending job
+	// ListJobsPage lists jobs with optional filtering and returns pagination metadata
+	ListJobsPage(ctx context.Context, filter *ListJobsFilter) (*models.JobList, error)
+	
+	// CancelJob cancels a pending or running job; a running job is stopped
+	// by its executor. IsNotFound holds for the error when the job doesn't
+	// exist, IsBadRequest when it already finished.
 	CancelJob(ctx context.Context, jobID uuid.UUID) error
 	
-	// ClaimNextJob claims the next available job for an executor
-	ClaimNextJob(ctx context.Context, executorID, executorIP string) (*models.Job, error)
+	// CancelJobWithReason is like CancelJob, but records why the job was
+	// cancelled; the reason is shown with the job until it is cleaned up
+	CancelJobWithReason(ctx context.Context, jobID uuid.UUID, reason string) error
+	
+	// RequeueJob clones a completed, failed, cancelled or dead-lettered job into a new pending job
+	RequeueJob(ctx context.Context, jobID uuid.UUID) (*models.Job, error)
+	
+	// UpdateJobPriority changes the priority of a pending job
+	UpdateJobPriority(ctx context.Context, jobID uuid.UUID, priority models.Priority) (*models.Job, error)
+	
+	// ClaimNextJob claims the next available job for an executor. Only jobs
+	// whose required capabilities are a subset of capabilities are claimed.
+	// It returns ErrNoJobsAvailable when there is no such job.
+	ClaimNextJob(ctx context.Context, executorID, executorIP string, capabilities []string) (*models.Job, error)
 	
-	// Heartbeat sends a heartbeat for a running job
-	Heartbeat(ctx context.Context, jobID uuid.UUID, executorID string) error
+	// ClaimNextJobWithWait is like ClaimNextJob, but when no job is available
+	// the server waits up to wait for one before returning ErrNoJobsAvailable
+	ClaimNextJobWithWait(ctx context.Context, executorID, executorIP string, capabilities []string, wait time.Duration) (*models.Job, error)
+	
+	// Heartbeat sends a heartbeat for a running job, optionally with the
+	// executor's current resource usage. The response tells whether the job
+	// was cancelled meanwhile and should be stopped.
+	Heartbeat(ctx context.Context, jobID uuid.UUID, heartbeat *models.HeartbeatRequest) (*models.HeartbeatResponse, error)
+	
+	// ReportCancelled sends the output of a job the executor stopped because
+	// the job was cancelled while running
+	ReportCancelled(ctx context.Context, jobID uuid.UUID, result *models.CancelledRequest) error
 	
 	// CompleteJob marks a job as completed
 	CompleteJob(ctx context.Context, jobID uuid.UUID, result *models.CompleteRequest) error
@@ -44,116 +111,1162 @@ type Client interface {
 	// FailJob marks a job as failed
 	FailJob(ctx context.Context, jobID uuid.UUID, result *models.FailRequest) error
 	
+	// AppendJobOutput sends a chunk of live output for a running job
+	AppendJobOutput(ctx context.Context, jobID uuid.UUID, output *models.OutputRequest) error
+	
+	// StreamLogs streams job output until the job reaches a terminal state
+	StreamLogs(ctx context.Context, jobID uuid.UUID) (<-chan LogChunk, error)
+	
+	// ListArtifacts lists the files collected from a job's working directory
+	// after it ran
+	ListArtifacts(ctx context.Context, jobID uuid.UUID) ([]models.Artifact, error)
+	
+	// UploadArtifact stores size bytes read from r as an artifact of a job
+	// running on executorID
+	UploadArtifact(ctx context.Context, jobID uuid.UUID, executorID, name string, r io.Reader, size int64) (*models.Artifact, error)
+	
+	// SubscribeEvents streams job creations and status changes until ctx
+	// is cancelled
+	SubscribeEvents(ctx context.Context, filter *EventFilter) (<-chan models.JobEvent, error)
+	
+	// RegisterExecutor registers an executor with the server
+	RegisterExecutor(ctx context.Context, registration *models.ExecutorRegistration) (*models.Executor, error)
+	
+	// ExecutorHeartbeat reports that a registered executor is alive. It returns
+	// ErrExecutorNotRegistered when the server doesn't know the executor.
+	ExecutorHeartbeat(ctx context.Context, executorID string, heartbeat *models.ExecutorHeartbeatRequest) error
+	
+	// DeregisterExecutor removes an executor's registration
+	DeregisterExecutor(ctx context.Context, executorID string) error
+	
+	// CreateSchedule stores a submission with a cron spec as a recurring job
+	// template; the server submits a new job each time the spec fires
+	CreateSchedule(ctx context.Context, job *models.JobSubmission) (*models.Schedule, error)
+	
+	// ListSchedules lists all recurring job templates
+	ListSchedules(ctx context.Context) ([]*models.Schedule, error)
+	
+	// DeleteSchedule removes a recurring job template
+	DeleteSchedule(ctx context.Context, scheduleID uuid.UUID) error
+	
+	// ListJobTypeLimits lists the concurrency limits of job types together
+	// with how many jobs of each type are running
+	ListJobTypeLimits(ctx context.Context) ([]*models.JobTypeLimit, error)
+	
+	// SetJobTypeLimit caps how many jobs of a type run at once across all
+	// executors; 0 holds back all jobs of the type
+	SetJobTypeLimit(ctx context.Context, jobType string, maxRunning int) (*models.JobTypeLimit, error)
+	
+	// DeleteJobTypeLimit removes the limit of a job type
+	DeleteJobTypeLimit(ctx context.Context, jobType string) error
+	
 	// Health checks the server health
 	Health(ctx context.Context) (*HealthResponse, error)
+	
+	// Version returns the build information of the server
+	Version(ctx context.Context) (*models.VersionInfo, error)
+	
+	// Ping checks that the server is reachable and speaks a compatible API
+	// version. Every other call checks the version as well, Ping only
+	// allows failing fast, e.g. at startup.
+	Ping(ctx context.Context) error
+}
+
+// ListJobsFilter contains filtering options for listing jobs
+type ListJobsFilter struct {
+	// Status is one of pending, running, completed, failed, cancelled,
+	// dead_letter or skipped (see models.Statuses)
+	Status   string
+	Type     string
+	Priority string
+	Limit    int
+	Offset   int
+
+	// Labels only matches jobs that have all of these labels
+	Labels map[string]string
+
+	// ExecutorID only matches jobs claimed by this executor
+	ExecutorID string
+
+	// SortBy is one of created_at, completed_at, priority, type or status
+	// (default created_at); SortOrder is asc or desc (default desc)
+	SortBy    string
+	SortOrder string
+
+	// NoOutput leaves stdout and stderr out of the listed jobs
+	NoOutput bool
+}
+
+// HealthResponse represents the server health status
+type HealthResponse struct {
+	Status   string `json:"status"`
+	Database string `json:"database"`
+
+	// ServerSideHashing is set when the server calculates the SHA256 of
+	// jobs submitted without one
+	ServerSideHashing bool `json:"server_side_hashing,omitempty"`
+
+	// Version is the server's version; servers before it was reported
+	// leave it empty
+	Version string `json:"version,omitempty"`
+
+	// MaxOutputBytesLimit is the server's ceiling for a job's stdout and
+	// stderr size; servers before it was reported leave it 0
+	MaxOutputBytesLimit int `json:"max_output_bytes_limit,omitempty"`
+}
+
+// EventFilter selects the job events a subscription receives; empty fields
+// match any job
+type EventFilter struct {
+	// Status is one of pending, running, completed, failed, cancelled,
+	// dead_letter or skipped (see models.Statuses)
+	Status string
+	Type   string
+
+	// Labels only matches jobs that have all of these labels
+	Labels map[string]string
+}
+
+// LogChunk is a piece of job output received from a log stream
+type LogChunk struct {
+	Stream string `json:"stream"` // "stdout" or "stderr"
+	Data   string `json:"data"`
+}
+
+// ErrorResponse represents an error response from the server
+type ErrorResponse struct {
+	Error   string                 `json:"error"`
+	Context map[string]interface{} `json:"context,omitempty"`
+}
+
+// HTTPClient implements the Client interface using HTTP
+type HTTPClient struct {
+	baseURL      string
+	httpClient   *utils.RetryableHTTPClient
+	streamClient *http.Client // no timeout, used for long-lived streams
+	apiKey       string       // sent as a bearer token when set
+	pollInterval time.Duration
+	tracer       trace.Tracer // nil uses the global tracer provider
+	userAgent    string
+
+	// newRequestID generates the IDs of requests whose context doesn't
+	// carry one
+	newRequestID func() string
+}
+
+// DefaultPollInterval is the initial interval between polls in WaitForJob
+const DefaultPollInterval = 500 * time.Millisecond
+
+// maxPollBackoff caps how far WaitForJob backs off, as a multiple of the
+// poll interval
+const maxPollBackoff = 10
+
+// Option configures an HTTPClient
+type Option func(*HTTPClient)
+
+// WithTimeout bounds each attempt of a request, 30 seconds by default.
+// Streams are not affected.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *HTTPClient) {
+		c.httpClient.SetTimeout(timeout)
+	}
+}
+
+// WithMaxRetries sets how often a failed request is retried, 3 times by
+// default. Only network errors, 5xx and 429 responses are retried.
+func WithMaxRetries(n int) Option {
+	return func(c *HTTPClient) {
+		c.httpClient.SetMaxRetries(n)
+	}
+}
+
+// WithHTTPClient sends requests with a copy of hc, e.g. to use a custom
+// transport or TLS configuration. Requests use hc's timeout unless
+// WithTimeout follows, streams never time out.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *HTTPClient) {
+		client := *hc
+		c.httpClient.SetHTTPClient(&client)
+
+		stream := *hc
+		stream.Timeout = 0
+		c.streamClient = &stream
+	}
+}
+
+// WithAPIKey authenticates every request with the given API key
+func WithAPIKey(key string) Option {
+	return func(c *HTTPClient) {
+		c.apiKey = key
+	}
+}
+
+// WithUserAgent sends userAgent as the User-Agent of every request instead
+// of executr-client/<version>
+func WithUserAgent(userAgent string) Option {
+	return func(c *HTTPClient) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithRequestIDGenerator generates request IDs with generate instead of as
+// random UUIDs. IDs taken from a request's context take precedence.
+func WithRequestIDGenerator(generate func() string) Option {
+	return func(c *HTTPClient) {
+		c.newRequestID = generate
+	}
+}
+
+// WithPollInterval sets the initial interval between polls in WaitForJob
+func WithPollInterval(interval time.Duration) Option {
+	return func(c *HTTPClient) {
+		c.pollInterval = interval
+	}
+}
+
+// WithRetryBudget caps the total time a request spends on retries and
+// backoffs, see utils.RetryableHTTPClient.SetRetryBudget
+func WithRetryBudget(budget time.Duration) Option {
+	return func(c *HTTPClient) {
+		c.httpClient.SetRetryBudget(budget)
+	}
+}
+
+// WithTracerProvider records a client span for every request with tp. The
+// trace context is sent along in the traceparent header either way.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *HTTPClient) {
+		c.tracer = tracing.Tracer(tp)
+	}
+}
+
+// New creates a new HTTP client for the Executr server, configured by opts,
+// e.g.
+//
+//	c := client.New("http://localhost:8080",
+//		client.WithAPIKey(key),
+//		client.WithTimeout(10*time.Second),
+//		client.WithMaxRetries(5),
+//	)
+func New(baseURL string, opts ...Option) Client {
+	// Ensure baseURL doesn't end with a slash
+	baseURL = strings.TrimRight(baseURL, "/")
+	
+	c := &HTTPClient{
+		baseURL:      baseURL,
+		httpClient:   utils.NewRetryableHTTPClient(),
+		streamClient: &http.Client{},
+		pollInterval: DefaultPollInterval,
+		userAgent:    utils.DefaultUserAgent,
+		newRequestID: uuid.NewString,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// NewClient creates a new HTTP client for the Executr server. It is the
+// same as New.
+func NewClient(baseURL string, opts ...Option) Client {
+	return New(baseURL, opts...)
+}
+
+// NewClientWithOptions creates a new HTTP client with the given retries and
+// timeout. It is the same as New with WithMaxRetries and WithTimeout.
+func NewClientWithOptions(baseURL string, maxRetries int, timeout time.Duration, opts ...Option) Client {
+	return New(baseURL, append([]Option{WithMaxRetries(maxRetries), WithTimeout(timeout)}, opts...)...)
+}
+
+// do sends a request through the retrying HTTP client
+func (c *HTTPClient) do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	id := c.prepare(req)
+	span := c.startSpan(req)
+	defer span.End()
+
+	resp, err := c.httpClient.DoWithContext(ctx, req)
+	endSpan(span, resp, err)
+	if err != nil {
+		return nil, fmt.Errorf("%w (request id %s)", err, id)
+	}
+	if err := checkAPIVersion(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// doStream sends a request for a long-lived response stream
+func (c *HTTPClient) doStream(req *http.Request) (*http.Response, error) {
+	id := c.prepare(req)
+	span := c.startSpan(req)
+	defer span.End()
+
+	resp, err := c.streamClient.Do(req)
+	endSpan(span, resp, err)
+	if err != nil {
+		return nil, fmt.Errorf("%w (request id %s)", err, id)
+	}
+	if err := checkAPIVersion(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// startSpan starts a client span for req as a child of the span in the
+// request's context, and sends the trace context along with the request
+func (c *HTTPClient) startSpan(req *http.Request) trace.Span {
+	tracer := c.tracer
+	if tracer == nil {
+		tracer = tracing.Tracer(nil)
+	}
+	ctx, span := tracer.Start(req.Context(), req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("url.full", req.URL.String()),
+			attribute.String("executr.request_id", req.Header.Get(utils.RequestIDHeader)),
+		),
+	)
+	tracing.Inject(ctx, req.Header)
+	return span
+}
+
+// endSpan records the outcome of a request in its span
+func endSpan(span trace.Span, resp *http.Response, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+}
+
+// prepare sets the headers every request carries and returns the request's
+// ID
+func (c *HTTPClient) prepare(req *http.Request) string {
+	c.authorize(req)
+	req.Header.Set(utils.APIVersionHeader, utils.CurrentAPIVersion.String())
+	req.Header.Set("User-Agent", c.userAgent)
+	return c.setRequestID(req)
+}
+
+// setRequestID tags the request with the request ID of its context, or a
+// new one, so that the server's log lines for it can be found. It returns
+// the ID.
+func (c *HTTPClient) setRequestID(req *http.Request) string {
+	id := utils.RequestIDFromContext(req.Context())
+	if id == "" {
+		id = c.newRequestID()
+	}
+	req.Header.Set(utils.RequestIDHeader, id)
+	return id
+}
+
+// checkAPIVersion fails with ErrIncompatibleVersion when the server doesn't
+// support the API version of this client. Servers that don't advertise
+// their versions predate the negotiation and speak version 1.0.
+func checkAPIVersion(resp *http.Response) error {
+	maxHeader := resp.Header.Get(utils.APIVersionHeader)
+	if maxHeader == "" {
+		return nil
+	}
+
+	max, err := utils.ParseAPIVersion(maxHeader)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrIncompatibleVersion, err)
+	}
+	min := max
+	if minHeader := resp.Header.Get(utils.MinAPIVersionHeader); minHeader != "" {
+		if min, err = utils.ParseAPIVersion(minHeader); err != nil {
+			return fmt.Errorf("%w: %v", ErrIncompatibleVersion, err)
+		}
+	}
+
+	if !utils.CurrentAPIVersion.CompatibleWith(min, max) {
+		return fmt.Errorf("%w: client speaks API version %s, server supports %s to %s",
+			ErrIncompatibleVersion, utils.CurrentAPIVersion, min, max)
+	}
+	return nil
+}
+
+// authorize adds the API key to the request
+func (c *HTTPClient) authorize(req *http.Request) {
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+}
+
+// SubmitJob submits a new job to the server
+func (c *HTTPClient) SubmitJob(ctx context.Context, job *models.JobSubmission) (*models.Job, error) {
+	return c.SubmitJobWithKey(ctx, job, "")
+}
+
+// SubmitJobWithKey submits a job with an idempotency key; an empty key
+// behaves like SubmitJob
+func (c *HTTPClient) SubmitJobWithKey(ctx context.Context, job *models.JobSubmission, idempotencyKey string) (*models.Job, error) {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v1/jobs", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// 200 means the key matched a job submitted earlier
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var result models.Job
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// ValidateJob submits a job as a dry run. The server downloads and hashes the
+// binary but doesn't create the job.
+func (c *HTTPClient) ValidateJob(ctx context.Context, job *models.JobSubmission) (*models.ValidationResult, error) {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v1/jobs?dry_run=true", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var result models.ValidationResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// GetJob retrieves a job by ID
+func (c *HTTPClient) GetJob(ctx context.Context, jobID uuid.UUID) (*models.Job, error) {
+	job, _, err := c.getJob(ctx, c.baseURL+"/api/v1/jobs/"+jobID.String(), "")
+	return job, err
+}
+
+// GetJobIfChanged retrieves a job unless it is unchanged since the response
+// that carried etag
+func (c *HTTPClient) GetJobIfChanged(ctx context.Context, jobID uuid.UUID, etag string) (*models.Job, string, error) {
+	return c.getJob(ctx, c.baseURL+"/api/v1/jobs/"+jobID.String(), etag)
+}
+
+// GetJobMetadata retrieves a job by ID without its stdout and stderr
+func (c *HTTPClient) GetJobMetadata(ctx context.Context, jobID uuid.UUID) (*models.Job, error) {
+	job, _, err := c.getJob(ctx, c.baseURL+"/api/v1/jobs/"+jobID.String()+"?no_output=true", "")
+	return job, err
+}
+
+// getJob fetches the job at reqURL and its ETag. A non-empty etag makes the
+// request conditional.
+func (c *HTTPClient) getJob(ctx context.Context, reqURL, etag string) (*models.Job, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return nil, "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, etag, ErrNotModified
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", c.parseError(resp)
+	}
+
+	var result models.Job
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, resp.Header.Get("ETag"), nil
+}
+
+// GetJobOutput retrieves only the output and exit code of a job
+func (c *HTTPClient) GetJobOutput(ctx context.Context, jobID uuid.UUID) (*models.JobOutput, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/v1/jobs/"+jobID.String()+"/output", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var result models.JobOutput
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
 }
 
-// ListJobsFilter contains filtering options for listing jobs
-type ListJobsFilter struct {
-	Status   string
-	Type     string
-	Priority string
-	Limit    int
-	Offset   int
-}
+// JobExists reports whether a job exists and its status, using a HEAD
+// request
+func (c *HTTPClient) JobExists(ctx context.Context, jobID uuid.UUID) (bool, models.Status, error) {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", c.baseURL+"/api/v1/jobs/"+jobID.String(), nil)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return false, "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, models.Status(resp.Header.Get("X-Job-Status")), nil
+	case http.StatusNotFound:
+		return false, "", nil
+	default:
+		// Answers to HEAD requests have no body to parse
+		return false, "", &APIError{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode), RequestID: responseRequestID(resp)}
+	}
+}
+
+// WaitForJob polls a job until it reaches one of terminalStates, or any
+// terminal state if none are given. Polling starts at the client's poll
+// interval and backs off up to ten times that. It returns ctx's error if ctx
+// ends first.
+func (c *HTTPClient) WaitForJob(ctx context.Context, jobID uuid.UUID, terminalStates ...models.Status) (*models.Job, error) {
+	// Only download the job again when it changed since the last poll
+	var (
+		cached *models.Job
+		etag   string
+	)
+	getJob := func(ctx context.Context, jobID uuid.UUID) (*models.Job, error) {
+		job, newETag, err := c.GetJobIfChanged(ctx, jobID, etag)
+		if IsNotModified(err) {
+			return cached, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		cached, etag = job, newETag
+		return job, nil
+	}
+
+	return waitForJob(ctx, getJob, jobID, c.pollInterval, terminalStates)
+}
+
+// SubmitAndWait submits a job and waits until it is terminal. A failed job
+// is returned without an error.
+func (c *HTTPClient) SubmitAndWait(ctx context.Context, job *models.JobSubmission) (*models.Job, error) {
+	return submitAndWait(ctx, c, job)
+}
+
+// submitAndWait implements SubmitAndWait on top of a Client
+func submitAndWait(ctx context.Context, c Client, submission *models.JobSubmission) (*models.Job, error) {
+	job, err := c.SubmitJob(ctx, submission)
+	if err != nil {
+		return nil, err
+	}
+
+	// Waiting for any terminal state rather than just completed, failed and
+	// cancelled keeps a skipped job from blocking forever
+	return c.WaitForJob(ctx, job.ID)
+}
+
+// waitForJob implements WaitForJob on top of a GetJob function
+func waitForJob(ctx context.Context, getJob func(context.Context, uuid.UUID) (*models.Job, error), jobID uuid.UUID, interval time.Duration, terminalStates []models.Status) (*models.Job, error) {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	maxInterval := interval * maxPollBackoff
+
+	for {
+		job, err := getJob(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+		if isWaitedFor(job.Status, terminalStates) {
+			return job, nil
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		interval = min(interval*3/2, maxInterval)
+	}
+}
+
+// isWaitedFor reports whether status is one of states, or terminal when
+// states is empty
+func isWaitedFor(status models.Status, states []models.Status) bool {
+	if len(states) == 0 {
+		return status.IsTerminal()
+	}
+	for _, state := range states {
+		if status == state {
+			return true
+		}
+	}
+	return false
+}
+
+// ListJobs lists jobs with optional filtering
+func (c *HTTPClient) ListJobs(ctx context.Context, filter *ListJobsFilter) ([]*models.Job, error) {
+	result, err := c.ListJobsPage(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return jobPointers(result.Jobs), nil
+}
+
+// jobPointers returns pointers to the jobs of a page
+func jobPointers(jobs []models.Job) []*models.Job {
+	pointers := make([]*models.Job, len(jobs))
+	for i := range jobs {
+		pointers[i] = &jobs[i]
+	}
+	return pointers
+}
+
+// ListJobsPage lists jobs with optional filtering and returns pagination metadata
+func (c *HTTPClient) ListJobsPage(ctx context.Context, filter *ListJobsFilter) (*models.JobList, error) {
+	params := url.Values{}
+	if filter != nil {
+		if filter.Status != "" {
+			params.Set("status", filter.Status)
+		}
+		if filter.Type != "" {
+			params.Set("type", filter.Type)
+		}
+		if filter.Priority != "" {
+			params.Set("priority", filter.Priority)
+		}
+		for key, value := range filter.Labels {
+			params.Add("label", key+"="+value)
+		}
+		if filter.ExecutorID != "" {
+			params.Set("executor_id", filter.ExecutorID)
+		}
+		if filter.Limit > 0 {
+			params.Set("limit", strconv.Itoa(filter.Limit))
+		}
+		if filter.Offset > 0 {
+			params.Set("offset", strconv.Itoa(filter.Offset))
+		}
+		if filter.SortBy != "" {
+			params.Set("sort", filter.SortBy)
+		}
+		if filter.SortOrder != "" {
+			params.Set("order", filter.SortOrder)
+		}
+		if filter.NoOutput {
+			params.Set("no_output", "true")
+		}
+	}
+
+	reqURL := c.baseURL + "/api/v1/jobs"
+	if len(params) > 0 {
+		reqURL += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	// Servers before pagination metadata was added return a bare array
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		var jobs []models.Job
+		if err := json.Unmarshal(trimmed, &jobs); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		result := &models.JobList{Jobs: jobs, Total: int64(len(jobs)), Limit: len(jobs)}
+		if filter != nil {
+			result.Limit = filter.Limit
+			result.Offset = filter.Offset
+		}
+		return result, nil
+	}
+
+	var result models.JobList
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// CancelJob cancels a pending or running job
+func (c *HTTPClient) CancelJob(ctx context.Context, jobID uuid.UUID) error {
+	return c.CancelJobWithReason(ctx, jobID, "")
+}
+
+// CancelJobWithReason cancels a pending or running job and records why
+func (c *HTTPClient) CancelJobWithReason(ctx context.Context, jobID uuid.UUID, reason string) error {
+	var body io.Reader
+	if reason != "" {
+		data, err := json.Marshal(models.CancelJobRequest{Reason: reason})
+		if err != nil {
+			return fmt.Errorf("failed to marshal cancel request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", c.baseURL+"/api/v1/jobs/"+jobID.String(), body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil
+	case http.StatusNotFound:
+		return ErrJobNotFound
+	case http.StatusConflict:
+		// The job can't be cancelled in its current state
+		return fmt.Errorf("%w: %w", ErrBadRequest, c.parseError(resp))
+	default:
+		return c.parseError(resp)
+	}
+}
+
+// RequeueJob clones a completed, failed, cancelled or dead-lettered job into a new pending job
+func (c *HTTPClient) RequeueJob(ctx context.Context, jobID uuid.UUID) (*models.Job, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v1/jobs/"+jobID.String()+"/requeue", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, c.parseError(resp)
+	}
+
+	var result models.Job
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// UpdateJobPriority changes the priority of a pending job
+func (c *HTTPClient) UpdateJobPriority(ctx context.Context, jobID uuid.UUID, priority models.Priority) (*models.Job, error) {
+	body, err := json.Marshal(models.UpdateJobRequest{Priority: priority})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal update request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PATCH", c.baseURL+"/api/v1/jobs/"+jobID.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var result models.Job
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// ClaimNextJob claims the next available job for an executor
+func (c *HTTPClient) ClaimNextJob(ctx context.Context, executorID, executorIP string, capabilities []string) (*models.Job, error) {
+	return c.ClaimNextJobWithWait(ctx, executorID, executorIP, capabilities, 0)
+}
+
+// ClaimNextJobWithWait claims the next available job, long-polling for up
+// to wait when there is none. The wait must stay below the request timeout.
+func (c *HTTPClient) ClaimNextJobWithWait(ctx context.Context, executorID, executorIP string, capabilities []string, wait time.Duration) (*models.Job, error) {
+	claim := models.ClaimRequest{
+		ExecutorID:   executorID,
+		ExecutorIP:   executorIP,
+		Capabilities: capabilities,
+	}
+
+	body, err := json.Marshal(claim)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal claim request: %w", err)
+	}
+
+	reqURL := c.baseURL + "/api/v1/jobs/claim"
+	if wait > 0 {
+		reqURL += "?" + url.Values{"wait": {wait.String()}}.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// No content means no jobs available
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, ErrNoJobsAvailable
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var result models.Job
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// Heartbeat sends a heartbeat for a running job
+func (c *HTTPClient) Heartbeat(ctx context.Context, jobID uuid.UUID, heartbeat *models.HeartbeatRequest) (*models.HeartbeatResponse, error) {
+	body, err := json.Marshal(heartbeat)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal heartbeat request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", c.baseURL+"/api/v1/jobs/"+jobID.String()+"/heartbeat", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return &models.HeartbeatResponse{}, nil
+	case http.StatusOK:
+		// Only sent when the job was cancelled
+		var result models.HeartbeatResponse
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		return &result, nil
+	default:
+		return nil, c.parseError(resp)
+	}
+}
+
+// ReportCancelled sends the output of a job stopped after its cancellation
+func (c *HTTPClient) ReportCancelled(ctx context.Context, jobID uuid.UUID, result *models.CancelledRequest) error {
+	body, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cancelled request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", c.baseURL+"/api/v1/jobs/"+jobID.String()+"/cancelled", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return c.parseError(resp)
+	}
+
+	return nil
+}
+
+// CompleteJob marks a job as completed
+func (c *HTTPClient) CompleteJob(ctx context.Context, jobID uuid.UUID, result *models.CompleteRequest) error {
+	body, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal complete request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", c.baseURL+"/api/v1/jobs/"+jobID.String()+"/complete", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return c.parseError(resp)
+	}
+
+	return nil
+}
+
+// FailJob marks a job as failed
+func (c *HTTPClient) FailJob(ctx context.Context, jobID uuid.UUID, result *models.FailRequest) error {
+	body, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal fail request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", c.baseURL+"/api/v1/jobs/"+jobID.String()+"/fail", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return c.parseError(resp)
+	}
 
-// HealthResponse represents the server health status
-type HealthResponse struct {
-	Status   string `json:"status"`
-	Database string `json:"database"`
+	return nil
 }
 
-// ErrorResponse represents an error response from the server
-type ErrorResponse struct {
-	Error   string                 `json:"error"`
-	Context map[string]interface{} `json:"context,omitempty"`
-}
+// AppendJobOutput sends a chunk of live output for a running job
+func (c *HTTPClient) AppendJobOutput(ctx context.Context, jobID uuid.UUID, output *models.OutputRequest) error {
+	body, err := json.Marshal(output)
+	if err != nil {
+		return fmt.Errorf("failed to marshal output request: %w", err)
+	}
 
-// HTTPClient implements the Client interface using HTTP
-type HTTPClient struct {
-	baseURL    string
-	httpClient *utils.RetryableHTTPClient
-}
+	req, err := http.NewRequestWithContext(ctx, "PUT", c.baseURL+"/api/v1/jobs/"+jobID.String()+"/output", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
 
-// New creates a new HTTP client for the Executr server (simplified alias)
-func New(baseURL string) Client {
-	return NewClient(baseURL)
-}
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
 
-// NewClient creates a new HTTP client for the Executr server
-func NewClient(baseURL string) Client {
-	// Ensure baseURL doesn't end with a slash
-	baseURL = strings.TrimRight(baseURL, "/")
-	
-	return &HTTPClient{
-		baseURL:    baseURL,
-		httpClient: utils.NewRetryableHTTPClient(),
+	if resp.StatusCode != http.StatusNoContent {
+		return c.parseError(resp)
 	}
+
+	return nil
 }
 
-// NewClientWithOptions creates a new HTTP client with custom options
-func NewClientWithOptions(baseURL string, maxRetries int, timeout time.Duration) Client {
-	baseURL = strings.TrimRight(baseURL, "/")
-	
-	httpClient := utils.NewRetryableHTTPClient()
-	httpClient.SetMaxRetries(maxRetries)
-	httpClient.SetTimeout(timeout)
-	
-	return &HTTPClient{
-		baseURL:    baseURL,
-		httpClient: httpClient,
+// StreamLogs streams job output as it is produced. The returned channel is
+// closed when the job reaches a terminal state, the stream ends, or ctx is
+// cancelled.
+func (c *HTTPClient) StreamLogs(ctx context.Context, jobID uuid.UUID) (<-chan LogChunk, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/v1/jobs/"+jobID.String()+"/logs?follow=true", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-}
+	req.Header.Set("Accept", "text/event-stream")
 
-// SubmitJob submits a new job to the server
-func (c *HTTPClient) SubmitJob(ctx context.Context, job *models.JobSubmission) (*models.Job, error) {
-	body, err := json.Marshal(job)
+	resp, err := c.doStream(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal job: %w", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v1/jobs", bytes.NewReader(body))
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, c.parseError(resp)
+	}
+
+	chunks := make(chan LogChunk)
+	go func() {
+		defer close(chunks)
+		defer resp.Body.Close()
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+		var event string
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				if event == "end" {
+					return
+				}
+				if event != "output" {
+					continue
+				}
+				var chunk LogChunk
+				if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &chunk); err != nil {
+					return
+				}
+				select {
+				case chunks <- chunk:
+				case <-ctx.Done():
+					return
+				}
+			case line == "":
+				event = ""
+			}
+		}
+	}()
+
+	return chunks, nil
+}
+
+// ListArtifacts lists the files collected from a job. Artifacts stored on
+// the server get absolute URLs pointing to it; downloading them needs the
+// same API key.
+func (c *HTTPClient) ListArtifacts(ctx context.Context, jobID uuid.UUID) ([]models.Artifact, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/v1/jobs/"+jobID.String()+"/artifacts", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.DoWithContext(ctx, req)
+	resp, err := c.do(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusCreated {
+	if resp.StatusCode != http.StatusOK {
 		return nil, c.parseError(resp)
 	}
 
-	var result models.Job
+	var result []models.Artifact
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return &result, nil
+	for i := range result {
+		if strings.HasPrefix(result[i].URL, "/") {
+			result[i].URL = c.baseURL + result[i].URL
+		}
+	}
+
+	return result, nil
 }
 
-// GetJob retrieves a job by ID
-func (c *HTTPClient) GetJob(ctx context.Context, jobID uuid.UUID) (*models.Job, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/v1/jobs/"+jobID.String(), nil)
+// UploadArtifact stores an artifact on the server. The body is streamed, so
+// the request is not retried.
+func (c *HTTPClient) UploadArtifact(ctx context.Context, jobID uuid.UUID, executorID, name string, r io.Reader, size int64) (*models.Artifact, error) {
+	params := url.Values{}
+	params.Set("executor_id", executorID)
+	artifactPath := (&url.URL{Path: "/api/v1/jobs/" + jobID.String() + "/artifacts/" + name}).EscapedPath()
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", c.baseURL+artifactPath+"?"+params.Encode(), r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
 
-	resp, err := c.httpClient.DoWithContext(ctx, req)
+	resp, err := c.doStream(req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode != http.StatusCreated {
 		return nil, c.parseError(resp)
 	}
 
-	var result models.Job
+	var result models.Artifact
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
@@ -161,8 +1274,12 @@ func (c *HTTPClient) GetJob(ctx context.Context, jobID uuid.UUID) (*models.Job,
 	return &result, nil
 }
 
-// ListJobs lists jobs with optional filtering
-func (c *HTTPClient) ListJobs(ctx context.Context, filter *ListJobsFilter) ([]*models.Job, error) {
+// SubscribeEvents streams job events as jobs are created and change status.
+// The server drops events for subscribers that don't keep up, so consumers
+// should read the channel promptly and re-list jobs if they need an exact
+// picture. The returned channel is closed when the stream ends or ctx is
+// cancelled.
+func (c *HTTPClient) SubscribeEvents(ctx context.Context, filter *EventFilter) (<-chan models.JobEvent, error) {
 	params := url.Values{}
 	if filter != nil {
 		if filter.Status != "" {
@@ -171,18 +1288,12 @@ func (c *HTTPClient) ListJobs(ctx context.Context, filter *ListJobsFilter) ([]*m
 		if filter.Type != "" {
 			params.Set("type", filter.Type)
 		}
-		if filter.Priority != "" {
-			params.Set("priority", filter.Priority)
-		}
-		if filter.Limit > 0 {
-			params.Set("limit", strconv.Itoa(filter.Limit))
-		}
-		if filter.Offset > 0 {
-			params.Set("offset", strconv.Itoa(filter.Offset))
+		for key, value := range filter.Labels {
+			params.Add("label", key+"="+value)
 		}
 	}
 
-	reqURL := c.baseURL + "/api/v1/jobs"
+	reqURL := c.baseURL + "/api/v1/jobs/events"
 	if len(params) > 0 {
 		reqURL += "?" + params.Encode()
 	}
@@ -191,38 +1302,107 @@ func (c *HTTPClient) ListJobs(ctx context.Context, filter *ListJobsFilter) ([]*m
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	req.Header.Set("Accept", "text/event-stream")
 
-	resp, err := c.httpClient.DoWithContext(ctx, req)
+	resp, err := c.doStream(req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, c.parseError(resp)
+	}
+
+	events := make(chan models.JobEvent)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+		var event string
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				if event != "job" {
+					continue
+				}
+				var jobEvent models.JobEvent
+				if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &jobEvent); err != nil {
+					return
+				}
+				select {
+				case events <- jobEvent:
+				case <-ctx.Done():
+					return
+				}
+			case line == "":
+				event = ""
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// RegisterExecutor registers an executor with the server
+func (c *HTTPClient) RegisterExecutor(ctx context.Context, registration *models.ExecutorRegistration) (*models.Executor, error) {
+	body, err := json.Marshal(registration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal registration: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v1/executors", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
 		return nil, c.parseError(resp)
 	}
 
-	var result []*models.Job
+	var result models.Executor
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return result, nil
+	return &result, nil
 }
 
-// CancelJob cancels a pending job
-func (c *HTTPClient) CancelJob(ctx context.Context, jobID uuid.UUID) error {
-	req, err := http.NewRequestWithContext(ctx, "DELETE", c.baseURL+"/api/v1/jobs/"+jobID.String(), nil)
+// ExecutorHeartbeat reports that a registered executor is alive
+func (c *HTTPClient) ExecutorHeartbeat(ctx context.Context, executorID string, heartbeat *models.ExecutorHeartbeatRequest) error {
+	body, err := json.Marshal(heartbeat)
+	if err != nil {
+		return fmt.Errorf("failed to marshal heartbeat request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", c.baseURL+"/api/v1/executors/"+url.PathEscape(executorID)+"/heartbeat", bytes.NewReader(body))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
+	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.DoWithContext(ctx, req)
+	resp, err := c.do(ctx, req)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrExecutorNotRegistered
+	}
 	if resp.StatusCode != http.StatusNoContent {
 		return c.parseError(resp)
 	}
@@ -230,40 +1410,59 @@ func (c *HTTPClient) CancelJob(ctx context.Context, jobID uuid.UUID) error {
 	return nil
 }
 
-// ClaimNextJob claims the next available job for an executor
-func (c *HTTPClient) ClaimNextJob(ctx context.Context, executorID, executorIP string) (*models.Job, error) {
-	claim := models.ClaimRequest{
-		ExecutorID: executorID,
-		ExecutorIP: executorIP,
+// DeregisterExecutor removes an executor's registration
+func (c *HTTPClient) DeregisterExecutor(ctx context.Context, executorID string) error {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", c.baseURL+"/api/v1/executors/"+url.PathEscape(executorID), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	body, err := json.Marshal(claim)
+	resp, err := c.do(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal claim request: %w", err)
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrExecutorNotRegistered
+	}
+	if resp.StatusCode != http.StatusNoContent {
+		return c.parseError(resp)
+	}
+
+	return nil
+}
+
+// CreateSchedule stores a submission with a cron spec as a recurring job
+// template. It is submitted like a job; the server stores it as a schedule
+// because the cron spec is set.
+func (c *HTTPClient) CreateSchedule(ctx context.Context, job *models.JobSubmission) (*models.Schedule, error) {
+	if job.CronSpec == "" {
+		return nil, fmt.Errorf("cron spec is required")
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v1/jobs/claim", bytes.NewReader(body))
+	body, err := json.Marshal(job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v1/jobs", bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.DoWithContext(ctx, req)
+	resp, err := c.do(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	// No content means no jobs available
-	if resp.StatusCode == http.StatusNoContent {
-		return nil, nil
-	}
-
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode != http.StatusCreated {
 		return nil, c.parseError(resp)
 	}
 
-	var result models.Job
+	var result models.Schedule
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
@@ -271,24 +1470,39 @@ func (c *HTTPClient) ClaimNextJob(ctx context.Context, executorID, executorIP st
 	return &result, nil
 }
 
-// Heartbeat sends a heartbeat for a running job
-func (c *HTTPClient) Heartbeat(ctx context.Context, jobID uuid.UUID, executorID string) error {
-	heartbeat := models.HeartbeatRequest{
-		ExecutorID: executorID,
+// ListSchedules lists all recurring job templates
+func (c *HTTPClient) ListSchedules(ctx context.Context) ([]*models.Schedule, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/v1/schedules", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	body, err := json.Marshal(heartbeat)
+	resp, err := c.do(ctx, req)
 	if err != nil {
-		return fmt.Errorf("failed to marshal heartbeat request: %w", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
+	defer resp.Body.Close()
 
-	req, err := http.NewRequestWithContext(ctx, "PUT", c.baseURL+"/api/v1/jobs/"+jobID.String()+"/heartbeat", bytes.NewReader(body))
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var result []*models.Schedule
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return result, nil
+}
+
+// DeleteSchedule removes a recurring job template
+func (c *HTTPClient) DeleteSchedule(ctx context.Context, scheduleID uuid.UUID) error {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", c.baseURL+"/api/v1/schedules/"+scheduleID.String(), nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.DoWithContext(ctx, req)
+	resp, err := c.do(ctx, req)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
@@ -301,46 +1515,70 @@ func (c *HTTPClient) Heartbeat(ctx context.Context, jobID uuid.UUID, executorID
 	return nil
 }
 
-// CompleteJob marks a job as completed
-func (c *HTTPClient) CompleteJob(ctx context.Context, jobID uuid.UUID, result *models.CompleteRequest) error {
-	body, err := json.Marshal(result)
+// ListJobTypeLimits lists the concurrency limits of job types
+func (c *HTTPClient) ListJobTypeLimits(ctx context.Context) ([]*models.JobTypeLimit, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/v1/admin/job-type-limits", nil)
 	if err != nil {
-		return fmt.Errorf("failed to marshal complete request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "PUT", c.baseURL+"/api/v1/jobs/"+jobID.String()+"/complete", bytes.NewReader(body))
+	resp, err := c.do(ctx, req)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
+	defer resp.Body.Close()
 
-	resp, err := c.httpClient.DoWithContext(ctx, req)
-	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusNoContent {
-		return c.parseError(resp)
+	var result []*models.JobTypeLimit
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return nil
+	return result, nil
 }
 
-// FailJob marks a job as failed
-func (c *HTTPClient) FailJob(ctx context.Context, jobID uuid.UUID, result *models.FailRequest) error {
-	body, err := json.Marshal(result)
+// SetJobTypeLimit creates or changes the concurrency limit of a job type
+func (c *HTTPClient) SetJobTypeLimit(ctx context.Context, jobType string, maxRunning int) (*models.JobTypeLimit, error) {
+	body, err := json.Marshal(models.JobTypeLimitRequest{MaxRunning: maxRunning})
 	if err != nil {
-		return fmt.Errorf("failed to marshal fail request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "PUT", c.baseURL+"/api/v1/jobs/"+jobID.String()+"/fail", bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, "PUT", c.baseURL+"/api/v1/admin/job-type-limits/"+url.PathEscape(jobType), bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.DoWithContext(ctx, req)
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var result models.JobTypeLimit
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// DeleteJobTypeLimit removes the concurrency limit of a job type
+func (c *HTTPClient) DeleteJobTypeLimit(ctx context.Context, jobType string) error {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", c.baseURL+"/api/v1/admin/job-type-limits/"+url.PathEscape(jobType), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(ctx, req)
 	if err != nil {
 		return fmt.Errorf("request failed: %w", err)
 	}
@@ -360,7 +1598,7 @@ func (c *HTTPClient) Health(ctx context.Context) (*HealthResponse, error) {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	resp, err := c.httpClient.DoWithContext(ctx, req)
+	resp, err := c.do(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -378,21 +1616,65 @@ func (c *HTTPClient) Health(ctx context.Context) (*HealthResponse, error) {
 	return &result, nil
 }
 
-// parseError parses an error response from the server
+// Version returns the build information of the server
+func (c *HTTPClient) Version(ctx context.Context) (*models.VersionInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/api/v1/version", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.parseError(resp)
+	}
+
+	var result models.VersionInfo
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// Ping checks that the server is reachable and speaks a compatible API
+// version
+func (c *HTTPClient) Ping(ctx context.Context) error {
+	_, err := c.Version(ctx)
+	return err
+}
+
+// parseError turns an error response from the server into an *APIError, so
+// IsNotFound, IsConflict and the other predicates can inspect its status
 func (c *HTTPClient) parseError(resp *http.Response) error {
+	requestID := responseRequestID(resp)
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("server returned status %d", resp.StatusCode)
+		return &APIError{StatusCode: resp.StatusCode, Message: "failed to read error response", RequestID: requestID}
 	}
 
 	var errResp ErrorResponse
 	if err := json.Unmarshal(body, &errResp); err != nil {
 		// If we can't parse the error, return the raw body
-		return fmt.Errorf("server returned status %d: %s", resp.StatusCode, string(body))
+		return &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body)), RequestID: requestID}
 	}
 
-	if errResp.Context != nil {
-		return fmt.Errorf("%s (context: %v)", errResp.Error, errResp.Context)
+	return &APIError{StatusCode: resp.StatusCode, Message: errResp.Error, Context: errResp.Context, RequestID: requestID}
+}
+
+// responseRequestID returns the request ID the server answered with, falling
+// back to the one the request was sent with
+func responseRequestID(resp *http.Response) string {
+	if id := resp.Header.Get(utils.RequestIDHeader); id != "" {
+		return id
+	}
+	if resp.Request != nil {
+		return resp.Request.Header.Get(utils.RequestIDHeader)
 	}
-	return fmt.Errorf("%s", errResp.Error)
+	return ""
 }
\ No newline at end of file
@@ -0,0 +1,58 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/draganm/executr/pkg/client"
+)
+
+// roundTripCounter counts the requests sent through it
+type roundTripCounter struct {
+	count atomic.Int32
+}
+
+func (rt *roundTripCounter) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.count.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestOptions(t *testing.T) {
+	var userAgent, requestID, apiKey string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		requestID = r.Header.Get("X-Request-ID")
+		apiKey = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	transport := &roundTripCounter{}
+	c := client.New(srv.URL,
+		client.WithHTTPClient(&http.Client{Transport: transport}),
+		client.WithMaxRetries(0),
+		client.WithAPIKey("secret"),
+		client.WithUserAgent("test-agent/1.0"),
+		client.WithRequestIDGenerator(func() string { return "generated-id" }),
+	)
+
+	if _, err := c.Health(context.Background()); err == nil {
+		t.Fatal("expected an error for a 503 response")
+	}
+
+	if got := transport.count.Load(); got != 1 {
+		t.Errorf("expected a single request through the custom transport, got %d", got)
+	}
+	if userAgent != "test-agent/1.0" {
+		t.Errorf("got user agent %q", userAgent)
+	}
+	if requestID != "generated-id" {
+		t.Errorf("got request ID %q", requestID)
+	}
+	if apiKey != "Bearer secret" {
+		t.Errorf("got authorization %q", apiKey)
+	}
+}
\ No newline at end of file
@@ -14,6 +14,9 @@ var (
 	// ErrNoJobsAvailable indicates that no jobs are available to claim
 	ErrNoJobsAvailable = errors.New("no jobs available")
 	
+	// ErrExecutorNotRegistered indicates that the server doesn't know the executor
+	ErrExecutorNotRegistered = errors.New("executor not registered")
+	
 	// ErrUnauthorized indicates that the request was unauthorized
 	ErrUnauthorized = errors.New("unauthorized")
 	
@@ -23,23 +26,42 @@ var (
 	// ErrBadRequest indicates a malformed request
 	ErrBadRequest = errors.New("bad request")
 	
+	// ErrConflict indicates the request conflicts with the current job state
+	ErrConflict = errors.New("conflict")
+	
 	// ErrNetworkError indicates a network-related error
 	ErrNetworkError = errors.New("network error")
+	
+	// ErrNotModified indicates that a resource didn't change since the
+	// version identified by the ETag of a conditional request
+	ErrNotModified = errors.New("not modified")
+	
+	// ErrIncompatibleVersion indicates that the server doesn't support the
+	// API version of the client
+	ErrIncompatibleVersion = errors.New("incompatible API version")
 )
 
-// APIError represents a detailed error from the API
+// APIError represents a detailed error from the API. HTTPClient returns it
+// for every error response of the server.
 type APIError struct {
 	StatusCode int
 	Message    string
 	Context    map[string]interface{}
+
+	// RequestID identifies the request in the server's logs
+	RequestID string
 }
 
 // Error implements the error interface
 func (e *APIError) Error() string {
+	msg := fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Message)
 	if e.Context != nil {
-		return fmt.Sprintf("API error (status %d): %s, context: %v", e.StatusCode, e.Message, e.Context)
+		msg += fmt.Sprintf(", context: %v", e.Context)
 	}
-	return fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Message)
+	if e.RequestID != "" {
+		msg += fmt.Sprintf(" (request id %s)", e.RequestID)
+	}
+	return msg
 }
 
 // IsNotFound checks if the error indicates a not found condition
@@ -61,6 +83,17 @@ func IsNoJobsAvailable(err error) bool {
 	return errors.Is(err, ErrNoJobsAvailable)
 }
 
+// IsNotModified checks if the error indicates an unchanged resource
+func IsNotModified(err error) bool {
+	return errors.Is(err, ErrNotModified)
+}
+
+// IsIncompatibleVersion checks if the error indicates that the client and
+// the server don't share an API version
+func IsIncompatibleVersion(err error) bool {
+	return errors.Is(err, ErrIncompatibleVersion)
+}
+
 // IsServerError checks if the error is a server-side error
 func IsServerError(err error) bool {
 	if errors.Is(err, ErrServerError) {
@@ -92,4 +125,18 @@ func IsBadRequest(err error) bool {
 // IsNetworkError checks if the error is network-related
 func IsNetworkError(err error) bool {
 	return errors.Is(err, ErrNetworkError)
+}
+
+// IsConflict checks if the error is due to a conflict with the job state
+func IsConflict(err error) bool {
+	if errors.Is(err, ErrConflict) {
+		return true
+	}
+	
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusConflict
+	}
+	
+	return false
 }
\ No newline at end of file
@@ -0,0 +1,132 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/draganm/executr/pkg/client"
+)
+
+// errorServer answers every request with status and body
+func errorServer(t *testing.T, status int, body string) client.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return client.NewClientWithOptions(srv.URL, 0, 5*time.Second)
+}
+
+func TestErrorPredicatesOnServerResponses(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		is     func(error) bool
+	}{
+		{"not found", http.StatusNotFound, client.IsNotFound},
+		{"bad request", http.StatusBadRequest, client.IsBadRequest},
+		{"conflict", http.StatusConflict, client.IsConflict},
+		{"server error", http.StatusServiceUnavailable, client.IsServerError},
+	}
+	predicates := []func(error) bool{client.IsNotFound, client.IsBadRequest, client.IsConflict, client.IsServerError}
+
+	for _, tt := range tests {
+		c := errorServer(t, tt.status, `{"error":"it went wrong","context":{"job_id":"42"}}`)
+		_, err := c.GetJob(context.Background(), uuid.New())
+
+		matches := 0
+		for _, is := range predicates {
+			if is(err) {
+				matches++
+			}
+		}
+		if !tt.is(err) || matches != 1 {
+			t.Errorf("%s: expected exactly its predicate to match %v", tt.name, err)
+		}
+
+		var apiErr *client.APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("%s: expected an APIError, got %T", tt.name, err)
+		}
+		if apiErr.StatusCode != tt.status || apiErr.Message != "it went wrong" || apiErr.Context["job_id"] != "42" {
+			t.Errorf("%s: unexpected APIError %+v", tt.name, apiErr)
+		}
+	}
+}
+
+func TestAPIErrorFromUnstructuredResponse(t *testing.T) {
+	c := errorServer(t, http.StatusBadGateway, "upstream unavailable\n")
+	err := c.CancelJob(context.Background(), uuid.New())
+
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected an APIError, got %T", err)
+	}
+	if apiErr.StatusCode != http.StatusBadGateway || apiErr.Message != "upstream unavailable" {
+		t.Fatalf("unexpected APIError %+v", apiErr)
+	}
+	if !client.IsServerError(err) {
+		t.Fatal("expected a server error")
+	}
+}
+
+func TestJobExistsReportsErrorsWithoutBody(t *testing.T) {
+	c := errorServer(t, http.StatusInternalServerError, "")
+	exists, _, err := c.JobExists(context.Background(), uuid.New())
+	if exists || !client.IsServerError(err) {
+		t.Fatalf("expected a server error, got %v", err)
+	}
+
+	c = errorServer(t, http.StatusNotFound, "")
+	exists, _, err = c.JobExists(context.Background(), uuid.New())
+	if exists || err != nil {
+		t.Fatalf("expected a missing job without error, got %v, %v", exists, err)
+	}
+}
+
+func TestAPIErrorCarriesRequestID(t *testing.T) {
+	var sent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent = r.Header.Get("X-Request-ID")
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"error":"not pending"}`))
+	}))
+	defer srv.Close()
+
+	err := client.NewClientWithOptions(srv.URL, 0, 5*time.Second).CancelJob(context.Background(), uuid.New())
+
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected an APIError, got %T", err)
+	}
+	if sent == "" || apiErr.RequestID != sent {
+		t.Fatalf("expected the error to carry the sent request ID %q, got %q", sent, apiErr.RequestID)
+	}
+}
+
+func TestIncompatibleServerVersion(t *testing.T) {
+	var sent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent = r.Header.Get("X-Executr-API-Version")
+		w.Header().Set("X-Executr-API-Version", "99.0")
+		w.Header().Set("X-Executr-Min-API-Version", "99.0")
+		w.Write([]byte(`{"status":"healthy","database":"connected"}`))
+	}))
+	defer srv.Close()
+
+	err := client.NewClientWithOptions(srv.URL, 0, 5*time.Second).Ping(context.Background())
+	if !client.IsIncompatibleVersion(err) {
+		t.Fatalf("expected ErrIncompatibleVersion, got %v", err)
+	}
+	if sent == "" {
+		t.Fatal("expected the client to send its API version")
+	}
+}
\ No newline at end of file
@@ -8,8 +8,8 @@ import (
 
 	"github.com/google/uuid"
 
-	"github.com/draganm/executr/internal/models"
 	"github.com/draganm/executr/pkg/client"
+	"github.com/draganm/executr/pkg/models"
 )
 
 func ExampleClient() {
@@ -62,17 +62,17 @@ func ExampleClient_executor() {
 	ctx := context.Background()
 	executorID := "worker-1"
 	executorIP := "192.168.1.100"
+	capabilities := []string{"gpu"}
 
-	// Claim a job
-	job, err := c.ClaimNextJob(ctx, executorID, executorIP)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	if job == nil {
+	// Claim a job this executor is able to run
+	job, err := c.ClaimNextJob(ctx, executorID, executorIP, capabilities)
+	if client.IsNoJobsAvailable(err) {
 		fmt.Println("No jobs available")
 		return
 	}
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	fmt.Printf("Claimed job: %s\n", job.ID)
 
@@ -87,8 +87,11 @@ func ExampleClient_executor() {
 			case <-heartbeatCtx.Done():
 				return
 			case <-ticker.C:
-				if err := c.Heartbeat(ctx, job.ID, executorID); err != nil {
+				resp, err := c.Heartbeat(ctx, job.ID, &models.HeartbeatRequest{ExecutorID: executorID})
+				if err != nil {
 					log.Printf("Heartbeat failed: %v", err)
+				} else if resp.CancelRequested {
+					log.Printf("Job was cancelled")
 				}
 			}
 		}
@@ -114,6 +117,64 @@ func ExampleClient_executor() {
 	fmt.Println("Job completed")
 }
 
+func ExampleClient_dependencies() {
+	c := client.NewClient("http://localhost:8080")
+
+	ctx := context.Background()
+
+	// Submit the first job of the pipeline
+	extract, err := c.SubmitJob(ctx, &models.JobSubmission{
+		Type:         "extract",
+		BinaryURL:    "https://example.com/extract",
+		BinarySHA256: "abc123def456",
+		Priority:     models.PriorityBackground,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// The second job is only claimed once the first one has completed. If the
+	// first job fails for good or is cancelled, it ends up skipped.
+	load, err := c.SubmitJob(ctx, &models.JobSubmission{
+		Type:         "load",
+		BinaryURL:    "https://example.com/load",
+		BinarySHA256: "789abc012def",
+		Priority:     models.PriorityBackground,
+		DependsOn:    []uuid.UUID{extract.ID},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Submitted %s, which runs after %s\n", load.ID, extract.ID)
+}
+
+func ExampleClient_waitForJob() {
+	// Start polling every second, backing off while the job is still running
+	c := client.NewClient("http://localhost:8080", client.WithPollInterval(time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	job, err := c.SubmitJob(ctx, &models.JobSubmission{
+		Type:         "report",
+		BinaryURL:    "https://example.com/report",
+		BinarySHA256: "abc123def456",
+		Priority:     models.PriorityForeground,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Without explicit states WaitForJob returns once the job is terminal
+	job, err = c.WaitForJob(ctx, job.ID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Job finished with status %s\n", job.Status)
+}
+
 func ExampleClient_errorHandling() {
 	c := client.NewClient("http://localhost:8080")
 
@@ -132,11 +193,10 @@ func ExampleClient_errorHandling() {
 	}
 
 	// Try to claim a job when none are available
-	job, err := c.ClaimNextJob(ctx, "worker-1", "192.168.1.100")
-	if err != nil {
-		log.Fatal(err)
-	}
-	if job == nil {
+	_, err = c.ClaimNextJob(ctx, "worker-1", "192.168.1.100", nil)
+	if client.IsNoJobsAvailable(err) {
 		fmt.Println("No jobs available to claim")
+	} else if err != nil {
+		log.Fatal(err)
 	}
 }
\ No newline at end of file
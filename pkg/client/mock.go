@@ -2,34 +2,96 @@ package client
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
 
-	"github.com/draganm/executr/internal/models"
+	"github.com/draganm/executr/pkg/models"
 )
 
+// mockPollInterval is how often the mock's WaitForJob polls
+const mockPollInterval = 10 * time.Millisecond
+
 // MockClient is a mock implementation of the Client interface for testing
 type MockClient struct {
-	mu   sync.RWMutex
-	jobs map[uuid.UUID]*models.Job
+	mu        sync.RWMutex
+	jobs      map[uuid.UUID]*models.Job
+	executors map[string]*models.Executor
+	schedules map[uuid.UUID]*models.Schedule
+	limits    map[string]*models.JobTypeLimit
+
+	// idempotencyKeys maps keys passed to SubmitJobWithKey to their jobs
+	idempotencyKeys map[string]idempotentSubmission
+
+	// artifacts holds the artifacts uploaded or reported for each job
+	artifacts map[uuid.UUID][]models.Artifact
 
 	// Configurable behavior
-	SubmitJobFunc      func(ctx context.Context, job *models.JobSubmission) (*models.Job, error)
-	GetJobFunc         func(ctx context.Context, jobID uuid.UUID) (*models.Job, error)
-	ListJobsFunc       func(ctx context.Context, filter *ListJobsFilter) ([]*models.Job, error)
-	CancelJobFunc      func(ctx context.Context, jobID uuid.UUID) error
-	ClaimNextJobFunc   func(ctx context.Context, executorID, executorIP string) (*models.Job, error)
-	HeartbeatFunc      func(ctx context.Context, jobID uuid.UUID, executorID string) error
-	CompleteJobFunc    func(ctx context.Context, jobID uuid.UUID, result *models.CompleteRequest) error
-	FailJobFunc        func(ctx context.Context, jobID uuid.UUID, result *models.FailRequest) error
-	HealthFunc         func(ctx context.Context) (*HealthResponse, error)
+	SubmitJobFunc       func(ctx context.Context, job *models.JobSubmission) (*models.Job, error)
+	GetJobFunc          func(ctx context.Context, jobID uuid.UUID) (*models.Job, error)
+	GetJobIfChangedFunc func(ctx context.Context, jobID uuid.UUID, etag string) (*models.Job, string, error)
+	GetJobMetadataFunc  func(ctx context.Context, jobID uuid.UUID) (*models.Job, error)
+	GetJobOutputFunc    func(ctx context.Context, jobID uuid.UUID) (*models.JobOutput, error)
+	JobExistsFunc       func(ctx context.Context, jobID uuid.UUID) (bool, models.Status, error)
+	WaitForJobFunc      func(ctx context.Context, jobID uuid.UUID, terminalStates ...models.Status) (*models.Job, error)
+	SubmitAndWaitFunc   func(ctx context.Context, job *models.JobSubmission) (*models.Job, error)
+	ListJobsFunc        func(ctx context.Context, filter *ListJobsFilter) ([]*models.Job, error)
+	ListJobsPageFunc    func(ctx context.Context, filter *ListJobsFilter) (*models.JobList, error)
+	CancelJobFunc       func(ctx context.Context, jobID uuid.UUID) error
+	RequeueJobFunc      func(ctx context.Context, jobID uuid.UUID) (*models.Job, error)
+	ClaimNextJobFunc    func(ctx context.Context, executorID, executorIP string, capabilities []string) (*models.Job, error)
+	ReportCancelledFunc func(ctx context.Context, jobID uuid.UUID, result *models.CancelledRequest) error
+	HeartbeatFunc       func(ctx context.Context, jobID uuid.UUID, heartbeat *models.HeartbeatRequest) (*models.HeartbeatResponse, error)
+	CompleteJobFunc     func(ctx context.Context, jobID uuid.UUID, result *models.CompleteRequest) error
+	FailJobFunc         func(ctx context.Context, jobID uuid.UUID, result *models.FailRequest) error
+	AppendJobOutputFunc func(ctx context.Context, jobID uuid.UUID, output *models.OutputRequest) error
+	StreamLogsFunc      func(ctx context.Context, jobID uuid.UUID) (<-chan LogChunk, error)
+	ListArtifactsFunc   func(ctx context.Context, jobID uuid.UUID) ([]models.Artifact, error)
+	UploadArtifactFunc  func(ctx context.Context, jobID uuid.UUID, executorID, name string, r io.Reader, size int64) (*models.Artifact, error)
+	HealthFunc          func(ctx context.Context) (*HealthResponse, error)
+	VersionFunc         func(ctx context.Context) (*models.VersionInfo, error)
+	PingFunc            func(ctx context.Context) error
+
+	SubmitJobWithKeyFunc  func(ctx context.Context, job *models.JobSubmission, idempotencyKey string) (*models.Job, error)
+	UpdateJobPriorityFunc func(ctx context.Context, jobID uuid.UUID, priority models.Priority) (*models.Job, error)
+	ValidateJobFunc       func(ctx context.Context, job *models.JobSubmission) (*models.ValidationResult, error)
+
+	CancelJobWithReasonFunc func(ctx context.Context, jobID uuid.UUID, reason string) error
+
+	ClaimNextJobWithWaitFunc func(ctx context.Context, executorID, executorIP string, capabilities []string, wait time.Duration) (*models.Job, error)
+	SubscribeEventsFunc      func(ctx context.Context, filter *EventFilter) (<-chan models.JobEvent, error)
+
+	RegisterExecutorFunc   func(ctx context.Context, registration *models.ExecutorRegistration) (*models.Executor, error)
+	ExecutorHeartbeatFunc  func(ctx context.Context, executorID string, heartbeat *models.ExecutorHeartbeatRequest) error
+	DeregisterExecutorFunc func(ctx context.Context, executorID string) error
+
+	CreateScheduleFunc func(ctx context.Context, job *models.JobSubmission) (*models.Schedule, error)
+	ListSchedulesFunc  func(ctx context.Context) ([]*models.Schedule, error)
+	DeleteScheduleFunc func(ctx context.Context, scheduleID uuid.UUID) error
+
+	ListJobTypeLimitsFunc  func(ctx context.Context) ([]*models.JobTypeLimit, error)
+	SetJobTypeLimitFunc    func(ctx context.Context, jobType string, maxRunning int) (*models.JobTypeLimit, error)
+	DeleteJobTypeLimitFunc func(ctx context.Context, jobType string) error
 }
 
 // NewMockClient creates a new mock client
 func NewMockClient() *MockClient {
 	return &MockClient{
-		jobs: make(map[uuid.UUID]*models.Job),
+		jobs:      make(map[uuid.UUID]*models.Job),
+		executors: make(map[string]*models.Executor),
+		schedules: make(map[uuid.UUID]*models.Schedule),
+		limits:    make(map[string]*models.JobTypeLimit),
+
+		idempotencyKeys: make(map[string]idempotentSubmission),
+		artifacts:       make(map[uuid.UUID][]models.Artifact),
 	}
 }
 
@@ -43,20 +105,100 @@ func (m *MockClient) SubmitJob(ctx context.Context, submission *models.JobSubmis
 	defer m.mu.Unlock()
 
 	job := &models.Job{
-		ID:           uuid.New(),
-		Type:         submission.Type,
-		BinaryURL:    submission.BinaryURL,
-		BinarySHA256: submission.BinarySHA256,
-		Arguments:    submission.Arguments,
-		EnvVariables: submission.EnvVariables,
-		Priority:     submission.Priority,
-		Status:       models.StatusPending,
+		ID:                   uuid.New(),
+		Type:                 submission.Type,
+		BinaryURL:            submission.BinaryURL,
+		BinarySHA256:         submission.BinarySHA256,
+		SignatureURL:         submission.SignatureURL,
+		PublicKey:            submission.PublicKey,
+		Arguments:            submission.Arguments,
+		EnvVariables:         submission.EnvVariables,
+		Priority:             submission.Priority,
+		Status:               models.StatusPending,
+		MaxRetries:           submission.MaxRetries,
+		RetryBackoffBase:     submission.RetryBackoffBase,
+		Timeout:              submission.Timeout,
+		MaxOutputBytes:       submission.MaxOutputBytes,
+		RequiredCapabilities: submission.RequiredCapabilities,
+		DependsOn:            submission.DependsOn,
+		ScheduledAt:          submission.ScheduledAt,
+		Labels:               submission.Labels,
+		Stdin:                submission.Stdin,
+		InputFiles:           submission.InputFiles,
+		OutputGlobs:          submission.OutputGlobs,
+		WorkdirQuotaBytes:    submission.WorkdirQuotaBytes,
+		CPUMillicores:        submission.CPUMillicores,
+		MemLimitBytes:        submission.MemLimitBytes,
 	}
 
 	m.jobs[job.ID] = job
 	return job, nil
 }
 
+// idempotentSubmission is a job submitted with an idempotency key, together
+// with the submission it was created from
+type idempotentSubmission struct {
+	job        *models.Job
+	submission []byte
+}
+
+// SubmitJobWithKey submits a job unless one was already submitted with the
+// same idempotency key, in which case that job is returned. Reusing a key for
+// a different submission returns ErrConflict.
+func (m *MockClient) SubmitJobWithKey(ctx context.Context, submission *models.JobSubmission, idempotencyKey string) (*models.Job, error) {
+	if m.SubmitJobWithKeyFunc != nil {
+		return m.SubmitJobWithKeyFunc(ctx, submission, idempotencyKey)
+	}
+
+	if idempotencyKey == "" {
+		return m.SubmitJob(ctx, submission)
+	}
+
+	encoded, _ := json.Marshal(submission)
+	m.mu.RLock()
+	existing, exists := m.idempotencyKeys[idempotencyKey]
+	m.mu.RUnlock()
+	if exists {
+		if string(existing.submission) != string(encoded) {
+			return nil, ErrConflict
+		}
+		return existing.job, nil
+	}
+
+	job, err := m.SubmitJob(ctx, submission)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job.IdempotencyKey = idempotencyKey
+	m.idempotencyKeys[idempotencyKey] = idempotentSubmission{job: job, submission: encoded}
+	return job, nil
+}
+
+// ValidateJob reports every submission with a binary_sha256 as valid
+func (m *MockClient) ValidateJob(ctx context.Context, submission *models.JobSubmission) (*models.ValidationResult, error) {
+	if m.ValidateJobFunc != nil {
+		return m.ValidateJobFunc(ctx, submission)
+	}
+
+	if submission.BinarySHA256 == "" {
+		return &models.ValidationResult{
+			BinaryReachable: true,
+			Errors:          []string{"binary_sha256 is not set"},
+		}, nil
+	}
+
+	return &models.ValidationResult{
+		Valid:           true,
+		BinaryReachable: true,
+		BinarySHA256:    submission.BinarySHA256,
+		SHA256Matches:   true,
+	}, nil
+}
+
 // GetJob retrieves a job by ID
 func (m *MockClient) GetJob(ctx context.Context, jobID uuid.UUID) (*models.Job, error) {
 	if m.GetJobFunc != nil {
@@ -74,12 +216,138 @@ func (m *MockClient) GetJob(ctx context.Context, jobID uuid.UUID) (*models.Job,
 	return job, nil
 }
 
+// GetJobIfChanged retrieves a job unless its ETag, a hash of its JSON
+// encoding, equals etag
+func (m *MockClient) GetJobIfChanged(ctx context.Context, jobID uuid.UUID, etag string) (*models.Job, string, error) {
+	if m.GetJobIfChangedFunc != nil {
+		return m.GetJobIfChangedFunc(ctx, jobID, etag)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	job, exists := m.jobs[jobID]
+	if !exists {
+		return nil, "", ErrJobNotFound
+	}
+
+	encoded, err := json.Marshal(job)
+	if err != nil {
+		return nil, "", err
+	}
+	sum := sha256.Sum256(encoded)
+	current := `"` + hex.EncodeToString(sum[:16]) + `"`
+	if etag == current {
+		return nil, etag, ErrNotModified
+	}
+
+	return job, current, nil
+}
+
+// GetJobMetadata retrieves a copy of a job without its stdout and stderr
+func (m *MockClient) GetJobMetadata(ctx context.Context, jobID uuid.UUID) (*models.Job, error) {
+	if m.GetJobMetadataFunc != nil {
+		return m.GetJobMetadataFunc(ctx, jobID)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	job, exists := m.jobs[jobID]
+	if !exists {
+		return nil, ErrJobNotFound
+	}
+
+	return withoutOutput(job), nil
+}
+
+// GetJobOutput retrieves only the output and exit code of a job
+func (m *MockClient) GetJobOutput(ctx context.Context, jobID uuid.UUID) (*models.JobOutput, error) {
+	if m.GetJobOutputFunc != nil {
+		return m.GetJobOutputFunc(ctx, jobID)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	job, exists := m.jobs[jobID]
+	if !exists {
+		return nil, ErrJobNotFound
+	}
+
+	return &models.JobOutput{
+		Stdout:    job.Stdout,
+		Stderr:    job.Stderr,
+		StdoutURL: job.StdoutURL,
+		StderrURL: job.StderrURL,
+		ExitCode:  job.ExitCode,
+	}, nil
+}
+
+// JobExists reports whether a job exists and its status
+func (m *MockClient) JobExists(ctx context.Context, jobID uuid.UUID) (bool, models.Status, error) {
+	if m.JobExistsFunc != nil {
+		return m.JobExistsFunc(ctx, jobID)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	job, exists := m.jobs[jobID]
+	if !exists {
+		return false, "", nil
+	}
+
+	return true, job.Status, nil
+}
+
+// withoutOutput returns a copy of job without stdout and stderr
+func withoutOutput(job *models.Job) *models.Job {
+	stripped := *job
+	stripped.Stdout = ""
+	stripped.Stderr = ""
+	return &stripped
+}
+
+// WaitForJob polls GetJob until the job reaches one of terminalStates, or
+// any terminal state if none are given
+func (m *MockClient) WaitForJob(ctx context.Context, jobID uuid.UUID, terminalStates ...models.Status) (*models.Job, error) {
+	if m.WaitForJobFunc != nil {
+		return m.WaitForJobFunc(ctx, jobID, terminalStates...)
+	}
+
+	return waitForJob(ctx, m.GetJob, jobID, mockPollInterval, terminalStates)
+}
+
+// SubmitAndWait submits a job and waits until it is terminal. Some other
+// goroutine has to drive the job, e.g. through ClaimNextJob and CompleteJob.
+func (m *MockClient) SubmitAndWait(ctx context.Context, submission *models.JobSubmission) (*models.Job, error) {
+	if m.SubmitAndWaitFunc != nil {
+		return m.SubmitAndWaitFunc(ctx, submission)
+	}
+
+	return submitAndWait(ctx, m, submission)
+}
+
 // ListJobs lists all jobs with optional filtering
 func (m *MockClient) ListJobs(ctx context.Context, filter *ListJobsFilter) ([]*models.Job, error) {
 	if m.ListJobsFunc != nil {
 		return m.ListJobsFunc(ctx, filter)
 	}
 
+	page, err := m.ListJobsPage(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return jobPointers(page.Jobs), nil
+}
+
+// ListJobsPage lists jobs with optional filtering and returns pagination metadata
+func (m *MockClient) ListJobsPage(ctx context.Context, filter *ListJobsFilter) (*models.JobList, error) {
+	if m.ListJobsPageFunc != nil {
+		return m.ListJobsPageFunc(ctx, filter)
+	}
+
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
@@ -96,12 +364,27 @@ func (m *MockClient) ListJobs(ctx context.Context, filter *ListJobsFilter) ([]*m
 			if filter.Priority != "" && string(job.Priority) != filter.Priority {
 				continue
 			}
+			if !hasLabels(job.Labels, filter.Labels) {
+				continue
+			}
+			if filter.ExecutorID != "" && job.ExecutorID != filter.ExecutorID {
+				continue
+			}
+		}
+		if filter != nil && filter.NoOutput {
+			job = withoutOutput(job)
 		}
 		result = append(result, job)
 	}
 
+	sortMockJobs(result, filter)
+
+	page := &models.JobList{Total: int64(len(result))}
+
 	// Apply limit and offset
 	if filter != nil {
+		page.Limit = filter.Limit
+		page.Offset = filter.Offset
 		if filter.Offset > 0 && filter.Offset < len(result) {
 			result = result[filter.Offset:]
 		}
@@ -110,7 +393,11 @@ func (m *MockClient) ListJobs(ctx context.Context, filter *ListJobsFilter) ([]*m
 		}
 	}
 
-	return result, nil
+	page.Jobs = make([]models.Job, len(result))
+	for i, job := range result {
+		page.Jobs[i] = *job
+	}
+	return page, nil
 }
 
 // CancelJob cancels a pending job
@@ -118,6 +405,14 @@ func (m *MockClient) CancelJob(ctx context.Context, jobID uuid.UUID) error {
 	if m.CancelJobFunc != nil {
 		return m.CancelJobFunc(ctx, jobID)
 	}
+	return m.CancelJobWithReason(ctx, jobID, "")
+}
+
+// CancelJobWithReason cancels a pending or running job and records why
+func (m *MockClient) CancelJobWithReason(ctx context.Context, jobID uuid.UUID, reason string) error {
+	if m.CancelJobWithReasonFunc != nil {
+		return m.CancelJobWithReasonFunc(ctx, jobID, reason)
+	}
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -127,39 +422,111 @@ func (m *MockClient) CancelJob(ctx context.Context, jobID uuid.UUID) error {
 		return ErrJobNotFound
 	}
 
-	if job.Status != models.StatusPending {
+	if job.Status != models.StatusPending && job.Status != models.StatusRunning {
 		return ErrBadRequest
 	}
 
 	job.Status = models.StatusCancelled
+	job.CancellationReason = reason
 	return nil
 }
 
+// RequeueJob clones a terminal job into a new pending job
+func (m *MockClient) RequeueJob(ctx context.Context, jobID uuid.UUID) (*models.Job, error) {
+	if m.RequeueJobFunc != nil {
+		return m.RequeueJobFunc(ctx, jobID)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	original, exists := m.jobs[jobID]
+	if !exists {
+		return nil, ErrJobNotFound
+	}
+
+	if !original.Status.IsTerminal() {
+		return nil, ErrConflict
+	}
+
+	job := &models.Job{
+		ID:                   uuid.New(),
+		Type:                 original.Type,
+		BinaryURL:            original.BinaryURL,
+		BinarySHA256:         original.BinarySHA256,
+		SignatureURL:         original.SignatureURL,
+		PublicKey:            original.PublicKey,
+		Arguments:            original.Arguments,
+		EnvVariables:         original.EnvVariables,
+		Priority:             original.Priority,
+		Status:               models.StatusPending,
+		MaxRetries:           original.MaxRetries,
+		RetryBackoffBase:     original.RetryBackoffBase,
+		Timeout:              original.Timeout,
+		MaxOutputBytes:       original.MaxOutputBytes,
+		RequiredCapabilities: original.RequiredCapabilities,
+	}
+
+	m.jobs[job.ID] = job
+	return job, nil
+}
+
+// UpdateJobPriority changes the priority of a pending job
+func (m *MockClient) UpdateJobPriority(ctx context.Context, jobID uuid.UUID, priority models.Priority) (*models.Job, error) {
+	if m.UpdateJobPriorityFunc != nil {
+		return m.UpdateJobPriorityFunc(ctx, jobID, priority)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, exists := m.jobs[jobID]
+	if !exists {
+		return nil, ErrJobNotFound
+	}
+
+	if job.Status != models.StatusPending {
+		return nil, ErrConflict
+	}
+
+	job.Priority = priority
+	return job, nil
+}
+
 // ClaimNextJob claims the next available job
-func (m *MockClient) ClaimNextJob(ctx context.Context, executorID, executorIP string) (*models.Job, error) {
+func (m *MockClient) ClaimNextJob(ctx context.Context, executorID, executorIP string, capabilities []string) (*models.Job, error) {
 	if m.ClaimNextJobFunc != nil {
-		return m.ClaimNextJobFunc(ctx, executorID, executorIP)
+		return m.ClaimNextJobFunc(ctx, executorID, executorIP, capabilities)
 	}
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// Find the next pending job
+	// Find the next pending job this executor is able to run
 	for _, job := range m.jobs {
-		if job.Status == models.StatusPending {
+		if job.Status == models.StatusPending && hasCapabilities(capabilities, job.RequiredCapabilities) && m.dependenciesCompleted(job) &&
+			(job.ScheduledAt == nil || !job.ScheduledAt.After(time.Now())) && !m.typeLimitReached(job.Type) {
 			job.Status = models.StatusRunning
 			job.ExecutorID = executorID
 			return job, nil
 		}
 	}
 
-	return nil, nil // No jobs available
+	return nil, ErrNoJobsAvailable
+}
+
+// ClaimNextJobWithWait claims the next available job without waiting
+func (m *MockClient) ClaimNextJobWithWait(ctx context.Context, executorID, executorIP string, capabilities []string, wait time.Duration) (*models.Job, error) {
+	if m.ClaimNextJobWithWaitFunc != nil {
+		return m.ClaimNextJobWithWaitFunc(ctx, executorID, executorIP, capabilities, wait)
+	}
+	return m.ClaimNextJob(ctx, executorID, executorIP, capabilities)
 }
 
 // Heartbeat sends a heartbeat for a running job
-func (m *MockClient) Heartbeat(ctx context.Context, jobID uuid.UUID, executorID string) error {
+func (m *MockClient) Heartbeat(ctx context.Context, jobID uuid.UUID, heartbeat *models.HeartbeatRequest) (*models.HeartbeatResponse, error) {
 	if m.HeartbeatFunc != nil {
-		return m.HeartbeatFunc(ctx, jobID, executorID)
+		return m.HeartbeatFunc(ctx, jobID, heartbeat)
 	}
 
 	m.mu.Lock()
@@ -167,17 +534,47 @@ func (m *MockClient) Heartbeat(ctx context.Context, jobID uuid.UUID, executorID
 
 	job, exists := m.jobs[jobID]
 	if !exists {
-		return ErrJobNotFound
+		return nil, ErrJobNotFound
+	}
+
+	if job.ExecutorID != heartbeat.ExecutorID {
+		return nil, ErrUnauthorized
+	}
+
+	if job.Status == models.StatusCancelled {
+		return &models.HeartbeatResponse{CancelRequested: true}, nil
 	}
 
 	if job.Status != models.StatusRunning {
-		return ErrBadRequest
+		return nil, ErrBadRequest
 	}
 
-	if job.ExecutorID != executorID {
-		return ErrUnauthorized
+	return &models.HeartbeatResponse{}, nil
+}
+
+// ReportCancelled records the output of a job stopped after its
+// cancellation
+func (m *MockClient) ReportCancelled(ctx context.Context, jobID uuid.UUID, result *models.CancelledRequest) error {
+	if m.ReportCancelledFunc != nil {
+		return m.ReportCancelledFunc(ctx, jobID, result)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, exists := m.jobs[jobID]
+	if !exists {
+		return ErrJobNotFound
+	}
+
+	if job.Status != models.StatusCancelled || job.ExecutorID != result.ExecutorID {
+		return ErrConflict
 	}
 
+	job.Stdout = result.Stdout
+	job.Stderr = result.Stderr
+	exitCode := result.ExitCode
+	job.ExitCode = &exitCode
 	return nil
 }
 
@@ -208,6 +605,7 @@ func (m *MockClient) CompleteJob(ctx context.Context, jobID uuid.UUID, result *m
 	job.Stderr = result.Stderr
 	exitCode := result.ExitCode
 	job.ExitCode = &exitCode
+	m.artifacts[jobID] = append(m.artifacts[jobID], result.Artifacts...)
 
 	return nil
 }
@@ -242,10 +640,341 @@ func (m *MockClient) FailJob(ctx context.Context, jobID uuid.UUID, result *model
 		exitCode := result.ExitCode
 		job.ExitCode = &exitCode
 	}
+	m.artifacts[jobID] = append(m.artifacts[jobID], result.Artifacts...)
 
 	return nil
 }
 
+// AppendJobOutput appends live output to a running job
+func (m *MockClient) AppendJobOutput(ctx context.Context, jobID uuid.UUID, output *models.OutputRequest) error {
+	if m.AppendJobOutputFunc != nil {
+		return m.AppendJobOutputFunc(ctx, jobID, output)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, exists := m.jobs[jobID]
+	if !exists {
+		return ErrJobNotFound
+	}
+
+	if job.Status != models.StatusRunning {
+		return ErrBadRequest
+	}
+
+	if job.ExecutorID != output.ExecutorID {
+		return ErrUnauthorized
+	}
+
+	job.Stdout += output.Stdout
+	job.Stderr += output.Stderr
+	return nil
+}
+
+// StreamLogs returns the job's current output as a closed stream
+func (m *MockClient) StreamLogs(ctx context.Context, jobID uuid.UUID) (<-chan LogChunk, error) {
+	if m.StreamLogsFunc != nil {
+		return m.StreamLogsFunc(ctx, jobID)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	job, exists := m.jobs[jobID]
+	if !exists {
+		return nil, ErrJobNotFound
+	}
+
+	chunks := make(chan LogChunk, 2)
+	if job.Stdout != "" {
+		chunks <- LogChunk{Stream: "stdout", Data: job.Stdout}
+	}
+	if job.Stderr != "" {
+		chunks <- LogChunk{Stream: "stderr", Data: job.Stderr}
+	}
+	close(chunks)
+	return chunks, nil
+}
+
+// ListArtifacts returns the artifacts recorded for a job
+func (m *MockClient) ListArtifacts(ctx context.Context, jobID uuid.UUID) ([]models.Artifact, error) {
+	if m.ListArtifactsFunc != nil {
+		return m.ListArtifactsFunc(ctx, jobID)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, exists := m.jobs[jobID]; !exists {
+		return nil, ErrJobNotFound
+	}
+
+	artifacts := make([]models.Artifact, len(m.artifacts[jobID]))
+	copy(artifacts, m.artifacts[jobID])
+	return artifacts, nil
+}
+
+// UploadArtifact records an artifact of a running job. Its content is only
+// hashed, not kept.
+func (m *MockClient) UploadArtifact(ctx context.Context, jobID uuid.UUID, executorID, name string, r io.Reader, size int64) (*models.Artifact, error) {
+	if m.UploadArtifactFunc != nil {
+		return m.UploadArtifactFunc(ctx, jobID, executorID, name, r, size)
+	}
+
+	h := sha256.New()
+	n, err := io.Copy(h, r)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, exists := m.jobs[jobID]
+	if !exists {
+		return nil, ErrJobNotFound
+	}
+
+	if job.Status != models.StatusRunning || job.ExecutorID != executorID {
+		return nil, ErrConflict
+	}
+
+	artifact := models.Artifact{
+		Name:      name,
+		Size:      n,
+		SHA256:    hex.EncodeToString(h.Sum(nil)),
+		URL:       "/api/v1/jobs/" + jobID.String() + "/artifacts/" + name,
+		CreatedAt: time.Now(),
+	}
+	m.artifacts[jobID] = append(m.artifacts[jobID], artifact)
+	return &artifact, nil
+}
+
+// SubscribeEvents returns a stream without events that is closed when ctx
+// is cancelled
+func (m *MockClient) SubscribeEvents(ctx context.Context, filter *EventFilter) (<-chan models.JobEvent, error) {
+	if m.SubscribeEventsFunc != nil {
+		return m.SubscribeEventsFunc(ctx, filter)
+	}
+
+	events := make(chan models.JobEvent)
+	go func() {
+		<-ctx.Done()
+		close(events)
+	}()
+	return events, nil
+}
+
+// RegisterExecutor registers an executor
+func (m *MockClient) RegisterExecutor(ctx context.Context, registration *models.ExecutorRegistration) (*models.Executor, error) {
+	if m.RegisterExecutorFunc != nil {
+		return m.RegisterExecutorFunc(ctx, registration)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	executor := &models.Executor{
+		ID:            registration.ExecutorID,
+		Name:          registration.Name,
+		Capabilities:  registration.Capabilities,
+		MaxJobs:       registration.MaxJobs,
+		RegisteredAt:  &now,
+		LastHeartbeat: now,
+	}
+	m.executors[executor.ID] = executor
+	return executor, nil
+}
+
+// ExecutorHeartbeat records a heartbeat for a registered executor
+func (m *MockClient) ExecutorHeartbeat(ctx context.Context, executorID string, heartbeat *models.ExecutorHeartbeatRequest) error {
+	if m.ExecutorHeartbeatFunc != nil {
+		return m.ExecutorHeartbeatFunc(ctx, executorID, heartbeat)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	executor, exists := m.executors[executorID]
+	if !exists {
+		return ErrExecutorNotRegistered
+	}
+
+	executor.LastHeartbeat = time.Now()
+	if heartbeat.CPUPercent != nil {
+		executor.CPUPercent = heartbeat.CPUPercent
+	}
+	if heartbeat.MemBytes != nil {
+		executor.MemBytes = heartbeat.MemBytes
+	}
+	return nil
+}
+
+// DeregisterExecutor removes an executor's registration
+func (m *MockClient) DeregisterExecutor(ctx context.Context, executorID string) error {
+	if m.DeregisterExecutorFunc != nil {
+		return m.DeregisterExecutorFunc(ctx, executorID)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.executors[executorID]; !exists {
+		return ErrExecutorNotRegistered
+	}
+	delete(m.executors, executorID)
+	return nil
+}
+
+// CreateSchedule stores a recurring job template
+func (m *MockClient) CreateSchedule(ctx context.Context, job *models.JobSubmission) (*models.Schedule, error) {
+	if m.CreateScheduleFunc != nil {
+		return m.CreateScheduleFunc(ctx, job)
+	}
+
+	spec, err := cron.ParseStandard(job.CronSpec)
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	template := *job
+	template.CronSpec = ""
+	schedule := &models.Schedule{
+		ID:        uuid.New(),
+		CronSpec:  job.CronSpec,
+		Template:  template,
+		CreatedAt: now,
+		NextRunAt: spec.Next(now),
+	}
+
+	m.schedules[schedule.ID] = schedule
+	return schedule, nil
+}
+
+// ListSchedules lists all recurring job templates
+func (m *MockClient) ListSchedules(ctx context.Context) ([]*models.Schedule, error) {
+	if m.ListSchedulesFunc != nil {
+		return m.ListSchedulesFunc(ctx)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	schedules := make([]*models.Schedule, 0, len(m.schedules))
+	for _, schedule := range m.schedules {
+		schedules = append(schedules, schedule)
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].CreatedAt.Before(schedules[j].CreatedAt)
+	})
+
+	return schedules, nil
+}
+
+// DeleteSchedule removes a recurring job template
+func (m *MockClient) DeleteSchedule(ctx context.Context, scheduleID uuid.UUID) error {
+	if m.DeleteScheduleFunc != nil {
+		return m.DeleteScheduleFunc(ctx, scheduleID)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.schedules[scheduleID]; !exists {
+		return &APIError{StatusCode: http.StatusNotFound, Message: "Schedule not found"}
+	}
+	delete(m.schedules, scheduleID)
+	return nil
+}
+
+// typeLimitReached reports whether as many jobs of jobType run as its limit
+// allows. The caller must hold m.mu.
+func (m *MockClient) typeLimitReached(jobType string) bool {
+	limit, exists := m.limits[jobType]
+	return exists && m.runningJobs(jobType) >= int64(limit.MaxRunning)
+}
+
+// runningJobs counts the running jobs of jobType. The caller must hold m.mu.
+func (m *MockClient) runningJobs(jobType string) int64 {
+	var running int64
+	for _, job := range m.jobs {
+		if job.Type == jobType && job.Status == models.StatusRunning {
+			running++
+		}
+	}
+	return running
+}
+
+// ListJobTypeLimits lists the concurrency limits of job types
+func (m *MockClient) ListJobTypeLimits(ctx context.Context) ([]*models.JobTypeLimit, error) {
+	if m.ListJobTypeLimitsFunc != nil {
+		return m.ListJobTypeLimitsFunc(ctx)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	limits := make([]*models.JobTypeLimit, 0, len(m.limits))
+	for _, limit := range m.limits {
+		l := *limit
+		l.Running = m.runningJobs(limit.Type)
+		limits = append(limits, &l)
+	}
+	sort.Slice(limits, func(i, j int) bool {
+		return limits[i].Type < limits[j].Type
+	})
+
+	return limits, nil
+}
+
+// SetJobTypeLimit creates or changes the concurrency limit of a job type
+func (m *MockClient) SetJobTypeLimit(ctx context.Context, jobType string, maxRunning int) (*models.JobTypeLimit, error) {
+	if m.SetJobTypeLimitFunc != nil {
+		return m.SetJobTypeLimitFunc(ctx, jobType, maxRunning)
+	}
+
+	if jobType == "" || maxRunning < 0 {
+		return nil, ErrBadRequest
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	limit, exists := m.limits[jobType]
+	if !exists {
+		limit = &models.JobTypeLimit{Type: jobType, CreatedAt: now}
+		m.limits[jobType] = limit
+	}
+	limit.MaxRunning = maxRunning
+	limit.UpdatedAt = now
+
+	l := *limit
+	return &l, nil
+}
+
+// DeleteJobTypeLimit removes the concurrency limit of a job type
+func (m *MockClient) DeleteJobTypeLimit(ctx context.Context, jobType string) error {
+	if m.DeleteJobTypeLimitFunc != nil {
+		return m.DeleteJobTypeLimitFunc(ctx, jobType)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.limits[jobType]; !exists {
+		return &APIError{StatusCode: http.StatusNotFound, Message: "Job type limit not found"}
+	}
+	delete(m.limits, jobType)
+	return nil
+}
+
 // Health checks the server health
 func (m *MockClient) Health(ctx context.Context) (*HealthResponse, error) {
 	if m.HealthFunc != nil {
@@ -255,9 +984,28 @@ func (m *MockClient) Health(ctx context.Context) (*HealthResponse, error) {
 	return &HealthResponse{
 		Status:   "healthy",
 		Database: "connected",
+		Version:  "mock",
 	}, nil
 }
 
+// Version returns the build information of the mock server
+func (m *MockClient) Version(ctx context.Context) (*models.VersionInfo, error) {
+	if m.VersionFunc != nil {
+		return m.VersionFunc(ctx)
+	}
+
+	return &models.VersionInfo{Version: "mock"}, nil
+}
+
+// Ping checks the mock server
+func (m *MockClient) Ping(ctx context.Context) error {
+	if m.PingFunc != nil {
+		return m.PingFunc(ctx)
+	}
+
+	return nil
+}
+
 // AddJob adds a job to the mock client's storage
 func (m *MockClient) AddJob(job *models.Job) {
 	m.mu.Lock()
@@ -282,4 +1030,86 @@ func (m *MockClient) ClearJobs() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.jobs = make(map[uuid.UUID]*models.Job)
+	m.idempotencyKeys = make(map[string]idempotentSubmission)
+}
+
+// sortMockJobs orders jobs like the server does for the filter's sort options
+func sortMockJobs(jobs []*models.Job, filter *ListJobsFilter) {
+	sortBy, sortOrder := "created_at", "desc"
+	if filter != nil && filter.SortBy != "" {
+		sortBy = filter.SortBy
+	}
+	if filter != nil && filter.SortOrder != "" {
+		sortOrder = filter.SortOrder
+	}
+
+	priorityRank := map[models.Priority]int{
+		models.PriorityForeground: 1,
+		models.PriorityBackground: 2,
+		models.PriorityBestEffort: 3,
+	}
+
+	less := func(a, b *models.Job) bool {
+		switch sortBy {
+		case "completed_at":
+			if a.CompletedAt == nil || b.CompletedAt == nil {
+				return a.CompletedAt != nil
+			}
+			return a.CompletedAt.Before(*b.CompletedAt)
+		case "priority":
+			return priorityRank[a.Priority] < priorityRank[b.Priority]
+		case "type":
+			return a.Type < b.Type
+		case "status":
+			return a.Status < b.Status
+		default:
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+	}
+
+	sort.SliceStable(jobs, func(i, j int) bool {
+		if sortOrder == "asc" {
+			return less(jobs[i], jobs[j])
+		}
+		return less(jobs[j], jobs[i])
+	})
+}
+
+// hasLabels reports whether labels contains every wanted key/value pair
+func hasLabels(labels, wanted map[string]string) bool {
+	for key, value := range wanted {
+		if v, ok := labels[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// hasCapabilities reports whether available contains every required capability
+func hasCapabilities(available, required []string) bool {
+	for _, r := range required {
+		found := false
+		for _, a := range available {
+			if a == r {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// dependenciesCompleted reports whether every job the given job depends on
+// has completed
+func (m *MockClient) dependenciesCompleted(job *models.Job) bool {
+	for _, id := range job.DependsOn {
+		dep, exists := m.jobs[id]
+		if !exists || dep.Status != models.StatusCompleted {
+			return false
+		}
+	}
+	return true
 }
\ No newline at end of file
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// ExecutorRegistration is sent by an executor when it starts
+type ExecutorRegistration struct {
+	ExecutorID   string   `json:"executor_id"`
+	Name         string   `json:"name"`
+	Capabilities []string `json:"capabilities,omitempty"`
+	MaxJobs      int      `json:"max_jobs"`
+
+	// AdvertiseAddr is where the executor's /metrics and /healthz can be
+	// reached, e.g. worker-1:9090
+	AdvertiseAddr string `json:"advertise_addr,omitempty"`
+}
+
+// ExecutorHeartbeatRequest is sent periodically by a registered executor,
+// whether or not it is running any jobs
+type ExecutorHeartbeatRequest struct {
+	CPUPercent *float64 `json:"cpu_percent,omitempty"` // host CPU usage, 0-100
+	MemBytes   *int64   `json:"mem_bytes,omitempty"`   // host memory in use
+}
+
+// Executor represents a registered executor
+type Executor struct {
+	ID            string     `json:"executor_id"`
+	Name          string     `json:"name"`
+	Capabilities  []string   `json:"capabilities"`
+	MaxJobs       int        `json:"max_jobs"`
+	CPUPercent    *float64   `json:"cpu_percent,omitempty"`
+	MemBytes      *int64     `json:"mem_bytes,omitempty"`
+	AdvertiseAddr string     `json:"advertise_addr,omitempty"`
+	RegisteredAt  *time.Time `json:"registered_at,omitempty"`
+	LastHeartbeat time.Time  `json:"last_heartbeat"`
+}
\ No newline at end of file
@@ -0,0 +1,367 @@
+// Package models defines the types exchanged over the executr API. They are
+// shared by the server and the executor, and returned by pkg/client, so
+// programs using the client can refer to them.
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Priority represents job execution priority
+type Priority string
+
+const (
+	PriorityForeground Priority = "foreground"
+	PriorityBackground Priority = "background"
+	PriorityBestEffort Priority = "best_effort"
+)
+
+// Priorities lists every valid job priority, highest first
+var Priorities = []Priority{
+	PriorityForeground,
+	PriorityBackground,
+	PriorityBestEffort,
+}
+
+// IsValid reports whether p is a known job priority
+func (p Priority) IsValid() bool {
+	for _, priority := range Priorities {
+		if p == priority {
+			return true
+		}
+	}
+	return false
+}
+
+// Status represents job execution status
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+
+	// StatusDeadLetter marks a failed job whose retries are exhausted
+	StatusDeadLetter Status = "dead_letter"
+	// StatusSkipped marks a job that never ran because a dependency didn't complete
+	StatusSkipped Status = "skipped"
+)
+
+// Statuses lists every valid job status
+var Statuses = []Status{
+	StatusPending,
+	StatusRunning,
+	StatusCompleted,
+	StatusFailed,
+	StatusCancelled,
+	StatusDeadLetter,
+	StatusSkipped,
+}
+
+// IsValid reports whether s is a known job status
+func (s Status) IsValid() bool {
+	for _, status := range Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal reports whether a job in this status will never change again
+func (s Status) IsTerminal() bool {
+	switch s {
+	case StatusCompleted, StatusFailed, StatusCancelled, StatusDeadLetter, StatusSkipped:
+		return true
+	default:
+		return false
+	}
+}
+
+// Job represents a job in the system
+type Job struct {
+	ID                   uuid.UUID         `json:"id"`
+	Type                 string            `json:"type"`
+	BinaryURL            string            `json:"binary_url"`
+	BinarySHA256         string            `json:"binary_sha256"`
+	SignatureURL         string            `json:"signature_url,omitempty"` // minisign detached signature
+	PublicKey            string            `json:"public_key,omitempty"`
+	Arguments            []string          `json:"arguments,omitempty"`
+	EnvVariables         map[string]string `json:"env_variables,omitempty"`
+	Priority             Priority          `json:"priority"`
+	Status               Status            `json:"status"`
+	ExecutorID           string            `json:"executor_id,omitempty"`
+	Stdout               string            `json:"stdout,omitempty"`
+	Stderr               string            `json:"stderr,omitempty"`
+	StdoutURL            string            `json:"stdout_url,omitempty"`
+	StderrURL            string            `json:"stderr_url,omitempty"`
+	ExitCode             *int              `json:"exit_code,omitempty"`
+	ErrorMessage         string            `json:"error_message,omitempty"`
+	CreatedAt            time.Time         `json:"created_at"`
+	ScheduledAt          *time.Time        `json:"scheduled_at,omitempty"`
+	StartedAt            *time.Time        `json:"started_at,omitempty"`
+	CompletedAt          *time.Time        `json:"completed_at,omitempty"`
+	LastHeartbeat        *time.Time        `json:"last_heartbeat,omitempty"`
+	MaxRetries           int               `json:"max_retries,omitempty"`
+	RetryCount           int               `json:"retry_count,omitempty"`
+	RetryBackoffBase     int               `json:"retry_backoff_base,omitempty"` // seconds
+	NextRetryAt          *time.Time        `json:"next_retry_at,omitempty"`
+	Timeout              int               `json:"timeout,omitempty"`          // seconds, 0 means no timeout
+	MaxOutputBytes       int               `json:"max_output_bytes,omitempty"` // 0 means the executor default
+	RequiredCapabilities []string          `json:"required_capabilities,omitempty"`
+	DependsOn            []uuid.UUID       `json:"depends_on,omitempty"`
+	Labels               map[string]string `json:"labels,omitempty"`
+	IdempotencyKey       string            `json:"idempotency_key,omitempty"`
+	Stdin                []byte            `json:"stdin,omitempty"`    // base64 in JSON
+	Attempts             []JobAttempt      `json:"attempts,omitempty"` // only set when fetching a single job
+
+	// InputFiles are written into the job's working directory before the
+	// binary runs, keyed by their path relative to it
+	InputFiles map[string]InputFile `json:"input_files,omitempty"`
+
+	// OutputGlobs select files collected as artifacts after the binary exits
+	OutputGlobs []string `json:"output_globs,omitempty"`
+
+	// WorkdirQuotaBytes limits the size of the job's working directory while
+	// it runs; the job is killed when it grows larger. 0 means unlimited.
+	WorkdirQuotaBytes int64 `json:"workdir_quota_bytes,omitempty"`
+
+	// CPUMillicores and MemLimitBytes limit the job's resources on executors
+	// that run jobs in cgroups; 0 means unlimited
+	CPUMillicores int   `json:"cpu_millicores,omitempty"`
+	MemLimitBytes int64 `json:"mem_limit_bytes,omitempty"`
+
+	// TraceParent is the W3C traceparent of the request that submitted the
+	// job; executors continue that trace while they run it
+	TraceParent string `json:"trace_parent,omitempty"`
+
+	// CancellationReason is why the job was cancelled, if the operator
+	// cancelling it said so
+	CancellationReason string `json:"cancellation_reason,omitempty"`
+
+	// ResultJSON is the structured result the job wrote to the file named
+	// by $EXECUTR_RESULT, e.g. how many of its units of work succeeded
+	ResultJSON json.RawMessage `json:"result_json,omitempty"`
+
+	// EstimatedCompletion is a rough guess of when a pending or running job
+	// completes, based on how long jobs of its type took so far. It's only
+	// set when fetching a single job and left out when there's no basis for
+	// a guess.
+	EstimatedCompletion *time.Time `json:"estimated_completion,omitempty"`
+}
+
+// JobList represents a page of jobs together with pagination metadata
+type JobList struct {
+	Jobs   []Job `json:"jobs"`
+	Total  int64 `json:"total"` // number of jobs matching the filters
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+}
+
+// JobEvent reports that a job was created or changed its status
+type JobEvent struct {
+	JobID          uuid.UUID         `json:"job_id"`
+	Type           string            `json:"type"`
+	Status         Status            `json:"status"`
+	PreviousStatus Status            `json:"previous_status,omitempty"` // empty for new jobs
+	Labels         map[string]string `json:"labels,omitempty"`
+	Time           time.Time         `json:"time"`
+}
+
+// JobResult represents the result of a job execution
+type JobResult struct {
+	Stdout       string `json:"stdout"`
+	Stderr       string `json:"stderr"`
+	StdoutURL    string `json:"stdout_url,omitempty"`
+	StderrURL    string `json:"stderr_url,omitempty"`
+	ExitCode     int    `json:"exit_code"`
+	ErrorMessage string `json:"error_message,omitempty"`
+
+	// ResultJSON is the content of the job's result file, if it wrote one
+	ResultJSON json.RawMessage `json:"result_json,omitempty"`
+}
+
+// JobOutput is the output of a job, fetched without the rest of the job.
+// While the job runs it holds the output streamed so far.
+type JobOutput struct {
+	Stdout    string `json:"stdout"`
+	Stderr    string `json:"stderr"`
+	StdoutURL string `json:"stdout_url,omitempty"`
+	StderrURL string `json:"stderr_url,omitempty"`
+	ExitCode  *int   `json:"exit_code,omitempty"` // unset until the job finished
+}
+
+// Artifact is a file collected from a job's working directory after the
+// binary exited
+type Artifact struct {
+	Name      string    `json:"name"` // path relative to the working directory
+	Size      int64     `json:"size"`
+	SHA256    string    `json:"sha256"`
+	URL       string    `json:"url"` // object store URL, or where the server serves it
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// JobAttempt represents a single execution attempt of a job
+type JobAttempt struct {
+	ID           uuid.UUID  `json:"id"`
+	JobID        uuid.UUID  `json:"job_id"`
+	ExecutorID   string     `json:"executor_id"`
+	ExecutorIP   string     `json:"executor_ip"` // falls back to the host name when the executor found no address
+	StartedAt    time.Time  `json:"started_at"`
+	EndedAt      *time.Time `json:"ended_at,omitempty"`
+	Status       string     `json:"status"`
+	ErrorMessage string     `json:"error_message,omitempty"`
+}
+
+// JobSubmission represents a job submission request
+type JobSubmission struct {
+	Type                 string            `json:"type"`
+	BinaryURL            string            `json:"binary_url"`
+	BinarySHA256         string            `json:"binary_sha256,omitempty"`
+	SignatureURL         string            `json:"signature_url,omitempty"` // minisign detached signature
+	PublicKey            string            `json:"public_key,omitempty"`
+	Arguments            []string          `json:"arguments,omitempty"`
+	EnvVariables         map[string]string `json:"env_variables,omitempty"`
+	Priority             Priority          `json:"priority"`
+	MaxRetries           int               `json:"max_retries,omitempty"`
+	RetryBackoffBase     int               `json:"retry_backoff_base,omitempty"`    // seconds, 0 means the server default
+	Timeout              int               `json:"timeout,omitempty"`               // seconds, 0 means no timeout
+	MaxOutputBytes       int               `json:"max_output_bytes,omitempty"`      // per stream, 0 means the executor default
+	RequiredCapabilities []string          `json:"required_capabilities,omitempty"` // executor must advertise all of them
+	DependsOn            []uuid.UUID       `json:"depends_on,omitempty"`            // jobs that must complete before this one runs
+	ScheduledAt          *time.Time        `json:"scheduled_at,omitempty"`          // not claimed before this time
+	CronSpec             string            `json:"cron_spec,omitempty"`             // 5-field cron spec, submits the job on a schedule
+	Labels               map[string]string `json:"labels,omitempty"`                // e.g. team=payments, for filtering and stats
+	Stdin                []byte            `json:"stdin,omitempty"`                 // base64 in JSON, fed to the binary's standard input
+	WorkdirQuotaBytes    int64             `json:"workdir_quota_bytes,omitempty"`   // working directory size limit, 0 means unlimited
+	CPUMillicores        int               `json:"cpu_millicores,omitempty"`        // CPU limit, 1000 is one CPU, 0 means unlimited
+	MemLimitBytes        int64             `json:"mem_limit_bytes,omitempty"`       // memory limit, 0 means unlimited
+
+	// InputFiles are written into the job's working directory before the
+	// binary runs, keyed by their path relative to it
+	InputFiles map[string]InputFile `json:"input_files,omitempty"`
+
+	// OutputGlobs select files in the job's working directory that are
+	// collected as artifacts after the binary exits, e.g. "out/*.csv"
+	OutputGlobs []string `json:"output_globs,omitempty"`
+}
+
+// InputFile is a file provided to a job, either downloaded from URL or
+// given inline as Content. Exactly one of them is set.
+type InputFile struct {
+	URL     string `json:"url,omitempty"`
+	Content []byte `json:"content,omitempty"` // base64 in JSON
+	SHA256  string `json:"sha256,omitempty"`  // verified before the job runs when set
+}
+
+// SecretRefPrefix marks env variable values that reference a secret, e.g.
+// secret://prod/db-pass. Executors resolve references when they run the job,
+// so the server only ever stores the reference.
+const SecretRefPrefix = "secret://"
+
+// SecretRef returns the name of the secret an env variable value references
+// and whether it is a reference at all
+func SecretRef(value string) (string, bool) {
+	return strings.CutPrefix(value, SecretRefPrefix)
+}
+
+// ValidationResult is the outcome of a dry-run submission, which checks that
+// the binary can be downloaded and matches its SHA256 without creating a job
+type ValidationResult struct {
+	Valid           bool     `json:"valid"`
+	BinaryReachable bool     `json:"binary_reachable"`
+	BinarySHA256    string   `json:"binary_sha256,omitempty"` // calculated from the downloaded binary
+	SHA256Matches   bool     `json:"sha256_matches"`
+	Errors          []string `json:"errors,omitempty"`
+}
+
+// UpdateJobRequest changes a pending job
+type UpdateJobRequest struct {
+	Priority Priority `json:"priority"`
+}
+
+// ClaimRequest represents a job claim request from an executor
+type ClaimRequest struct {
+	ExecutorID   string   `json:"executor_id"`
+	ExecutorIP   string   `json:"executor_ip"`
+	Capabilities []string `json:"capabilities,omitempty"` // only jobs requiring a subset of these are claimed
+}
+
+// HeartbeatRequest represents a heartbeat update from an executor
+type HeartbeatRequest struct {
+	ExecutorID string   `json:"executor_id"`
+	CPUPercent *float64 `json:"cpu_percent,omitempty"` // host CPU usage, 0-100
+	MemBytes   *int64   `json:"mem_bytes,omitempty"`   // host memory in use
+}
+
+// CancelJobRequest is the optional body of a job cancellation
+type CancelJobRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// CancelledRequest reports the output of a job that its executor stopped
+// because it was cancelled while running
+type CancelledRequest struct {
+	ExecutorID string `json:"executor_id"`
+	Stdout     string `json:"stdout,omitempty"`
+	Stderr     string `json:"stderr,omitempty"`
+	StdoutURL  string `json:"stdout_url,omitempty"`
+	StderrURL  string `json:"stderr_url,omitempty"`
+	ExitCode   int    `json:"exit_code"`
+}
+
+// HeartbeatResponse is the server's answer to a heartbeat
+type HeartbeatResponse struct {
+	// CancelRequested is set when the job was cancelled while running; the
+	// executor should stop it and not report a result
+	CancelRequested bool `json:"cancel_requested"`
+}
+
+// CompleteRequest represents a job completion request
+type CompleteRequest struct {
+	ExecutorID string `json:"executor_id"`
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	StdoutURL  string `json:"stdout_url,omitempty"` // set instead of Stdout when output is in object storage
+	StderrURL  string `json:"stderr_url,omitempty"` // set instead of Stderr when output is in object storage
+	ExitCode   int    `json:"exit_code"`
+
+	// Artifacts uploaded to object storage by the executor. Artifacts
+	// uploaded to the server are recorded by the upload itself.
+	Artifacts []Artifact `json:"artifacts,omitempty"`
+
+	// ResultJSON is the structured result the job wrote, if any
+	ResultJSON json.RawMessage `json:"result_json,omitempty"`
+}
+
+// OutputRequest represents a chunk of live output sent by an executor while
+// a job is running
+type OutputRequest struct {
+	ExecutorID string `json:"executor_id"`
+	Stdout     string `json:"stdout,omitempty"`
+	Stderr     string `json:"stderr,omitempty"`
+}
+
+// FailRequest represents a job failure request
+type FailRequest struct {
+	ExecutorID   string `json:"executor_id"`
+	ErrorMessage string `json:"error_message"`
+	Stdout       string `json:"stdout,omitempty"`
+	Stderr       string `json:"stderr,omitempty"`
+	StdoutURL    string `json:"stdout_url,omitempty"`
+	StderrURL    string `json:"stderr_url,omitempty"`
+	ExitCode     int    `json:"exit_code,omitempty"`
+
+	// Artifacts uploaded to object storage, as in CompleteRequest
+	Artifacts []Artifact `json:"artifacts,omitempty"`
+
+	// ResultJSON is the structured result the job wrote before it failed,
+	// e.g. which of its units of work did succeed
+	ResultJSON json.RawMessage `json:"result_json,omitempty"`
+}
\ No newline at end of file
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// JobTypeLimit caps how many jobs of a type run at once across all
+// executors. Pending jobs of the type aren't claimed while Running is at
+// MaxRunning.
+type JobTypeLimit struct {
+	Type       string    `json:"type"`
+	MaxRunning int       `json:"max_running"`
+	Running    int64     `json:"running"` // jobs of the type running right now
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// JobTypeLimitRequest sets the limit of a job type
+type JobTypeLimitRequest struct {
+	MaxRunning int `json:"max_running"`
+}
\ No newline at end of file
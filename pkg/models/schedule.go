@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Schedule is a recurring job template. Each time its cron spec fires the
+// server submits a new pending job built from the template.
+type Schedule struct {
+	ID        uuid.UUID     `json:"id"`
+	CronSpec  string        `json:"cron_spec"`
+	Template  JobSubmission `json:"template"`
+	CreatedAt time.Time     `json:"created_at"`
+	LastRunAt *time.Time    `json:"last_run_at,omitempty"`
+	NextRunAt time.Time     `json:"next_run_at"`
+
+	// Disabled is set when the schedule can't fire anymore, e.g. because
+	// its template can't be decoded; LastError says why
+	Disabled  bool   `json:"disabled,omitempty"`
+	LastError string `json:"last_error,omitempty"`
+}
\ No newline at end of file
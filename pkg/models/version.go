@@ -0,0 +1,11 @@
+package models
+
+// VersionInfo identifies the build of an executr binary. The values are set
+// with -ldflags when building, e.g.
+//
+//	go build -ldflags "-X main.version=v1.2.0 -X main.commit=$(git rev-parse HEAD)" ./cmd/executr
+type VersionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"build_date,omitempty"`
+}
\ No newline at end of file